@@ -23,20 +23,36 @@ func Init(di *dig.Container, app *fiber.App) {
 
 	serverIdGroup := groups.Group("/server/:id")
 	routeGroups := &common.RouteGroups{
-		Api:          groups.Group("/api"),
-		Auth:         groups.Group("/auth"),
-		Server:       groups.Group("/server"),
-		Config:       serverIdGroup.Group("/config"),
-		Lookup:       groups.Group("/lookup"),
-		StateHistory: serverIdGroup.Group("/state-history"),
-		Membership:   groups.Group("/membership"),
-		System:       groups.Group("/system"),
-		WebSocket:    groups.Group("/ws"),
-		Leaderboard:  serverIdGroup.Group("/leaderboard"),
+		Api:            groups.Group("/api"),
+		Auth:           groups.Group("/auth"),
+		Server:         groups.Group("/server"),
+		Config:         serverIdGroup.Group("/config"),
+		Lookup:         groups.Group("/lookup"),
+		StateHistory:   serverIdGroup.Group("/state-history"),
+		Membership:     groups.Group("/membership"),
+		System:         groups.Group("/system"),
+		WebSocket:      groups.Group("/ws"),
+		Leaderboard:    serverIdGroup.Group("/leaderboard"),
+		Championships:  serverIdGroup.Group("/championships"),
+		EntryList:      serverIdGroup.Group("/entrylist"),
+		BOP:            serverIdGroup.Group("/bop"),
+		ConfigTemplate: groups.Group("/config-template"),
+		Players:        serverIdGroup.Group("/players"),
+		Results:        serverIdGroup.Group("/results"),
+		Schedules:      serverIdGroup.Group("/schedules"),
+		RestartPolicy:  serverIdGroup.Group("/restart-policy"),
+		Crashes:        serverIdGroup.Group("/crashes"),
+		Updates:        groups.Group("/updates"),
+		ServerUpdates:  serverIdGroup.Group("/updates"),
+		Rotation:       serverIdGroup.Group("/rotation"),
+		Metrics:        app.Group("/metrics"),
+		Webhooks:       groups.Group("/webhooks"),
+		Jobs:           groups.Group("/jobs"),
 	}
 
 	accessKeyMiddleware := middleware.NewAccessKeyMiddleware()
 	routeGroups.Api.Use(accessKeyMiddleware.Authenticate)
+	routeGroups.Metrics.Use(accessKeyMiddleware.AuthenticateScrape)
 
 	err := di.Provide(func() *common.RouteGroups {
 		return routeGroups
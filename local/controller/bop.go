@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BOPController struct {
+	service      *service.BOPService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewBOPController initializes BOPController.
+func NewBOPController(bs *service.BOPService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *BOPController {
+	bc := &BOPController{
+		service:      bs,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	bopRoutes := routeGroups.BOP
+	bopRoutes.Use(auth.Authenticate)
+
+	bopRoutes.Get("/", auth.HasPermission(model.ConfigView), bc.GetBOP)
+	bopRoutes.Put("/", auth.HasPermission(model.ConfigUpdate), bc.ReplaceBOP)
+	bopRoutes.Post("/entries", auth.HasPermission(model.ConfigUpdate), bc.SetEntry)
+	bopRoutes.Delete("/entries/:track/:carModel", auth.HasPermission(model.ConfigUpdate), bc.RemoveEntry)
+
+	return bc
+}
+
+// GetBOP returns the balance of performance of a server
+// @Summary Get server BOP
+// @Description Get the ballast and restrictor entries from bop.json
+// @Tags BOP
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param track query string false "Only return entries for this track"
+// @Success 200 {object} model.BOP "BOP entries"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/bop [get]
+func (bc *BOPController) GetBOP(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return bc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	bop, err := bc.service.GetBOP(c.UserContext(), serverID, c.Query("track"))
+	if err != nil {
+		return handleConfigError(bc.errorHandler, c, err)
+	}
+	return c.JSON(bop)
+}
+
+// ReplaceBOP overwrites the balance of performance of a server
+// @Summary Replace server BOP
+// @Description Validate and overwrite bop.json with the given entries
+// @Tags BOP
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param bop body model.BOP true "BOP entries"
+// @Success 200 {object} model.BOP "Saved BOP"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request or validation failed"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/bop [put]
+func (bc *BOPController) ReplaceBOP(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return bc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var bop model.BOP
+	if err := c.BodyParser(&bop); err != nil {
+		return bc.errorHandler.HandleParsingError(c, err)
+	}
+
+	saved, err := bc.service.ReplaceBOP(c.UserContext(), serverID, &bop)
+	if err != nil {
+		return handleConfigError(bc.errorHandler, c, err)
+	}
+	return c.JSON(saved)
+}
+
+// SetEntry adds or replaces a BOP entry
+// @Summary Add BOP entry
+// @Description Add a ballast/restrictor entry for a track and car model, replacing any existing one for the same pair
+// @Tags BOP
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param entry body model.BOPEntry true "BOP entry"
+// @Success 200 {object} model.BOP "Saved BOP"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request or validation failed"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/bop/entries [post]
+func (bc *BOPController) SetEntry(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return bc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var entry model.BOPEntry
+	if err := c.BodyParser(&entry); err != nil {
+		return bc.errorHandler.HandleParsingError(c, err)
+	}
+
+	saved, err := bc.service.SetEntry(c.UserContext(), serverID, &entry)
+	if err != nil {
+		return handleConfigError(bc.errorHandler, c, err)
+	}
+	return c.JSON(saved)
+}
+
+// RemoveEntry deletes a BOP entry
+// @Summary Remove BOP entry
+// @Description Remove the ballast/restrictor entry for a track and car model
+// @Tags BOP
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param track path string true "Track name"
+// @Param carModel path int true "Car model value"
+// @Success 200 {object} model.BOP "Saved BOP"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or car model"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server or BOP entry not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/bop/entries/{track}/{carModel} [delete]
+func (bc *BOPController) RemoveEntry(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return bc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	carModel, err := strconv.Atoi(c.Params("carModel"))
+	if err != nil {
+		return bc.errorHandler.HandleValidationError(c, fmt.Errorf("invalid car model"), "carModel")
+	}
+
+	saved, err := bc.service.RemoveEntry(c.UserContext(), serverID, c.Params("track"), carModel)
+	if err != nil {
+		return handleConfigError(bc.errorHandler, c, err)
+	}
+	return c.JSON(saved)
+}
@@ -2,10 +2,12 @@ package controller
 
 import (
 	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
 	"acc-server-manager/local/service"
 	"acc-server-manager/local/utl/common"
 	"acc-server-manager/local/utl/error_handler"
 	"acc-server-manager/local/utl/logging"
+	"errors"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -34,6 +36,10 @@ func NewConfigController(as *service.ConfigService, routeGroups *common.RouteGro
 
 	configGroup := routeGroups.Config
 	configGroup.Use(auth.Authenticate)
+	configGroup.Get("/history", auth.HasPermission(model.ConfigView), ac.GetHistory)
+	configGroup.Get("/history/diff", auth.HasPermission(model.ConfigView), ac.DiffRevisions)
+	configGroup.Get("/history/:revisionId", auth.HasPermission(model.ConfigView), ac.GetRevision)
+	configGroup.Post("/history/rollback/:revisionId", auth.HasPermission(model.ConfigUpdate), ac.RollbackConfig)
 	configGroup.Put("/:file", ac.UpdateConfig)
 	configGroup.Get("/:file", ac.GetConfig)
 	configGroup.Get("/", ac.GetConfigs)
@@ -51,8 +57,11 @@ func NewConfigController(as *service.ConfigService, routeGroups *common.RouteGro
 //	@Param			id path string true "Server ID (UUID format)"
 //	@Param			file path string true "Config file name (e.g., configuration.json, settings.json, event.json)"
 //	@Param			content body object true "Configuration file content as JSON"
-//	@Success		200	{object} object{message=string} "Update successful"
-//	@Failure		400	{object} error_handler.ErrorResponse "Invalid request or JSON format"
+//	@Param			override query bool false "Replace the file instead of merging into it"
+//	@Param			dryRun query bool false "Validate and return the resulting change without writing it"
+//	@Param			restart query bool false "Restart the server after the update"
+//	@Success		200	{object} model.Config "Recorded change, or the change that would be made on a dry run"
+//	@Failure		400	{object} error_handler.ErrorResponse "Invalid request, JSON format or field values"
 //	@Failure		401	{object} error_handler.ErrorResponse "Unauthorized"
 //	@Failure		403	{object} error_handler.ErrorResponse "Insufficient permissions"
 //	@Failure		404	{object} error_handler.ErrorResponse "Server or config file not found"
@@ -77,10 +86,10 @@ func (ac *ConfigController) UpdateConfig(c *fiber.Ctx) error {
 
 	ConfigModel, err := ac.service.UpdateConfig(c, &config)
 	if err != nil {
-		return ac.errorHandler.HandleServiceError(c, err)
+		return handleConfigError(ac.errorHandler, c, err)
 	}
 	logging.Info("restart: %v", restart)
-	if restart {
+	if restart && !c.QueryBool("dryRun") {
 		_, err := ac.apiService.ServiceControlRestartServer(c)
 		if err != nil {
 			logging.ErrorWithContext("CONFIG_RESTART", "Failed to restart server after config update: %v", err)
@@ -138,3 +147,174 @@ func (ac *ConfigController) GetConfigs(c *fiber.Ctx) error {
 	}
 	return c.JSON(Model)
 }
+
+// GetHistory returns the change history of a server's config files
+//
+//	@Summary		List configuration history
+//	@Description	List recorded config file changes of an ACC server, newest first
+//	@Tags			Server Configuration
+//	@Accept			json
+//	@Produce		json
+//	@Param			id path string true "Server ID (UUID format)"
+//	@Param			filter query model.ConfigFilter false "Filter and pagination options"
+//	@Success		200	{object} model.FilteredResponse "Paginated config revisions"
+//	@Failure		400	{object} error_handler.ErrorResponse "Invalid server ID or filter"
+//	@Failure		401	{object} error_handler.ErrorResponse "Unauthorized"
+//	@Failure		403	{object} error_handler.ErrorResponse "Insufficient permissions"
+//	@Failure		404	{object} error_handler.ErrorResponse "Server not found"
+//	@Failure		500	{object} error_handler.ErrorResponse "Internal server error"
+//	@Security		BearerAuth
+//	@Router			/server/{id}/config/history [get]
+func (ac *ConfigController) GetHistory(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var filter model.ConfigFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return ac.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	history, err := ac.service.GetHistory(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(ac.errorHandler, c, err)
+	}
+	return c.JSON(history)
+}
+
+// GetRevision returns a single config revision
+//
+//	@Summary		Get configuration revision
+//	@Description	Get a recorded config file change including the old and new content
+//	@Tags			Server Configuration
+//	@Accept			json
+//	@Produce		json
+//	@Param			id path string true "Server ID (UUID format)"
+//	@Param			revisionId path string true "Revision ID (UUID format)"
+//	@Success		200	{object} model.Config "Config revision"
+//	@Failure		400	{object} error_handler.ErrorResponse "Invalid server or revision ID"
+//	@Failure		401	{object} error_handler.ErrorResponse "Unauthorized"
+//	@Failure		403	{object} error_handler.ErrorResponse "Insufficient permissions"
+//	@Failure		404	{object} error_handler.ErrorResponse "Revision not found"
+//	@Failure		500	{object} error_handler.ErrorResponse "Internal server error"
+//	@Security		BearerAuth
+//	@Router			/server/{id}/config/history/{revisionId} [get]
+func (ac *ConfigController) GetRevision(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "server ID")
+	}
+	revisionID, err := uuid.Parse(c.Params("revisionId"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "revision ID")
+	}
+
+	revision, err := ac.service.GetRevision(c.UserContext(), serverID, revisionID)
+	if err != nil {
+		return handleConfigError(ac.errorHandler, c, err)
+	}
+	return c.JSON(revision)
+}
+
+// DiffRevisions compares two config revisions
+//
+//	@Summary		Diff configuration revisions
+//	@Description	Structured diff between the state after two revisions of the same file. Without "from", the revision is compared with the state it replaced.
+//	@Tags			Server Configuration
+//	@Accept			json
+//	@Produce		json
+//	@Param			id path string true "Server ID (UUID format)"
+//	@Param			from query string false "Older revision ID (UUID format)"
+//	@Param			to query string true "Newer revision ID (UUID format)"
+//	@Success		200	{object} model.ConfigDiff "Changes between the revisions"
+//	@Failure		400	{object} error_handler.ErrorResponse "Invalid IDs or revisions of different files"
+//	@Failure		401	{object} error_handler.ErrorResponse "Unauthorized"
+//	@Failure		403	{object} error_handler.ErrorResponse "Insufficient permissions"
+//	@Failure		404	{object} error_handler.ErrorResponse "Revision not found"
+//	@Failure		500	{object} error_handler.ErrorResponse "Internal server error"
+//	@Security		BearerAuth
+//	@Router			/server/{id}/config/history/diff [get]
+func (ac *ConfigController) DiffRevisions(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "server ID")
+	}
+	toID, err := uuid.Parse(c.Query("to"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "to revision ID")
+	}
+
+	var fromID *uuid.UUID
+	if from := c.Query("from"); from != "" {
+		parsed, err := uuid.Parse(from)
+		if err != nil {
+			return ac.errorHandler.HandleUUIDError(c, "from revision ID")
+		}
+		fromID = &parsed
+	}
+
+	diff, err := ac.service.DiffRevisions(c.UserContext(), serverID, fromID, toID)
+	if err != nil {
+		return handleConfigError(ac.errorHandler, c, err)
+	}
+	return c.JSON(diff)
+}
+
+// RollbackConfig restores a config file to an earlier revision
+//
+//	@Summary		Roll back configuration
+//	@Description	Write a config file back to the state it had after the given revision. The rollback is recorded as a new revision.
+//	@Tags			Server Configuration
+//	@Accept			json
+//	@Produce		json
+//	@Param			id path string true "Server ID (UUID format)"
+//	@Param			revisionId path string true "Revision ID (UUID format)"
+//	@Param			restart query bool false "Restart the server after the rollback"
+//	@Success		200	{object} model.Config "Revision recorded for the rollback"
+//	@Failure		400	{object} error_handler.ErrorResponse "Invalid server or revision ID"
+//	@Failure		401	{object} error_handler.ErrorResponse "Unauthorized"
+//	@Failure		403	{object} error_handler.ErrorResponse "Insufficient permissions"
+//	@Failure		404	{object} error_handler.ErrorResponse "Revision not found"
+//	@Failure		500	{object} error_handler.ErrorResponse "Internal server error"
+//	@Security		BearerAuth
+//	@Router			/server/{id}/config/history/rollback/{revisionId} [post]
+func (ac *ConfigController) RollbackConfig(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "server ID")
+	}
+	revisionID, err := uuid.Parse(c.Params("revisionId"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "revision ID")
+	}
+
+	revision, err := ac.service.RollbackConfig(c.UserContext(), serverID, revisionID)
+	if err != nil {
+		return handleConfigError(ac.errorHandler, c, err)
+	}
+
+	if c.QueryBool("restart") {
+		c.Locals("serverId", serverID.String())
+		if _, err := ac.apiService.ServiceControlRestartServer(c); err != nil {
+			logging.ErrorWithContext("CONFIG_RESTART", "Failed to restart server after config rollback: %v", err)
+		}
+	}
+
+	return c.JSON(revision)
+}
+
+// handleConfigError maps errors from the typed config file services to
+// responses: field-level validation failures become 400s with details and
+// client errors such as not-found keep their status code.
+func handleConfigError(errorHandler *error_handler.ControllerErrorHandler, c *fiber.Ctx, err error) error {
+	var validationErr *model.ValidationError
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &validationErr):
+		return errorHandler.HandleFieldErrors(c, err, validationErr.Fields())
+	case errors.As(err, &fiberErr) && fiberErr.Code < fiber.StatusInternalServerError:
+		return errorHandler.HandleError(c, err, fiberErr.Code, "SERVICE")
+	default:
+		return errorHandler.HandleServiceError(c, err)
+	}
+}
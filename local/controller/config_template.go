@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ConfigTemplateController struct {
+	service      *service.ConfigTemplateService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewConfigTemplateController initializes ConfigTemplateController.
+func NewConfigTemplateController(ts *service.ConfigTemplateService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *ConfigTemplateController {
+	tc := &ConfigTemplateController{
+		service:      ts,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	templateRoutes := routeGroups.ConfigTemplate
+	templateRoutes.Use(auth.Authenticate)
+
+	templateRoutes.Get("/", auth.HasPermission(model.ConfigView), tc.GetAll)
+	templateRoutes.Post("/", auth.HasPermission(model.ConfigUpdate), tc.Create)
+	templateRoutes.Get("/:id", auth.HasPermission(model.ConfigView), tc.GetByID)
+	templateRoutes.Put("/:id", auth.HasPermission(model.ConfigUpdate), tc.Update)
+	templateRoutes.Delete("/:id", auth.HasPermission(model.ConfigUpdate), tc.Delete)
+	templateRoutes.Post("/:id/apply", auth.HasPermission(model.ConfigUpdate), tc.Apply)
+	templateRoutes.Get("/:id/drift", auth.HasPermission(model.ConfigView), tc.Drift)
+
+	return tc
+}
+
+// GetAll lists config templates
+// @Summary List config templates
+// @Description List reusable configuration templates
+// @Tags Config Templates
+// @Accept json
+// @Produce json
+// @Param filter query model.ConfigTemplateFilter false "Filter and pagination options"
+// @Success 200 {object} model.FilteredResponse "Paginated config templates"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid filter parameters"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /config-template [get]
+func (tc *ConfigTemplateController) GetAll(c *fiber.Ctx) error {
+	var filter model.ConfigTemplateFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return tc.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	templates, err := tc.service.GetAll(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(tc.errorHandler, c, err)
+	}
+	return c.JSON(templates)
+}
+
+// GetByID returns a config template
+// @Summary Get config template
+// @Description Get a configuration template by ID
+// @Tags Config Templates
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID (UUID format)"
+// @Success 200 {object} model.ConfigTemplate "Config template"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid template ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Template not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /config-template/{id} [get]
+func (tc *ConfigTemplateController) GetByID(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return tc.errorHandler.HandleUUIDError(c, "template ID")
+	}
+
+	template, err := tc.service.GetByID(c.UserContext(), templateID)
+	if err != nil {
+		return handleConfigError(tc.errorHandler, c, err)
+	}
+	return c.JSON(template)
+}
+
+// Create creates a config template
+// @Summary Create config template
+// @Description Create a template holding a partial configuration keyed by section (configuration, assistRules, event, eventRules, settings)
+// @Tags Config Templates
+// @Accept json
+// @Produce json
+// @Param template body model.ConfigTemplate true "Config template"
+// @Success 200 {object} model.ConfigTemplate "Created config template"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request or validation failed"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 409 {object} error_handler.ErrorResponse "Template name already in use"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /config-template [post]
+func (tc *ConfigTemplateController) Create(c *fiber.Ctx) error {
+	var template model.ConfigTemplate
+	if err := c.BodyParser(&template); err != nil {
+		return tc.errorHandler.HandleParsingError(c, err)
+	}
+
+	created, err := tc.service.Create(c.UserContext(), &template)
+	if err != nil {
+		return handleConfigError(tc.errorHandler, c, err)
+	}
+	return c.JSON(created)
+}
+
+// Update updates a config template
+// @Summary Update config template
+// @Description Replace the name, description and content of a configuration template
+// @Tags Config Templates
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID (UUID format)"
+// @Param template body model.ConfigTemplate true "Config template"
+// @Success 200 {object} model.ConfigTemplate "Updated config template"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request or validation failed"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Template not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /config-template/{id} [put]
+func (tc *ConfigTemplateController) Update(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return tc.errorHandler.HandleUUIDError(c, "template ID")
+	}
+
+	var template model.ConfigTemplate
+	if err := c.BodyParser(&template); err != nil {
+		return tc.errorHandler.HandleParsingError(c, err)
+	}
+
+	updated, err := tc.service.Update(c.UserContext(), templateID, &template)
+	if err != nil {
+		return handleConfigError(tc.errorHandler, c, err)
+	}
+	return c.JSON(updated)
+}
+
+// Delete deletes a config template
+// @Summary Delete config template
+// @Description Delete a configuration template and forget which servers it was applied to
+// @Tags Config Templates
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID (UUID format)"
+// @Success 204 "No Content"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid template ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Template not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /config-template/{id} [delete]
+func (tc *ConfigTemplateController) Delete(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return tc.errorHandler.HandleUUIDError(c, "template ID")
+	}
+
+	if err := tc.service.Delete(c.UserContext(), templateID); err != nil {
+		return handleConfigError(tc.errorHandler, c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Apply applies a config template to servers
+// @Summary Apply config template
+// @Description Write the template to one or more servers, merging into their files or replacing them when override is set
+// @Tags Config Templates
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID (UUID format)"
+// @Param override query bool false "Replace the files instead of merging"
+// @Param request body model.ApplyConfigTemplateRequest true "Servers to apply the template to"
+// @Success 200 {array} model.ConfigTemplateApplyResult "Result per server"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Template not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /config-template/{id}/apply [post]
+func (tc *ConfigTemplateController) Apply(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return tc.errorHandler.HandleUUIDError(c, "template ID")
+	}
+
+	var request model.ApplyConfigTemplateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return tc.errorHandler.HandleParsingError(c, err)
+	}
+
+	results, err := tc.service.Apply(c.UserContext(), templateID, request.ServerIDs, c.QueryBool("override", false))
+	if err != nil {
+		return handleConfigError(tc.errorHandler, c, err)
+	}
+	return c.JSON(results)
+}
+
+// Drift reports servers that drifted from a config template
+// @Summary Config template drift
+// @Description Compare every server the template was last applied to with the template
+// @Tags Config Templates
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID (UUID format)"
+// @Success 200 {array} model.ConfigTemplateDrift "Drift per server"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid template ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Template not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /config-template/{id}/drift [get]
+func (tc *ConfigTemplateController) Drift(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return tc.errorHandler.HandleUUIDError(c, "template ID")
+	}
+
+	drift, err := tc.service.Drift(c.UserContext(), templateID)
+	if err != nil {
+		return handleConfigError(tc.errorHandler, c, err)
+	}
+	return c.JSON(drift)
+}
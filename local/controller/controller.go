@@ -64,4 +64,64 @@ func InitializeControllers(c *dig.Container) {
 	if err != nil {
 		logging.Panic("unable to initialize leaderboard controller")
 	}
+
+	err = c.Invoke(NewEntryListController)
+	if err != nil {
+		logging.Panic("unable to initialize entry list controller")
+	}
+
+	err = c.Invoke(NewBOPController)
+	if err != nil {
+		logging.Panic("unable to initialize bop controller")
+	}
+
+	err = c.Invoke(NewConfigTemplateController)
+	if err != nil {
+		logging.Panic("unable to initialize config template controller")
+	}
+
+	err = c.Invoke(NewPlayerController)
+	if err != nil {
+		logging.Panic("unable to initialize player controller")
+	}
+
+	err = c.Invoke(NewResultController)
+	if err != nil {
+		logging.Panic("unable to initialize result controller")
+	}
+
+	err = c.Invoke(NewScheduleController)
+	if err != nil {
+		logging.Panic("unable to initialize schedule controller")
+	}
+
+	err = c.Invoke(NewSupervisorController)
+	if err != nil {
+		logging.Panic("unable to initialize supervisor controller")
+	}
+
+	err = c.Invoke(NewUpdateController)
+	if err != nil {
+		logging.Panic("unable to initialize update controller")
+	}
+
+	err = c.Invoke(NewRotationController)
+	if err != nil {
+		logging.Panic("unable to initialize rotation controller")
+	}
+
+	err = c.Invoke(NewMetricsController)
+	if err != nil {
+		logging.Panic("unable to initialize metrics controller")
+	}
+
+	err = c.Invoke(NewWebhookController)
+	if err != nil {
+		logging.Panic("unable to initialize webhook controller")
+	}
+
+	err = c.Invoke(NewJobController)
+	if err != nil {
+		logging.Panic("unable to initialize job controller")
+	}
 }
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type EntryListController struct {
+	service      *service.EntryListService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewEntryListController initializes EntryListController.
+func NewEntryListController(es *service.EntryListService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *EntryListController {
+	ec := &EntryListController{
+		service:      es,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	entryListRoutes := routeGroups.EntryList
+	entryListRoutes.Use(auth.Authenticate)
+
+	entryListRoutes.Get("/", auth.HasPermission(model.ConfigView), ec.GetEntryList)
+	entryListRoutes.Put("/", auth.HasPermission(model.ConfigUpdate), ec.ReplaceEntryList)
+	entryListRoutes.Post("/entries", auth.HasPermission(model.ConfigUpdate), ec.AddEntry)
+	entryListRoutes.Put("/entries/:index", auth.HasPermission(model.ConfigUpdate), ec.UpdateEntry)
+	entryListRoutes.Delete("/entries/:index", auth.HasPermission(model.ConfigUpdate), ec.DeleteEntry)
+
+	return ec
+}
+
+// GetEntryList returns the entry list of a server
+// @Summary Get server entry list
+// @Description Get the entries, drivers and forceEntryList flag from entrylist.json
+// @Tags Entry List
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 200 {object} model.EntryList "Entry list"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/entrylist [get]
+func (ec *EntryListController) GetEntryList(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ec.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	entryList, err := ec.service.GetEntryList(c.UserContext(), serverID)
+	if err != nil {
+		return handleConfigError(ec.errorHandler, c, err)
+	}
+	return c.JSON(entryList)
+}
+
+// ReplaceEntryList overwrites the entry list of a server
+// @Summary Replace server entry list
+// @Description Validate and overwrite entrylist.json with the given entry list
+// @Tags Entry List
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param entryList body model.EntryList true "Entry list"
+// @Success 200 {object} model.EntryList "Saved entry list"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request or validation failed"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/entrylist [put]
+func (ec *EntryListController) ReplaceEntryList(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ec.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var entryList model.EntryList
+	if err := c.BodyParser(&entryList); err != nil {
+		return ec.errorHandler.HandleParsingError(c, err)
+	}
+
+	saved, err := ec.service.ReplaceEntryList(c.UserContext(), serverID, &entryList)
+	if err != nil {
+		return handleConfigError(ec.errorHandler, c, err)
+	}
+	return c.JSON(saved)
+}
+
+// AddEntry appends an entry to the entry list of a server
+// @Summary Add entry
+// @Description Append an entry (car with its drivers) to entrylist.json
+// @Tags Entry List
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param entry body model.Entry true "Entry"
+// @Success 200 {object} model.EntryList "Saved entry list"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request or validation failed"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/entrylist/entries [post]
+func (ec *EntryListController) AddEntry(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ec.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	entry := model.NewEntry()
+	if err := c.BodyParser(&entry); err != nil {
+		return ec.errorHandler.HandleParsingError(c, err)
+	}
+
+	saved, err := ec.service.AddEntry(c.UserContext(), serverID, &entry)
+	if err != nil {
+		return handleConfigError(ec.errorHandler, c, err)
+	}
+	return c.JSON(saved)
+}
+
+// UpdateEntry replaces a single entry of the entry list
+// @Summary Update entry
+// @Description Replace the entry at the given index in entrylist.json
+// @Tags Entry List
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param index path int true "Entry index"
+// @Param entry body model.Entry true "Entry"
+// @Success 200 {object} model.EntryList "Saved entry list"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request or validation failed"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server or entry not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/entrylist/entries/{index} [put]
+func (ec *EntryListController) UpdateEntry(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ec.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	index, err := strconv.Atoi(c.Params("index"))
+	if err != nil {
+		return ec.errorHandler.HandleValidationError(c, fmt.Errorf("invalid entry index"), "index")
+	}
+
+	entry := model.NewEntry()
+	if err := c.BodyParser(&entry); err != nil {
+		return ec.errorHandler.HandleParsingError(c, err)
+	}
+
+	saved, err := ec.service.UpdateEntry(c.UserContext(), serverID, index, &entry)
+	if err != nil {
+		return handleConfigError(ec.errorHandler, c, err)
+	}
+	return c.JSON(saved)
+}
+
+// DeleteEntry removes a single entry from the entry list
+// @Summary Delete entry
+// @Description Remove the entry at the given index from entrylist.json
+// @Tags Entry List
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param index path int true "Entry index"
+// @Success 200 {object} model.EntryList "Saved entry list"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or index"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server or entry not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/entrylist/entries/{index} [delete]
+func (ec *EntryListController) DeleteEntry(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ec.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	index, err := strconv.Atoi(c.Params("index"))
+	if err != nil {
+		return ec.errorHandler.HandleValidationError(c, fmt.Errorf("invalid entry index"), "index")
+	}
+
+	saved, err := ec.service.DeleteEntry(c.UserContext(), serverID, index)
+	if err != nil {
+		return handleConfigError(ec.errorHandler, c, err)
+	}
+	return c.JSON(saved)
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type JobController struct {
+	service      *service.JobService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewJobController initializes JobController.
+func NewJobController(js *service.JobService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *JobController {
+	jc := &JobController{
+		service:      js,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	jobRoutes := routeGroups.Jobs
+	jobRoutes.Use(auth.Authenticate)
+	jobRoutes.Get("/", auth.HasPermission(model.ServerView), jc.GetAll)
+	jobRoutes.Get("/:id", auth.HasPermission(model.ServerView), jc.GetByID)
+	jobRoutes.Post("/:id/cancel", auth.HasPermission(model.ServerUpdate), jc.Cancel)
+
+	return jc
+}
+
+// GetAll lists background jobs
+// @Summary List jobs
+// @Description List the jobs that create, update, reinstall and back up servers, latest first
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param filter query model.JobFilter false "Filter and pagination options"
+// @Success 200 {object} model.FilteredResponse "Paginated jobs"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid filter parameters"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /jobs [get]
+func (jc *JobController) GetAll(c *fiber.Ctx) error {
+	var filter model.JobFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return jc.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	jobs, err := jc.service.GetAll(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(jc.errorHandler, c, err)
+	}
+	return c.JSON(jobs)
+}
+
+// GetByID returns one job
+// @Summary Get job
+// @Description Get the status, step, progress, logs and error of a job
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID (UUID format)"
+// @Success 200 {object} model.Job "Job"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid job ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Job not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /jobs/{id} [get]
+func (jc *JobController) GetByID(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return jc.errorHandler.HandleUUIDError(c, "job ID")
+	}
+
+	job, err := jc.service.GetByID(c.UserContext(), jobID)
+	if err != nil {
+		return handleConfigError(jc.errorHandler, c, err)
+	}
+	return c.JSON(job)
+}
+
+// Cancel cancels a job
+// @Summary Cancel job
+// @Description Cancel a queued job, or ask a running one to stop; a running job stops at the next point where it is safe to and is then marked cancelled
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID (UUID format)"
+// @Success 200 {object} model.Job "Job"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid job ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Job not found"
+// @Failure 409 {object} error_handler.ErrorResponse "Job has already finished"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /jobs/{id}/cancel [post]
+func (jc *JobController) Cancel(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return jc.errorHandler.HandleUUIDError(c, "job ID")
+	}
+
+	job, err := jc.service.Cancel(c.UserContext(), jobID)
+	if err != nil {
+		return handleConfigError(jc.errorHandler, c, err)
+	}
+	return c.JSON(job)
+}
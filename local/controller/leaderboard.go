@@ -24,30 +24,79 @@ func NewLeaderboardController(ls *service.LeaderboardService, routeGroups *commo
 
 	apiServerRoutes := routeGroups.Api.Group("/server/:id")
 	apiServerRoutes.Get("/leaderboard", lc.Get)
+	apiServerRoutes.Get("/leaderboard/standings", lc.GetStandings)
+	apiServerRoutes.Get("/leaderboard/export/:format", lc.Export)
+	apiServerRoutes.Get("/championships/:championshipId/export/:format", lc.Export)
 
 	routeGroups.Leaderboard.Get("/", lc.Get)
+	routeGroups.Leaderboard.Get("/standings", lc.GetStandings)
 	routeGroups.Leaderboard.Put("/", auth.Authenticate, lc.Update)
+	routeGroups.Leaderboard.Post("/races", auth.Authenticate, lc.AddRace)
+
+	championshipRoutes := routeGroups.Championships
+	championshipRoutes.Use(auth.Authenticate)
+	championshipRoutes.Get("/", lc.List)
+	championshipRoutes.Post("/", lc.Create)
+	championshipRoutes.Get("/:championshipId", lc.Get)
+	championshipRoutes.Put("/:championshipId", lc.UpdateChampionship)
+	championshipRoutes.Delete("/:championshipId", lc.Delete)
+	championshipRoutes.Post("/:championshipId/default", lc.SetDefault)
+	championshipRoutes.Get("/:championshipId/standings", lc.GetStandings)
+	championshipRoutes.Post("/:championshipId/races", lc.AddRace)
 
 	return lc
 }
 
-// Get returns the leaderboard for a server (public, no auth required).
+// Get returns a championship, or the default leaderboard for a server when no championship is given (public, no auth required).
 func (lc *LeaderboardController) Get(c *fiber.Ctx) error {
-	serverIDStr := c.Params("id")
-	serverID, err := uuid.Parse(serverIDStr)
+	serverID, championshipID, invalid := parseChampionshipIDs(c)
+	if invalid != "" {
+		return lc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	data, err := lc.service.GetChampionship(c.UserContext(), serverID, championshipID)
 	if err != nil {
-		return lc.errorHandler.HandleUUIDError(c, "server ID")
+		return handleConfigError(lc.errorHandler, c, err)
+	}
+
+	return c.JSON(data)
+}
+
+// GetStandings returns the computed championship standings for a server (public, no auth required).
+func (lc *LeaderboardController) GetStandings(c *fiber.Ctx) error {
+	serverID, championshipID, invalid := parseChampionshipIDs(c)
+	if invalid != "" {
+		return lc.errorHandler.HandleUUIDError(c, invalid)
 	}
 
-	data, err := lc.service.Get(c.UserContext(), serverID)
+	data, err := lc.service.GetStandings(c.UserContext(), serverID, championshipID)
 	if err != nil {
-		return lc.errorHandler.HandleServiceError(c, err)
+		return handleConfigError(lc.errorHandler, c, err)
 	}
 
 	return c.JSON(data)
 }
 
-// Update replaces the leaderboard for a server (requires JWT auth).
+// Export renders the standings of a championship as csv, json, html or svg (access key required).
+func (lc *LeaderboardController) Export(c *fiber.Ctx) error {
+	serverID, championshipID, invalid := parseChampionshipIDs(c)
+	if invalid != "" {
+		return lc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	file, err := lc.service.Export(c.UserContext(), serverID, championshipID, c.Params("format"))
+	if err != nil {
+		return handleConfigError(lc.errorHandler, c, err)
+	}
+
+	if c.QueryBool("download") {
+		c.Attachment(file.FileName)
+	}
+	c.Set(fiber.HeaderContentType, file.ContentType)
+	return c.Send(file.Data)
+}
+
+// Update replaces the default leaderboard for a server (requires JWT auth).
 func (lc *LeaderboardController) Update(c *fiber.Ctx) error {
 	serverIDStr := c.Params("id")
 	serverID, err := uuid.Parse(serverIDStr)
@@ -67,3 +116,123 @@ func (lc *LeaderboardController) Update(c *fiber.Ctx) error {
 
 	return c.JSON(data)
 }
+
+// AddRace appends an ingested race result to a championship (requires JWT auth).
+func (lc *LeaderboardController) AddRace(c *fiber.Ctx) error {
+	serverID, championshipID, invalid := parseChampionshipIDs(c)
+	if invalid != "" {
+		return lc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	var input model.LeaderboardRaceImport
+	if err := c.BodyParser(&input); err != nil {
+		return lc.errorHandler.HandleParsingError(c, err)
+	}
+
+	data, err := lc.service.AddRace(c.UserContext(), serverID, championshipID, &input)
+	if err != nil {
+		return handleConfigError(lc.errorHandler, c, err)
+	}
+
+	return c.JSON(data)
+}
+
+// List returns the championships of a server (requires JWT auth).
+func (lc *LeaderboardController) List(c *fiber.Ctx) error {
+	serverID, _, invalid := parseChampionshipIDs(c)
+	if invalid != "" {
+		return lc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	data, err := lc.service.List(c.UserContext(), serverID)
+	if err != nil {
+		return lc.errorHandler.HandleServiceError(c, err)
+	}
+
+	return c.JSON(data)
+}
+
+// Create adds a championship to a server (requires JWT auth).
+func (lc *LeaderboardController) Create(c *fiber.Ctx) error {
+	serverID, _, invalid := parseChampionshipIDs(c)
+	if invalid != "" {
+		return lc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	var input model.Leaderboard
+	if err := c.BodyParser(&input); err != nil {
+		return lc.errorHandler.HandleParsingError(c, err)
+	}
+
+	data, err := lc.service.CreateChampionship(c.UserContext(), serverID, &input)
+	if err != nil {
+		return handleConfigError(lc.errorHandler, c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(data)
+}
+
+// UpdateChampionship replaces a championship (requires JWT auth).
+func (lc *LeaderboardController) UpdateChampionship(c *fiber.Ctx) error {
+	serverID, championshipID, invalid := parseChampionshipIDs(c)
+	if invalid != "" {
+		return lc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	var input model.Leaderboard
+	if err := c.BodyParser(&input); err != nil {
+		return lc.errorHandler.HandleParsingError(c, err)
+	}
+
+	data, err := lc.service.UpdateChampionship(c.UserContext(), serverID, championshipID, &input)
+	if err != nil {
+		return handleConfigError(lc.errorHandler, c, err)
+	}
+
+	return c.JSON(data)
+}
+
+// Delete removes a championship (requires JWT auth).
+func (lc *LeaderboardController) Delete(c *fiber.Ctx) error {
+	serverID, championshipID, invalid := parseChampionshipIDs(c)
+	if invalid != "" {
+		return lc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	if err := lc.service.DeleteChampionship(c.UserContext(), serverID, championshipID); err != nil {
+		return handleConfigError(lc.errorHandler, c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// SetDefault makes a championship the one served by the public leaderboard route (requires JWT auth).
+func (lc *LeaderboardController) SetDefault(c *fiber.Ctx) error {
+	serverID, championshipID, invalid := parseChampionshipIDs(c)
+	if invalid != "" {
+		return lc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	data, err := lc.service.SetDefaultChampionship(c.UserContext(), serverID, championshipID)
+	if err != nil {
+		return handleConfigError(lc.errorHandler, c, err)
+	}
+
+	return c.JSON(data)
+}
+
+// parseChampionshipIDs reads the server ID and the optional championship ID of
+// a request. invalid names the ID that could not be parsed.
+func parseChampionshipIDs(c *fiber.Ctx) (serverID, championshipID uuid.UUID, invalid string) {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, "server ID"
+	}
+	if param := c.Params("championshipId"); param != "" {
+		championshipID, err = uuid.Parse(param)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, "championship ID"
+		}
+	}
+	return serverID, championshipID, ""
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MetricsController struct {
+	registry *metrics.Registry
+}
+
+// NewMetricsController initializes MetricsController. The route group is
+// protected by the access key or the scrape token in api.Init.
+func NewMetricsController(routeGroups *common.RouteGroups) *MetricsController {
+	mc := &MetricsController{registry: metrics.Default}
+
+	routeGroups.Metrics.Get("/", mc.Get)
+
+	return mc
+}
+
+// Get writes every metric in the Prometheus text format
+// @Summary Prometheus metrics
+// @Description Server players, sessions and service status, and HTTP, SteamCMD, cache and database metrics of the manager. Authenticate with the Access-Key header or the METRICS_TOKEN as a bearer token
+// @Tags System
+// @Produce plain
+// @Success 200 {string} string "Metrics in the Prometheus text format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Router /metrics [get]
+func (mc *MetricsController) Get(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return mc.registry.Write(c)
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PlayerController struct {
+	service      *service.PlayerService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewPlayerController initializes PlayerController.
+func NewPlayerController(ps *service.PlayerService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *PlayerController {
+	pc := &PlayerController{
+		service:      ps,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	playerRoutes := routeGroups.Players
+	playerRoutes.Use(auth.Authenticate)
+
+	playerRoutes.Get("/", auth.HasPermission(model.ServerView), pc.GetAll)
+
+	return pc
+}
+
+// GetAll lists the player sessions of a server
+// @Summary List player sessions
+// @Description List the drivers that connected to a server, read from its log, newest first
+// @Tags Players
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param filter query model.PlayerSessionFilter false "Filter and pagination options"
+// @Success 200 {object} model.FilteredResponse "Paginated player sessions"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or filter parameters"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/players [get]
+func (pc *PlayerController) GetAll(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return pc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var filter model.PlayerSessionFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return pc.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	players, err := pc.service.GetAll(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(pc.errorHandler, c, err)
+	}
+	return c.JSON(players)
+}
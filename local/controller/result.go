@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ResultController struct {
+	service      *service.ResultService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewResultController initializes ResultController.
+func NewResultController(rs *service.ResultService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *ResultController {
+	rc := &ResultController{
+		service:      rs,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	resultRoutes := routeGroups.Results
+	resultRoutes.Use(auth.Authenticate)
+
+	resultRoutes.Get("/", auth.HasPermission(model.ServerView), rc.GetAll)
+	resultRoutes.Get("/:resultId", auth.HasPermission(model.ServerView), rc.GetByID)
+
+	return rc
+}
+
+// GetAll lists the session results of a server
+// @Summary List session results
+// @Description List the sessions read from the server's results folder, newest first
+// @Tags Results
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param filter query model.ResultSessionFilter false "Filter and pagination options"
+// @Success 200 {object} model.FilteredResponse "Paginated result sessions"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or filter parameters"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/results [get]
+func (rc *ResultController) GetAll(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return rc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var filter model.ResultSessionFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return rc.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	results, err := rc.service.GetAll(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(rc.errorHandler, c, err)
+	}
+	return c.JSON(results)
+}
+
+// GetByID returns one session result
+// @Summary Get session result
+// @Description Get a session with its leaderboard, drivers, laps and penalties
+// @Tags Results
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param resultId path string true "Result ID (UUID format)"
+// @Success 200 {object} model.ResultSession "Session result"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server or result ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Result not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/results/{resultId} [get]
+func (rc *ResultController) GetByID(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return rc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+	if _, err := uuid.Parse(c.Params("resultId")); err != nil {
+		return rc.errorHandler.HandleUUIDError(c, "result ID")
+	}
+
+	result, err := rc.service.GetByID(c.UserContext(), c.Params("id"), c.Params("resultId"))
+	if err != nil {
+		return handleConfigError(rc.errorHandler, c, err)
+	}
+	return c.JSON(result)
+}
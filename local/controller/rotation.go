@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RotationController struct {
+	service      *service.RotationService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewRotationController initializes RotationController.
+func NewRotationController(rs *service.RotationService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *RotationController {
+	rc := &RotationController{
+		service:      rs,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	routes := routeGroups.Rotation
+	routes.Use(auth.Authenticate)
+	routes.Get("/", auth.HasPermission(model.ServerView), rc.Get)
+	routes.Put("/", auth.HasPermission(model.ConfigUpdate), rc.Update)
+	routes.Delete("/", auth.HasPermission(model.ConfigUpdate), rc.Delete)
+	routes.Post("/skip", auth.HasPermission(model.ConfigUpdate), rc.Skip)
+	routes.Post("/pin", auth.HasPermission(model.ConfigUpdate), rc.Pin)
+	routes.Delete("/pin", auth.HasPermission(model.ConfigUpdate), rc.Unpin)
+
+	return rc
+}
+
+// Get returns the event rotation of a server
+// @Summary Get event rotation
+// @Description Get the entries the server cycles through and where it is in the list
+// @Tags Rotation
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 200 {object} model.Rotation "Event rotation"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server or rotation not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/rotation [get]
+func (rc *RotationController) Get(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return rc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	rotation, err := rc.service.Get(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleConfigError(rc.errorHandler, c, err)
+	}
+	return c.JSON(rotation)
+}
+
+// Update replaces the event rotation of a server
+// @Summary Update event rotation
+// @Description Set the entries and when the rotation advances. Each entry is a partial event.json
+// @Tags Rotation
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param rotation body model.Rotation true "Event rotation"
+// @Success 200 {object} model.Rotation "Updated event rotation"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or rotation"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/rotation [put]
+func (rc *RotationController) Update(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return rc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var rotation model.Rotation
+	if err := c.BodyParser(&rotation); err != nil {
+		return rc.errorHandler.HandleParsingError(c, err)
+	}
+
+	updated, err := rc.service.Update(c.UserContext(), c.Params("id"), &rotation)
+	if err != nil {
+		return handleConfigError(rc.errorHandler, c, err)
+	}
+	return c.JSON(updated)
+}
+
+// Delete removes the event rotation of a server
+// @Summary Delete event rotation
+// @Description Stop rotating events. The current event.json is kept
+// @Tags Rotation
+// @Param id path string true "Server ID (UUID format)"
+// @Success 204 "Rotation deleted"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server or rotation not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/rotation [delete]
+func (rc *RotationController) Delete(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return rc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	if err := rc.service.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return handleConfigError(rc.errorHandler, c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Skip moves a server to the next entry of its rotation
+// @Summary Skip rotation entry
+// @Description Apply the next entry now and restart the server if it is running. Releases the pin
+// @Tags Rotation
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 200 {object} model.Rotation "Event rotation"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or entry"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server or rotation not found"
+// @Failure 409 {object} error_handler.ErrorResponse "Rotation has no entries"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/rotation/skip [post]
+func (rc *RotationController) Skip(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return rc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	rotation, err := rc.service.Skip(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleConfigError(rc.errorHandler, c, err)
+	}
+	return c.JSON(rotation)
+}
+
+// Pin keeps a server on the current entry of its rotation
+// @Summary Pin rotation entry
+// @Description Stop advancing on race ends and the schedule until unpinned
+// @Tags Rotation
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 200 {object} model.Rotation "Event rotation"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server or rotation not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/rotation/pin [post]
+func (rc *RotationController) Pin(c *fiber.Ctx) error {
+	return rc.setPinned(c, true)
+}
+
+// Unpin lets a server's rotation advance again
+// @Summary Unpin rotation entry
+// @Description Resume advancing on race ends and the schedule
+// @Tags Rotation
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 200 {object} model.Rotation "Event rotation"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server or rotation not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/rotation/pin [delete]
+func (rc *RotationController) Unpin(c *fiber.Ctx) error {
+	return rc.setPinned(c, false)
+}
+
+func (rc *RotationController) setPinned(c *fiber.Ctx, pinned bool) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return rc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	rotation, err := rc.service.SetPinned(c.UserContext(), c.Params("id"), pinned)
+	if err != nil {
+		return handleConfigError(rc.errorHandler, c, err)
+	}
+	return c.JSON(rotation)
+}
@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ScheduleController struct {
+	service      *service.ScheduleService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewScheduleController initializes ScheduleController.
+func NewScheduleController(ss *service.ScheduleService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *ScheduleController {
+	sc := &ScheduleController{
+		service:      ss,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	scheduleRoutes := routeGroups.Schedules
+	scheduleRoutes.Use(auth.Authenticate)
+
+	scheduleRoutes.Get("/", auth.HasPermission(model.ServerView), sc.GetAll)
+	scheduleRoutes.Post("/", auth.HasPermission(model.ServerUpdate), sc.Create)
+	scheduleRoutes.Get("/:scheduleId", auth.HasPermission(model.ServerView), sc.GetByID)
+	scheduleRoutes.Put("/:scheduleId", auth.HasPermission(model.ServerUpdate), sc.Update)
+	scheduleRoutes.Delete("/:scheduleId", auth.HasPermission(model.ServerUpdate), sc.Delete)
+	scheduleRoutes.Get("/:scheduleId/executions", auth.HasPermission(model.ServerView), sc.GetExecutions)
+
+	return sc
+}
+
+// GetAll lists the schedules of a server
+// @Summary List schedules
+// @Description List the start, stop and restart schedules of a server
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param filter query model.ScheduleFilter false "Filter and pagination options"
+// @Success 200 {object} model.FilteredResponse "Paginated schedules"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or filter parameters"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/schedules [get]
+func (sc *ScheduleController) GetAll(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return sc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var filter model.ScheduleFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return sc.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	schedules, err := sc.service.GetAll(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(sc.errorHandler, c, err)
+	}
+	return c.JSON(schedules)
+}
+
+// Create adds a schedule to a server
+// @Summary Create schedule
+// @Description Create a schedule that starts, stops or restarts the server on a cron expression
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param schedule body model.Schedule true "Schedule"
+// @Success 201 {object} model.Schedule "Created schedule"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or schedule"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/schedules [post]
+func (sc *ScheduleController) Create(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return sc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var schedule model.Schedule
+	if err := c.BodyParser(&schedule); err != nil {
+		return sc.errorHandler.HandleParsingError(c, err)
+	}
+
+	created, err := sc.service.Create(c.UserContext(), c.Params("id"), &schedule)
+	if err != nil {
+		return handleConfigError(sc.errorHandler, c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// GetByID returns one schedule
+// @Summary Get schedule
+// @Description Get a schedule with its next and last run
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param scheduleId path string true "Schedule ID (UUID format)"
+// @Success 200 {object} model.Schedule "Schedule"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server or schedule ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Schedule not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/schedules/{scheduleId} [get]
+func (sc *ScheduleController) GetByID(c *fiber.Ctx) error {
+	if invalid := sc.parseIDs(c); invalid != "" {
+		return sc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	schedule, err := sc.service.GetByID(c.UserContext(), c.Params("id"), c.Params("scheduleId"))
+	if err != nil {
+		return handleConfigError(sc.errorHandler, c, err)
+	}
+	return c.JSON(schedule)
+}
+
+// Update replaces a schedule
+// @Summary Update schedule
+// @Description Replace the name, action, cron expression, timezone and flags of a schedule
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param scheduleId path string true "Schedule ID (UUID format)"
+// @Param schedule body model.Schedule true "Schedule"
+// @Success 200 {object} model.Schedule "Updated schedule"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid IDs or schedule"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Schedule not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/schedules/{scheduleId} [put]
+func (sc *ScheduleController) Update(c *fiber.Ctx) error {
+	if invalid := sc.parseIDs(c); invalid != "" {
+		return sc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	var schedule model.Schedule
+	if err := c.BodyParser(&schedule); err != nil {
+		return sc.errorHandler.HandleParsingError(c, err)
+	}
+
+	updated, err := sc.service.Update(c.UserContext(), c.Params("id"), c.Params("scheduleId"), &schedule)
+	if err != nil {
+		return handleConfigError(sc.errorHandler, c, err)
+	}
+	return c.JSON(updated)
+}
+
+// Delete removes a schedule
+// @Summary Delete schedule
+// @Description Delete a schedule; its execution history is kept
+// @Tags Schedules
+// @Param id path string true "Server ID (UUID format)"
+// @Param scheduleId path string true "Schedule ID (UUID format)"
+// @Success 204 "Schedule deleted"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server or schedule ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Schedule not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/schedules/{scheduleId} [delete]
+func (sc *ScheduleController) Delete(c *fiber.Ctx) error {
+	if invalid := sc.parseIDs(c); invalid != "" {
+		return sc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	if err := sc.service.Delete(c.UserContext(), c.Params("id"), c.Params("scheduleId")); err != nil {
+		return handleConfigError(sc.errorHandler, c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetExecutions lists the runs of a schedule
+// @Summary List schedule executions
+// @Description List the runs of a schedule with their outcome, latest first
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param scheduleId path string true "Schedule ID (UUID format)"
+// @Param filter query model.ScheduleExecutionFilter false "Filter and pagination options"
+// @Success 200 {object} model.FilteredResponse "Paginated executions"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid IDs or filter parameters"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Schedule not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/schedules/{scheduleId}/executions [get]
+func (sc *ScheduleController) GetExecutions(c *fiber.Ctx) error {
+	if invalid := sc.parseIDs(c); invalid != "" {
+		return sc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	var filter model.ScheduleExecutionFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return sc.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	executions, err := sc.service.GetExecutions(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(sc.errorHandler, c, err)
+	}
+	return c.JSON(executions)
+}
+
+// parseIDs checks the server and schedule IDs of the route and names the
+// first invalid one.
+func (sc *ScheduleController) parseIDs(c *fiber.Ctx) string {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return "server ID"
+	}
+	if _, err := uuid.Parse(c.Params("scheduleId")); err != nil {
+		return "schedule ID"
+	}
+	return ""
+}
@@ -30,6 +30,11 @@ func NewServerController(ss *service.ServerService, routeGroups *common.RouteGro
 	serverRoutes.Get("/:id", auth.HasPermission(model.ServerView), ac.GetById)
 	serverRoutes.Post("/", auth.HasPermission(model.ServerCreate), ac.CreateServer)
 	serverRoutes.Delete("/:id", auth.HasPermission(model.ServerDelete), ac.DeleteServer)
+	serverRoutes.Get("/:id/creation", auth.HasPermission(model.ServerView), ac.GetCreationState)
+	serverRoutes.Get("/:id/firewall", auth.HasPermission(model.ServerView), ac.GetFirewallRules)
+	serverRoutes.Post("/:id/firewall/reconcile", auth.HasPermission(model.ServerUpdate), ac.ReconcileFirewallRules)
+	serverRoutes.Post("/:id/reinstall", auth.HasPermission(model.ServerUpdate), ac.Reinstall)
+	serverRoutes.Post("/:id/backup", auth.HasPermission(model.ServerUpdate), ac.Backup)
 
 	apiServerRoutes := routeGroups.Api.Group("/server")
 	apiServerRoutes.Get("/", auth.HasPermission(model.ServerView), ac.GetAllApi)
@@ -179,3 +184,142 @@ func (ac *ServerController) DeleteServer(c *fiber.Ctx) error {
 
 	return c.SendStatus(204)
 }
+
+// GetCreationState returns the progress of a server's creation
+// @Summary Get server creation progress
+// @Description Get the steps of a server that is being created or was created within the last hour, and the sequence number to resume its websocket creation topic from
+// @Tags Server
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 200 {object} model.ServerCreationState "Creation progress"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 404 {object} error_handler.ErrorResponse "No creation found for this server"
+// @Security BearerAuth
+// @Router /server/{id}/creation [get]
+func (ac *ServerController) GetCreationState(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	state, err := ac.service.GetCreationState(serverID)
+	if err != nil {
+		return ac.errorHandler.HandleServiceError(c, err)
+	}
+	return c.JSON(state)
+}
+
+// Reinstall queues a reinstall of a server's files
+// @Summary Reinstall server files
+// @Description Queue a job that backs up the configuration, stops the server, reinstalls its files with SteamCMD, restores the configuration and starts it again
+// @Tags Server
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 202 {object} model.Job "Queued job"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or server not installed through SteamCMD"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 409 {object} error_handler.ErrorResponse "An install or update is already queued or running"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/reinstall [post]
+func (ac *ServerController) Reinstall(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	job, err := ac.service.Reinstall(c.UserContext(), serverID)
+	if err != nil {
+		return handleConfigError(ac.errorHandler, c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// Backup queues a backup of a server
+// @Summary Back up server
+// @Description Queue a job that archives the server's cfg and results directories into a zip file in its backups directory
+// @Tags Server
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 202 {object} model.Job "Queued job"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 409 {object} error_handler.ErrorResponse "A backup is already queued or running"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/backup [post]
+func (ac *ServerController) Backup(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	job, err := ac.service.Backup(c.UserContext(), serverID)
+	if err != nil {
+		return handleConfigError(ac.errorHandler, c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// GetFirewallRules lists the firewall rules of a server
+// @Summary List server firewall rules
+// @Description List the inbound firewall rules that currently exist for an ACC server
+// @Tags Server
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 200 {array} model.FirewallRule "Existing firewall rules"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/firewall [get]
+func (ac *ServerController) GetFirewallRules(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	rules, err := ac.service.GetFirewallRules(c, serverID)
+	if err != nil {
+		return ac.errorHandler.HandleServiceError(c, err)
+	}
+	return c.JSON(rules)
+}
+
+// ReconcileFirewallRules syncs the firewall with the server ports
+// @Summary Reconcile server firewall rules
+// @Description Create missing and remove stale firewall rules so they match the ports in configuration.json
+// @Tags Server
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 200 {object} model.FirewallReconcileResult "Reconciliation result"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/firewall/reconcile [post]
+func (ac *ServerController) ReconcileFirewallRules(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return ac.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	result, err := ac.service.ReconcileFirewallRules(c, serverID)
+	if err != nil {
+		return ac.errorHandler.HandleServiceError(c, err)
+	}
+	return c.JSON(result)
+}
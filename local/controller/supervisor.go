@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SupervisorController struct {
+	service      *service.SupervisorService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewSupervisorController initializes SupervisorController.
+func NewSupervisorController(ss *service.SupervisorService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *SupervisorController {
+	sc := &SupervisorController{
+		service:      ss,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	policyRoutes := routeGroups.RestartPolicy
+	policyRoutes.Use(auth.Authenticate)
+	policyRoutes.Get("/", auth.HasPermission(model.ServerView), sc.GetPolicy)
+	policyRoutes.Put("/", auth.HasPermission(model.ServerUpdate), sc.UpdatePolicy)
+
+	crashRoutes := routeGroups.Crashes
+	crashRoutes.Use(auth.Authenticate)
+	crashRoutes.Get("/", auth.HasPermission(model.ServerView), sc.GetCrashes)
+	crashRoutes.Get("/:crashId", auth.HasPermission(model.ServerView), sc.GetCrash)
+
+	return sc
+}
+
+// GetPolicy returns the restart policy of a server
+// @Summary Get restart policy
+// @Description Get how the server is restarted after it stops unexpectedly
+// @Tags Supervisor
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Success 200 {object} model.RestartPolicy "Restart policy"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/restart-policy [get]
+func (sc *SupervisorController) GetPolicy(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return sc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	policy, err := sc.service.GetPolicy(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleConfigError(sc.errorHandler, c, err)
+	}
+	return c.JSON(policy)
+}
+
+// UpdatePolicy replaces the restart policy of a server
+// @Summary Update restart policy
+// @Description Set the retries, backoff, crash-loop cutoff and log lines kept for crashes
+// @Tags Supervisor
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param policy body model.RestartPolicy true "Restart policy"
+// @Success 200 {object} model.RestartPolicy "Updated restart policy"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or policy"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/restart-policy [put]
+func (sc *SupervisorController) UpdatePolicy(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return sc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var policy model.RestartPolicy
+	if err := c.BodyParser(&policy); err != nil {
+		return sc.errorHandler.HandleParsingError(c, err)
+	}
+
+	updated, err := sc.service.UpdatePolicy(c.UserContext(), c.Params("id"), &policy)
+	if err != nil {
+		return handleConfigError(sc.errorHandler, c, err)
+	}
+	return c.JSON(updated)
+}
+
+// GetCrashes lists the crashes of a server
+// @Summary List crashes
+// @Description List the unexpected stops of a server with their outcome, latest first
+// @Tags Supervisor
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param filter query model.ServerCrashFilter false "Filter and pagination options"
+// @Success 200 {object} model.FilteredResponse "Paginated crashes"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or filter parameters"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/crashes [get]
+func (sc *SupervisorController) GetCrashes(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return sc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var filter model.ServerCrashFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return sc.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	crashes, err := sc.service.GetCrashes(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(sc.errorHandler, c, err)
+	}
+	return c.JSON(crashes)
+}
+
+// GetCrash returns one crash
+// @Summary Get crash
+// @Description Get a crash with the end of the server log when it was detected
+// @Tags Supervisor
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param crashId path string true "Crash ID (UUID format)"
+// @Success 200 {object} model.ServerCrash "Crash"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server or crash ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Crash not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/crashes/{crashId} [get]
+func (sc *SupervisorController) GetCrash(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return sc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+	if _, err := uuid.Parse(c.Params("crashId")); err != nil {
+		return sc.errorHandler.HandleUUIDError(c, "crash ID")
+	}
+
+	crash, err := sc.service.GetCrash(c.UserContext(), c.Params("id"), c.Params("crashId"))
+	if err != nil {
+		return handleConfigError(sc.errorHandler, c, err)
+	}
+	return c.JSON(crash)
+}
@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type UpdateController struct {
+	service      *service.UpdateService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewUpdateController initializes UpdateController.
+func NewUpdateController(us *service.UpdateService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *UpdateController {
+	uc := &UpdateController{
+		service:      us,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	updateRoutes := routeGroups.Updates
+	updateRoutes.Use(auth.Authenticate)
+	updateRoutes.Post("/", auth.HasPermission(model.ServerUpdate), uc.UpdateAll)
+
+	serverUpdateRoutes := routeGroups.ServerUpdates
+	serverUpdateRoutes.Use(auth.Authenticate)
+	serverUpdateRoutes.Get("/", auth.HasPermission(model.ServerView), uc.GetAll)
+	serverUpdateRoutes.Post("/", auth.HasPermission(model.ServerUpdate), uc.Create)
+	serverUpdateRoutes.Get("/:updateId", auth.HasPermission(model.ServerView), uc.GetByID)
+	serverUpdateRoutes.Post("/:updateId/cancel", auth.HasPermission(model.ServerUpdate), uc.Cancel)
+
+	return uc
+}
+
+// UpdateAll queues an update of every server
+// @Summary Update all servers
+// @Description Queue an update of the server files of every server installed through SteamCMD; the updates run one after the other and stop at the first failure
+// @Tags Updates
+// @Accept json
+// @Produce json
+// @Param request body model.UpdateJobRequest false "Update options"
+// @Success 202 {array} model.UpdateJob "Queued updates"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /updates [post]
+func (uc *UpdateController) UpdateAll(c *fiber.Ctx) error {
+	var request model.UpdateJobRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return uc.errorHandler.HandleParsingError(c, err)
+		}
+	}
+
+	jobs, err := uc.service.UpdateAll(c.UserContext(), &request)
+	if err != nil {
+		return handleConfigError(uc.errorHandler, c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(jobs)
+}
+
+// GetAll lists the updates of a server
+// @Summary List server updates
+// @Description List the updates of a server's files, latest first
+// @Tags Updates
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param filter query model.UpdateJobFilter false "Filter and pagination options"
+// @Success 200 {object} model.FilteredResponse "Paginated updates"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or filter parameters"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/updates [get]
+func (uc *UpdateController) GetAll(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return uc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var filter model.UpdateJobFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return uc.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	jobs, err := uc.service.GetAll(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(uc.errorHandler, c, err)
+	}
+	return c.JSON(jobs)
+}
+
+// Create queues an update of a server
+// @Summary Update server files
+// @Description Queue an update that waits for the server to empty, stops it, runs SteamCMD, checks accServer.exe and starts it again
+// @Tags Updates
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param request body model.UpdateJobRequest false "Update options"
+// @Success 202 {object} model.UpdateJob "Queued update"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server ID or server not installed through SteamCMD"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 409 {object} error_handler.ErrorResponse "An update is already queued or running"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/updates [post]
+func (uc *UpdateController) Create(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return uc.errorHandler.HandleUUIDError(c, "server ID")
+	}
+
+	var request model.UpdateJobRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return uc.errorHandler.HandleParsingError(c, err)
+		}
+	}
+
+	job, err := uc.service.Enqueue(c.UserContext(), c.Params("id"), model.UpdateJobManual, request.WaitMinutes, nil)
+	if err != nil {
+		return handleConfigError(uc.errorHandler, c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// GetByID returns one update
+// @Summary Get server update
+// @Description Get the status and versions of an update
+// @Tags Updates
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param updateId path string true "Update ID (UUID format)"
+// @Success 200 {object} model.UpdateJob "Update"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server or update ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Update not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/updates/{updateId} [get]
+func (uc *UpdateController) GetByID(c *fiber.Ctx) error {
+	if invalid := uc.parseIDs(c); invalid != "" {
+		return uc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	job, err := uc.service.GetByID(c.UserContext(), c.Params("id"), c.Params("updateId"))
+	if err != nil {
+		return handleConfigError(uc.errorHandler, c, err)
+	}
+	return c.JSON(job)
+}
+
+// Cancel stops an update before it stops the server
+// @Summary Cancel server update
+// @Description Cancel an update that is queued or waiting for players to leave
+// @Tags Updates
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID (UUID format)"
+// @Param updateId path string true "Update ID (UUID format)"
+// @Success 200 {object} model.UpdateJob "Update"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid server or update ID"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Update not found"
+// @Failure 409 {object} error_handler.ErrorResponse "Update can no longer be cancelled"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /server/{id}/updates/{updateId}/cancel [post]
+func (uc *UpdateController) Cancel(c *fiber.Ctx) error {
+	if invalid := uc.parseIDs(c); invalid != "" {
+		return uc.errorHandler.HandleUUIDError(c, invalid)
+	}
+
+	job, err := uc.service.Cancel(c.UserContext(), c.Params("id"), c.Params("updateId"))
+	if err != nil {
+		return handleConfigError(uc.errorHandler, c, err)
+	}
+	return c.JSON(job)
+}
+
+// parseIDs checks the server and update IDs of the route and names the
+// first invalid one.
+func (uc *UpdateController) parseIDs(c *fiber.Ctx) string {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return "server ID"
+	}
+	if _, err := uuid.Parse(c.Params("updateId")); err != nil {
+		return "update ID"
+	}
+	return ""
+}
@@ -0,0 +1,239 @@
+package controller
+
+import (
+	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/common"
+	"acc-server-manager/local/utl/error_handler"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type WebhookController struct {
+	service      *service.WebhookService
+	errorHandler *error_handler.ControllerErrorHandler
+}
+
+// NewWebhookController initializes WebhookController.
+func NewWebhookController(ws *service.WebhookService, routeGroups *common.RouteGroups, auth *middleware.AuthMiddleware) *WebhookController {
+	wc := &WebhookController{
+		service:      ws,
+		errorHandler: error_handler.NewControllerErrorHandler(),
+	}
+
+	webhookRoutes := routeGroups.Webhooks
+	webhookRoutes.Use(auth.Authenticate)
+
+	webhookRoutes.Get("/", auth.HasPermission(model.ConfigView), wc.GetAll)
+	webhookRoutes.Post("/", auth.HasPermission(model.ConfigUpdate), wc.Create)
+	webhookRoutes.Get("/:id", auth.HasPermission(model.ConfigView), wc.GetByID)
+	webhookRoutes.Put("/:id", auth.HasPermission(model.ConfigUpdate), wc.Update)
+	webhookRoutes.Delete("/:id", auth.HasPermission(model.ConfigUpdate), wc.Delete)
+	webhookRoutes.Post("/:id/test", auth.HasPermission(model.ConfigUpdate), wc.Test)
+	webhookRoutes.Get("/:id/deliveries", auth.HasPermission(model.ConfigView), wc.GetDeliveries)
+
+	return wc
+}
+
+// GetAll lists webhooks
+// @Summary List webhooks
+// @Description List webhooks, optionally only those of one server
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param filter query model.WebhookFilter false "Filter and pagination options"
+// @Success 200 {object} model.FilteredResponse "Paginated webhooks"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid filter parameters"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /webhooks [get]
+func (wc *WebhookController) GetAll(c *fiber.Ctx) error {
+	var filter model.WebhookFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return wc.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	webhooks, err := wc.service.GetAll(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(wc.errorHandler, c, err)
+	}
+	return c.JSON(webhooks)
+}
+
+// GetByID returns a webhook
+// @Summary Get webhook
+// @Description Get a webhook by ID. The secret is never returned, only whether one is set
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID (UUID format)"
+// @Success 200 {object} model.Webhook "Webhook"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid webhook ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Webhook not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /webhooks/{id} [get]
+func (wc *WebhookController) GetByID(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return wc.errorHandler.HandleUUIDError(c, "webhook ID")
+	}
+
+	webhook, err := wc.service.GetByID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleConfigError(wc.errorHandler, c, err)
+	}
+	return c.JSON(webhook)
+}
+
+// Create creates a webhook
+// @Summary Create webhook
+// @Description Create a generic, Discord or Slack webhook for one server or, without serverId, for all servers
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body model.Webhook true "Webhook"
+// @Success 200 {object} model.Webhook "Created webhook"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request or validation failed"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /webhooks [post]
+func (wc *WebhookController) Create(c *fiber.Ctx) error {
+	var webhook model.Webhook
+	if err := c.BodyParser(&webhook); err != nil {
+		return wc.errorHandler.HandleParsingError(c, err)
+	}
+
+	created, err := wc.service.Create(c.UserContext(), &webhook)
+	if err != nil {
+		return handleConfigError(wc.errorHandler, c, err)
+	}
+	return c.JSON(created)
+}
+
+// Update updates a webhook
+// @Summary Update webhook
+// @Description Replace a webhook's settings. The secret is kept when none is given
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID (UUID format)"
+// @Param webhook body model.Webhook true "Webhook"
+// @Success 200 {object} model.Webhook "Updated webhook"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid request or validation failed"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Webhook or server not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /webhooks/{id} [put]
+func (wc *WebhookController) Update(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return wc.errorHandler.HandleUUIDError(c, "webhook ID")
+	}
+
+	var webhook model.Webhook
+	if err := c.BodyParser(&webhook); err != nil {
+		return wc.errorHandler.HandleParsingError(c, err)
+	}
+
+	updated, err := wc.service.Update(c.UserContext(), c.Params("id"), &webhook)
+	if err != nil {
+		return handleConfigError(wc.errorHandler, c, err)
+	}
+	return c.JSON(updated)
+}
+
+// Delete deletes a webhook
+// @Summary Delete webhook
+// @Description Delete a webhook together with its delivery log
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID (UUID format)"
+// @Success 204 "No Content"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid webhook ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Webhook not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /webhooks/{id} [delete]
+func (wc *WebhookController) Delete(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return wc.errorHandler.HandleUUIDError(c, "webhook ID")
+	}
+
+	if err := wc.service.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return handleConfigError(wc.errorHandler, c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Test sends a test notification
+// @Summary Test webhook
+// @Description Send a webhook.test notification straight away, even to a disabled webhook, and return the delivery
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID (UUID format)"
+// @Success 200 {object} model.WebhookDelivery "Delivery of the test notification"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid webhook ID format"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Webhook not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /webhooks/{id}/test [post]
+func (wc *WebhookController) Test(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return wc.errorHandler.HandleUUIDError(c, "webhook ID")
+	}
+
+	delivery, err := wc.service.Test(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleConfigError(wc.errorHandler, c, err)
+	}
+	return c.JSON(delivery)
+}
+
+// GetDeliveries lists the deliveries of a webhook
+// @Summary List webhook deliveries
+// @Description List attempts to send notifications to a webhook, latest first
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID (UUID format)"
+// @Param filter query model.WebhookDeliveryFilter false "Filter and pagination options"
+// @Success 200 {object} model.FilteredResponse "Paginated deliveries"
+// @Failure 400 {object} error_handler.ErrorResponse "Invalid webhook ID or filter parameters"
+// @Failure 401 {object} error_handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} error_handler.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} error_handler.ErrorResponse "Webhook not found"
+// @Failure 500 {object} error_handler.ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (wc *WebhookController) GetDeliveries(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return wc.errorHandler.HandleUUIDError(c, "webhook ID")
+	}
+
+	var filter model.WebhookDeliveryFilter
+	if err := common.ParseQueryFilter(c, &filter); err != nil {
+		return wc.errorHandler.HandleValidationError(c, err, "query_filter")
+	}
+
+	deliveries, err := wc.service.GetDeliveries(c.UserContext(), &filter)
+	if err != nil {
+		return handleConfigError(wc.errorHandler, c, err)
+	}
+	return c.JSON(deliveries)
+}
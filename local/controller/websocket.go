@@ -2,19 +2,28 @@ package controller
 
 import (
 	"acc-server-manager/local/middleware"
+	"acc-server-manager/local/model"
 	"acc-server-manager/local/service"
 	"acc-server-manager/local/utl/common"
 	"acc-server-manager/local/utl/jwt"
 	"acc-server-manager/local/utl/logging"
+	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
 	"github.com/google/uuid"
 )
 
+// pongWait is how long a connection may stay silent before it is closed.
+// Pings are answered with a pong, which resets the wait.
+const pongWait = 2 * service.WebSocketPingInterval
+
 type WebSocketController struct {
 	webSocketService *service.WebSocketService
 	jwtHandler       *jwt.OpenJWTHandler
+	auth             *middleware.AuthMiddleware
 }
 
 func NewWebSocketController(
@@ -26,6 +35,7 @@ func NewWebSocketController(
 	wsc := &WebSocketController{
 		webSocketService: wsService,
 		jwtHandler:       jwtHandler,
+		auth:             auth,
 	}
 
 	wsRoutes := routeGroups.WebSocket
@@ -88,6 +98,11 @@ func (wsc *WebSocketController) handleWebSocket(c *websocket.Conn) {
 		logging.Info("WebSocket connection closed for user: %s", username)
 	}()
 
+	c.SetReadDeadline(time.Now().Add(pongWait))
+	c.SetPongHandler(func(string) error {
+		return c.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
 	for {
 		messageType, message, err := c.ReadMessage()
 		if err != nil {
@@ -96,17 +111,13 @@ func (wsc *WebSocketController) handleWebSocket(c *websocket.Conn) {
 			}
 			break
 		}
+		c.SetReadDeadline(time.Now().Add(pongWait))
 
 		switch messageType {
 		case websocket.TextMessage:
 			wsc.handleTextMessage(connID, userID, message)
 		case websocket.BinaryMessage:
 			logging.Debug("Received binary message from user %s (not supported)", username)
-		case websocket.PingMessage:
-			if err := c.WriteMessage(websocket.PongMessage, nil); err != nil {
-				logging.Error("Failed to send pong to user %s: %v", username, err)
-				break
-			}
 		}
 	}
 }
@@ -114,17 +125,61 @@ func (wsc *WebSocketController) handleWebSocket(c *websocket.Conn) {
 func (wsc *WebSocketController) handleTextMessage(connID string, userID uuid.UUID, message []byte) {
 	logging.Debug("Received WebSocket message from user %s: %s", userID.String(), string(message))
 
+	if len(message) > 0 && message[0] == '{' {
+		var command model.WebSocketCommand
+		if err := json.Unmarshal(message, &command); err != nil {
+			wsc.webSocketService.SendError(connID, "", "Malformed command")
+			return
+		}
+		switch command.Type {
+		case model.CommandSubscribe:
+			wsc.subscribe(connID, userID, command.CommandTopic(), command.LastSeq)
+		case model.CommandUnsubscribe:
+			wsc.webSocketService.Unsubscribe(connID, command.CommandTopic())
+		case model.CommandPing:
+			wsc.webSocketService.Pong(connID)
+		default:
+			wsc.webSocketService.SendError(connID, command.Topic, "Unknown command")
+		}
+		return
+	}
+
+	// Older clients send "server_id:<id>" to follow the creation of a server.
 	messageStr := string(message)
 	if len(messageStr) > 10 && messageStr[:9] == "server_id" {
 		if serverIDStr := messageStr[10:]; len(serverIDStr) > 0 {
 			if serverID, err := uuid.Parse(serverIDStr); err == nil {
-				wsc.webSocketService.SetServerID(connID, serverID)
-				logging.Info("Associated WebSocket connection %s with server %s", connID, serverID.String())
+				wsc.subscribe(connID, userID, model.ServerCreationTopic(serverID), 0)
 			}
 		}
 	}
 }
 
+// subscribe adds a topic to a connection if the user may see it.
+func (wsc *WebSocketController) subscribe(connID string, userID uuid.UUID, topic model.WebSocketTopic, lastSeq uint64) {
+	permission, ok := topic.Permission()
+	if !ok {
+		wsc.webSocketService.SendError(connID, topic, "Unknown topic")
+		return
+	}
+
+	allowed, err := wsc.auth.UserHasPermission(context.Background(), userID.String(), permission)
+	if err != nil {
+		logging.Error("Failed to check permissions of user %s: %v", userID.String(), err)
+	}
+	if !allowed {
+		logging.WarnWithContext("AUTH", "Permission denied: user %s lacks permission %s for topic %s", userID.String(), permission, topic)
+		wsc.webSocketService.SendError(connID, topic, "Forbidden")
+		return
+	}
+
+	if err := wsc.webSocketService.Subscribe(connID, topic, lastSeq); err != nil {
+		wsc.webSocketService.SendError(connID, topic, err.Error())
+		return
+	}
+	logging.Info("WebSocket connection %s subscribed to %s", connID, topic)
+}
+
 func (wsc *WebSocketController) GetWebSocketUpgrade() fiber.Handler {
 	return wsc.upgradeWebSocket
 }
@@ -4,6 +4,8 @@ import (
 	"acc-server-manager/local/model"
 	"acc-server-manager/local/utl/configs"
 	"acc-server-manager/local/utl/logging"
+	"crypto/subtle"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -52,7 +54,20 @@ func (m *AccessKeyMiddleware) Authenticate(ctx *fiber.Ctx) error {
 	ctx.Locals("userID", m.userInfo.UserID)
 	ctx.Locals("userInfo", &m.userInfo)
 	ctx.Locals("authTime", time.Now())
+	ctx.SetUserContext(model.ContextWithActor(ctx.UserContext(), model.NewUserActor(m.userInfo.UserID, m.userInfo.Username)))
 
 	logging.InfoWithContext("AUTH", "User %s authenticated successfully from IP %s", m.userInfo.UserID, ip)
 	return ctx.Next()
 }
+
+// AuthenticateScrape lets metrics scrapers in with the scrape token as a
+// bearer token, and anyone else with the access key.
+func (m *AccessKeyMiddleware) AuthenticateScrape(ctx *fiber.Ctx) error {
+	if configs.MetricsToken != "" {
+		token, ok := strings.CutPrefix(ctx.Get("Authorization"), "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(configs.MetricsToken)) == 1 {
+			return ctx.Next()
+		}
+	}
+	return m.Authenticate(ctx)
+}
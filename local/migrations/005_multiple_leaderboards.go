@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"acc-server-manager/local/utl/logging"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Migration005MultipleLeaderboards drops the unique index that limited each
+// server to a single leaderboard. AutoMigrate recreates it as a plain index.
+type Migration005MultipleLeaderboards struct {
+	DB *gorm.DB
+}
+
+func NewMigration005MultipleLeaderboards(db *gorm.DB) *Migration005MultipleLeaderboards {
+	return &Migration005MultipleLeaderboards{DB: db}
+}
+
+func (m *Migration005MultipleLeaderboards) Up() error {
+	if err := m.DB.AutoMigrate(&MigrationRecord{}); err != nil {
+		return fmt.Errorf("failed to create migration tracking table: %v", err)
+	}
+
+	var record MigrationRecord
+	if err := m.DB.Where("migration_name = ?", "005_multiple_leaderboards").First(&record).Error; err == nil {
+		logging.Info("Multiple leaderboards migration already applied, skipping")
+		return nil
+	}
+
+	if err := m.DB.Exec("DROP INDEX IF EXISTS idx_leaderboards_server_id").Error; err != nil {
+		return fmt.Errorf("failed to drop leaderboard server index: %v", err)
+	}
+
+	record = MigrationRecord{
+		MigrationName: "005_multiple_leaderboards",
+		AppliedAt:     "datetime('now')",
+		Success:       true,
+		Notes:         "Dropped unique index idx_leaderboards_server_id",
+	}
+	if err := m.DB.Create(&record).Error; err != nil {
+		return fmt.Errorf("failed to record migration: %v", err)
+	}
+
+	return nil
+}
+
+func (m *Migration005MultipleLeaderboards) Down() error {
+	return fmt.Errorf("multiple leaderboards migration rollback is not supported")
+}
+
+func RunMultipleLeaderboardsMigration(db *gorm.DB) error {
+	return NewMigration005MultipleLeaderboards(db).Up()
+}
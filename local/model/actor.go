@@ -0,0 +1,50 @@
+package model
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ActorExternal marks changes that were made outside the manager, e.g. by
+	// editing config files on disk.
+	ActorExternal = "external"
+	// ActorSystem marks changes made by the manager itself without a user.
+	ActorSystem = "system"
+)
+
+// Actor identifies who triggered an action, for audit fields such as
+// Config.ChangedBy.
+type Actor struct {
+	UserID   *uuid.UUID
+	Username string
+}
+
+type actorContextKey struct{}
+
+// ContextWithActor attaches the acting user to ctx.
+func ContextWithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the acting user, or the system actor when the
+// context was not created by an authenticated request.
+func ActorFromContext(ctx context.Context) Actor {
+	if ctx != nil {
+		if actor, ok := ctx.Value(actorContextKey{}).(Actor); ok {
+			return actor
+		}
+	}
+	return Actor{Username: ActorSystem}
+}
+
+// NewUserActor builds an Actor from the user ID and name stored by the auth
+// middleware. An unparsable ID is kept out of the actor rather than failing.
+func NewUserActor(userID, username string) Actor {
+	actor := Actor{Username: username}
+	if id, err := uuid.Parse(userID); err == nil {
+		actor.UserID = &id
+	}
+	return actor
+}
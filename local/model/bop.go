@@ -0,0 +1,91 @@
+package model
+
+import "fmt"
+
+const (
+	MinBOPBallastKg  = -40
+	MaxBOPBallastKg  = 40
+	MaxBOPRestrictor = 20
+)
+
+// BOP is the content of bop.json, the per-track balance of performance
+// applied on top of ACC's defaults.
+type BOP struct {
+	Entries []BOPEntry `json:"entries"`
+}
+
+type BOPEntry struct {
+	Track      string    `json:"track"`
+	CarModel   IntString `json:"carModel"`
+	BallastKg  IntString `json:"ballastKg"`
+	Restrictor IntString `json:"restrictor"`
+}
+
+func (e BOPEntry) matches(track string, carModel int) bool {
+	return e.Track == track && e.CarModel.ToInt() == carModel
+}
+
+// Set adds the entry, replacing any existing entry for the same track and car.
+func (b *BOP) Set(entry BOPEntry) {
+	for i := range b.Entries {
+		if b.Entries[i].matches(entry.Track, entry.CarModel.ToInt()) {
+			b.Entries[i] = entry
+			return
+		}
+	}
+	b.Entries = append(b.Entries, entry)
+}
+
+// Remove deletes the entry for the track and car and reports whether it existed.
+func (b *BOP) Remove(track string, carModel int) bool {
+	for i := range b.Entries {
+		if b.Entries[i].matches(track, carModel) {
+			b.Entries = append(b.Entries[:i], b.Entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ForTrack returns the entries for a single track.
+func (b *BOP) ForTrack(track string) []BOPEntry {
+	entries := make([]BOPEntry, 0)
+	for _, entry := range b.Entries {
+		if entry.Track == track {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Validate checks every entry against the known tracks and car models.
+func (b *BOP) Validate(tracks map[string]Track, carModels map[int]bool) error {
+	verr := &ValidationError{}
+
+	seen := make(map[string]int)
+	for i, entry := range b.Entries {
+		prefix := fmt.Sprintf("entries[%d]", i)
+
+		if _, ok := tracks[entry.Track]; !ok {
+			verr.Add(prefix+".track", "unknown track %q", entry.Track)
+		}
+		if !carModels[entry.CarModel.ToInt()] {
+			verr.Add(prefix+".carModel", "unknown car model %d", entry.CarModel.ToInt())
+		}
+		if ballast := entry.BallastKg.ToInt(); ballast < MinBOPBallastKg || ballast > MaxBOPBallastKg {
+			verr.Add(prefix+".ballastKg", "must be between %d and %d", MinBOPBallastKg, MaxBOPBallastKg)
+		}
+		if restrictor := entry.Restrictor.ToInt(); restrictor < 0 || restrictor > MaxBOPRestrictor {
+			verr.Add(prefix+".restrictor", "must be between 0 and %d", MaxBOPRestrictor)
+		}
+
+		key := fmt.Sprintf("%s/%d", entry.Track, entry.CarModel.ToInt())
+		if other, ok := seen[key]; ok {
+			verr.Add(prefix, "duplicates entries[%d] for %s car model %d", other, entry.Track, entry.CarModel.ToInt())
+		} else {
+			seen[key] = i
+		}
+	}
+
+	return verr.ErrOrNil()
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"acc-server-manager/local/utl/logging"
+	"acc-server-manager/local/utl/metrics"
 	"sync"
 	"time"
 )
@@ -38,6 +39,7 @@ func (c *ServerStatusCache) GetStatus(serviceName string) (ServiceStatus, bool)
 
 	if lastCheck, exists := c.lastChecked[serviceName]; exists {
 		if time.Since(lastCheck) < c.config.ThrottleTime {
+			metrics.CacheRequests.Inc(metrics.CacheStatus, metrics.CacheHit)
 			if cached, ok := c.cache[serviceName]; ok {
 				return cached.Status, false
 			}
@@ -47,10 +49,12 @@ func (c *ServerStatusCache) GetStatus(serviceName string) (ServiceStatus, bool)
 
 	if cached, ok := c.cache[serviceName]; ok {
 		if time.Since(cached.UpdatedAt) < c.config.ExpirationTime {
+			metrics.CacheRequests.Inc(metrics.CacheStatus, metrics.CacheHit)
 			return cached.Status, false
 		}
 	}
 
+	metrics.CacheRequests.Inc(metrics.CacheStatus, metrics.CacheMiss)
 	return StatusUnknown, true
 }
 
@@ -131,12 +135,14 @@ func getConfigFromCache[T any](cache map[string]*ConfigEntry[T], serverID string
 	if entry, ok := cache[serverID]; ok {
 		if time.Since(entry.UpdatedAt) < expirationTime {
 			logging.Debug("Config cache HIT for server ID: %s", serverID)
+			metrics.CacheRequests.Inc(metrics.CacheConfig, metrics.CacheHit)
 			return &entry.Data, true
 		}
 		logging.Debug("Config cache EXPIRED for server ID: %s", serverID)
 	} else {
 		logging.Debug("Config cache MISS for server ID: %s", serverID)
 	}
+	metrics.CacheRequests.Inc(metrics.CacheConfig, metrics.CacheMiss)
 	return nil, false
 }
 
@@ -14,12 +14,14 @@ type IntString int
 type IntBool int
 
 type Config struct {
-	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
-	ServerID   uuid.UUID `json:"serverId" gorm:"not null;type:uuid"`
-	ConfigFile string    `json:"configFile" gorm:"not null"`
-	OldConfig  string    `json:"oldConfig" gorm:"type:text"`
-	NewConfig  string    `json:"newConfig" gorm:"type:text"`
-	ChangedAt  time.Time `json:"changedAt" gorm:"default:CURRENT_TIMESTAMP"`
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;"`
+	ServerID    uuid.UUID  `json:"serverId" gorm:"not null;type:uuid"`
+	ConfigFile  string     `json:"configFile" gorm:"not null"`
+	OldConfig   string     `json:"oldConfig" gorm:"type:text"`
+	NewConfig   string     `json:"newConfig" gorm:"type:text"`
+	ChangedAt   time.Time  `json:"changedAt" gorm:"default:CURRENT_TIMESTAMP"`
+	ChangedByID *uuid.UUID `json:"changedById" gorm:"type:uuid"`
+	ChangedBy   string     `json:"changedBy"`
 }
 
 func (c *Config) BeforeCreate(tx *gorm.DB) error {
@@ -56,6 +58,7 @@ type ServerSettings struct {
 	CentralEntryListPath       string    `json:"centralEntryListPath"`
 	AllowAutoDQ                IntString `json:"allowAutoDQ"`
 	ShortFormationLap          IntString `json:"shortFormationLap"`
+	DumpEntryList              IntString `json:"dumpEntryList"`
 	FormationLapType           IntString `json:"formationLapType"`
 	IgnorePrematureDisconnects IntString `json:"ignorePrematureDisconnects"`
 }
@@ -72,6 +75,7 @@ type EventConfig struct {
 	PostRaceSeconds               IntString `json:"postRaceSeconds"`
 	SimracerWeatherConditions     IntString `json:"simracerWeatherConditions"`
 	IsFixedConditionQualification IntString `json:"isFixedConditionQualification"`
+	MetaData                      string    `json:"metaData,omitempty"`
 
 	Sessions []Session `json:"sessions"`
 }
@@ -99,9 +103,10 @@ type AssistRules struct {
 type EventRules struct {
 	QualifyStandingType                  IntString `json:"qualifyStandingType"`
 	PitWindowLengthSec                   IntString `json:"pitWindowLengthSec"`
-	DriverStIntStringTimeSec             IntString `json:"driverStIntStringTimeSec"`
+	DriverStintTimeSec                   IntString `json:"driverStintTimeSec"`
 	MandatoryPitstopCount                IntString `json:"mandatoryPitstopCount"`
 	MaxTotalDrivingTime                  IntString `json:"maxTotalDrivingTime"`
+	MaxDriversCount                      IntString `json:"maxDriversCount"`
 	IsRefuellingAllowedInRace            IntBool   `json:"isRefuellingAllowedInRace"`
 	IsRefuellingTimeFixed                IntBool   `json:"isRefuellingTimeFixed"`
 	IsMandatoryPitstopRefuellingRequired IntBool   `json:"isMandatoryPitstopRefuellingRequired"`
@@ -117,6 +122,29 @@ type Configuration struct {
 	LanDiscovery    IntString `json:"lanDiscovery"`
 	RegisterToLobby IntString `json:"registerToLobby"`
 	ConfigVersion   IntString `json:"configVersion"`
+	PublicIP        string    `json:"publicIP,omitempty"`
+}
+
+// Broadcasting is broadcasting.json, the settings of the server's UDP
+// broadcasting API. A zero UdpListenerPort disables it.
+type Broadcasting struct {
+	UdpListenerPort    IntString `json:"updListenerPort"`
+	ConnectionPassword string    `json:"connectionPassword"`
+	CommandPassword    string    `json:"commandPassword"`
+}
+
+// Validate checks the listener port, which must not be one of the ports the
+// server is reached on.
+func (b *Broadcasting) Validate(configuration *Configuration) error {
+	verr := &ValidationError{}
+	port := b.UdpListenerPort.ToInt()
+	if port < 0 || port > 65535 {
+		verr.Add("updListenerPort", "must be between 0 and 65535")
+	} else if port != 0 && configuration != nil &&
+		(port == configuration.UdpPort.ToInt() || port == configuration.TcpPort.ToInt()) {
+		verr.Add("updListenerPort", "must differ from the server's udpPort and tcpPort")
+	}
+	return verr.ErrOrNil()
 }
 
 func (i *IntBool) UnmarshalJSON(b []byte) error {
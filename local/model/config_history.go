@@ -0,0 +1,126 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+type ConfigChangeType string
+
+const (
+	ConfigChangeAdded   ConfigChangeType = "added"
+	ConfigChangeRemoved ConfigChangeType = "removed"
+	ConfigChangeChanged ConfigChangeType = "changed"
+)
+
+// ConfigChange is a single difference between two versions of a config file.
+// Path uses dot notation for object keys and [i] for array indexes, e.g.
+// "sessions[1].sessionDurationMinutes".
+type ConfigChange struct {
+	Path     string           `json:"path"`
+	Type     ConfigChangeType `json:"type"`
+	OldValue interface{}      `json:"oldValue,omitempty"`
+	NewValue interface{}      `json:"newValue,omitempty"`
+}
+
+type ConfigDiff struct {
+	ConfigFile     string         `json:"configFile"`
+	FromRevisionID *uuid.UUID     `json:"fromRevisionId"`
+	ToRevisionID   uuid.UUID      `json:"toRevisionId"`
+	Changes        []ConfigChange `json:"changes"`
+}
+
+// DiffConfigs compares two JSON documents and lists every leaf that was
+// added, removed or changed. Empty input is treated as an empty object.
+func DiffConfigs(oldConfig, newConfig string) ([]ConfigChange, error) {
+	oldValue, err := decodeConfigForDiff(oldConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse old config: %v", err)
+	}
+	newValue, err := decodeConfigForDiff(newConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new config: %v", err)
+	}
+
+	return DiffConfigValues(oldValue, newValue), nil
+}
+
+// DiffConfigValues is DiffConfigs for already decoded JSON values.
+func DiffConfigValues(oldValue, newValue interface{}) []ConfigChange {
+	changes := make([]ConfigChange, 0)
+	diffValues("", oldValue, newValue, &changes)
+	return changes
+}
+
+func decodeConfigForDiff(config string) (interface{}, error) {
+	if config == "" {
+		return map[string]interface{}{}, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(config), &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func diffValues(path string, oldValue, newValue interface{}, changes *[]ConfigChange) {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make(map[string]struct{}, len(oldMap)+len(newMap))
+		for key := range oldMap {
+			keys[key] = struct{}{}
+		}
+		for key := range newMap {
+			keys[key] = struct{}{}
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+
+		for _, key := range sorted {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			oldChild, inOld := oldMap[key]
+			newChild, inNew := newMap[key]
+			switch {
+			case !inOld:
+				*changes = append(*changes, ConfigChange{Path: childPath, Type: ConfigChangeAdded, NewValue: newChild})
+			case !inNew:
+				*changes = append(*changes, ConfigChange{Path: childPath, Type: ConfigChangeRemoved, OldValue: oldChild})
+			default:
+				diffValues(childPath, oldChild, newChild, changes)
+			}
+		}
+		return
+	}
+
+	oldSlice, oldIsSlice := oldValue.([]interface{})
+	newSlice, newIsSlice := newValue.([]interface{})
+	if oldIsSlice && newIsSlice {
+		for i := 0; i < len(oldSlice) || i < len(newSlice); i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(oldSlice):
+				*changes = append(*changes, ConfigChange{Path: childPath, Type: ConfigChangeAdded, NewValue: newSlice[i]})
+			case i >= len(newSlice):
+				*changes = append(*changes, ConfigChange{Path: childPath, Type: ConfigChangeRemoved, OldValue: oldSlice[i]})
+			default:
+				diffValues(childPath, oldSlice[i], newSlice[i], changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(oldValue, newValue) {
+		*changes = append(*changes, ConfigChange{Path: path, Type: ConfigChangeChanged, OldValue: oldValue, NewValue: newValue})
+	}
+}
@@ -0,0 +1,183 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ConfigTemplateContent is a partial Configurations document keyed by the
+// same section names ("assistRules", "eventRules", ...). Only the fields that
+// are present are written when the template is applied.
+type ConfigTemplateContent map[string]map[string]interface{}
+
+func (c *ConfigTemplateContent) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case nil:
+		*c = ConfigTemplateContent{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported type for ConfigTemplateContent: %T", value)
+	}
+	return json.Unmarshal(data, c)
+}
+
+func (c ConfigTemplateContent) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Sections returns the section names in a stable order.
+func (c ConfigTemplateContent) Sections() []string {
+	sections := make([]string, 0, len(c))
+	for section := range c {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	return sections
+}
+
+// Validate makes sure every section and field exists in Configurations and
+// has an accepted value. Errors are reported as "section.field".
+func (c ConfigTemplateContent) Validate() error {
+	verr := &ValidationError{}
+	if len(c) == 0 {
+		verr.Add("content", "at least one section is required")
+		return verr
+	}
+
+	for _, section := range c.Sections() {
+		if !IsConfigSection(section) {
+			verr.Add(section, "unknown config section")
+			continue
+		}
+		err := ValidateConfigSection(section, c[section], ConfigValidationContext{})
+		if sectionErr, ok := err.(*ValidationError); ok {
+			for _, fieldErr := range sectionErr.Errors {
+				verr.Add(section+"."+fieldErr.Field, "%s", fieldErr.Message)
+			}
+		}
+	}
+	return verr.ErrOrNil()
+}
+
+type ConfigTemplate struct {
+	ID          uuid.UUID             `json:"id" gorm:"type:uuid;primary_key;"`
+	Name        string                `json:"name" gorm:"not null;uniqueIndex"`
+	Description string                `json:"description"`
+	Content     ConfigTemplateContent `json:"content" gorm:"type:text"`
+	CreatedAt   time.Time             `json:"createdAt"`
+	UpdatedAt   time.Time             `json:"updatedAt"`
+}
+
+func (t *ConfigTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// ConfigTemplateAssignment remembers the template a server was last given, so
+// drift can be reported against it.
+type ConfigTemplateAssignment struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	TemplateID uuid.UUID `json:"templateId" gorm:"not null;type:uuid;index"`
+	ServerID   uuid.UUID `json:"serverId" gorm:"not null;type:uuid;uniqueIndex"`
+	Override   bool      `json:"override"`
+	AppliedAt  time.Time `json:"appliedAt"`
+	AppliedBy  string    `json:"appliedBy"`
+}
+
+func (a *ConfigTemplateAssignment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+type ConfigTemplateFilter struct {
+	BaseFilter
+	Name string `query:"name"`
+}
+
+func (f *ConfigTemplateFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.Name != "" {
+		query = query.Where("name LIKE ?", "%"+f.Name+"%")
+	}
+	return query
+}
+
+func (f *ConfigTemplateFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+func (f *ConfigTemplateFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "name", false
+	}
+	return f.BaseFilter.GetSorting()
+}
+
+type ApplyConfigTemplateRequest struct {
+	ServerIDs []uuid.UUID `json:"serverIds"`
+}
+
+type ConfigTemplateApplyResult struct {
+	ServerID uuid.UUID `json:"serverId"`
+	Success  bool      `json:"success"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// ConfigTemplateDrift reports how a server's files differ from the template
+// it was last given. Changes are keyed by config file.
+type ConfigTemplateDrift struct {
+	ServerID   uuid.UUID                 `json:"serverId"`
+	ServerName string                    `json:"serverName"`
+	AppliedAt  time.Time                 `json:"appliedAt"`
+	Override   bool                      `json:"override"`
+	Drifted    bool                      `json:"drifted"`
+	Changes    map[string][]ConfigChange `json:"changes,omitempty"`
+	Error      string                    `json:"error,omitempty"`
+}
+
+// NormalizeConfigValue makes values comparable across the loose encodings
+// ACC accepts, where "30" and 30 mean the same thing.
+func NormalizeConfigValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			normalized[key] = NormalizeConfigValue(child)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, child := range v {
+			normalized[i] = NormalizeConfigValue(child)
+		}
+		return normalized
+	default:
+		return v
+	}
+}
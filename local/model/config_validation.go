@@ -0,0 +1,340 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigValidationContext carries what validating one config section needs
+// to know about the rest of the server's configuration.
+type ConfigValidationContext struct {
+	// Tracks are the known tracks keyed by name. Track names are not checked
+	// when it is nil.
+	Tracks map[string]Track
+	// Track is the track currently set in event.json.
+	Track string
+	// MaxCarSlots is the value currently set in settings.json.
+	MaxCarSlots int
+}
+
+type configRange struct {
+	min, max float64
+}
+
+const unbounded = math.MaxInt32
+
+var (
+	flagRange    = configRange{0, 1}
+	seconds      = configRange{0, unbounded}
+	disableRange = configRange{-1, unbounded}
+)
+
+// configSections are the typed structs backing each section of
+// Configurations, keyed by their JSON name.
+var configSections = map[string]reflect.Type{
+	"configuration": reflect.TypeOf(Configuration{}),
+	"assistRules":   reflect.TypeOf(AssistRules{}),
+	"event":         reflect.TypeOf(EventConfig{}),
+	"eventRules":    reflect.TypeOf(EventRules{}),
+	"settings":      reflect.TypeOf(ServerSettings{}),
+}
+
+// configRanges are the accepted values of the numeric fields, as documented
+// for the ACC dedicated server.
+var configRanges = map[string]map[string]configRange{
+	"configuration": {
+		"udpPort":         {1, 65535},
+		"tcpPort":         {1, 65535},
+		"maxConnections":  {1, unbounded},
+		"lanDiscovery":    flagRange,
+		"registerToLobby": flagRange,
+		"configVersion":   {1, 1},
+	},
+	"assistRules": {
+		"stabilityControlLevelMax": {0, 100},
+		"disableAutosteer":         flagRange,
+		"disableAutoLights":        flagRange,
+		"disableAutoWiper":         flagRange,
+		"disableAutoEngineStart":   flagRange,
+		"disableAutoPitLimiter":    flagRange,
+		"disableAutoGear":          flagRange,
+		"disableAutoClutch":        flagRange,
+		"disableIdealLine":         flagRange,
+	},
+	"event": {
+		"preRaceWaitingTimeSeconds":     {30, unbounded},
+		"sessionOverTimeSeconds":        seconds,
+		"ambientTemp":                   {0, 50},
+		"cloudLevel":                    {0, 1},
+		"rain":                          {0, 1},
+		"weatherRandomness":             {0, 7},
+		"postQualySeconds":              seconds,
+		"postRaceSeconds":               seconds,
+		"simracerWeatherConditions":     flagRange,
+		"isFixedConditionQualification": flagRange,
+	},
+	"eventRules": {
+		"qualifyStandingType":   {1, 2},
+		"pitWindowLengthSec":    disableRange,
+		"driverStintTimeSec":    disableRange,
+		"mandatoryPitstopCount": {0, unbounded},
+		"maxTotalDrivingTime":   disableRange,
+		"maxDriversCount":       {1, unbounded},
+		"tyreSetCount":          {0, 50},
+	},
+	"settings": {
+		"trackMedalsRequirement":     {0, 3},
+		"safetyRatingRequirement":    {-1, 99},
+		"racecraftRatingRequirement": {-1, 99},
+		"maxCarSlots":                {1, unbounded},
+		"dumpLeaderboards":           flagRange,
+		"isRaceLocked":               flagRange,
+		"randomizeTrackWhenEmpty":    flagRange,
+		"allowAutoDQ":                flagRange,
+		"shortFormationLap":          flagRange,
+		"dumpEntryList":              flagRange,
+		"formationLapType":           {0, 3},
+		"ignorePrematureDisconnects": flagRange,
+	},
+}
+
+var sessionRanges = map[string]configRange{
+	"hourOfDay":              {0, 23},
+	"dayOfWeekend":           {1, 3},
+	"timeMultiplier":         {0, 24},
+	"sessionDurationMinutes": {1, unbounded},
+}
+
+// CarGroups are the values ACC accepts for settings.carGroup.
+var CarGroups = []string{"FreeForAll", "GT3", "GT4", "GT2", "GTC", "TCX"}
+
+// sharedConfigKeys are present in every file ACC writes but are not part of
+// the typed sections.
+var sharedConfigKeys = map[string]bool{"configVersion": true}
+
+// IsSharedConfigKey reports whether key is one ACC writes into every config
+// file, such as configVersion.
+func IsSharedConfigKey(key string) bool {
+	return sharedConfigKeys[key]
+}
+
+// IsConfigSection reports whether section names a part of Configurations.
+func IsConfigSection(section string) bool {
+	_, ok := configSections[section]
+	return ok
+}
+
+// ValidateConfigSection checks the fields of a config update for one section
+// against its typed struct: unknown keys, values that do not decode, values
+// out of range and the rules that span several fields. Only the fields in
+// body are checked, so partial updates are validated on their own.
+func ValidateConfigSection(section string, body map[string]interface{}, vctx ConfigValidationContext) error {
+	verr := &ValidationError{}
+	typ, ok := configSections[section]
+	if !ok {
+		verr.Add("section", "unknown config section %q", section)
+		return verr
+	}
+
+	decoded, valid := decodeConfigFields(typ, body, "", verr)
+	checkConfigRanges(decoded, valid, configRanges[section], "", verr)
+
+	switch value := decoded.Interface().(type) {
+	case EventConfig:
+		validateEvent(&value, valid, vctx, verr)
+	case ServerSettings:
+		validateSettings(&value, valid, vctx, verr)
+	}
+	return verr.ErrOrNil()
+}
+
+// decodeConfigFields decodes body into a new value of typ one field at a
+// time, so every unknown or malformed field is reported rather than only the
+// first one. It returns the value and the keys that decoded cleanly.
+func decodeConfigFields(typ reflect.Type, body map[string]interface{}, prefix string, verr *ValidationError) (reflect.Value, map[string]bool) {
+	value := reflect.New(typ)
+	fields := jsonFields(typ)
+	valid := make(map[string]bool, len(body))
+
+	for _, key := range sortedKeys(body) {
+		field, known := fields[key]
+		if !known {
+			if !sharedConfigKeys[key] || prefix != "" {
+				verr.Add(prefix+key, "unknown field")
+			}
+			continue
+		}
+		if field.Type == reflect.TypeOf([]Session{}) {
+			valid[key] = decodeSessions(body[key], value.Elem().FieldByIndex(field.Index), prefix+key, verr)
+			continue
+		}
+
+		raw, err := json.Marshal(body[key])
+		if err != nil {
+			verr.Add(prefix+key, "%v", err)
+			continue
+		}
+		target := reflect.New(field.Type)
+		if err := json.Unmarshal(raw, target.Interface()); err != nil {
+			verr.Add(prefix+key, "invalid value %s", raw)
+			continue
+		}
+		value.Elem().FieldByIndex(field.Index).Set(target.Elem())
+		valid[key] = true
+	}
+	return value.Elem(), valid
+}
+
+func decodeSessions(raw interface{}, target reflect.Value, field string, verr *ValidationError) bool {
+	items, ok := raw.([]interface{})
+	if !ok {
+		verr.Add(field, "must be a list of sessions")
+		return false
+	}
+
+	errorCount := len(verr.Errors)
+
+	sessions := make([]Session, len(items))
+	for i, item := range items {
+		prefix := fmt.Sprintf("%s[%d].", field, i)
+		body, ok := item.(map[string]interface{})
+		if !ok {
+			verr.Add(fmt.Sprintf("%s[%d]", field, i), "must be an object")
+			continue
+		}
+
+		decoded, valid := decodeConfigFields(reflect.TypeOf(Session{}), body, prefix, verr)
+		checkConfigRanges(decoded, valid, sessionRanges, prefix, verr)
+		sessions[i] = decoded.Interface().(Session)
+		if valid["sessionType"] && sessions[i].SessionType == SessionUnknown {
+			verr.Add(prefix+"sessionType", "must be P, Q or R")
+		}
+	}
+	target.Set(reflect.ValueOf(sessions))
+	return len(verr.Errors) == errorCount
+}
+
+// checkConfigRanges reports the decoded fields that fall outside their range.
+func checkConfigRanges(value reflect.Value, valid map[string]bool, ranges map[string]configRange, prefix string, verr *ValidationError) {
+	fields := jsonFields(value.Type())
+	for _, key := range sortedRangeKeys(ranges) {
+		field, ok := fields[key]
+		if !ok || !valid[key] {
+			continue
+		}
+		fieldValue := value.FieldByIndex(field.Index)
+
+		var number float64
+		switch fieldValue.Kind() {
+		case reflect.Int, reflect.Int64:
+			number = float64(fieldValue.Int())
+		case reflect.Float64:
+			number = fieldValue.Float()
+		default:
+			continue
+		}
+
+		r := ranges[key]
+		if number < r.min || number > r.max {
+			verr.Add(prefix+key, "%s", r.describe())
+		}
+	}
+}
+
+func (r configRange) describe() string {
+	if r.max == unbounded {
+		return fmt.Sprintf("must be at least %g", r.min)
+	}
+	if r.min == r.max {
+		return fmt.Sprintf("must be %g", r.min)
+	}
+	return fmt.Sprintf("must be between %g and %g", r.min, r.max)
+}
+
+func validateEvent(event *EventConfig, valid map[string]bool, vctx ConfigValidationContext, verr *ValidationError) {
+	if valid["track"] && vctx.Tracks != nil {
+		track, known := vctx.Tracks[event.Track]
+		if !known {
+			verr.Add("track", "unknown track %q", event.Track)
+		} else if vctx.MaxCarSlots > track.PrivateServerSlots && track.PrivateServerSlots > 0 {
+			verr.Add("track", "%s has %d slots but settings.maxCarSlots is %d", track.Name, track.PrivateServerSlots, vctx.MaxCarSlots)
+		}
+	}
+
+	if !valid["sessions"] {
+		return
+	}
+	if len(event.Sessions) == 0 {
+		verr.Add("sessions", "at least one session is required")
+		return
+	}
+
+	order := map[TrackSession]int{SessionPractice: 0, SessionQualify: 1, SessionRace: 2}
+	for i := 1; i < len(event.Sessions); i++ {
+		previous, current := event.Sessions[i-1], event.Sessions[i]
+		field := fmt.Sprintf("sessions[%d]", i)
+		if current.DayOfWeekend < previous.DayOfWeekend {
+			verr.Add(field+".dayOfWeekend", "must not be before the previous session")
+		}
+		if order[current.SessionType] < order[previous.SessionType] {
+			verr.Add(field+".sessionType", "%s cannot follow %s", current.SessionType.Humanize(), previous.SessionType.Humanize())
+		}
+	}
+}
+
+func validateSettings(settings *ServerSettings, valid map[string]bool, vctx ConfigValidationContext, verr *ValidationError) {
+	if valid["carGroup"] && settings.CarGroup != "" {
+		valid := false
+		for _, group := range CarGroups {
+			if settings.CarGroup == group {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			verr.Add("carGroup", "must be one of %s", strings.Join(CarGroups, ", "))
+		}
+	}
+
+	if valid["maxCarSlots"] && vctx.Tracks != nil {
+		if track, known := vctx.Tracks[vctx.Track]; known && track.PrivateServerSlots > 0 && settings.MaxCarSlots.ToInt() > track.PrivateServerSlots {
+			verr.Add("maxCarSlots", "must be at most %d on %s", track.PrivateServerSlots, track.Name)
+		}
+	}
+}
+
+// jsonFields maps the JSON names of a struct's fields to the fields.
+func jsonFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func sortedKeys(body map[string]interface{}) []string {
+	keys := make([]string, 0, len(body))
+	for key := range body {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedRangeKeys(ranges map[string]configRange) []string {
+	keys := make([]string, 0, len(ranges))
+	for key := range ranges {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+const (
+	// NoForcedCarModel is the forcedCarModel value ACC uses for "driver picks the car".
+	NoForcedCarModel = -1
+
+	MaxRaceNumber = 998
+	MaxBallastKg  = 100
+	MaxRestrictor = 20
+)
+
+var steamIDRegex = regexp.MustCompile(`^S\d{17}$`)
+
+type EntryList struct {
+	Entries        []Entry   `json:"entries"`
+	ForceEntryList IntString `json:"forceEntryList"`
+}
+
+type Entry struct {
+	Drivers                      []EntryDriver `json:"drivers"`
+	RaceNumber                   IntString     `json:"raceNumber"`
+	ForcedCarModel               IntString     `json:"forcedCarModel"`
+	OverrideDriverInfo           IntString     `json:"overrideDriverInfo"`
+	CustomCar                    string        `json:"customCar,omitempty"`
+	OverrideCarModelForCustomCar IntString     `json:"overrideCarModelForCustomCar"`
+	IsServerAdmin                IntString     `json:"isServerAdmin"`
+	DefaultGridPosition          IntString     `json:"defaultGridPosition"`
+	BallastKg                    IntString     `json:"ballastKg"`
+	Restrictor                   IntString     `json:"restrictor"`
+}
+
+// NewEntry returns an entry with ACC's defaults for fields that mean
+// "not set", so a zero value is never read as car model 0 or race number 0.
+func NewEntry() Entry {
+	return Entry{
+		RaceNumber:     -1,
+		ForcedCarModel: NoForcedCarModel,
+	}
+}
+
+func (e *Entry) UnmarshalJSON(b []byte) error {
+	type entryAlias Entry
+	alias := entryAlias(NewEntry())
+	if err := json.Unmarshal(b, &alias); err != nil {
+		return err
+	}
+	*e = Entry(alias)
+	return nil
+}
+
+type EntryDriver struct {
+	FirstName      string    `json:"firstName"`
+	LastName       string    `json:"lastName"`
+	ShortName      string    `json:"shortName"`
+	DriverCategory IntString `json:"driverCategory"`
+	PlayerID       string    `json:"playerID"`
+	Nationality    IntString `json:"nationality"`
+}
+
+// Validate checks the entry list against the known car models. carModels is
+// keyed by the CarModel lookup value.
+func (l *EntryList) Validate(carModels map[int]bool) error {
+	verr := &ValidationError{}
+
+	if l.ForceEntryList != 0 && l.ForceEntryList != 1 {
+		verr.Add("forceEntryList", "must be 0 or 1")
+	}
+
+	raceNumbers := make(map[int]int)
+	playerIDs := make(map[string]int)
+	for i := range l.Entries {
+		l.Entries[i].validate(fmt.Sprintf("entries[%d]", i), carModels, verr)
+
+		if number := l.Entries[i].RaceNumber.ToInt(); number > 0 {
+			if other, ok := raceNumbers[number]; ok {
+				verr.Add(fmt.Sprintf("entries[%d].raceNumber", i), "race number %d is already used by entries[%d]", number, other)
+			} else {
+				raceNumbers[number] = i
+			}
+		}
+
+		for j, driver := range l.Entries[i].Drivers {
+			if driver.PlayerID == "" {
+				continue
+			}
+			if other, ok := playerIDs[driver.PlayerID]; ok {
+				verr.Add(fmt.Sprintf("entries[%d].drivers[%d].playerID", i, j), "player %s is already in entries[%d]", driver.PlayerID, other)
+			} else {
+				playerIDs[driver.PlayerID] = i
+			}
+		}
+	}
+
+	return verr.ErrOrNil()
+}
+
+func (e *Entry) validate(prefix string, carModels map[int]bool, verr *ValidationError) {
+	if len(e.Drivers) == 0 {
+		verr.Add(prefix+".drivers", "at least one driver is required")
+	}
+	for i, driver := range e.Drivers {
+		field := fmt.Sprintf("%s.drivers[%d].playerID", prefix, i)
+		if !steamIDRegex.MatchString(driver.PlayerID) {
+			verr.Add(field, "must be a Steam ID in the form S7656119xxxxxxxxxx")
+		}
+	}
+
+	if number := e.RaceNumber.ToInt(); number != -1 && (number < 1 || number > MaxRaceNumber) {
+		verr.Add(prefix+".raceNumber", "must be between 1 and %d, or -1 for unassigned", MaxRaceNumber)
+	}
+	if car := e.ForcedCarModel.ToInt(); car != NoForcedCarModel && !carModels[car] {
+		verr.Add(prefix+".forcedCarModel", "unknown car model %d", car)
+	}
+	if e.CustomCar != "" {
+		if car := e.OverrideCarModelForCustomCar.ToInt(); car != 0 && !carModels[car] {
+			verr.Add(prefix+".overrideCarModelForCustomCar", "unknown car model %d", car)
+		}
+	}
+	if e.IsServerAdmin != 0 && e.IsServerAdmin != 1 {
+		verr.Add(prefix+".isServerAdmin", "must be 0 or 1")
+	}
+	if e.OverrideDriverInfo != 0 && e.OverrideDriverInfo != 1 {
+		verr.Add(prefix+".overrideDriverInfo", "must be 0 or 1")
+	}
+	if ballast := e.BallastKg.ToInt(); ballast < 0 || ballast > MaxBallastKg {
+		verr.Add(prefix+".ballastKg", "must be between 0 and %d", MaxBallastKg)
+	}
+	if restrictor := e.Restrictor.ToInt(); restrictor < 0 || restrictor > MaxRestrictor {
+		verr.Add(prefix+".restrictor", "must be between 0 and %d", MaxRestrictor)
+	}
+}
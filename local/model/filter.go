@@ -68,6 +68,33 @@ func (f *DateRangeFilter) IsDateRangeValid() bool {
 	return f.StartDate.Before(f.EndDate)
 }
 
+func (f *ConfigFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.ServerID != "" {
+		if serverUUID, err := uuid.Parse(f.ServerID); err == nil {
+			query = query.Where("server_id = ?", serverUUID)
+		}
+	}
+	if f.ConfigFile != "" {
+		query = query.Where("config_file = ?", f.ConfigFile)
+	}
+	if !f.ChangedAt.IsZero() {
+		query = query.Where("changed_at >= ?", f.ChangedAt)
+	}
+	return query
+}
+
+func (f *ConfigFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+// GetSorting lists the newest revisions first unless asked otherwise.
+func (f *ConfigFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "changed_at", true
+	}
+	return f.BaseFilter.GetSorting()
+}
+
 func (f *MembershipFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
 	if f.Username != "" {
 		query = query.Where("username LIKE ?", "%"+f.Username+"%")
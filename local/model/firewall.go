@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type FirewallProtocol string
+
+const (
+	FirewallTCP FirewallProtocol = "TCP"
+	FirewallUDP FirewallProtocol = "UDP"
+)
+
+// FirewallRule is an inbound allow rule owned by a server. Rules are named
+// "<serviceName>-<protocol>-<port>" on every backend so they can be listed
+// and reconciled per Server.ServiceName.
+type FirewallRule struct {
+	Name     string           `json:"name"`
+	Protocol FirewallProtocol `json:"protocol"`
+	Port     int              `json:"port"`
+}
+
+func NewFirewallRule(serviceName string, protocol FirewallProtocol, port int) FirewallRule {
+	return FirewallRule{
+		Name:     fmt.Sprintf("%s-%s-%d", serviceName, protocol, port),
+		Protocol: protocol,
+		Port:     port,
+	}
+}
+
+// ParseFirewallRuleName parses a rule name created by NewFirewallRule.
+func ParseFirewallRuleName(name string) (FirewallRule, bool) {
+	parts := strings.Split(name, "-")
+	if len(parts) < 3 {
+		return FirewallRule{}, false
+	}
+
+	port, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return FirewallRule{}, false
+	}
+
+	protocol := FirewallProtocol(strings.ToUpper(parts[len(parts)-2]))
+	if protocol != FirewallTCP && protocol != FirewallUDP {
+		return FirewallRule{}, false
+	}
+
+	return FirewallRule{Name: name, Protocol: protocol, Port: port}, true
+}
+
+// BelongsTo reports whether the rule was created for the given service.
+func (r FirewallRule) BelongsTo(serviceName string) bool {
+	return r.Name == NewFirewallRule(serviceName, r.Protocol, r.Port).Name
+}
+
+type FirewallReconcileResult struct {
+	Backend  string         `json:"backend"`
+	Managed  bool           `json:"managed"`
+	Expected []FirewallRule `json:"expected"`
+	Existing []FirewallRule `json:"existing"`
+	Created  []FirewallRule `json:"created"`
+	Removed  []FirewallRule `json:"removed"`
+}
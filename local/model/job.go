@@ -0,0 +1,131 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobType string
+
+const (
+	JobServerCreate    JobType = "server.create"
+	JobServerUpdate    JobType = "server.update"
+	JobServerReinstall JobType = "server.reinstall"
+	JobServerBackup    JobType = "server.backup"
+)
+
+type JobStatus string
+
+const (
+	JobQueued     JobStatus = "queued"
+	JobRunning    JobStatus = "running"
+	JobSucceeded  JobStatus = "succeeded"
+	JobFailed     JobStatus = "failed"
+	JobCancelled  JobStatus = "cancelled"
+	JobRolledBack JobStatus = "rolled_back"
+)
+
+// IsFinished reports whether the job has stopped for good.
+func (s JobStatus) IsFinished() bool {
+	return s != JobQueued && s != JobRunning
+}
+
+// MaxJobLogLines is how many lines of output a job keeps; older lines are
+// dropped first.
+const MaxJobLogLines = 500
+
+// JobLogs is the output of a job, one line per entry.
+type JobLogs []string
+
+func (l *JobLogs) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case nil:
+		*l = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported type for JobLogs: %T", value)
+	}
+	return json.Unmarshal(data, l)
+}
+
+func (l JobLogs) Value() (driver.Value, error) {
+	if l == nil {
+		l = JobLogs{}
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Job is a long-running operation on a server, run in the background by the
+// job workers. Step and Progress tell how far a running job got, and are
+// what a restarted manager rolls back from. Payload holds what the job's
+// handler needs to run it.
+type Job struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;"`
+	Type       JobType    `json:"type" gorm:"not null;index"`
+	ServerID   uuid.UUID  `json:"serverId" gorm:"not null;type:uuid;index"`
+	Status     JobStatus  `json:"status" gorm:"index"`
+	Step       string     `json:"step"`
+	Progress   int        `json:"progress"`
+	Logs       JobLogs    `json:"logs" gorm:"type:text"`
+	Result     string     `json:"result"`
+	Error      string     `json:"error"`
+	Payload    string     `json:"-" gorm:"type:text"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"index"`
+	StartedAt  *time.Time `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+type JobFilter struct {
+	BaseFilter
+	ServerBasedFilter
+	Type   JobType   `query:"type"`
+	Status JobStatus `query:"status"`
+}
+
+func (f *JobFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.ServerID != "" {
+		if serverUUID, err := uuid.Parse(f.ServerID); err == nil {
+			query = query.Where("server_id = ?", serverUUID)
+		}
+	}
+	if f.Type != "" {
+		query = query.Where("type = ?", f.Type)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	return query
+}
+
+func (f *JobFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+// GetSorting lists the latest jobs first unless asked otherwise.
+func (f *JobFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "created_at", true
+	}
+	return f.BaseFilter.GetSorting()
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"strconv"
+	"time"
 )
 
 // Score is a custom type that accepts both numbers and strings in JSON.
@@ -31,21 +32,32 @@ func (s Score) MarshalJSON() ([]byte, error) {
 	return json.Marshal(string(s))
 }
 
+// Leaderboard is a championship run on a server. A server can run several;
+// the default one is served by the public leaderboard route. CountedRounds is
+// the number of best rounds that count towards the standings, 0 counts all.
 type Leaderboard struct {
-	ID          uuid.UUID             `gorm:"type:uuid;primary_key;" json:"id"`
-	ServerID    uuid.UUID             `gorm:"uniqueIndex;not null;type:uuid" json:"serverId"`
-	FLPoints    int                   `gorm:"default:1" json:"flPoints"`
-	FLColor     string                `gorm:"default:'#8b5cf6'" json:"flColor"`
-	FLTextColor string                `gorm:"default:'#000000'" json:"flTextColor"`
-	Drivers     []LeaderboardDriver   `gorm:"foreignKey:LeaderboardID;constraint:OnDelete:CASCADE" json:"drivers"`
-	Races       []LeaderboardRace     `gorm:"foreignKey:LeaderboardID;constraint:OnDelete:CASCADE" json:"tracks"`
-	PointRows   []LeaderboardPointRow `gorm:"foreignKey:LeaderboardID;constraint:OnDelete:CASCADE" json:"pointsTable"`
+	ID            uuid.UUID             `gorm:"type:uuid;primary_key;" json:"id"`
+	ServerID      uuid.UUID             `gorm:"index;not null;type:uuid" json:"serverId"`
+	Name          string                `json:"name"`
+	Season        string                `json:"season"`
+	Archived      bool                  `json:"archived"`
+	IsDefault     bool                  `json:"isDefault"`
+	CreatedAt     time.Time             `json:"createdAt"`
+	FLPoints      int                   `gorm:"default:1" json:"flPoints"`
+	FLColor       string                `gorm:"default:'#8b5cf6'" json:"flColor"`
+	FLTextColor   string                `gorm:"default:'#000000'" json:"flTextColor"`
+	CountedRounds int                   `json:"countedRounds"`
+	Drivers       []LeaderboardDriver   `gorm:"foreignKey:LeaderboardID;constraint:OnDelete:CASCADE" json:"drivers"`
+	Races         []LeaderboardRace     `gorm:"foreignKey:LeaderboardID;constraint:OnDelete:CASCADE" json:"tracks"`
+	PointRows     []LeaderboardPointRow `gorm:"foreignKey:LeaderboardID;constraint:OnDelete:CASCADE" json:"pointsTable"`
+	Statuses      []LeaderboardStatus   `gorm:"foreignKey:LeaderboardID;constraint:OnDelete:CASCADE" json:"statuses"`
 }
 
 type LeaderboardDriver struct {
 	ID            uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
 	LeaderboardID uuid.UUID `gorm:"not null;type:uuid" json:"-"`
 	Name          string    `gorm:"not null" json:"name"`
+	SteamID       string    `json:"steamId"`
 	Initials      string    `json:"initials"`
 	Color         string    `json:"color"`
 	Position      int       `json:"-"`
@@ -57,6 +69,7 @@ type LeaderboardRace struct {
 	Name               string              `gorm:"not null" json:"name"`
 	Position           int                 `json:"-"`
 	FastestLapDriverID *uuid.UUID          `gorm:"type:uuid" json:"fastestLapDriverId"`
+	ResultSessionID    *uuid.UUID          `gorm:"type:uuid;index" json:"resultSessionId"`
 	Results            []LeaderboardResult `gorm:"foreignKey:RaceID;constraint:OnDelete:CASCADE" json:"results"`
 }
 
@@ -65,6 +78,127 @@ type LeaderboardResult struct {
 	RaceID   uuid.UUID `gorm:"not null;type:uuid" json:"-"`
 	DriverID uuid.UUID `gorm:"not null;type:uuid" json:"driverId"`
 	Score    Score     `json:"score"`
+	// Position is the finishing position, or 0 when it is only known from
+	// the score.
+	Position int `json:"position"`
+}
+
+// LeaderboardSummary lists a championship without its drivers and races.
+type LeaderboardSummary struct {
+	ID        uuid.UUID `json:"id"`
+	ServerID  uuid.UUID `json:"serverId"`
+	Name      string    `json:"name"`
+	Season    string    `json:"season"`
+	Archived  bool      `json:"archived"`
+	IsDefault bool      `json:"isDefault"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LeaderboardStatus gives the points of a non-numeric score such as "DNF".
+// Statuses that always count cannot be dropped from the standings.
+type LeaderboardStatus struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	LeaderboardID uuid.UUID `gorm:"not null;type:uuid" json:"-"`
+	Code          string    `gorm:"not null" json:"code"`
+	Points        int       `json:"points"`
+	AlwaysCounts  bool      `json:"alwaysCounts"`
+}
+
+// LeaderboardStandings is the championship table computed from the scores of
+// a leaderboard.
+type LeaderboardStandings struct {
+	LeaderboardID uuid.UUID        `json:"leaderboardId"`
+	CountedRounds int              `json:"countedRounds"`
+	Rounds        []StandingsRound `json:"rounds"`
+	Standings     []DriverStanding `json:"standings"`
+}
+
+type StandingsRound struct {
+	RaceID uuid.UUID `json:"raceId"`
+	Name   string    `json:"name"`
+}
+
+type DriverStanding struct {
+	Position      int                   `json:"position"`
+	DriverID      uuid.UUID             `json:"driverId"`
+	Name          string                `json:"name"`
+	Initials      string                `json:"initials"`
+	Color         string                `json:"color"`
+	Points        int                   `json:"points"`
+	DroppedPoints int                   `json:"droppedPoints"`
+	Wins          int                   `json:"wins"`
+	Podiums       int                   `json:"podiums"`
+	BestFinish    int                   `json:"bestFinish"`
+	FastestLaps   int                   `json:"fastestLaps"`
+	Rounds        []DriverRoundStanding `json:"rounds"`
+}
+
+// DriverRoundStanding is a driver's result in one round and their place in
+// the championship after it.
+type DriverRoundStanding struct {
+	RaceID               uuid.UUID `json:"raceId"`
+	Score                Score     `json:"score"`
+	Finish               int       `json:"finish"`
+	Points               int       `json:"points"`
+	FastestLap           bool      `json:"fastestLap"`
+	Dropped              bool      `json:"dropped"`
+	ChampionshipPoints   int       `json:"championshipPoints"`
+	ChampionshipPosition int       `json:"championshipPosition"`
+}
+
+// LeaderboardExportVersion is the version of the LeaderboardExport schema.
+// Fields may be added within a version but never renamed or removed.
+const LeaderboardExportVersion = 1
+
+// LeaderboardExport is the JSON export of a championship for embedding on
+// websites, with the styling of every cell resolved.
+type LeaderboardExport struct {
+	Version      int                         `json:"version"`
+	GeneratedAt  time.Time                   `json:"generatedAt"`
+	Championship LeaderboardExportInfo       `json:"championship"`
+	FastestLap   LeaderboardExportStyle      `json:"fastestLap"`
+	Rounds       []StandingsRound            `json:"rounds"`
+	Standings    []LeaderboardExportStanding `json:"standings"`
+}
+
+type LeaderboardExportInfo struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	Season        string    `json:"season"`
+	CountedRounds int       `json:"countedRounds"`
+	FLPoints      int       `json:"flPoints"`
+}
+
+type LeaderboardExportStyle struct {
+	Color     string `json:"color"`
+	TextColor string `json:"textColor"`
+}
+
+type LeaderboardExportStanding struct {
+	Position int                      `json:"position"`
+	Name     string                   `json:"name"`
+	Initials string                   `json:"initials"`
+	Color    string                   `json:"color"`
+	Points   int                      `json:"points"`
+	Wins     int                      `json:"wins"`
+	Podiums  int                      `json:"podiums"`
+	Rounds   []LeaderboardExportRound `json:"rounds"`
+}
+
+type LeaderboardExportRound struct {
+	Score      Score                  `json:"score"`
+	Points     int                    `json:"points"`
+	Finish     int                    `json:"finish"`
+	FastestLap bool                   `json:"fastestLap"`
+	Dropped    bool                   `json:"dropped"`
+	Style      LeaderboardExportStyle `json:"style"`
+}
+
+// LeaderboardRaceImport adds an ingested race result to a leaderboard. Name
+// defaults to the result's track.
+type LeaderboardRaceImport struct {
+	ResultID uuid.UUID `json:"resultId"`
+	Name     string    `json:"name"`
 }
 
 type LeaderboardPointRow struct {
@@ -105,6 +239,13 @@ func (r *LeaderboardResult) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (s *LeaderboardStatus) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
 func (p *LeaderboardPointRow) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
 		p.ID = uuid.New()
@@ -7,21 +7,21 @@ type Track struct {
 }
 
 type CarModel struct {
-	Value    int    `json:"value" gorm:"primaryKey"`
+	Value    int    `json:"value" gorm:"primaryKey;autoIncrement:false"`
 	CarModel string `json:"car_model"`
 }
 
 type DriverCategory struct {
-	Value    int    `json:"value" gorm:"primaryKey"`
+	Value    int    `json:"value" gorm:"primaryKey;autoIncrement:false"`
 	Category string `json:"category"`
 }
 
 type CupCategory struct {
-	Value    int    `json:"value" gorm:"primaryKey"`
+	Value    int    `json:"value" gorm:"primaryKey;autoIncrement:false"`
 	Category string `json:"category"`
 }
 
 type SessionType struct {
-	Value       int    `json:"value" gorm:"primaryKey"`
+	Value       int    `json:"value" gorm:"primaryKey;autoIncrement:false"`
 	SessionType string `json:"session_type"`
 }
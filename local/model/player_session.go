@@ -0,0 +1,105 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PlayerSession is one connection of a driver to a server, as read from
+// server.log. SessionID links it to the StateHistory of the server session
+// the driver joined.
+type PlayerSession struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;"`
+	ServerID       uuid.UUID  `json:"serverId" gorm:"not null;type:uuid;index"`
+	SessionID      uuid.UUID  `json:"sessionId" gorm:"type:uuid;index"`
+	ConnectionID   int        `json:"connectionId"`
+	CarID          int        `json:"carId"`
+	RaceNumber     int        `json:"raceNumber"`
+	CarModel       int        `json:"carModel"`
+	DriverName     string     `json:"driverName"`
+	SteamID        string     `json:"steamId" gorm:"index"`
+	ConnectedAt    time.Time  `json:"connectedAt" gorm:"index"`
+	DisconnectedAt *time.Time `json:"disconnectedAt"`
+}
+
+func (p *PlayerSession) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewPlayerSession records player as a connection to serverID during the
+// server session sessionID.
+func NewPlayerSession(serverID, sessionID uuid.UUID, player *PlayerState) *PlayerSession {
+	return &PlayerSession{
+		ID:             player.ID,
+		ServerID:       serverID,
+		SessionID:      sessionID,
+		ConnectionID:   player.ConnectionID,
+		CarID:          player.CarID,
+		RaceNumber:     player.RaceNumber,
+		CarModel:       player.CarModel,
+		DriverName:     player.DriverName,
+		SteamID:        player.SteamID,
+		ConnectedAt:    player.ConnectedAt,
+		DisconnectedAt: player.DisconnectedAt,
+	}
+}
+
+type PlayerSessionFilter struct {
+	BaseFilter
+	ServerBasedFilter
+	DateRangeFilter
+	SteamID    string `query:"steam_id"`
+	DriverName string `query:"driver_name"`
+	SessionID  string `query:"session_id"`
+	Online     *bool  `query:"online"`
+}
+
+func (f *PlayerSessionFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.ServerID != "" {
+		if serverUUID, err := uuid.Parse(f.ServerID); err == nil {
+			query = query.Where("server_id = ?", serverUUID)
+		}
+	}
+	if f.SteamID != "" {
+		query = query.Where("steam_id = ?", f.SteamID)
+	}
+	if f.DriverName != "" {
+		query = query.Where("driver_name LIKE ?", "%"+f.DriverName+"%")
+	}
+	if f.SessionID != "" {
+		if sessionUUID, err := uuid.Parse(f.SessionID); err == nil {
+			query = query.Where("session_id = ?", sessionUUID)
+		}
+	}
+	if f.Online != nil {
+		if *f.Online {
+			query = query.Where("disconnected_at IS NULL")
+		} else {
+			query = query.Where("disconnected_at IS NOT NULL")
+		}
+	}
+	if !f.StartDate.IsZero() {
+		query = query.Where("connected_at >= ?", f.StartDate)
+	}
+	if !f.EndDate.IsZero() {
+		query = query.Where("connected_at <= ?", f.EndDate)
+	}
+	return query
+}
+
+func (f *PlayerSessionFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+// GetSorting lists the latest connections first unless asked otherwise.
+func (f *PlayerSessionFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "connected_at", true
+	}
+	return f.BaseFilter.GetSorting()
+}
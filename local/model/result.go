@@ -0,0 +1,315 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ResultSession is one results file written by the ACC server at the end of a
+// session. SessionID links it to the StateHistory of that session when one
+// could be matched.
+type ResultSession struct {
+	ID               uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;"`
+	ServerID         uuid.UUID       `json:"serverId" gorm:"not null;type:uuid;uniqueIndex:idx_result_server_file"`
+	SessionID        *uuid.UUID      `json:"sessionId" gorm:"type:uuid;index"`
+	FileName         string          `json:"fileName" gorm:"not null;uniqueIndex:idx_result_server_file"`
+	SessionType      TrackSession    `json:"sessionType"`
+	Track            string          `json:"track"`
+	ServerName       string          `json:"serverName"`
+	SessionIndex     int             `json:"sessionIndex"`
+	RaceWeekendIndex int             `json:"raceWeekendIndex"`
+	IsWetSession     bool            `json:"isWetSession"`
+	BestLap          int             `json:"bestLap"`
+	FinishedAt       time.Time       `json:"finishedAt" gorm:"index"`
+	CreatedAt        time.Time       `json:"createdAt"`
+	Cars             []ResultCar     `json:"cars,omitempty" gorm:"foreignKey:ResultSessionID;constraint:OnDelete:CASCADE"`
+	Laps             []ResultLap     `json:"laps,omitempty" gorm:"foreignKey:ResultSessionID;constraint:OnDelete:CASCADE"`
+	Penalties        []ResultPenalty `json:"penalties,omitempty" gorm:"foreignKey:ResultSessionID;constraint:OnDelete:CASCADE"`
+}
+
+// ResultCar is a car's line on the final leaderboard of a session. Times are
+// in milliseconds.
+type ResultCar struct {
+	ID                      uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;"`
+	ResultSessionID         uuid.UUID      `json:"resultSessionId" gorm:"not null;type:uuid;index"`
+	Position                int            `json:"position"`
+	CarID                   int            `json:"carId"`
+	RaceNumber              int            `json:"raceNumber"`
+	CarModel                int            `json:"carModel"`
+	CupCategory             int            `json:"cupCategory"`
+	CarGroup                string         `json:"carGroup"`
+	TeamName                string         `json:"teamName"`
+	BestLap                 int            `json:"bestLap"`
+	TotalTime               int            `json:"totalTime"`
+	LapCount                int            `json:"lapCount"`
+	MissingMandatoryPitstop bool           `json:"missingMandatoryPitstop"`
+	Drivers                 []ResultDriver `json:"drivers,omitempty" gorm:"foreignKey:ResultCarID;constraint:OnDelete:CASCADE"`
+}
+
+type ResultDriver struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	ResultCarID uuid.UUID `json:"resultCarId" gorm:"not null;type:uuid;index"`
+	DriverIndex int       `json:"driverIndex"`
+	FirstName   string    `json:"firstName"`
+	LastName    string    `json:"lastName"`
+	ShortName   string    `json:"shortName"`
+	PlayerID    string    `json:"playerId" gorm:"index"`
+	DrivingTime int       `json:"drivingTime"`
+}
+
+type ResultLap struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	ResultSessionID uuid.UUID `json:"resultSessionId" gorm:"not null;type:uuid;index"`
+	CarID           int       `json:"carId"`
+	DriverIndex     int       `json:"driverIndex"`
+	LapNumber       int       `json:"lapNumber"`
+	LapTime         int       `json:"lapTime"`
+	Sector1         int       `json:"sector1"`
+	Sector2         int       `json:"sector2"`
+	Sector3         int       `json:"sector3"`
+	IsValidForBest  bool      `json:"isValidForBest"`
+}
+
+type ResultPenalty struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	ResultSessionID uuid.UUID `json:"resultSessionId" gorm:"not null;type:uuid;index"`
+	CarID           int       `json:"carId"`
+	DriverIndex     int       `json:"driverIndex"`
+	Reason          string    `json:"reason"`
+	Penalty         string    `json:"penalty"`
+	PenaltyValue    int       `json:"penaltyValue"`
+	ViolationInLap  int       `json:"violationInLap"`
+	ClearedInLap    int       `json:"clearedInLap"`
+	PostRace        bool      `json:"postRace"`
+}
+
+func (r *ResultSession) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *ResultCar) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *ResultDriver) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *ResultLap) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *ResultPenalty) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+type ResultSessionFilter struct {
+	BaseFilter
+	ServerBasedFilter
+	DateRangeFilter
+	SessionType TrackSession `query:"session_type"`
+	Track       string       `query:"track"`
+}
+
+func (f *ResultSessionFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.ServerID != "" {
+		if serverUUID, err := uuid.Parse(f.ServerID); err == nil {
+			query = query.Where("server_id = ?", serverUUID)
+		}
+	}
+	if f.SessionType != "" {
+		query = query.Where("session_type = ?", f.SessionType)
+	}
+	if f.Track != "" {
+		query = query.Where("track = ?", f.Track)
+	}
+	if !f.StartDate.IsZero() {
+		query = query.Where("finished_at >= ?", f.StartDate)
+	}
+	if !f.EndDate.IsZero() {
+		query = query.Where("finished_at <= ?", f.EndDate)
+	}
+	return query
+}
+
+func (f *ResultSessionFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+// GetSorting lists the latest sessions first unless asked otherwise.
+func (f *ResultSessionFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "finished_at", true
+	}
+	return f.BaseFilter.GetSorting()
+}
+
+// ACCResultFile is the layout of the results/*.json files written by the ACC
+// dedicated server.
+type ACCResultFile struct {
+	SessionType       string             `json:"sessionType"`
+	TrackName         string             `json:"trackName"`
+	SessionIndex      int                `json:"sessionIndex"`
+	RaceWeekendIndex  int                `json:"raceWeekendIndex"`
+	ServerName        string             `json:"serverName"`
+	SessionResult     ACCSessionResult   `json:"sessionResult"`
+	Laps              []ACCResultLap     `json:"laps"`
+	Penalties         []ACCResultPenalty `json:"penalties"`
+	PostRacePenalties []ACCResultPenalty `json:"post_race_penalties"`
+}
+
+type ACCSessionResult struct {
+	BestLap          int                  `json:"bestlap"`
+	IsWetSession     int                  `json:"isWetSession"`
+	LeaderBoardLines []ACCLeaderBoardLine `json:"leaderBoardLines"`
+}
+
+type ACCLeaderBoardLine struct {
+	Car                     ACCResultCar `json:"car"`
+	Timing                  ACCTiming    `json:"timing"`
+	MissingMandatoryPitstop int          `json:"missingMandatoryPitstop"`
+	DriverTotalTimes        []float64    `json:"driverTotalTimes"`
+}
+
+type ACCResultCar struct {
+	CarID       int               `json:"carId"`
+	RaceNumber  int               `json:"raceNumber"`
+	CarModel    int               `json:"carModel"`
+	CupCategory int               `json:"cupCategory"`
+	CarGroup    string            `json:"carGroup"`
+	TeamName    string            `json:"teamName"`
+	Drivers     []ACCResultDriver `json:"drivers"`
+}
+
+type ACCResultDriver struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	ShortName string `json:"shortName"`
+	PlayerID  string `json:"playerId"`
+}
+
+type ACCTiming struct {
+	BestLap   int `json:"bestLap"`
+	TotalTime int `json:"totalTime"`
+	LapCount  int `json:"lapCount"`
+}
+
+type ACCResultLap struct {
+	CarID          int   `json:"carId"`
+	DriverIndex    int   `json:"driverIndex"`
+	LapTime        int   `json:"laptime"`
+	IsValidForBest bool  `json:"isValidForBest"`
+	Splits         []int `json:"splits"`
+}
+
+type ACCResultPenalty struct {
+	CarID          int    `json:"carId"`
+	DriverIndex    int    `json:"driverIndex"`
+	Reason         string `json:"reason"`
+	Penalty        string `json:"penalty"`
+	PenaltyValue   int    `json:"penaltyValue"`
+	ViolationInLap int    `json:"violationInLap"`
+	ClearedInLap   int    `json:"clearedInLap"`
+}
+
+// ToResultSession normalizes a results file into a ResultSession with its
+// cars, drivers, laps and penalties.
+func (f *ACCResultFile) ToResultSession(serverID uuid.UUID, fileName string, finishedAt time.Time) *ResultSession {
+	session := &ResultSession{
+		ServerID:         serverID,
+		FileName:         fileName,
+		SessionType:      ToTrackSession(f.SessionType),
+		Track:            f.TrackName,
+		ServerName:       f.ServerName,
+		SessionIndex:     f.SessionIndex,
+		RaceWeekendIndex: f.RaceWeekendIndex,
+		IsWetSession:     f.SessionResult.IsWetSession == 1,
+		BestLap:          f.SessionResult.BestLap,
+		FinishedAt:       finishedAt,
+	}
+
+	for i, line := range f.SessionResult.LeaderBoardLines {
+		car := ResultCar{
+			Position:                i + 1,
+			CarID:                   line.Car.CarID,
+			RaceNumber:              line.Car.RaceNumber,
+			CarModel:                line.Car.CarModel,
+			CupCategory:             line.Car.CupCategory,
+			CarGroup:                line.Car.CarGroup,
+			TeamName:                line.Car.TeamName,
+			BestLap:                 line.Timing.BestLap,
+			TotalTime:               line.Timing.TotalTime,
+			LapCount:                line.Timing.LapCount,
+			MissingMandatoryPitstop: line.MissingMandatoryPitstop == 1,
+		}
+		for j, driver := range line.Car.Drivers {
+			resultDriver := ResultDriver{
+				DriverIndex: j,
+				FirstName:   driver.FirstName,
+				LastName:    driver.LastName,
+				ShortName:   driver.ShortName,
+				PlayerID:    driver.PlayerID,
+			}
+			if j < len(line.DriverTotalTimes) {
+				resultDriver.DrivingTime = int(line.DriverTotalTimes[j])
+			}
+			car.Drivers = append(car.Drivers, resultDriver)
+		}
+		session.Cars = append(session.Cars, car)
+	}
+
+	lapNumbers := make(map[int]int)
+	for _, lap := range f.Laps {
+		lapNumbers[lap.CarID]++
+		resultLap := ResultLap{
+			CarID:          lap.CarID,
+			DriverIndex:    lap.DriverIndex,
+			LapNumber:      lapNumbers[lap.CarID],
+			LapTime:        lap.LapTime,
+			IsValidForBest: lap.IsValidForBest,
+		}
+		sectors := []*int{&resultLap.Sector1, &resultLap.Sector2, &resultLap.Sector3}
+		for j := 0; j < len(lap.Splits) && j < len(sectors); j++ {
+			*sectors[j] = lap.Splits[j]
+		}
+		session.Laps = append(session.Laps, resultLap)
+	}
+
+	for _, penalty := range f.Penalties {
+		session.Penalties = append(session.Penalties, penalty.toResultPenalty(false))
+	}
+	for _, penalty := range f.PostRacePenalties {
+		session.Penalties = append(session.Penalties, penalty.toResultPenalty(true))
+	}
+	return session
+}
+
+func (p ACCResultPenalty) toResultPenalty(postRace bool) ResultPenalty {
+	return ResultPenalty{
+		CarID:          p.CarID,
+		DriverIndex:    p.DriverIndex,
+		Reason:         p.Reason,
+		Penalty:        p.Penalty,
+		PenaltyValue:   p.PenaltyValue,
+		ViolationInLap: p.ViolationInLap,
+		ClearedInLap:   p.ClearedInLap,
+		PostRace:       postRace,
+	}
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"acc-server-manager/local/utl/cron"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RotationTrigger says what advanced a rotation.
+type RotationTrigger string
+
+const (
+	RotationTriggerRaceEnd  RotationTrigger = "race_end"
+	RotationTriggerSchedule RotationTrigger = "schedule"
+	RotationTriggerManual   RotationTrigger = "manual"
+)
+
+// RotationEvent is a partial event.json. Only the fields that are present
+// are written when its entry becomes current.
+type RotationEvent map[string]interface{}
+
+func (e *RotationEvent) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case nil:
+		*e = RotationEvent{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported type for RotationEvent: %T", value)
+	}
+	return json.Unmarshal(data, e)
+}
+
+func (e RotationEvent) Value() (driver.Value, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Rotation cycles a server through a list of events. It advances to the next
+// entry when a race session ends if AdvanceOnRaceEnd is set, and whenever
+// its optional cron expression matches. A pinned rotation only advances
+// when skipped by hand.
+type Rotation struct {
+	ID               uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;"`
+	ServerID         uuid.UUID       `json:"serverId" gorm:"not null;type:uuid;uniqueIndex"`
+	Enabled          bool            `json:"enabled"`
+	AdvanceOnRaceEnd bool            `json:"advanceOnRaceEnd"`
+	CronExpression   string          `json:"cronExpression"`
+	Timezone         string          `json:"timezone"`
+	Pinned           bool            `json:"pinned"`
+	CurrentIndex     int             `json:"currentIndex"`
+	NextRunAt        *time.Time      `json:"nextRunAt"`
+	LastAdvancedAt   *time.Time      `json:"lastAdvancedAt"`
+	LastTrigger      RotationTrigger `json:"lastTrigger"`
+	UpdatedAt        time.Time       `json:"updatedAt"`
+	Entries          []RotationEntry `json:"entries" gorm:"foreignKey:RotationID;constraint:OnDelete:CASCADE"`
+}
+
+type RotationEntry struct {
+	ID         uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;"`
+	RotationID uuid.UUID     `json:"-" gorm:"not null;type:uuid;index"`
+	Position   int           `json:"-"`
+	Name       string        `json:"name"`
+	Event      RotationEvent `json:"event" gorm:"type:text"`
+}
+
+func (r *Rotation) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *RotationEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks the schedule and every entry's event fields. Entry errors
+// are reported as "entries[i].field".
+func (r *Rotation) Validate() error {
+	verr := &ValidationError{}
+	if r.Enabled && len(r.Entries) == 0 {
+		verr.Add("entries", "at least one entry is required")
+	}
+	if r.CronExpression != "" {
+		if _, err := cron.Parse(r.CronExpression); err != nil {
+			verr.Add("cronExpression", "%s", err.Error())
+		}
+	}
+	if _, err := r.Location(); err != nil {
+		verr.Add("timezone", "unknown timezone %q", r.Timezone)
+	}
+
+	for i, entry := range r.Entries {
+		field := fmt.Sprintf("entries[%d]", i)
+		if strings.TrimSpace(entry.Name) == "" {
+			verr.Add(field+".name", "is required")
+		}
+		if len(entry.Event) == 0 {
+			verr.Add(field+".event", "at least one field is required")
+			continue
+		}
+		err := ValidateConfigSection("event", entry.Event, ConfigValidationContext{})
+		if sectionErr, ok := err.(*ValidationError); ok {
+			for _, fieldErr := range sectionErr.Errors {
+				verr.Add(field+".event."+fieldErr.Field, "%s", fieldErr.Message)
+			}
+		}
+	}
+	return verr.ErrOrNil()
+}
+
+// NextRun returns the first time after t the rotation advances on its
+// schedule, or nil when it is disabled or has none.
+func (r *Rotation) NextRun(t time.Time) *time.Time {
+	if !r.Enabled || r.CronExpression == "" {
+		return nil
+	}
+	schedule, err := cron.Parse(r.CronExpression)
+	if err != nil {
+		return nil
+	}
+	loc, err := r.Location()
+	if err != nil {
+		return nil
+	}
+	next := schedule.Next(t.In(loc))
+	if next.IsZero() {
+		return nil
+	}
+	next = next.UTC()
+	return &next
+}
+
+// Location returns the timezone the cron expression is evaluated in, UTC
+// when none is set.
+func (r *Rotation) Location() (*time.Location, error) {
+	if r.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(r.Timezone)
+}
+
+// Current returns the entry the server is running, or nil when there are no
+// entries.
+func (r *Rotation) Current() *RotationEntry {
+	if len(r.Entries) == 0 {
+		return nil
+	}
+	return &r.Entries[r.CurrentIndex%len(r.Entries)]
+}
@@ -0,0 +1,204 @@
+package model
+
+import (
+	"acc-server-manager/local/utl/cron"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ScheduleAction string
+
+const (
+	ScheduleActionStart   ScheduleAction = "start"
+	ScheduleActionStop    ScheduleAction = "stop"
+	ScheduleActionRestart ScheduleAction = "restart"
+	ScheduleActionUpdate  ScheduleAction = "update"
+)
+
+type ScheduleExecutionStatus string
+
+const (
+	ScheduleExecutionSucceeded ScheduleExecutionStatus = "succeeded"
+	ScheduleExecutionSkipped   ScheduleExecutionStatus = "skipped"
+	ScheduleExecutionFailed    ScheduleExecutionStatus = "failed"
+)
+
+// Schedule starts, stops, restarts or updates a server whenever its cron
+// expression matches in its timezone. A stop is skipped while players are
+// online unless Force is set. An update waits up to WindowMinutes for the
+// server to empty.
+type Schedule struct {
+	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;"`
+	ServerID       uuid.UUID      `json:"serverId" gorm:"not null;type:uuid;index"`
+	Name           string         `json:"name" gorm:"not null"`
+	Action         ScheduleAction `json:"action" gorm:"not null"`
+	CronExpression string         `json:"cronExpression" gorm:"not null"`
+	Timezone       string         `json:"timezone"`
+	Enabled        bool           `json:"enabled"`
+	Force          bool           `json:"force"`
+	WindowMinutes  int            `json:"windowMinutes"`
+	NextRunAt      *time.Time     `json:"nextRunAt"`
+	LastRunAt      *time.Time     `json:"lastRunAt"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+}
+
+func (s *Schedule) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks the action, cron expression and timezone of a schedule.
+func (s *Schedule) Validate() error {
+	verr := &ValidationError{}
+	if strings.TrimSpace(s.Name) == "" {
+		verr.Add("name", "is required")
+	}
+	switch s.Action {
+	case ScheduleActionStart, ScheduleActionStop, ScheduleActionRestart, ScheduleActionUpdate:
+	default:
+		verr.Add("action", "must be %q, %q, %q or %q", ScheduleActionStart, ScheduleActionStop, ScheduleActionRestart, ScheduleActionUpdate)
+	}
+	if s.WindowMinutes < 0 {
+		verr.Add("windowMinutes", "must not be negative")
+	}
+	if _, err := cron.Parse(s.CronExpression); err != nil {
+		verr.Add("cronExpression", "%s", err.Error())
+	}
+	if _, err := s.Location(); err != nil {
+		verr.Add("timezone", "unknown timezone %q", s.Timezone)
+	}
+	return verr.ErrOrNil()
+}
+
+// Location returns the timezone the cron expression is evaluated in, UTC
+// when none is set.
+func (s *Schedule) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// NextRun returns the first time after t the schedule is due, or nil when it
+// is disabled or never due again.
+func (s *Schedule) NextRun(t time.Time) *time.Time {
+	if !s.Enabled {
+		return nil
+	}
+	schedule, err := cron.Parse(s.CronExpression)
+	if err != nil {
+		return nil
+	}
+	loc, err := s.Location()
+	if err != nil {
+		return nil
+	}
+	next := schedule.Next(t.In(loc))
+	if next.IsZero() {
+		return nil
+	}
+	next = next.UTC()
+	return &next
+}
+
+// ScheduleExecution records one run of a schedule.
+type ScheduleExecution struct {
+	ID           uuid.UUID               `json:"id" gorm:"type:uuid;primary_key;"`
+	ScheduleID   uuid.UUID               `json:"scheduleId" gorm:"not null;type:uuid;index"`
+	ServerID     uuid.UUID               `json:"serverId" gorm:"not null;type:uuid;index"`
+	Action       ScheduleAction          `json:"action"`
+	Status       ScheduleExecutionStatus `json:"status"`
+	Message      string                  `json:"message"`
+	ScheduledFor time.Time               `json:"scheduledFor"`
+	StartedAt    time.Time               `json:"startedAt" gorm:"index"`
+	FinishedAt   time.Time               `json:"finishedAt"`
+}
+
+func (e *ScheduleExecution) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+type ScheduleFilter struct {
+	BaseFilter
+	ServerBasedFilter
+	Action  ScheduleAction `query:"action"`
+	Enabled *bool          `query:"enabled"`
+}
+
+func (f *ScheduleFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.ServerID != "" {
+		if serverUUID, err := uuid.Parse(f.ServerID); err == nil {
+			query = query.Where("server_id = ?", serverUUID)
+		}
+	}
+	if f.Action != "" {
+		query = query.Where("action = ?", f.Action)
+	}
+	if f.Enabled != nil {
+		query = query.Where("enabled = ?", *f.Enabled)
+	}
+	return query
+}
+
+func (f *ScheduleFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+func (f *ScheduleFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "created_at", false
+	}
+	return f.BaseFilter.GetSorting()
+}
+
+type ScheduleExecutionFilter struct {
+	BaseFilter
+	ServerBasedFilter
+	DateRangeFilter
+	ScheduleID string                  `param:"scheduleId"`
+	Status     ScheduleExecutionStatus `query:"status"`
+}
+
+func (f *ScheduleExecutionFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.ServerID != "" {
+		if serverUUID, err := uuid.Parse(f.ServerID); err == nil {
+			query = query.Where("server_id = ?", serverUUID)
+		}
+	}
+	if f.ScheduleID != "" {
+		if scheduleUUID, err := uuid.Parse(f.ScheduleID); err == nil {
+			query = query.Where("schedule_id = ?", scheduleUUID)
+		}
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if !f.StartDate.IsZero() {
+		query = query.Where("started_at >= ?", f.StartDate)
+	}
+	if !f.EndDate.IsZero() {
+		query = query.Where("started_at <= ?", f.EndDate)
+	}
+	return query
+}
+
+func (f *ScheduleExecutionFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+// GetSorting lists the latest executions first unless asked otherwise.
+func (f *ScheduleExecutionFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "started_at", true
+	}
+	return f.BaseFilter.GetSorting()
+}
@@ -47,18 +47,32 @@ type Server struct {
 	FromSteamCMD bool          `gorm:"not null; default:true" json:"-"`
 }
 
+// PlayerState is the live state of a driver connected to a server. ID is the
+// PlayerSession the connection is recorded as. The timing fields come from
+// the server's broadcasting API; times are in milliseconds and sectors hold
+// the three splits of a lap, 0 while unknown.
 type PlayerState struct {
-	CarID          int
-	DriverName     string
-	TeamName       string
-	CarModel       string
-	CurrentLap     int
-	LastLapTime    int
-	BestLapTime    int
-	Position       int
-	ConnectedAt    time.Time
-	DisconnectedAt *time.Time
-	IsConnected    bool
+	ID                uuid.UUID  `json:"id"`
+	ConnectionID      int        `json:"connectionId"`
+	CarID             int        `json:"carId"`
+	RaceNumber        int        `json:"raceNumber"`
+	DriverName        string     `json:"driverName"`
+	SteamID           string     `json:"steamId"`
+	TeamName          string     `json:"teamName"`
+	CarModel          int        `json:"carModel"`
+	CurrentLap        int        `json:"currentLap"`
+	CurrentLapTime    int        `json:"currentLapTime"`
+	CurrentLapSectors []int      `json:"currentLapSectors"`
+	LastLapTime       int        `json:"lastLapTime"`
+	LastLapSectors    []int      `json:"lastLapSectors"`
+	LastLapInvalid    bool       `json:"lastLapInvalid"`
+	BestLapTime       int        `json:"bestLapTime"`
+	Position          int        `json:"position"`
+	SplinePosition    float32    `json:"splinePosition"`
+	Location          string     `json:"location"`
+	ConnectedAt       time.Time  `json:"connectedAt"`
+	DisconnectedAt    *time.Time `json:"disconnectedAt"`
+	IsConnected       bool       `json:"isConnected"`
 }
 
 type State struct {
@@ -75,6 +89,9 @@ type ServerState struct {
 	Track                  string       `json:"track"`
 	MaxConnections         int          `json:"maxConnections"`
 	SessionDurationMinutes int          `json:"sessionDurationMinutes"`
+	// Cars is the live timing of every car, ordered by position. It is only
+	// filled while the broadcasting API of the server is reachable.
+	Cars []PlayerState `json:"cars,omitempty"`
 }
 
 type ServerFilter struct {
@@ -150,6 +167,10 @@ func (s *Server) GetConfigPath() string {
 	return filepath.Join(s.GetServerPath(), "cfg")
 }
 
+func (s *Server) GetResultsPath() string {
+	return filepath.Join(s.GetServerPath(), "results")
+}
+
 func (s *Server) GetLogPath() string {
 	if !s.FromSteamCMD {
 		return s.Path
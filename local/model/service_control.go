@@ -51,6 +51,24 @@ func ParseServiceStatus(s string) ServiceStatus {
 	}
 }
 
+// ParseSystemdState maps the output of `systemctl is-active` onto ServiceStatus.
+func ParseSystemdState(s string) ServiceStatus {
+	switch s {
+	case "active":
+		return StatusRunning
+	case "inactive", "failed":
+		return StatusStopped
+	case "activating":
+		return StatusStarting
+	case "deactivating":
+		return StatusStopping
+	case "reloading":
+		return StatusRestarting
+	default:
+		return StatusUnknown
+	}
+}
+
 func (s ServiceStatus) MarshalJSON() ([]byte, error) {
 	return []byte(strconv.Itoa(int(s))), nil
 }
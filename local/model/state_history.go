@@ -81,6 +81,9 @@ func (i TrackSession) Humanize() string {
 }
 
 func ToTrackSession(i string) TrackSession {
+	if i == "" {
+		return SessionUnknown
+	}
 	sessionAbrv := strings.ToUpper(i[:1])
 	switch sessionAbrv {
 	case "P":
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxCrashLogLines is the most log lines a crash record can keep.
+const MaxCrashLogLines = 200
+
+// RestartPolicy decides how the supervisor reacts when a server stops without
+// being asked to. A crash is retried up to MaxRetries times, waiting
+// BackoffSeconds before the first attempt and doubling the wait up to
+// MaxBackoffSeconds. Once CrashLoopCount crashes happen within
+// CrashLoopWindowMinutes the server is left stopped. A running server that
+// logs nothing for StallTimeoutMinutes is treated as hung and restarted under
+// the same rules; 0 turns stall detection off.
+type RestartPolicy struct {
+	ID                     uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	ServerID               uuid.UUID `json:"serverId" gorm:"not null;type:uuid;uniqueIndex"`
+	Enabled                bool      `json:"enabled"`
+	MaxRetries             int       `json:"maxRetries"`
+	BackoffSeconds         int       `json:"backoffSeconds"`
+	MaxBackoffSeconds      int       `json:"maxBackoffSeconds"`
+	CrashLoopCount         int       `json:"crashLoopCount"`
+	CrashLoopWindowMinutes int       `json:"crashLoopWindowMinutes"`
+	LogLines               int       `json:"logLines"`
+	StallTimeoutMinutes    int       `json:"stallTimeoutMinutes"`
+	UpdatedAt              time.Time `json:"updatedAt"`
+}
+
+// DefaultRestartPolicy is the policy of a server that has not set its own.
+func DefaultRestartPolicy(serverID uuid.UUID) *RestartPolicy {
+	return &RestartPolicy{
+		ServerID:               serverID,
+		Enabled:                true,
+		MaxRetries:             3,
+		BackoffSeconds:         10,
+		MaxBackoffSeconds:      300,
+		CrashLoopCount:         5,
+		CrashLoopWindowMinutes: 30,
+		LogLines:               50,
+	}
+}
+
+func (p *RestartPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (p *RestartPolicy) Validate() error {
+	verr := &ValidationError{}
+	if p.MaxRetries < 0 {
+		verr.Add("maxRetries", "must not be negative")
+	}
+	if p.BackoffSeconds < 0 {
+		verr.Add("backoffSeconds", "must not be negative")
+	}
+	if p.MaxBackoffSeconds < p.BackoffSeconds {
+		verr.Add("maxBackoffSeconds", "must be at least backoffSeconds (%d)", p.BackoffSeconds)
+	}
+	if p.CrashLoopCount < 0 {
+		verr.Add("crashLoopCount", "must not be negative")
+	}
+	if p.CrashLoopCount > 0 && p.CrashLoopWindowMinutes <= 0 {
+		verr.Add("crashLoopWindowMinutes", "must be positive when crashLoopCount is set")
+	}
+	if p.StallTimeoutMinutes < 0 {
+		verr.Add("stallTimeoutMinutes", "must not be negative")
+	}
+	if p.LogLines < 0 || p.LogLines > MaxCrashLogLines {
+		verr.Add("logLines", "must be between 0 and %d", MaxCrashLogLines)
+	}
+	return verr.ErrOrNil()
+}
+
+// Backoff returns how long to wait before the given restart attempt,
+// counting from 0.
+func (p *RestartPolicy) Backoff(attempt int) time.Duration {
+	backoff := time.Duration(p.BackoffSeconds) * time.Second
+	limit := time.Duration(p.MaxBackoffSeconds) * time.Second
+	for i := 0; i < attempt && backoff < limit; i++ {
+		backoff *= 2
+	}
+	if backoff > limit {
+		return limit
+	}
+	return backoff
+}
+
+type CrashOutcome string
+
+const (
+	// CrashOutcomePending is a crash whose restart is still being attempted.
+	CrashOutcomePending   CrashOutcome = "pending"
+	CrashOutcomeRestarted CrashOutcome = "restarted"
+	CrashOutcomeGaveUp    CrashOutcome = "gave_up"
+	CrashOutcomeCrashLoop CrashOutcome = "crash_loop"
+	CrashOutcomeDisabled  CrashOutcome = "disabled"
+	// CrashOutcomeCancelled is a crash whose restart was overtaken by a
+	// user starting or stopping the server, or by the manager shutting down.
+	CrashOutcomeCancelled CrashOutcome = "cancelled"
+)
+
+// ServerCrash records a server that stopped without being asked to, or that
+// hung with its service still running, with the end of its log at that
+// moment.
+type ServerCrash struct {
+	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;"`
+	ServerID   uuid.UUID    `json:"serverId" gorm:"not null;type:uuid;index"`
+	DetectedAt time.Time    `json:"detectedAt" gorm:"index"`
+	Hung       bool         `json:"hung"`
+	LastLogAt  *time.Time   `json:"lastLogAt"`
+	LogTail    string       `json:"logTail"`
+	Outcome    CrashOutcome `json:"outcome"`
+	Attempts   int          `json:"attempts"`
+	Error      string       `json:"error"`
+	ResolvedAt *time.Time   `json:"resolvedAt"`
+}
+
+func (c *ServerCrash) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+type ServerCrashFilter struct {
+	BaseFilter
+	ServerBasedFilter
+	DateRangeFilter
+	Outcome CrashOutcome `query:"outcome"`
+}
+
+func (f *ServerCrashFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.ServerID != "" {
+		if serverUUID, err := uuid.Parse(f.ServerID); err == nil {
+			query = query.Where("server_id = ?", serverUUID)
+		}
+	}
+	if f.Outcome != "" {
+		query = query.Where("outcome = ?", f.Outcome)
+	}
+	if !f.StartDate.IsZero() {
+		query = query.Where("detected_at >= ?", f.StartDate)
+	}
+	if !f.EndDate.IsZero() {
+		query = query.Where("detected_at <= ?", f.EndDate)
+	}
+	return query
+}
+
+func (f *ServerCrashFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+// GetSorting lists the latest crashes first unless asked otherwise.
+func (f *ServerCrashFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "detected_at", true
+	}
+	return f.BaseFilter.GetSorting()
+}
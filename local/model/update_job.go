@@ -0,0 +1,105 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UpdateJobStatus string
+
+const (
+	UpdateJobQueued    UpdateJobStatus = "queued"
+	UpdateJobWaiting   UpdateJobStatus = "waiting_for_players"
+	UpdateJobStopping  UpdateJobStatus = "stopping"
+	UpdateJobUpdating  UpdateJobStatus = "updating"
+	UpdateJobVerifying UpdateJobStatus = "verifying"
+	UpdateJobStarting  UpdateJobStatus = "starting"
+	UpdateJobSucceeded UpdateJobStatus = "succeeded"
+	UpdateJobFailed    UpdateJobStatus = "failed"
+	UpdateJobCancelled UpdateJobStatus = "cancelled"
+)
+
+// IsFinished reports whether the update has stopped for good.
+func (s UpdateJobStatus) IsFinished() bool {
+	return s == UpdateJobSucceeded || s == UpdateJobFailed || s == UpdateJobCancelled
+}
+
+type UpdateJobTrigger string
+
+const (
+	UpdateJobManual   UpdateJobTrigger = "manual"
+	UpdateJobSchedule UpdateJobTrigger = "schedule"
+)
+
+// DefaultUpdateWaitMinutes is how long an update waits for players to leave
+// when no limit is given.
+const DefaultUpdateWaitMinutes = 60
+
+// UpdateJob updates the ACC server files of a server through SteamCMD. Jobs
+// started together for several servers share a BatchID.
+type UpdateJob struct {
+	ID              uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;"`
+	ServerID        uuid.UUID        `json:"serverId" gorm:"not null;type:uuid;index"`
+	BatchID         *uuid.UUID       `json:"batchId" gorm:"type:uuid;index"`
+	Trigger         UpdateJobTrigger `json:"trigger"`
+	Status          UpdateJobStatus  `json:"status" gorm:"index"`
+	WaitMinutes     int              `json:"waitMinutes"`
+	WasRunning      bool             `json:"wasRunning"`
+	PreviousVersion string           `json:"previousVersion"`
+	NewVersion      string           `json:"newVersion"`
+	Error           string           `json:"error"`
+	CreatedAt       time.Time        `json:"createdAt" gorm:"index"`
+	StartedAt       *time.Time       `json:"startedAt"`
+	FinishedAt      *time.Time       `json:"finishedAt"`
+}
+
+func (u *UpdateJob) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
+
+// UpdateJobRequest starts an update. WaitMinutes defaults to
+// DefaultUpdateWaitMinutes.
+type UpdateJobRequest struct {
+	WaitMinutes int `json:"waitMinutes"`
+}
+
+type UpdateJobFilter struct {
+	BaseFilter
+	ServerBasedFilter
+	Status  UpdateJobStatus `query:"status"`
+	BatchID string          `query:"batch_id"`
+}
+
+func (f *UpdateJobFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.ServerID != "" {
+		if serverUUID, err := uuid.Parse(f.ServerID); err == nil {
+			query = query.Where("server_id = ?", serverUUID)
+		}
+	}
+	if f.BatchID != "" {
+		if batchUUID, err := uuid.Parse(f.BatchID); err == nil {
+			query = query.Where("batch_id = ?", batchUUID)
+		}
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	return query
+}
+
+func (f *UpdateJobFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+// GetSorting lists the latest updates first unless asked otherwise.
+func (f *UpdateJobFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "created_at", true
+	}
+	return f.BaseFilter.GetSorting()
+}
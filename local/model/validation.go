@@ -0,0 +1,55 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes a single invalid field in a submitted config.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every FieldError found while validating a config
+// so callers can report them all at once instead of failing on the first.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Add(field, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *ValidationError) HasErrors() bool {
+	return len(e.Errors) > 0
+}
+
+// ErrOrNil returns the ValidationError as an error, or nil when it is empty.
+func (e *ValidationError) ErrOrNil() error {
+	if !e.HasErrors() {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message)
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Fields maps each invalid field to its message(s), for error responses.
+func (e *ValidationError) Fields() map[string]string {
+	fields := make(map[string]string, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		if existing, ok := fields[fieldErr.Field]; ok {
+			fields[fieldErr.Field] = existing + "; " + fieldErr.Message
+		} else {
+			fields[fieldErr.Field] = fieldErr.Message
+		}
+	}
+	return fields
+}
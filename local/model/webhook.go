@@ -0,0 +1,296 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookKind decides the payload a webhook is sent.
+type WebhookKind string
+
+const (
+	// WebhookGeneric receives the WebhookNotification as JSON, signed with
+	// the webhook's secret.
+	WebhookGeneric WebhookKind = "generic"
+	WebhookDiscord WebhookKind = "discord"
+	WebhookSlack   WebhookKind = "slack"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookServerStarted      WebhookEvent = "server.started"
+	WebhookServerStopped      WebhookEvent = "server.stopped"
+	WebhookServerCrashed      WebhookEvent = "server.crashed"
+	WebhookSessionChanged     WebhookEvent = "session.changed"
+	WebhookPlayerThreshold    WebhookEvent = "players.threshold"
+	WebhookServerCreated      WebhookEvent = "server.created"
+	WebhookServerCreateFailed WebhookEvent = "server.create_failed"
+	WebhookConfigChanged      WebhookEvent = "config.changed"
+	// WebhookTest is only sent when a webhook is tested by hand.
+	WebhookTest WebhookEvent = "webhook.test"
+)
+
+var webhookEvents = map[WebhookEvent]bool{
+	WebhookServerStarted:      true,
+	WebhookServerStopped:      true,
+	WebhookServerCrashed:      true,
+	WebhookSessionChanged:     true,
+	WebhookPlayerThreshold:    true,
+	WebhookServerCreated:      true,
+	WebhookServerCreateFailed: true,
+	WebhookConfigChanged:      true,
+}
+
+// IsAlert reports whether the event is also sent to the system alerts topic
+// of the websocket.
+func (e WebhookEvent) IsAlert() bool {
+	return e == WebhookServerCrashed || e == WebhookServerCreateFailed
+}
+
+// WebhookEvents is the list of events a webhook is subscribed to. An empty
+// list subscribes to all of them.
+type WebhookEvents []WebhookEvent
+
+func (e *WebhookEvents) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case nil:
+		*e = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported type for WebhookEvents: %T", value)
+	}
+	return json.Unmarshal(data, e)
+}
+
+func (e WebhookEvents) Value() (driver.Value, error) {
+	if e == nil {
+		e = WebhookEvents{}
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (e WebhookEvents) Has(event WebhookEvent) bool {
+	if len(e) == 0 {
+		return true
+	}
+	for _, subscribed := range e {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook sends notifications about one server, or about every server when
+// ServerID is nil. PlayerThreshold is the player count that triggers
+// players.threshold when it is reached or dropped below.
+type Webhook struct {
+	ID              uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;"`
+	ServerID        *uuid.UUID    `json:"serverId" gorm:"type:uuid;index"`
+	Name            string        `json:"name" gorm:"not null"`
+	Kind            WebhookKind   `json:"kind" gorm:"not null"`
+	URL             string        `json:"url" gorm:"not null"`
+	Secret          string        `json:"secret,omitempty"`
+	HasSecret       bool          `json:"hasSecret" gorm:"-"`
+	Events          WebhookEvents `json:"events" gorm:"type:text"`
+	PlayerThreshold int           `json:"playerThreshold"`
+	Enabled         bool          `json:"enabled"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	UpdatedAt       time.Time     `json:"updatedAt"`
+}
+
+func (w *Webhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks the kind, URL, events and player threshold of a webhook.
+func (w *Webhook) Validate() error {
+	verr := &ValidationError{}
+	if strings.TrimSpace(w.Name) == "" {
+		verr.Add("name", "is required")
+	}
+	switch w.Kind {
+	case WebhookGeneric, WebhookDiscord, WebhookSlack:
+	default:
+		verr.Add("kind", "must be one of generic, discord, slack")
+	}
+	if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add("url", "must be an http or https URL")
+	}
+	for i, event := range w.Events {
+		if !webhookEvents[event] {
+			verr.Add(fmt.Sprintf("events[%d]", i), "unknown event %q", event)
+		}
+	}
+	if w.PlayerThreshold < 0 {
+		verr.Add("playerThreshold", "must not be negative")
+	}
+	if len(w.Events) > 0 && w.Events.Has(WebhookPlayerThreshold) && w.PlayerThreshold == 0 {
+		verr.Add("playerThreshold", "is required for %s", WebhookPlayerThreshold)
+	}
+	return verr.ErrOrNil()
+}
+
+// Matches reports whether the webhook is sent n. A player count change only
+// matches when it crosses the webhook's threshold.
+func (w *Webhook) Matches(n *WebhookNotification) bool {
+	if !w.Enabled || !w.Events.Has(n.Event) {
+		return false
+	}
+	if w.ServerID != nil && *w.ServerID != n.ServerID {
+		return false
+	}
+	if n.Event == WebhookPlayerThreshold {
+		return w.PlayerThreshold > 0 && n.CrossedThreshold(w.PlayerThreshold)
+	}
+	return true
+}
+
+// WebhookNotification is an event to be sent, and the payload of generic
+// webhooks.
+type WebhookNotification struct {
+	Event      WebhookEvent           `json:"event"`
+	ServerID   uuid.UUID              `json:"serverId"`
+	ServerName string                 `json:"serverName"`
+	Message    string                 `json:"message"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	OccurredAt time.Time              `json:"occurredAt"`
+}
+
+// CrossedThreshold reports whether a player count change went from below
+// threshold to at least threshold or back.
+func (n *WebhookNotification) CrossedThreshold(threshold int) bool {
+	previous, ok1 := n.Data["previous"].(int)
+	current, ok2 := n.Data["current"].(int)
+	if !ok1 || !ok2 {
+		return false
+	}
+	return (previous < threshold) != (current < threshold)
+}
+
+type WebhookFilter struct {
+	BaseFilter
+	ServerID string `query:"server_id"`
+}
+
+func (f *WebhookFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.ServerID != "" {
+		if serverUUID, err := uuid.Parse(f.ServerID); err == nil {
+			query = query.Where("server_id = ?", serverUUID)
+		}
+	}
+	return query
+}
+
+func (f *WebhookFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+func (f *WebhookFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "name", false
+	}
+	return f.BaseFilter.GetSorting()
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookMaxAttempts is how often a delivery is tried before it fails.
+const WebhookMaxAttempts = 5
+
+// WebhookDelivery records sending one notification to one webhook. Payload
+// is the exact body, so retries send the same one.
+type WebhookDelivery struct {
+	ID            uuid.UUID             `json:"id" gorm:"type:uuid;primary_key;"`
+	WebhookID     uuid.UUID             `json:"webhookId" gorm:"not null;type:uuid;index"`
+	ServerID      uuid.UUID             `json:"serverId" gorm:"type:uuid"`
+	Event         WebhookEvent          `json:"event"`
+	Payload       string                `json:"payload" gorm:"type:text"`
+	Status        WebhookDeliveryStatus `json:"status" gorm:"index"`
+	Attempts      int                   `json:"attempts"`
+	StatusCode    int                   `json:"statusCode"`
+	Error         string                `json:"error"`
+	NextAttemptAt *time.Time            `json:"nextAttemptAt"`
+	CreatedAt     time.Time             `json:"createdAt"`
+	DeliveredAt   *time.Time            `json:"deliveredAt"`
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// RetryDelay is how long to wait after the given number of failed attempts:
+// 10 seconds, doubled after every attempt.
+func (d *WebhookDelivery) RetryDelay() time.Duration {
+	return 10 * time.Second << (d.Attempts - 1)
+}
+
+type WebhookDeliveryFilter struct {
+	BaseFilter
+	DateRangeFilter
+	WebhookID string                `param:"id"`
+	Status    WebhookDeliveryStatus `query:"status"`
+	Event     WebhookEvent          `query:"event"`
+}
+
+func (f *WebhookDeliveryFilter) ApplyFilter(query *gorm.DB) *gorm.DB {
+	if f.WebhookID != "" {
+		if webhookUUID, err := uuid.Parse(f.WebhookID); err == nil {
+			query = query.Where("webhook_id = ?", webhookUUID)
+		}
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Event != "" {
+		query = query.Where("event = ?", f.Event)
+	}
+	if !f.StartDate.IsZero() {
+		query = query.Where("created_at >= ?", f.StartDate)
+	}
+	if !f.EndDate.IsZero() {
+		query = query.Where("created_at <= ?", f.EndDate)
+	}
+	return query
+}
+
+func (f *WebhookDeliveryFilter) Pagination() (offset, limit int) {
+	return f.BaseFilter.Pagination()
+}
+
+// GetSorting lists the latest deliveries first unless asked otherwise.
+func (f *WebhookDeliveryFilter) GetSorting() (field string, desc bool) {
+	if f.SortBy == "" {
+		return "created_at", true
+	}
+	return f.BaseFilter.GetSorting()
+}
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -29,14 +32,126 @@ const (
 type WebSocketMessageType string
 
 const (
-	MessageTypeStep        WebSocketMessageType = "step"
-	MessageTypeSteamOutput WebSocketMessageType = "steam_output"
-	MessageTypeError       WebSocketMessageType = "error"
-	MessageTypeComplete    WebSocketMessageType = "complete"
+	MessageTypeStep          WebSocketMessageType = "step"
+	MessageTypeSteamOutput   WebSocketMessageType = "steam_output"
+	MessageTypeError         WebSocketMessageType = "error"
+	MessageTypeComplete      WebSocketMessageType = "complete"
+	MessageTypeConfigChanged WebSocketMessageType = "config_changed"
+	MessageTypeUpdate        WebSocketMessageType = "update_progress"
+
+	// Messages of a server's live channel, sent to connections that
+	// subscribed to it.
+	MessageTypeLiveSnapshot   WebSocketMessageType = "live_snapshot"
+	MessageTypePlayerCount    WebSocketMessageType = "player_count"
+	MessageTypeSessionChanged WebSocketMessageType = "session_changed"
+	MessageTypeDriverJoined   WebSocketMessageType = "driver_joined"
+	MessageTypeDriverLeft     WebSocketMessageType = "driver_left"
+	MessageTypeLapCompleted   WebSocketMessageType = "lap_completed"
+	MessageTypeBestLap        WebSocketMessageType = "best_lap"
+
+	// Replies to the commands of a client.
+	MessageTypeSubscribed   WebSocketMessageType = "subscribed"
+	MessageTypeUnsubscribed WebSocketMessageType = "unsubscribed"
+	MessageTypePong         WebSocketMessageType = "pong"
+
+	MessageTypeAlert WebSocketMessageType = "alert"
+)
+
+type WebSocketCommandType string
+
+const (
+	CommandSubscribe   WebSocketCommandType = "subscribe"
+	CommandUnsubscribe WebSocketCommandType = "unsubscribe"
+	CommandPing        WebSocketCommandType = "ping"
+)
+
+// WebSocketCommand is a JSON message sent by a client, e.g. to subscribe to
+// a topic. A subscription naming only a ServerID is to the server's live
+// topic. LastSeq is the last message a reconnecting client received on a
+// replayable topic.
+type WebSocketCommand struct {
+	Type     WebSocketCommandType `json:"type"`
+	Topic    WebSocketTopic       `json:"topic,omitempty"`
+	ServerID uuid.UUID            `json:"server_id"`
+	LastSeq  uint64               `json:"last_seq,omitempty"`
+}
+
+// CommandTopic returns the topic a subscribe or unsubscribe command is for.
+func (c *WebSocketCommand) CommandTopic() WebSocketTopic {
+	if c.Topic == "" && c.ServerID != uuid.Nil {
+		return ServerLiveTopic(c.ServerID)
+	}
+	return c.Topic
+}
+
+// WebSocketTopic is what a websocket connection subscribes to. Server topics
+// have the form server:<id>:<channel>.
+type WebSocketTopic string
+
+const TopicSystemAlerts WebSocketTopic = "system:alerts"
+
+const (
+	topicServerCreation = "creation"
+	topicServerLive     = "live"
 )
 
+// ServerCreationTopic carries the progress of creating and updating a
+// server.
+func ServerCreationTopic(serverID uuid.UUID) WebSocketTopic {
+	return WebSocketTopic("server:" + serverID.String() + ":" + topicServerCreation)
+}
+
+// ServerLiveTopic carries the live timing and config changes of a server.
+func ServerLiveTopic(serverID uuid.UUID) WebSocketTopic {
+	return WebSocketTopic("server:" + serverID.String() + ":" + topicServerLive)
+}
+
+// ServerID returns the server of a server topic.
+func (t WebSocketTopic) ServerID() (uuid.UUID, bool) {
+	parts := strings.Split(string(t), ":")
+	if len(parts) != 3 || parts[0] != "server" {
+		return uuid.Nil, false
+	}
+	serverID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return serverID, true
+}
+
+// Replayable reports whether the recent messages of t are kept for clients
+// that reconnect, which is the case for the creation topics of servers.
+func (t WebSocketTopic) Replayable() bool {
+	return strings.HasSuffix(string(t), ":"+topicServerCreation)
+}
+
+// IsLive reports whether t is the live topic of a server.
+func (t WebSocketTopic) IsLive() bool {
+	return strings.HasSuffix(string(t), ":"+topicServerLive)
+}
+
+// Permission returns the permission needed to subscribe to t. It returns
+// false for topics that do not exist.
+func (t WebSocketTopic) Permission() (string, bool) {
+	if t == TopicSystemAlerts {
+		return ServerView, true
+	}
+	serverID, ok := t.ServerID()
+	if !ok {
+		return "", false
+	}
+	switch t {
+	case ServerCreationTopic(serverID), ServerLiveTopic(serverID):
+		return ServerView, true
+	}
+	return "", false
+}
+
+// WebSocketMessage is a message sent to clients. Seq numbers the messages
+// of a replayable topic.
 type WebSocketMessage struct {
 	Type      WebSocketMessageType `json:"type"`
+	Seq       uint64               `json:"seq,omitempty"`
 	ServerID  *uuid.UUID           `json:"server_id,omitempty"`
 	Timestamp int64                `json:"timestamp"`
 	Data      interface{}          `json:"data"`
@@ -49,11 +164,36 @@ type StepMessage struct {
 	Error   string             `json:"error,omitempty"`
 }
 
+// ServerCreationState is the progress of creating a server, for clients
+// that lost their websocket. LastSeq is the last message of the server's
+// creation topic that the state includes.
+type ServerCreationState struct {
+	ServerID    uuid.UUID          `json:"serverId"`
+	ServerName  string             `json:"serverName"`
+	Status      StepStatus         `json:"status"`
+	CurrentStep ServerCreationStep `json:"currentStep"`
+	Steps       []StepMessage      `json:"steps"`
+	Message     string             `json:"message,omitempty"`
+	StartedAt   time.Time          `json:"startedAt"`
+	FinishedAt  *time.Time         `json:"finishedAt,omitempty"`
+	LastSeq     uint64             `json:"lastSeq"`
+}
+
 type SteamOutputMessage struct {
 	Output  string `json:"output"`
 	IsError bool   `json:"is_error"`
 }
 
+// SubscriptionMessage confirms a subscribe or unsubscribe command. For a
+// replayable topic, FirstSeq and LastSeq are the oldest and newest message
+// still kept; messages after the client's last_seq are replayed right after
+// the confirmation.
+type SubscriptionMessage struct {
+	Topic    WebSocketTopic `json:"topic"`
+	FirstSeq uint64         `json:"first_seq,omitempty"`
+	LastSeq  uint64         `json:"last_seq,omitempty"`
+}
+
 type ErrorMessage struct {
 	Error   string `json:"error"`
 	Details string `json:"details,omitempty"`
@@ -65,16 +205,86 @@ type CompleteMessage struct {
 	Message  string    `json:"message"`
 }
 
+// ConfigChangedMessage tells open editors that a config file changed on disk.
+type ConfigChangedMessage struct {
+	ConfigFile string    `json:"config_file"`
+	RevisionID uuid.UUID `json:"revision_id"`
+	ChangedBy  string    `json:"changed_by"`
+}
+
+// LiveSnapshotMessage is the state of a server sent when a client subscribes
+// to its live channel. Cars is only filled while the server's broadcasting
+// API is reachable.
+type LiveSnapshotMessage struct {
+	Session        TrackSession  `json:"session"`
+	SessionStart   time.Time     `json:"session_start"`
+	PlayerCount    int           `json:"player_count"`
+	Track          string        `json:"track"`
+	MaxConnections int           `json:"max_connections"`
+	Players        []PlayerState `json:"players"`
+	Cars           []PlayerState `json:"cars"`
+}
+
+type PlayerCountMessage struct {
+	PlayerCount int `json:"player_count"`
+}
+
+type SessionChangedMessage struct {
+	From TrackSession `json:"from"`
+	To   TrackSession `json:"to"`
+}
+
+// DriverMessage reports a driver joining or leaving the server.
+type DriverMessage struct {
+	Player PlayerState `json:"player"`
+}
+
+// LapMessage reports a lap completed by a car. Lap is the number of the
+// completed lap and times are in milliseconds.
+type LapMessage struct {
+	CarID      int    `json:"car_id"`
+	RaceNumber int    `json:"race_number"`
+	DriverName string `json:"driver_name"`
+	Lap        int    `json:"lap"`
+	LapTime    int    `json:"lap_time"`
+	Sectors    []int  `json:"sectors"`
+	Position   int    `json:"position"`
+	Invalid    bool   `json:"invalid"`
+}
+
+// NewLapMessage describes the last lap of car.
+func NewLapMessage(car PlayerState) LapMessage {
+	return LapMessage{
+		CarID:      car.CarID,
+		RaceNumber: car.RaceNumber,
+		DriverName: car.DriverName,
+		Lap:        car.CurrentLap - 1,
+		LapTime:    car.LastLapTime,
+		Sectors:    car.LastLapSectors,
+		Position:   car.Position,
+		Invalid:    car.LastLapInvalid,
+	}
+}
+
 func GetStepDescription(step ServerCreationStep) string {
 	descriptions := map[ServerCreationStep]string{
 		StepValidation:        "Validating server configuration",
 		StepDirectoryCreation: "Creating server directories",
 		StepSteamDownload:     "Downloading server files via Steam",
 		StepConfigGeneration:  "Generating server configuration files",
-		StepServiceCreation:   "Creating Windows service",
+		StepServiceCreation:   "Creating server service",
 		StepFirewallRules:     "Configuring firewall rules",
 		StepDatabaseSave:      "Saving server to database",
 		StepCompleted:         "Server creation completed",
 	}
 	return descriptions[step]
 }
+
+// UpdateProgressMessage reports a step of a server files update.
+type UpdateProgressMessage struct {
+	UpdateID        uuid.UUID       `json:"update_id"`
+	Status          UpdateJobStatus `json:"status"`
+	Message         string          `json:"message"`
+	PreviousVersion string          `json:"previous_version,omitempty"`
+	NewVersion      string          `json:"new_version,omitempty"`
+}
@@ -3,7 +3,10 @@ package repository
 import (
 	"acc-server-manager/local/model"
 	"context"
+	"errors"
+	"fmt"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -25,3 +28,19 @@ func (r *ConfigRepository) UpdateConfig(ctx context.Context, config *model.Confi
 	}
 	return config
 }
+
+// GetRevision returns a single config change of a server, or nil if the
+// revision does not exist or belongs to another server.
+func (r *ConfigRepository) GetRevision(ctx context.Context, serverID, revisionID uuid.UUID) (*model.Config, error) {
+	revision := new(model.Config)
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND server_id = ?", revisionID, serverID).
+		First(revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting config revision: %w", err)
+	}
+	return revision, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"acc-server-manager/local/model"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ConfigTemplateRepository struct {
+	*BaseRepository[model.ConfigTemplate, model.ConfigTemplateFilter]
+}
+
+func NewConfigTemplateRepository(db *gorm.DB) *ConfigTemplateRepository {
+	return &ConfigTemplateRepository{
+		BaseRepository: NewBaseRepository[model.ConfigTemplate, model.ConfigTemplateFilter](db, model.ConfigTemplate{}),
+	}
+}
+
+// DeleteTemplate removes a template together with its server assignments.
+func (r *ConfigTemplateRepository) DeleteTemplate(ctx context.Context, templateID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", templateID).Delete(&model.ConfigTemplateAssignment{}).Error; err != nil {
+			return fmt.Errorf("error deleting template assignments: %w", err)
+		}
+		if err := tx.Delete(&model.ConfigTemplate{}, "id = ?", templateID).Error; err != nil {
+			return fmt.Errorf("error deleting template: %w", err)
+		}
+		return nil
+	})
+}
+
+// SaveAssignment records that a template was applied to a server, replacing
+// whatever template the server was given before.
+func (r *ConfigTemplateRepository) SaveAssignment(ctx context.Context, assignment *model.ConfigTemplateAssignment) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "server_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"template_id", "override", "applied_at", "applied_by"}),
+		}).
+		Create(assignment).Error
+	if err != nil {
+		return fmt.Errorf("error saving template assignment: %w", err)
+	}
+	return nil
+}
+
+func (r *ConfigTemplateRepository) GetAssignments(ctx context.Context, templateID uuid.UUID) ([]model.ConfigTemplateAssignment, error) {
+	assignments := make([]model.ConfigTemplateAssignment, 0)
+	if err := r.db.WithContext(ctx).Where("template_id = ?", templateID).Order("applied_at").Find(&assignments).Error; err != nil {
+		return nil, fmt.Errorf("error getting template assignments: %w", err)
+	}
+	return assignments, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"acc-server-manager/local/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobRepository struct {
+	*BaseRepository[model.Job, model.JobFilter]
+}
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{
+		BaseRepository: NewBaseRepository[model.Job, model.JobFilter](db, model.Job{}),
+	}
+}
+
+// ClaimNext marks the oldest queued job as running and returns it, or nil
+// if there is none. A job is only ever claimed by one worker, and a job of
+// an exclusive type is not claimed while another one of its type runs.
+func (r *JobRepository) ClaimNext(ctx context.Context, at time.Time, exclusive []model.JobType) (*model.Job, error) {
+	for {
+		query := r.db.WithContext(ctx).Where("status = ?", model.JobQueued)
+		if len(exclusive) > 0 {
+			query = query.Where("(type NOT IN ? OR NOT EXISTS (SELECT 1 FROM jobs AS running WHERE running.type = jobs.type AND running.status = ?))",
+				exclusive, model.JobRunning)
+		}
+
+		job := new(model.Job)
+		err := query.Order("created_at").First(job).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("error getting queued job: %w", err)
+		}
+
+		result := r.db.WithContext(ctx).
+			Model(&model.Job{}).
+			Where("id = ? AND status = ?", job.ID, model.JobQueued).
+			Updates(map[string]interface{}{"status": model.JobRunning, "started_at": at})
+		if result.Error != nil {
+			return nil, fmt.Errorf("error claiming job: %w", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			job.Status = model.JobRunning
+			job.StartedAt = &at
+			return job, nil
+		}
+	}
+}
+
+// GetRunning returns the jobs that are marked as running.
+func (r *JobRepository) GetRunning(ctx context.Context) ([]model.Job, error) {
+	var jobs []model.Job
+	err := r.db.WithContext(ctx).
+		Where("status = ?", model.JobRunning).
+		Order("created_at").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("error getting running jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// HasUnfinished reports whether a server has a job of one of the given types
+// that is queued or running.
+func (r *JobRepository) HasUnfinished(ctx context.Context, serverID uuid.UUID, types ...model.JobType) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.Job{}).
+		Where("server_id = ? AND type IN ? AND status IN ?", serverID, types, []model.JobStatus{model.JobQueued, model.JobRunning}).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("error checking jobs: %w", err)
+	}
+	return count > 0, nil
+}
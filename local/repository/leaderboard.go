@@ -10,6 +10,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// DefaultLeaderboardName names the championship created for a server that has
+// none yet.
+const DefaultLeaderboardName = "Championship"
+
 type LeaderboardRepository struct {
 	db *gorm.DB
 }
@@ -18,14 +22,21 @@ func NewLeaderboardRepository(db *gorm.DB) *LeaderboardRepository {
 	return &LeaderboardRepository{db: db}
 }
 
-// GetOrCreateByServerID fetches the leaderboard for a server, creating an empty one if it doesn't exist.
-func (r *LeaderboardRepository) GetOrCreateByServerID(ctx context.Context, serverID uuid.UUID) (*model.Leaderboard, error) {
-	lb := new(model.Leaderboard)
-	err := r.db.WithContext(ctx).
+func (r *LeaderboardRepository) preload(db *gorm.DB) *gorm.DB {
+	return db.
 		Preload("Drivers").
 		Preload("Races.Results").
 		Preload("PointRows").
+		Preload("Statuses")
+}
+
+// GetOrCreateByServerID fetches the default leaderboard for a server, creating an empty one if it doesn't exist.
+// A server without a leaderboard marked as default falls back to its oldest one.
+func (r *LeaderboardRepository) GetOrCreateByServerID(ctx context.Context, serverID uuid.UUID) (*model.Leaderboard, error) {
+	lb := new(model.Leaderboard)
+	err := r.preload(r.db.WithContext(ctx)).
 		Where("server_id = ?", serverID).
+		Order("is_default DESC, created_at ASC").
 		First(lb).Error
 
 	if err == nil {
@@ -37,6 +48,8 @@ func (r *LeaderboardRepository) GetOrCreateByServerID(ctx context.Context, serve
 
 	lb = &model.Leaderboard{
 		ServerID:    serverID,
+		Name:        DefaultLeaderboardName,
+		IsDefault:   true,
 		FLPoints:    1,
 		FLColor:     "#8b5cf6",
 		FLTextColor: "#000000",
@@ -47,44 +60,97 @@ func (r *LeaderboardRepository) GetOrCreateByServerID(ctx context.Context, serve
 	return lb, nil
 }
 
-// FullReplace replaces all leaderboard data for a server in a single transaction.
-func (r *LeaderboardRepository) FullReplace(ctx context.Context, serverID uuid.UUID, lb *model.Leaderboard) (*model.Leaderboard, error) {
-	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		existing := new(model.Leaderboard)
-		err := tx.Where("server_id = ?", serverID).First(existing).Error
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("error fetching existing leaderboard: %w", err)
-		}
-
+// GetByID fetches a leaderboard of a server with all its data, or nil if it doesn't exist.
+func (r *LeaderboardRepository) GetByID(ctx context.Context, serverID, id uuid.UUID) (*model.Leaderboard, error) {
+	lb := new(model.Leaderboard)
+	err := r.preload(r.db.WithContext(ctx)).
+		Where("id = ? AND server_id = ?", id, serverID).
+		First(lb).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			lb.ServerID = serverID
-			return tx.Create(lb).Error
+			return nil, nil
 		}
+		return nil, fmt.Errorf("error fetching leaderboard: %w", err)
+	}
+	return lb, nil
+}
 
-		// Delete children
-		if err := tx.Where("leaderboard_id = ?", existing.ID).Delete(&model.LeaderboardPointRow{}).Error; err != nil {
-			return err
+// ListByServerID lists the leaderboards of a server without their data, oldest first.
+func (r *LeaderboardRepository) ListByServerID(ctx context.Context, serverID uuid.UUID) ([]model.Leaderboard, error) {
+	var leaderboards []model.Leaderboard
+	err := r.db.WithContext(ctx).
+		Where("server_id = ?", serverID).
+		Order("created_at ASC").
+		Find(&leaderboards).Error
+	if err != nil {
+		return nil, fmt.Errorf("error listing leaderboards: %w", err)
+	}
+	return leaderboards, nil
+}
+
+// Create inserts a leaderboard with all its data.
+func (r *LeaderboardRepository) Create(ctx context.Context, lb *model.Leaderboard) error {
+	if err := r.db.WithContext(ctx).Create(lb).Error; err != nil {
+		return fmt.Errorf("error creating leaderboard: %w", err)
+	}
+	return nil
+}
+
+// SetDefault makes a leaderboard the default of its server.
+func (r *LeaderboardRepository) SetDefault(ctx context.Context, serverID, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Leaderboard{}).Where("server_id = ?", serverID).Update("is_default", false).Error; err != nil {
+			return fmt.Errorf("error clearing default leaderboard: %w", err)
+		}
+		if err := tx.Model(&model.Leaderboard{}).Where("id = ? AND server_id = ?", id, serverID).Update("is_default", true).Error; err != nil {
+			return fmt.Errorf("error setting default leaderboard: %w", err)
 		}
-		if err := tx.Where("leaderboard_id = ?", existing.ID).Delete(&model.LeaderboardDriver{}).Error; err != nil {
+		return nil
+	})
+}
+
+// Delete removes a leaderboard and all its data.
+func (r *LeaderboardRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteLeaderboardData(tx, id); err != nil {
 			return err
 		}
+		return tx.Delete(&model.Leaderboard{}, "id = ?", id).Error
+	})
+}
 
-		// Get race IDs to delete results
-		var raceIDs []uuid.UUID
-		tx.Model(&model.LeaderboardRace{}).Where("leaderboard_id = ?", existing.ID).Pluck("id", &raceIDs)
-		if len(raceIDs) > 0 {
-			if err := tx.Where("race_id IN ?", raceIDs).Delete(&model.LeaderboardResult{}).Error; err != nil {
-				return err
-			}
+// FullReplace replaces all data of the default leaderboard for a server in a single transaction.
+func (r *LeaderboardRepository) FullReplace(ctx context.Context, serverID uuid.UUID, lb *model.Leaderboard) (*model.Leaderboard, error) {
+	existing, err := r.GetOrCreateByServerID(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Replace(ctx, existing.ID, lb); err != nil {
+		return nil, err
+	}
+	return r.GetOrCreateByServerID(ctx, serverID)
+}
+
+// Replace replaces a leaderboard's fields and all its data in a single transaction.
+func (r *LeaderboardRepository) Replace(ctx context.Context, id uuid.UUID, lb *model.Leaderboard) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing := new(model.Leaderboard)
+		if err := tx.Where("id = ?", id).First(existing).Error; err != nil {
+			return fmt.Errorf("error fetching existing leaderboard: %w", err)
 		}
-		if err := tx.Where("leaderboard_id = ?", existing.ID).Delete(&model.LeaderboardRace{}).Error; err != nil {
+
+		if err := deleteLeaderboardData(tx, existing.ID); err != nil {
 			return err
 		}
 
 		// Update parent fields
+		existing.Name = lb.Name
+		existing.Season = lb.Season
+		existing.Archived = lb.Archived
 		existing.FLPoints = lb.FLPoints
 		existing.FLColor = lb.FLColor
 		existing.FLTextColor = lb.FLTextColor
+		existing.CountedRounds = lb.CountedRounds
 		if err := tx.Save(existing).Error; err != nil {
 			return err
 		}
@@ -109,6 +175,16 @@ func (r *LeaderboardRepository) FullReplace(ctx context.Context, serverID uuid.U
 			}
 		}
 
+		for i := range lb.Statuses {
+			lb.Statuses[i].LeaderboardID = existing.ID
+			lb.Statuses[i].ID = uuid.Nil
+		}
+		if len(lb.Statuses) > 0 {
+			if err := tx.Create(&lb.Statuses).Error; err != nil {
+				return err
+			}
+		}
+
 		for i := range lb.Races {
 			lb.Races[i].LeaderboardID = existing.ID
 		}
@@ -136,9 +212,49 @@ func (r *LeaderboardRepository) FullReplace(ctx context.Context, serverID uuid.U
 		lb.ID = existing.ID
 		return nil
 	})
-	if err != nil {
-		return nil, err
+}
+
+// deleteLeaderboardData deletes the drivers, races, results, points table and
+// statuses of a leaderboard.
+func deleteLeaderboardData(tx *gorm.DB, leaderboardID uuid.UUID) error {
+	if err := tx.Where("leaderboard_id = ?", leaderboardID).Delete(&model.LeaderboardPointRow{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("leaderboard_id = ?", leaderboardID).Delete(&model.LeaderboardStatus{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("leaderboard_id = ?", leaderboardID).Delete(&model.LeaderboardDriver{}).Error; err != nil {
+		return err
 	}
 
-	return r.GetOrCreateByServerID(ctx, serverID)
+	// Get race IDs to delete results
+	var raceIDs []uuid.UUID
+	tx.Model(&model.LeaderboardRace{}).Where("leaderboard_id = ?", leaderboardID).Pluck("id", &raceIDs)
+	if len(raceIDs) > 0 {
+		if err := tx.Where("race_id IN ?", raceIDs).Delete(&model.LeaderboardResult{}).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("leaderboard_id = ?", leaderboardID).Delete(&model.LeaderboardRace{}).Error
+}
+
+// AppendRace adds drivers and a race with its results to an existing
+// leaderboard in a single transaction.
+func (r *LeaderboardRepository) AppendRace(ctx context.Context, leaderboardID uuid.UUID, drivers []model.LeaderboardDriver, race *model.LeaderboardRace) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range drivers {
+			drivers[i].LeaderboardID = leaderboardID
+		}
+		if len(drivers) > 0 {
+			if err := tx.Create(&drivers).Error; err != nil {
+				return fmt.Errorf("error adding leaderboard drivers: %w", err)
+			}
+		}
+
+		race.LeaderboardID = leaderboardID
+		if err := tx.Create(race).Error; err != nil {
+			return fmt.Errorf("error adding leaderboard race: %w", err)
+		}
+		return nil
+	})
 }
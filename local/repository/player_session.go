@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"acc-server-manager/local/model"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PlayerSessionRepository struct {
+	*BaseRepository[model.PlayerSession, model.PlayerSessionFilter]
+}
+
+func NewPlayerSessionRepository(db *gorm.DB) *PlayerSessionRepository {
+	return &PlayerSessionRepository{
+		BaseRepository: NewBaseRepository[model.PlayerSession, model.PlayerSessionFilter](db, model.PlayerSession{}),
+	}
+}
+
+// SavePlayerSession inserts a player session or updates the car and
+// disconnect time of an existing one. The session it started in is kept.
+func (r *PlayerSessionRepository) SavePlayerSession(ctx context.Context, session *model.PlayerSession) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"car_id", "race_number", "car_model", "disconnected_at"}),
+		}).
+		Create(session).Error
+	if err != nil {
+		return fmt.Errorf("error saving player session: %w", err)
+	}
+	return nil
+}
+
+// CloseOpenSessions marks every player session of a server that was never
+// closed as disconnected at the given time.
+func (r *PlayerSessionRepository) CloseOpenSessions(ctx context.Context, serverID uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.PlayerSession{}).
+		Where("server_id = ? AND disconnected_at IS NULL", serverID).
+		Update("disconnected_at", at).Error
+	if err != nil {
+		return fmt.Errorf("error closing player sessions: %w", err)
+	}
+	return nil
+}
@@ -20,6 +20,18 @@ func InitializeRepositories(c *dig.Container) {
 	c.Provide(NewSteamCredentialsRepository)
 	c.Provide(NewMembershipRepository)
 	c.Provide(NewLeaderboardRepository)
+	c.Provide(NewConfigTemplateRepository)
+	c.Provide(NewPlayerSessionRepository)
+	c.Provide(NewResultRepository)
+	c.Provide(NewScheduleRepository)
+	c.Provide(NewScheduleExecutionRepository)
+	c.Provide(NewRestartPolicyRepository)
+	c.Provide(NewServerCrashRepository)
+	c.Provide(NewUpdateJobRepository)
+	c.Provide(NewRotationRepository)
+	c.Provide(NewWebhookRepository)
+	c.Provide(NewWebhookDeliveryRepository)
+	c.Provide(NewJobRepository)
 
 	if err := c.Provide(func() *model.Steam2FAManager {
 		manager := model.NewSteam2FAManager()
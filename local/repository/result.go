@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"acc-server-manager/local/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ResultRepository struct {
+	*BaseRepository[model.ResultSession, model.ResultSessionFilter]
+}
+
+func NewResultRepository(db *gorm.DB) *ResultRepository {
+	return &ResultRepository{
+		BaseRepository: NewBaseRepository[model.ResultSession, model.ResultSessionFilter](db, model.ResultSession{}),
+	}
+}
+
+// GetResult returns a session with its cars, drivers, laps and penalties, or
+// nil if it does not belong to the server.
+func (r *ResultRepository) GetResult(ctx context.Context, serverID, resultID uuid.UUID) (*model.ResultSession, error) {
+	var result model.ResultSession
+	err := r.db.WithContext(ctx).
+		Preload("Cars", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Cars.Drivers", func(db *gorm.DB) *gorm.DB { return db.Order("driver_index") }).
+		Preload("Laps", func(db *gorm.DB) *gorm.DB { return db.Order("car_id, lap_number") }).
+		Preload("Penalties").
+		Where("id = ? AND server_id = ?", resultID, serverID).
+		First(&result).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting result: %w", err)
+	}
+	return &result, nil
+}
+
+// Exists reports whether a results file of the server was already ingested.
+func (r *ResultRepository) Exists(ctx context.Context, serverID uuid.UUID, fileName string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.ResultSession{}).
+		Where("server_id = ? AND file_name = ?", serverID, fileName).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("error checking result: %w", err)
+	}
+	return count > 0, nil
+}
+
+// FindSessionID returns the StateHistory session of the server that was
+// running the given session type on track when finishedAt was reached, or nil
+// if there is none.
+func (r *ResultRepository) FindSessionID(ctx context.Context, serverID uuid.UUID, session model.TrackSession, track string, finishedAt time.Time) (*uuid.UUID, error) {
+	var history model.StateHistory
+	err := r.db.WithContext(ctx).
+		Where("server_id = ? AND session = ? AND track = ? AND date_created <= ?", serverID, session, track, finishedAt).
+		Order("date_created DESC").
+		First(&history).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error finding result session: %w", err)
+	}
+	return &history.SessionID, nil
+}
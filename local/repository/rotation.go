@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"acc-server-manager/local/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RotationRepository struct {
+	*BaseRepository[model.Rotation, model.ServerBasedFilter]
+}
+
+func NewRotationRepository(db *gorm.DB) *RotationRepository {
+	return &RotationRepository{
+		BaseRepository: NewBaseRepository[model.Rotation, model.ServerBasedFilter](db, model.Rotation{}),
+	}
+}
+
+func preloadRotationEntries(db *gorm.DB) *gorm.DB {
+	return db.Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("position") })
+}
+
+// GetByServerID returns a server's rotation with its entries in order, or nil
+// if it has none.
+func (r *RotationRepository) GetByServerID(ctx context.Context, serverID uuid.UUID) (*model.Rotation, error) {
+	rotation := new(model.Rotation)
+	err := preloadRotationEntries(r.db.WithContext(ctx)).Where("server_id = ?", serverID).First(rotation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting rotation: %w", err)
+	}
+	return rotation, nil
+}
+
+// Replace stores a server's rotation and replaces all its entries in a
+// single transaction.
+func (r *RotationRepository) Replace(ctx context.Context, rotation *model.Rotation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entries := rotation.Entries
+		rotation.Entries = nil
+		defer func() { rotation.Entries = entries }()
+
+		if err := tx.Save(rotation).Error; err != nil {
+			return fmt.Errorf("error saving rotation: %w", err)
+		}
+		if err := tx.Where("rotation_id = ?", rotation.ID).Delete(&model.RotationEntry{}).Error; err != nil {
+			return fmt.Errorf("error clearing rotation entries: %w", err)
+		}
+		for i := range entries {
+			entries[i].ID = uuid.Nil
+			entries[i].RotationID = rotation.ID
+			entries[i].Position = i
+			if err := tx.Create(&entries[i]).Error; err != nil {
+				return fmt.Errorf("error saving rotation entry: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteByServerID removes a server's rotation and its entries.
+func (r *RotationRepository) DeleteByServerID(ctx context.Context, serverID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := tx.Model(&model.Rotation{}).Select("id").Where("server_id = ?", serverID)
+		if err := tx.Where("rotation_id IN (?)", ids).Delete(&model.RotationEntry{}).Error; err != nil {
+			return fmt.Errorf("error deleting rotation entries: %w", err)
+		}
+		if err := tx.Where("server_id = ?", serverID).Delete(&model.Rotation{}).Error; err != nil {
+			return fmt.Errorf("error deleting rotation: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetDue returns the enabled rotations whose next scheduled advance is at or
+// before now.
+func (r *RotationRepository) GetDue(ctx context.Context, now time.Time) ([]model.Rotation, error) {
+	var rotations []model.Rotation
+	err := preloadRotationEntries(r.db.WithContext(ctx)).
+		Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Find(&rotations).Error
+	if err != nil {
+		return nil, fmt.Errorf("error getting due rotations: %w", err)
+	}
+	return rotations, nil
+}
+
+// GetEnabled returns every enabled rotation without its entries.
+func (r *RotationRepository) GetEnabled(ctx context.Context) ([]model.Rotation, error) {
+	var rotations []model.Rotation
+	if err := r.db.WithContext(ctx).Where("enabled = ?", true).Find(&rotations).Error; err != nil {
+		return nil, fmt.Errorf("error getting enabled rotations: %w", err)
+	}
+	return rotations, nil
+}
+
+// UpdateState stores a rotation's position, pin and run times without
+// touching its entries.
+func (r *RotationRepository) UpdateState(ctx context.Context, rotation *model.Rotation) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.Rotation{}).
+		Where("id = ?", rotation.ID).
+		Updates(map[string]interface{}{
+			"current_index":    rotation.CurrentIndex,
+			"pinned":           rotation.Pinned,
+			"next_run_at":      rotation.NextRunAt,
+			"last_advanced_at": rotation.LastAdvancedAt,
+			"last_trigger":     rotation.LastTrigger,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("error updating rotation: %w", err)
+	}
+	return nil
+}
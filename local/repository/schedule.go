@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"acc-server-manager/local/model"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ScheduleRepository struct {
+	*BaseRepository[model.Schedule, model.ScheduleFilter]
+}
+
+func NewScheduleRepository(db *gorm.DB) *ScheduleRepository {
+	return &ScheduleRepository{
+		BaseRepository: NewBaseRepository[model.Schedule, model.ScheduleFilter](db, model.Schedule{}),
+	}
+}
+
+// GetDue returns the enabled schedules whose next run is at or before now.
+func (r *ScheduleRepository) GetDue(ctx context.Context, now time.Time) ([]model.Schedule, error) {
+	var schedules []model.Schedule
+	err := r.db.WithContext(ctx).
+		Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("error getting due schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// GetEnabled returns every enabled schedule.
+func (r *ScheduleRepository) GetEnabled(ctx context.Context) ([]model.Schedule, error) {
+	var schedules []model.Schedule
+	if err := r.db.WithContext(ctx).Where("enabled = ?", true).Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("error getting enabled schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// UpdateRunTimes stores when a schedule last ran and when it is due next.
+func (r *ScheduleRepository) UpdateRunTimes(ctx context.Context, id uuid.UUID, lastRunAt time.Time, nextRunAt *time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.Schedule{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"last_run_at": lastRunAt, "next_run_at": nextRunAt}).Error
+	if err != nil {
+		return fmt.Errorf("error updating schedule run times: %w", err)
+	}
+	return nil
+}
+
+// SetNextRun stores when a schedule is due next.
+func (r *ScheduleRepository) SetNextRun(ctx context.Context, id uuid.UUID, nextRunAt *time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.Schedule{}).
+		Where("id = ?", id).
+		Update("next_run_at", nextRunAt).Error
+	if err != nil {
+		return fmt.Errorf("error updating schedule next run: %w", err)
+	}
+	return nil
+}
+
+type ScheduleExecutionRepository struct {
+	*BaseRepository[model.ScheduleExecution, model.ScheduleExecutionFilter]
+}
+
+func NewScheduleExecutionRepository(db *gorm.DB) *ScheduleExecutionRepository {
+	return &ScheduleExecutionRepository{
+		BaseRepository: NewBaseRepository[model.ScheduleExecution, model.ScheduleExecutionFilter](db, model.ScheduleExecution{}),
+	}
+}
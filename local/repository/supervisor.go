@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"acc-server-manager/local/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RestartPolicyRepository struct {
+	*BaseRepository[model.RestartPolicy, model.ServerBasedFilter]
+}
+
+func NewRestartPolicyRepository(db *gorm.DB) *RestartPolicyRepository {
+	return &RestartPolicyRepository{
+		BaseRepository: NewBaseRepository[model.RestartPolicy, model.ServerBasedFilter](db, model.RestartPolicy{}),
+	}
+}
+
+// GetByServerID returns the restart policy a server has set, or nil if it
+// uses the default.
+func (r *RestartPolicyRepository) GetByServerID(ctx context.Context, serverID uuid.UUID) (*model.RestartPolicy, error) {
+	policy := new(model.RestartPolicy)
+	if err := r.db.WithContext(ctx).Where("server_id = ?", serverID).First(policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting restart policy: %w", err)
+	}
+	return policy, nil
+}
+
+type ServerCrashRepository struct {
+	*BaseRepository[model.ServerCrash, model.ServerCrashFilter]
+}
+
+func NewServerCrashRepository(db *gorm.DB) *ServerCrashRepository {
+	return &ServerCrashRepository{
+		BaseRepository: NewBaseRepository[model.ServerCrash, model.ServerCrashFilter](db, model.ServerCrash{}),
+	}
+}
+
+// CountSince counts the crashes of a server detected at or after since.
+func (r *ServerCrashRepository) CountSince(ctx context.Context, serverID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.ServerCrash{}).
+		Where("server_id = ? AND detected_at >= ?", serverID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("error counting crashes: %w", err)
+	}
+	return count, nil
+}
+
+// CancelPending resolves every crash still waiting for a restart, as happens
+// when the manager restarts.
+func (r *ServerCrashRepository) CancelPending(ctx context.Context, reason string, at time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.ServerCrash{}).
+		Where("outcome = ?", model.CrashOutcomePending).
+		Updates(map[string]interface{}{"outcome": model.CrashOutcomeCancelled, "error": reason, "resolved_at": at}).Error
+	if err != nil {
+		return fmt.Errorf("error cancelling pending crashes: %w", err)
+	}
+	return nil
+}
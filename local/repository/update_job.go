@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"acc-server-manager/local/model"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var unfinishedUpdateJobStatuses = []model.UpdateJobStatus{
+	model.UpdateJobQueued,
+	model.UpdateJobWaiting,
+	model.UpdateJobStopping,
+	model.UpdateJobUpdating,
+	model.UpdateJobVerifying,
+	model.UpdateJobStarting,
+}
+
+type UpdateJobRepository struct {
+	*BaseRepository[model.UpdateJob, model.UpdateJobFilter]
+}
+
+func NewUpdateJobRepository(db *gorm.DB) *UpdateJobRepository {
+	return &UpdateJobRepository{
+		BaseRepository: NewBaseRepository[model.UpdateJob, model.UpdateJobFilter](db, model.UpdateJob{}),
+	}
+}
+
+// HasUnfinished reports whether a server has an update that is queued or
+// running.
+func (r *UpdateJobRepository) HasUnfinished(ctx context.Context, serverID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.UpdateJob{}).
+		Where("server_id = ? AND status IN ?", serverID, unfinishedUpdateJobStatuses).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("error checking updates: %w", err)
+	}
+	return count > 0, nil
+}
+
+// CancelQueuedInBatch cancels the updates of a batch that have not started.
+func (r *UpdateJobRepository) CancelQueuedInBatch(ctx context.Context, batchID uuid.UUID, reason string, at time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.UpdateJob{}).
+		Where("batch_id = ? AND status = ?", batchID, model.UpdateJobQueued).
+		Updates(map[string]interface{}{"status": model.UpdateJobCancelled, "error": reason, "finished_at": at}).Error
+	if err != nil {
+		return fmt.Errorf("error cancelling batch updates: %w", err)
+	}
+	return nil
+}
+
+// FailUnfinished fails every update that was running or queued, as happens
+// when the manager restarts in the middle of one.
+func (r *UpdateJobRepository) FailUnfinished(ctx context.Context, reason string, at time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.UpdateJob{}).
+		Where("status IN ? AND status <> ?", unfinishedUpdateJobStatuses, model.UpdateJobQueued).
+		Updates(map[string]interface{}{"status": model.UpdateJobFailed, "error": reason, "finished_at": at}).Error
+	if err != nil {
+		return fmt.Errorf("error failing interrupted updates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"acc-server-manager/local/model"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository struct {
+	*BaseRepository[model.Webhook, model.WebhookFilter]
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{
+		BaseRepository: NewBaseRepository[model.Webhook, model.WebhookFilter](db, model.Webhook{}),
+	}
+}
+
+// GetEnabledFor returns the enabled webhooks of a server and those for every
+// server.
+func (r *WebhookRepository) GetEnabledFor(ctx context.Context, serverID uuid.UUID) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	err := r.db.WithContext(ctx).
+		Where("enabled = ? AND (server_id IS NULL OR server_id = ?)", true, serverID).
+		Find(&webhooks).Error
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// Delete removes a webhook and its delivery log.
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return fmt.Errorf("error deleting webhook deliveries: %w", err)
+		}
+		if err := tx.Delete(&model.Webhook{}, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("error deleting webhook: %w", err)
+		}
+		return nil
+	})
+}
+
+type WebhookDeliveryRepository struct {
+	*BaseRepository[model.WebhookDelivery, model.WebhookDeliveryFilter]
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{
+		BaseRepository: NewBaseRepository[model.WebhookDelivery, model.WebhookDeliveryFilter](db, model.WebhookDelivery{}),
+	}
+}
+
+// GetDueRetries returns up to limit pending deliveries whose next attempt is
+// at or before now, oldest first.
+func (r *WebhookDeliveryRepository) GetDueRetries(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at IS NOT NULL AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("error getting due webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
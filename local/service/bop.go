@@ -0,0 +1,124 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var ErrBOPEntryNotFound = fiber.NewError(fiber.StatusNotFound, "BOP entry not found")
+
+// BOPService manages a server's bop.json. Entries are keyed by track and car
+// model, so adding an entry for an existing pair replaces it.
+type BOPService struct {
+	configService    *ConfigService
+	serverRepository *repository.ServerRepository
+	lookupRepository *repository.LookupRepository
+}
+
+func NewBOPService(configService *ConfigService, serverRepository *repository.ServerRepository, lookupRepository *repository.LookupRepository) *BOPService {
+	logging.Debug("Initializing BOPService")
+	return &BOPService{
+		configService:    configService,
+		serverRepository: serverRepository,
+		lookupRepository: lookupRepository,
+	}
+}
+
+// GetBOP returns the BOP of a server, limited to one track when track is set.
+func (s *BOPService) GetBOP(ctx context.Context, serverID uuid.UUID, track string) (*model.BOP, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	bop, err := s.configService.GetBOP(server)
+	if err != nil {
+		return nil, err
+	}
+	if track != "" {
+		bop.Entries = bop.ForTrack(track)
+	}
+	return bop, nil
+}
+
+func (s *BOPService) ReplaceBOP(ctx context.Context, serverID uuid.UUID, bop *model.BOP) (*model.BOP, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if bop.Entries == nil {
+		bop.Entries = []model.BOPEntry{}
+	}
+	return s.save(ctx, server, bop)
+}
+
+func (s *BOPService) SetEntry(ctx context.Context, serverID uuid.UUID, entry *model.BOPEntry) (*model.BOP, error) {
+	return s.modify(ctx, serverID, func(bop *model.BOP) error {
+		bop.Set(*entry)
+		return nil
+	})
+}
+
+func (s *BOPService) RemoveEntry(ctx context.Context, serverID uuid.UUID, track string, carModel int) (*model.BOP, error) {
+	return s.modify(ctx, serverID, func(bop *model.BOP) error {
+		if !bop.Remove(track, carModel) {
+			return ErrBOPEntryNotFound
+		}
+		return nil
+	})
+}
+
+func (s *BOPService) modify(ctx context.Context, serverID uuid.UUID, change func(*model.BOP) error) (*model.BOP, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	bop, err := s.configService.GetBOP(server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bop: %v", err)
+	}
+
+	if err := change(bop); err != nil {
+		return nil, err
+	}
+
+	return s.save(ctx, server, bop)
+}
+
+func (s *BOPService) save(ctx context.Context, server *model.Server, bop *model.BOP) (*model.BOP, error) {
+	tracks, err := tracksByName(ctx, s.lookupRepository)
+	if err != nil {
+		return nil, err
+	}
+	carModels, err := carModelValues(ctx, s.lookupRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bop.Validate(tracks, carModels); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.configService.SaveBOP(ctx, server, bop); err != nil {
+		logging.Error("Failed to save bop for server %s: %v", server.ID, err)
+		return nil, fmt.Errorf("failed to save bop: %v", err)
+	}
+
+	logging.Info("Saved bop with %d entries for server %s", len(bop.Entries), server.ID)
+	return bop, nil
+}
+
+func (s *BOPService) getServer(ctx context.Context, serverID uuid.UUID) (*model.Server, error) {
+	server, err := s.serverRepository.GetByID(ctx, serverID)
+	if err != nil || server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+	return server, nil
+}
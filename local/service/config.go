@@ -5,6 +5,7 @@ import (
 	"acc-server-manager/local/repository"
 	"acc-server-manager/local/utl/common"
 	"acc-server-manager/local/utl/logging"
+	"acc-server-manager/local/utl/tracking"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -13,6 +14,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -28,8 +30,21 @@ const (
 	EventJson         = "event.json"
 	EventRulesJson    = "eventRules.json"
 	SettingsJson      = "settings.json"
+	EntryListJson     = "entrylist.json"
+	BOPJson           = "bop.json"
+	BroadcastingJson  = "broadcasting.json"
 )
 
+// configSectionFiles maps the sections of model.Configurations to the files
+// they are stored in.
+var configSectionFiles = map[string]string{
+	"configuration": ConfigurationJson,
+	"assistRules":   AssistRulesJson,
+	"event":         EventJson,
+	"eventRules":    EventRulesJson,
+	"settings":      SettingsJson,
+}
+
 var decodeMap = map[string]func(string) (interface{}, error){
 	ConfigurationJson: func(f string) (interface{}, error) {
 		return readAndDecode[model.Configuration](f, ConfigurationJson)
@@ -46,6 +61,15 @@ var decodeMap = map[string]func(string) (interface{}, error){
 	SettingsJson: func(f string) (interface{}, error) {
 		return readAndDecode[model.ServerSettings](f, SettingsJson)
 	},
+	EntryListJson: func(f string) (interface{}, error) {
+		return readAndDecode[model.EntryList](f, EntryListJson)
+	},
+	BOPJson: func(f string) (interface{}, error) {
+		return readAndDecode[model.BOP](f, BOPJson)
+	},
+	BroadcastingJson: func(f string) (interface{}, error) {
+		return readAndDecode[model.Broadcasting](f, BroadcastingJson)
+	},
 }
 
 func DecodeFileName(fileName string) func(path string) (interface{}, error) {
@@ -68,9 +92,17 @@ type ConfigService struct {
 	repository       *repository.ConfigRepository
 	serverRepository *repository.ServerRepository
 	serverService    *ServerService
+	lookupRepository *repository.LookupRepository
+	webSocketService *WebSocketService
+	webhookService   *WebhookService
 	configCache      *model.ServerConfigCache
+	watchers         sync.Map // Track config watchers per server
 }
 
+// configWatchInterval is how often config directories are checked for edits
+// made outside the manager.
+const configWatchInterval = 2 * time.Second
+
 func NewConfigService(repository *repository.ConfigRepository, serverRepository *repository.ServerRepository) *ConfigService {
 	logging.Debug("Initializing ConfigService with 5m expiration and 1s throttle")
 	return &ConfigService{
@@ -88,6 +120,21 @@ func (as *ConfigService) SetServerService(serverService *ServerService) {
 	as.serverService = serverService
 }
 
+// SetWebSocketService enables notifications about config files edited on disk.
+func (as *ConfigService) SetWebSocketService(webSocketService *WebSocketService) {
+	as.webSocketService = webSocketService
+}
+
+// SetWebhookService enables notifications about config changes.
+func (as *ConfigService) SetWebhookService(webhookService *WebhookService) {
+	as.webhookService = webhookService
+}
+
+// SetLookupRepository enables the track and car model checks of ValidateConfig.
+func (as *ConfigService) SetLookupRepository(lookupRepository *repository.LookupRepository) {
+	as.lookupRepository = lookupRepository
+}
+
 //	   		context.Context: Application context
 //		Returns:
 //			string: Application version
@@ -95,36 +142,176 @@ func (as *ConfigService) UpdateConfig(ctx *fiber.Ctx, body *map[string]interface
 	serverID := ctx.Locals("serverId").(string)
 	configFile := ctx.Params("file")
 	override := ctx.QueryBool("override", false)
+	dryRun := ctx.QueryBool("dryRun", false)
+
+	server, err := as.serverRepository.GetByID(ctx.UserContext(), serverID)
+	if err != nil || server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+
+	if err := as.ValidateConfig(ctx.UserContext(), server, configFile, *body); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return as.previewConfigUpdate(ctx.UserContext(), server, configFile, body, override)
+	}
 
 	return as.updateConfigInternal(ctx.UserContext(), serverID, configFile, body, override)
 }
 
-func (as *ConfigService) updateConfigFiles(ctx context.Context, server *model.Server, configFile string, body *map[string]interface{}, override bool) ([]byte, []byte, error) {
-	if server == nil {
-		logging.Error("Server not found")
-		return nil, nil, fmt.Errorf("server not found")
+// ValidateConfig checks an update to one of a server's config files before it
+// is written. Every invalid field is reported in a *model.ValidationError.
+func (as *ConfigService) ValidateConfig(ctx context.Context, server *model.Server, configFile string, body map[string]interface{}) error {
+	switch configFile {
+	case EntryListJson:
+		return as.validateEntryListUpdate(ctx, body)
+	case BOPJson:
+		return as.validateBOPUpdate(ctx, body)
+	case BroadcastingJson:
+		return as.validateBroadcastingUpdate(server, body)
 	}
 
-	configPath := filepath.Join(server.GetConfigPath(), configFile)
-	oldData, err := os.ReadFile(configPath)
+	section := configFileSection(configFile)
+	if section == "" {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown config file %s", configFile))
+	}
+
+	var vctx model.ConfigValidationContext
+	if as.lookupRepository != nil {
+		tracks, err := tracksByName(ctx, as.lookupRepository)
+		if err != nil {
+			return err
+		}
+		vctx.Tracks = tracks
+	}
+	if event, err := mustDecode[model.EventConfig](EventJson, server.GetConfigPath()); err == nil {
+		vctx.Track = event.Track
+	}
+	if settings, err := mustDecode[model.ServerSettings](SettingsJson, server.GetConfigPath()); err == nil {
+		vctx.MaxCarSlots = settings.MaxCarSlots.ToInt()
+	}
+
+	return model.ValidateConfigSection(section, body, vctx)
+}
+
+func (as *ConfigService) validateEntryListUpdate(ctx context.Context, body map[string]interface{}) error {
+	var entryList model.EntryList
+	if err := decodeConfigBody(body, &entryList); err != nil {
+		return err
+	}
+	if as.lookupRepository == nil {
+		return nil
+	}
+
+	carModels, err := carModelValues(ctx, as.lookupRepository)
 	if err != nil {
-		if os.IsNotExist(err) {
-			dir := filepath.Dir(configPath)
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				return nil, nil, err
-			}
-			if err := os.WriteFile(configPath, []byte("{}"), 0644); err != nil {
-				return nil, nil, err
-			}
-			oldData = []byte("{}")
-		} else {
-			return nil, nil, err
+		return err
+	}
+	return entryList.Validate(carModels)
+}
+
+func (as *ConfigService) validateBOPUpdate(ctx context.Context, body map[string]interface{}) error {
+	var bop model.BOP
+	if err := decodeConfigBody(body, &bop); err != nil {
+		return err
+	}
+	if as.lookupRepository == nil {
+		return nil
+	}
+
+	tracks, err := tracksByName(ctx, as.lookupRepository)
+	if err != nil {
+		return err
+	}
+	carModels, err := carModelValues(ctx, as.lookupRepository)
+	if err != nil {
+		return err
+	}
+	return bop.Validate(tracks, carModels)
+}
+
+func (as *ConfigService) validateBroadcastingUpdate(server *model.Server, body map[string]interface{}) error {
+	var broadcasting model.Broadcasting
+	if err := decodeConfigBody(body, &broadcasting); err != nil {
+		return err
+	}
+	configuration, err := mustDecode[model.Configuration](ConfigurationJson, server.GetConfigPath())
+	if err != nil {
+		return broadcasting.Validate(nil)
+	}
+	return broadcasting.Validate(&configuration)
+}
+
+// decodeConfigBody decodes an untyped config body into its typed struct,
+// rejecting keys the struct does not have other than those ACC writes into
+// every file.
+func decodeConfigBody(body map[string]interface{}, target interface{}) error {
+	fields := make(map[string]interface{}, len(body))
+	for key, value := range body {
+		if !model.IsSharedConfigKey(key) {
+			fields[key] = value
 		}
 	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
 
-	oldDataUTF8, err := DecodeUTF16LEBOM(oldData)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		verr := &model.ValidationError{}
+		verr.Add("body", "%v", err)
+		return verr
+	}
+	return nil
+}
+
+// configFileSection returns the Configurations section stored in configFile,
+// or "" when the file is not one of them.
+func configFileSection(configFile string) string {
+	for section, file := range configSectionFiles {
+		if file == configFile {
+			return section
+		}
+	}
+	return ""
+}
+
+// previewConfigUpdate returns the change an update would make without writing
+// it or recording it in the history.
+func (as *ConfigService) previewConfigUpdate(ctx context.Context, server *model.Server, configFile string, body *map[string]interface{}, override bool) (*model.Config, error) {
+	oldDataUTF8, newData, err := as.mergeConfigFile(server, configFile, body, override)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+
+	actor := model.ActorFromContext(ctx)
+	return &model.Config{
+		ServerID:    server.ID,
+		ConfigFile:  configFile,
+		OldConfig:   string(oldDataUTF8),
+		NewConfig:   string(newData),
+		ChangedAt:   time.Now(),
+		ChangedByID: actor.UserID,
+		ChangedBy:   actor.Username,
+	}, nil
+}
+
+// mergeConfigFile returns the current content of a config file and the
+// content it would have after applying body, both as UTF-8 JSON.
+func (as *ConfigService) mergeConfigFile(server *model.Server, configFile string, body *map[string]interface{}, override bool) ([]byte, []byte, error) {
+	oldDataUTF8 := []byte("{}")
+	oldData, err := os.ReadFile(filepath.Join(server.GetConfigPath(), configFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+	} else {
+		oldDataUTF8, err = DecodeUTF16LEBOM(oldData)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	newData, err := json.Marshal(&body)
@@ -142,13 +329,35 @@ func (as *ConfigService) updateConfigFiles(ctx context.Context, server *model.Se
 	if err != nil {
 		return nil, nil, err
 	}
+	return oldDataUTF8, newData, nil
+}
+
+func (as *ConfigService) updateConfigFiles(ctx context.Context, server *model.Server, configFile string, body *map[string]interface{}, override bool) ([]byte, []byte, error) {
+	if server == nil {
+		logging.Error("Server not found")
+		return nil, nil, fmt.Errorf("server not found")
+	}
+
+	oldDataUTF8, newData, err := as.mergeConfigFile(server, configFile, body, override)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	newDataUTF16, err := EncodeUTF16LEBOM(newData)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if err := os.WriteFile(configPath, newDataUTF16, 0644); err != nil {
+	configPath := filepath.Join(server.GetConfigPath(), configFile)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return nil, nil, err
+	}
+	if watcher, ok := as.watchers.Load(server.ID); ok {
+		err = watcher.(*tracking.FileWatcher).WriteFile(configFile, newDataUTF16, 0644)
+	} else {
+		err = os.WriteFile(configPath, newDataUTF16, 0644)
+	}
+	if err != nil {
 		return nil, nil, err
 	}
 
@@ -175,14 +384,169 @@ func (as *ConfigService) updateConfigInternal(ctx context.Context, serverID stri
 
 	as.configCache.InvalidateServerCache(serverID)
 
-	as.serverService.StartAccServerRuntime(server)
+	if as.serverService != nil {
+		as.serverService.StartAccServerRuntime(server)
+	}
+	return as.recordConfigChange(ctx, serverUUID, configFile, oldDataUTF8, newData), nil
+}
+
+// WatchConfigFiles starts watching a server's config directory so edits made
+// directly on disk reach the cache, the config history and open editors.
+func (as *ConfigService) WatchConfigFiles(server *model.Server) {
+	if _, ok := as.watchers.Load(server.ID); ok {
+		return
+	}
+	watcher := tracking.NewFileWatcher(server.GetConfigPath(), configWatchInterval, func(fileName string, oldData, newData []byte) {
+		as.handleExternalChange(server.ID, fileName, oldData, newData)
+	})
+	if _, loaded := as.watchers.LoadOrStore(server.ID, watcher); loaded {
+		return
+	}
+	watcher.Start()
+}
+
+// StopWatchingConfigFiles stops the watcher started by WatchConfigFiles.
+func (as *ConfigService) StopWatchingConfigFiles(serverID uuid.UUID) {
+	if watcher, ok := as.watchers.LoadAndDelete(serverID); ok {
+		watcher.(*tracking.FileWatcher).Stop()
+	}
+}
+
+func (as *ConfigService) handleExternalChange(serverID uuid.UUID, configFile string, oldData, newData []byte) {
+	if DecodeFileName(configFile) == nil {
+		return
+	}
+	as.configCache.InvalidateServerCache(serverID.String())
+
+	oldDataUTF8, err := DecodeUTF16LEBOM(oldData)
+	if err != nil {
+		oldDataUTF8 = oldData
+	}
+	newDataUTF8, err := DecodeUTF16LEBOM(newData)
+	if err != nil {
+		newDataUTF8 = newData
+	}
+
+	logging.Info("Config file %s of server %s was changed on disk", configFile, serverID)
+	ctx := model.ContextWithActor(context.Background(), model.Actor{Username: model.ActorExternal})
+	revision := as.recordConfigChange(ctx, serverID, configFile, oldDataUTF8, newDataUTF8)
+	if revision != nil && as.webSocketService != nil {
+		as.webSocketService.BroadcastConfigChanged(serverID, revision)
+	}
+}
+
+// recordConfigChange stores a revision in the config history, attributed to
+// the actor carried by ctx.
+func (as *ConfigService) recordConfigChange(ctx context.Context, serverID uuid.UUID, configFile string, oldData, newData []byte) *model.Config {
+	actor := model.ActorFromContext(ctx)
+	as.webhookService.Notify(model.WebhookNotification{
+		Event:    model.WebhookConfigChanged,
+		ServerID: serverID,
+		Message:  fmt.Sprintf("%s was changed by %s", configFile, actor.Username),
+		Data:     map[string]interface{}{"configFile": configFile, "changedBy": actor.Username},
+	})
 	return as.repository.UpdateConfig(ctx, &model.Config{
-		ServerID:   serverUUID,
-		ConfigFile: configFile,
-		OldConfig:  string(oldDataUTF8),
-		NewConfig:  string(newData),
-		ChangedAt:  time.Now(),
-	}), nil
+		ServerID:    serverID,
+		ConfigFile:  configFile,
+		OldConfig:   string(oldData),
+		NewConfig:   string(newData),
+		ChangedAt:   time.Now(),
+		ChangedByID: actor.UserID,
+		ChangedBy:   actor.Username,
+	})
+}
+
+// GetHistory lists the recorded changes of a server's config files, newest first.
+func (as *ConfigService) GetHistory(ctx context.Context, filter *model.ConfigFilter) (*model.FilteredResponse, error) {
+	serverUUID, err := uuid.Parse(filter.ServerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server ID format")
+	}
+	if server, err := as.serverRepository.GetByID(ctx, serverUUID); err != nil || server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+
+	revisions, err := as.repository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := as.repository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: revisions,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+// GetRevision returns a single recorded config change of a server.
+func (as *ConfigService) GetRevision(ctx context.Context, serverID, revisionID uuid.UUID) (*model.Config, error) {
+	revision, err := as.repository.GetRevision(ctx, serverID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if revision == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Config revision not found")
+	}
+	return revision, nil
+}
+
+// DiffRevisions compares the state after two revisions of the same file. When
+// fromID is nil the revision is compared with the state it replaced.
+func (as *ConfigService) DiffRevisions(ctx context.Context, serverID uuid.UUID, fromID *uuid.UUID, toID uuid.UUID) (*model.ConfigDiff, error) {
+	to, err := as.GetRevision(ctx, serverID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldConfig := to.OldConfig
+	if fromID != nil {
+		from, err := as.GetRevision(ctx, serverID, *fromID)
+		if err != nil {
+			return nil, err
+		}
+		if from.ConfigFile != to.ConfigFile {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("cannot compare %s with %s", from.ConfigFile, to.ConfigFile))
+		}
+		oldConfig = from.NewConfig
+	}
+
+	changes, err := model.DiffConfigs(oldConfig, to.NewConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ConfigDiff{
+		ConfigFile:     to.ConfigFile,
+		FromRevisionID: fromID,
+		ToRevisionID:   to.ID,
+		Changes:        changes,
+	}, nil
+}
+
+// RollbackConfig writes a file back to the state it had after the given
+// revision. The rollback itself is recorded as a new revision.
+func (as *ConfigService) RollbackConfig(ctx context.Context, serverID, revisionID uuid.UUID) (*model.Config, error) {
+	revision, err := as.GetRevision(ctx, serverID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	body := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(revision.NewConfig), &body); err != nil {
+		return nil, fmt.Errorf("failed to parse config revision: %v", err)
+	}
+
+	logging.Info("Rolling back %s of server %s to revision %s", revision.ConfigFile, serverID, revisionID)
+	return as.updateConfigInternal(ctx, serverID.String(), revision.ConfigFile, &body, true)
 }
 
 //	   		context.Context: Application context
@@ -256,6 +620,12 @@ func (as *ConfigService) getConfigFile(server *model.Server, configFile string)
 				return model.EventRules{}, nil
 			case SettingsJson:
 				return model.ServerSettings{}, nil
+			case EntryListJson:
+				return model.EntryList{Entries: []model.Entry{}}, nil
+			case BOPJson:
+				return model.BOP{Entries: []model.BOPEntry{}}, nil
+			case BroadcastingJson:
+				return model.Broadcasting{}, nil
 			}
 		}
 		return nil, err
@@ -420,6 +790,106 @@ func (as *ConfigService) GetConfiguration(server *model.Server) (*model.Configur
 	return &config, nil
 }
 
+// readConfigJSON reads a config file as untyped JSON, keeping exactly the keys
+// that are on disk. A missing file reads as an empty object.
+func (as *ConfigService) readConfigJSON(server *model.Server, configFile string) (map[string]interface{}, error) {
+	data, err := readFile(server.GetConfigPath(), configFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]interface{}{}, nil
+		}
+		return nil, err
+	}
+
+	decoded, err := DecodeUTF16LEBOM(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode UTF16: %v", err)
+	}
+
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(decoded, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+	return config, nil
+}
+
+// GetEntryList reads entrylist.json from disk. A server without an entry list
+// gets an empty one rather than an error.
+func (as *ConfigService) GetEntryList(server *model.Server) (*model.EntryList, error) {
+	entryList, err := mustDecode[model.EntryList](EntryListJson, server.GetConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &model.EntryList{Entries: []model.Entry{}}, nil
+		}
+		return nil, err
+	}
+	if entryList.Entries == nil {
+		entryList.Entries = []model.Entry{}
+	}
+	return &entryList, nil
+}
+
+// SaveEntryList replaces entrylist.json and records the change in the config
+// history.
+func (as *ConfigService) SaveEntryList(ctx context.Context, server *model.Server, entryList *model.EntryList) (*model.Config, error) {
+	return as.saveConfigFile(ctx, server, EntryListJson, entryList)
+}
+
+// GetBOP reads bop.json from disk. A server without one gets an empty BOP.
+func (as *ConfigService) GetBOP(server *model.Server) (*model.BOP, error) {
+	bop, err := mustDecode[model.BOP](BOPJson, server.GetConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &model.BOP{Entries: []model.BOPEntry{}}, nil
+		}
+		return nil, err
+	}
+	if bop.Entries == nil {
+		bop.Entries = []model.BOPEntry{}
+	}
+	return &bop, nil
+}
+
+// SaveBOP replaces bop.json and records the change in the config history.
+func (as *ConfigService) SaveBOP(ctx context.Context, server *model.Server, bop *model.BOP) (*model.Config, error) {
+	return as.saveConfigFile(ctx, server, BOPJson, bop)
+}
+
+// GetBroadcasting reads broadcasting.json from disk. A server without one
+// has the broadcasting API disabled.
+func (as *ConfigService) GetBroadcasting(server *model.Server) (*model.Broadcasting, error) {
+	broadcasting, err := mustDecode[model.Broadcasting](BroadcastingJson, server.GetConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &model.Broadcasting{}, nil
+		}
+		return nil, err
+	}
+	return &broadcasting, nil
+}
+
+// saveConfigFile overwrites a config file with a typed value and records the
+// change, going through the same UTF-16 write path as UpdateConfig.
+func (as *ConfigService) saveConfigFile(ctx context.Context, server *model.Server, configFile string, value interface{}) (*model.Config, error) {
+	configMap := make(map[string]interface{})
+	configBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %v", configFile, err)
+	}
+	if err := json.Unmarshal(configBytes, &configMap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %v", configFile, err)
+	}
+
+	oldDataUTF8, newData, err := as.updateConfigFiles(ctx, server, configFile, &configMap, true)
+	if err != nil {
+		return nil, err
+	}
+
+	as.configCache.InvalidateServerCache(server.ID.String())
+
+	return as.recordConfigChange(ctx, server.ID, configFile, oldDataUTF8, newData), nil
+}
+
 func (as *ConfigService) SaveConfiguration(server *model.Server, config *model.Configuration) error {
 	configMap := make(map[string]interface{})
 	configBytes, err := json.Marshal(config)
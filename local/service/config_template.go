@@ -0,0 +1,274 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ConfigTemplateService manages reusable partial configurations that can be
+// pushed to many servers at once.
+type ConfigTemplateService struct {
+	repository       *repository.ConfigTemplateRepository
+	serverRepository *repository.ServerRepository
+	configService    *ConfigService
+}
+
+func NewConfigTemplateService(repository *repository.ConfigTemplateRepository, serverRepository *repository.ServerRepository, configService *ConfigService) *ConfigTemplateService {
+	logging.Debug("Initializing ConfigTemplateService")
+	return &ConfigTemplateService{
+		repository:       repository,
+		serverRepository: serverRepository,
+		configService:    configService,
+	}
+}
+
+func (s *ConfigTemplateService) GetAll(ctx context.Context, filter *model.ConfigTemplateFilter) (*model.FilteredResponse, error) {
+	templates, err := s.repository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: templates,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+func (s *ConfigTemplateService) GetByID(ctx context.Context, templateID uuid.UUID) (*model.ConfigTemplate, error) {
+	template, err := s.repository.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Config template not found")
+	}
+	return template, nil
+}
+
+func (s *ConfigTemplateService) Create(ctx context.Context, template *model.ConfigTemplate) (*model.ConfigTemplate, error) {
+	if err := validateConfigTemplate(template); err != nil {
+		return nil, err
+	}
+
+	template.ID = uuid.Nil
+	if err := s.repository.Insert(ctx, template); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return nil, fiber.NewError(fiber.StatusConflict, fmt.Sprintf("config template %q already exists", template.Name))
+		}
+		return nil, err
+	}
+
+	logging.Info("Created config template %s (%s)", template.Name, template.ID)
+	return template, nil
+}
+
+func (s *ConfigTemplateService) Update(ctx context.Context, templateID uuid.UUID, input *model.ConfigTemplate) (*model.ConfigTemplate, error) {
+	template, err := s.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateConfigTemplate(input); err != nil {
+		return nil, err
+	}
+
+	template.Name = input.Name
+	template.Description = input.Description
+	template.Content = input.Content
+	if err := s.repository.Update(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *ConfigTemplateService) Delete(ctx context.Context, templateID uuid.UUID) error {
+	if _, err := s.GetByID(ctx, templateID); err != nil {
+		return err
+	}
+	return s.repository.DeleteTemplate(ctx, templateID)
+}
+
+// Apply writes the template to each server. With override the sections
+// replace the server's files, otherwise they are merged into them like
+// UpdateConfig does. A failure on one server does not stop the others.
+func (s *ConfigTemplateService) Apply(ctx context.Context, templateID uuid.UUID, serverIDs []uuid.UUID, override bool) ([]model.ConfigTemplateApplyResult, error) {
+	template, err := s.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if len(serverIDs) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "at least one server ID is required")
+	}
+
+	actor := model.ActorFromContext(ctx)
+	results := make([]model.ConfigTemplateApplyResult, 0, len(serverIDs))
+	for _, serverID := range serverIDs {
+		result := model.ConfigTemplateApplyResult{ServerID: serverID}
+		if err := s.applyToServer(ctx, template, serverID, override); err != nil {
+			logging.Error("Failed to apply config template %s to server %s: %v", template.Name, serverID, err)
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		err := s.repository.SaveAssignment(ctx, &model.ConfigTemplateAssignment{
+			TemplateID: template.ID,
+			ServerID:   serverID,
+			Override:   override,
+			AppliedAt:  time.Now().UTC(),
+			AppliedBy:  actor.Username,
+		})
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+	}
+
+	logging.Info("Applied config template %s to %d server(s)", template.Name, len(serverIDs))
+	return results, nil
+}
+
+func (s *ConfigTemplateService) applyToServer(ctx context.Context, template *model.ConfigTemplate, serverID uuid.UUID, override bool) error {
+	server, err := s.serverRepository.GetByID(ctx, serverID)
+	if err != nil || server == nil {
+		return fmt.Errorf("server not found")
+	}
+
+	for _, section := range template.Content.Sections() {
+		configFile := configSectionFiles[section]
+		body := make(map[string]interface{}, len(template.Content[section]))
+		for key, value := range template.Content[section] {
+			body[key] = value
+		}
+
+		if !override {
+			current, err := s.configService.readConfigJSON(server, configFile)
+			if err != nil {
+				return fmt.Errorf("%s: %v", configFile, err)
+			}
+			matchConfigTypes(body, current)
+		}
+		if err := s.configService.ValidateConfig(ctx, server, configFile, body); err != nil {
+			return fmt.Errorf("%s: %v", configFile, err)
+		}
+
+		if _, err := s.configService.updateConfigInternal(ctx, serverID.String(), configFile, &body, override); err != nil {
+			return fmt.Errorf("%s: %v", configFile, err)
+		}
+	}
+	return nil
+}
+
+// matchConfigTypes rewrites scalar template values as strings where the
+// server's file stores that field as a string, since the merge refuses to
+// change a field's type.
+func matchConfigTypes(body, current map[string]interface{}) {
+	for key, value := range body {
+		if _, ok := current[key].(string); !ok {
+			continue
+		}
+		switch value.(type) {
+		case float64, bool:
+			body[key] = model.NormalizeConfigValue(value)
+		case int:
+			body[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// Drift compares every server the template was last applied to with the
+// template. Only the fields set in the template are compared.
+func (s *ConfigTemplateService) Drift(ctx context.Context, templateID uuid.UUID) ([]model.ConfigTemplateDrift, error) {
+	template, err := s.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.repository.GetAssignments(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]model.ConfigTemplateDrift, 0, len(assignments))
+	for _, assignment := range assignments {
+		drift := model.ConfigTemplateDrift{
+			ServerID:  assignment.ServerID,
+			AppliedAt: assignment.AppliedAt,
+			Override:  assignment.Override,
+		}
+
+		server, err := s.serverRepository.GetByID(ctx, assignment.ServerID)
+		if err != nil || server == nil {
+			drift.Error = "server not found"
+			report = append(report, drift)
+			continue
+		}
+		drift.ServerName = server.Name
+
+		changes, err := s.serverDrift(server, template.Content)
+		if err != nil {
+			drift.Error = err.Error()
+		} else if len(changes) > 0 {
+			drift.Drifted = true
+			drift.Changes = changes
+		}
+		report = append(report, drift)
+	}
+	return report, nil
+}
+
+func (s *ConfigTemplateService) serverDrift(server *model.Server, content model.ConfigTemplateContent) (map[string][]model.ConfigChange, error) {
+	changes := make(map[string][]model.ConfigChange)
+	for _, section := range content.Sections() {
+		configFile := configSectionFiles[section]
+		current, err := s.configService.readConfigJSON(server, configFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", configFile, err)
+		}
+
+		expected := content[section]
+		actual := make(map[string]interface{}, len(expected))
+		for key := range expected {
+			if value, ok := current[key]; ok {
+				actual[key] = value
+			}
+		}
+
+		diff := model.DiffConfigValues(
+			model.NormalizeConfigValue(map[string]interface{}(expected)),
+			model.NormalizeConfigValue(actual),
+		)
+		if len(diff) > 0 {
+			changes[configFile] = diff
+		}
+	}
+	return changes, nil
+}
+
+func validateConfigTemplate(template *model.ConfigTemplate) error {
+	if strings.TrimSpace(template.Name) == "" {
+		verr := &model.ValidationError{}
+		verr.Add("name", "is required")
+		return verr
+	}
+	return template.Content.Validate()
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var ErrEntryNotFound = fiber.NewError(fiber.StatusNotFound, "Entry not found")
+
+// EntryListService manages a server's entrylist.json. Entries have no ID of
+// their own in ACC, so they are addressed by their index in the list.
+type EntryListService struct {
+	configService    *ConfigService
+	serverRepository *repository.ServerRepository
+	lookupRepository *repository.LookupRepository
+}
+
+func NewEntryListService(configService *ConfigService, serverRepository *repository.ServerRepository, lookupRepository *repository.LookupRepository) *EntryListService {
+	logging.Debug("Initializing EntryListService")
+	return &EntryListService{
+		configService:    configService,
+		serverRepository: serverRepository,
+		lookupRepository: lookupRepository,
+	}
+}
+
+func (s *EntryListService) GetEntryList(ctx context.Context, serverID uuid.UUID) (*model.EntryList, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	return s.configService.GetEntryList(server)
+}
+
+func (s *EntryListService) ReplaceEntryList(ctx context.Context, serverID uuid.UUID, entryList *model.EntryList) (*model.EntryList, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if entryList.Entries == nil {
+		entryList.Entries = []model.Entry{}
+	}
+	return s.save(ctx, server, entryList)
+}
+
+func (s *EntryListService) AddEntry(ctx context.Context, serverID uuid.UUID, entry *model.Entry) (*model.EntryList, error) {
+	return s.modify(ctx, serverID, func(entryList *model.EntryList) error {
+		entryList.Entries = append(entryList.Entries, *entry)
+		return nil
+	})
+}
+
+func (s *EntryListService) UpdateEntry(ctx context.Context, serverID uuid.UUID, index int, entry *model.Entry) (*model.EntryList, error) {
+	return s.modify(ctx, serverID, func(entryList *model.EntryList) error {
+		if index < 0 || index >= len(entryList.Entries) {
+			return ErrEntryNotFound
+		}
+		entryList.Entries[index] = *entry
+		return nil
+	})
+}
+
+func (s *EntryListService) DeleteEntry(ctx context.Context, serverID uuid.UUID, index int) (*model.EntryList, error) {
+	return s.modify(ctx, serverID, func(entryList *model.EntryList) error {
+		if index < 0 || index >= len(entryList.Entries) {
+			return ErrEntryNotFound
+		}
+		entryList.Entries = append(entryList.Entries[:index], entryList.Entries[index+1:]...)
+		return nil
+	})
+}
+
+func (s *EntryListService) modify(ctx context.Context, serverID uuid.UUID, change func(*model.EntryList) error) (*model.EntryList, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	entryList, err := s.configService.GetEntryList(server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entry list: %v", err)
+	}
+
+	if err := change(entryList); err != nil {
+		return nil, err
+	}
+
+	return s.save(ctx, server, entryList)
+}
+
+func (s *EntryListService) save(ctx context.Context, server *model.Server, entryList *model.EntryList) (*model.EntryList, error) {
+	carModels, err := carModelValues(ctx, s.lookupRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := entryList.Validate(carModels); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.configService.SaveEntryList(ctx, server, entryList); err != nil {
+		logging.Error("Failed to save entry list for server %s: %v", server.ID, err)
+		return nil, fmt.Errorf("failed to save entry list: %v", err)
+	}
+
+	logging.Info("Saved entry list with %d entries for server %s", len(entryList.Entries), server.ID)
+	return entryList, nil
+}
+
+func (s *EntryListService) getServer(ctx context.Context, serverID uuid.UUID) (*model.Server, error) {
+	server, err := s.serverRepository.GetByID(ctx, serverID)
+	if err != nil || server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+	return server, nil
+}
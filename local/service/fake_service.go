@@ -0,0 +1,146 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"context"
+	"fmt"
+	"sync"
+)
+
+type fakeServiceEntry struct {
+	execPath   string
+	workingDir string
+	args       []string
+	status     model.ServiceStatus
+}
+
+// FakeServiceManager keeps services in memory. It backs SERVICE_BACKEND=fake
+// and the unit tests, and never touches the host.
+type FakeServiceManager struct {
+	mu       sync.Mutex
+	services map[string]*fakeServiceEntry
+	calls    []string
+	failOn   map[string]error
+}
+
+func NewFakeServiceManager() *FakeServiceManager {
+	return &FakeServiceManager{
+		services: make(map[string]*fakeServiceEntry),
+		failOn:   make(map[string]error),
+	}
+}
+
+// FailOn makes the given operation ("create", "start", ...) return err.
+func (s *FakeServiceManager) FailOn(operation string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failOn[operation] = err
+}
+
+// Calls returns the recorded operations as "operation:serviceName".
+func (s *FakeServiceManager) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+// SetStatus forces a service into the given state, e.g. to simulate a crash.
+func (s *FakeServiceManager) SetStatus(serviceName string, status model.ServiceStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.services[serviceName]; ok {
+		entry.status = status
+	}
+}
+
+func (s *FakeServiceManager) record(operation, serviceName string) error {
+	s.calls = append(s.calls, operation+":"+serviceName)
+	return s.failOn[operation]
+}
+
+func (s *FakeServiceManager) get(serviceName string) (*fakeServiceEntry, error) {
+	entry, ok := s.services[serviceName]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", serviceName)
+	}
+	return entry, nil
+}
+
+func (s *FakeServiceManager) CreateService(ctx context.Context, serviceName, execPath, workingDir string, args []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.record("create", serviceName); err != nil {
+		return err
+	}
+
+	s.services[serviceName] = &fakeServiceEntry{
+		execPath:   execPath,
+		workingDir: workingDir,
+		args:       args,
+		status:     model.StatusStopped,
+	}
+	return nil
+}
+
+func (s *FakeServiceManager) DeleteService(ctx context.Context, serviceName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.record("delete", serviceName); err != nil {
+		return err
+	}
+
+	if _, err := s.get(serviceName); err != nil {
+		return err
+	}
+	delete(s.services, serviceName)
+	return nil
+}
+
+func (s *FakeServiceManager) UpdateService(ctx context.Context, serviceName, execPath, workingDir string, args []string) error {
+	if err := s.DeleteService(ctx, serviceName); err != nil {
+		return err
+	}
+
+	return s.CreateService(ctx, serviceName, execPath, workingDir, args)
+}
+
+func (s *FakeServiceManager) Status(ctx context.Context, serviceName string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.record("status", serviceName); err != nil {
+		return "", err
+	}
+
+	entry, err := s.get(serviceName)
+	if err != nil {
+		return "", err
+	}
+	return entry.status.String(), nil
+}
+
+func (s *FakeServiceManager) setStatus(operation, serviceName string, status model.ServiceStatus) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.record(operation, serviceName); err != nil {
+		return "", err
+	}
+
+	entry, err := s.get(serviceName)
+	if err != nil {
+		return "", err
+	}
+	entry.status = status
+	return entry.status.String(), nil
+}
+
+func (s *FakeServiceManager) Start(ctx context.Context, serviceName string) (string, error) {
+	return s.setStatus("start", serviceName, model.StatusRunning)
+}
+
+func (s *FakeServiceManager) Stop(ctx context.Context, serviceName string) (string, error) {
+	return s.setStatus("stop", serviceName, model.StatusStopped)
+}
+
+func (s *FakeServiceManager) Restart(ctx context.Context, serviceName string) (string, error) {
+	return s.setStatus("restart", serviceName, model.StatusRunning)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/command"
+	"acc-server-manager/local/utl/env"
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var iptablesCommentRegex = regexp.MustCompile(`--comment "?([^"\s]+)"?`)
+
+// IptablesFirewall inserts its rules at the top of the INPUT chain, so that
+// they come before any DROP or REJECT the host appends to it.
+type IptablesFirewall struct {
+	executor *command.CommandExecutor
+}
+
+func NewIptablesFirewall() *IptablesFirewall {
+	return NewIptablesFirewallWithExecutor(&command.CommandExecutor{
+		ExePath:   "iptables",
+		LogOutput: true,
+	})
+}
+
+// NewIptablesFirewallWithExecutor returns an iptables firewall that runs
+// iptables through executor.
+func NewIptablesFirewallWithExecutor(executor *command.CommandExecutor) *IptablesFirewall {
+	return &IptablesFirewall{executor: executor}
+}
+
+func (f *IptablesFirewall) Name() string {
+	return env.FirewallBackendIptables
+}
+
+func (f *IptablesFirewall) Managed() bool {
+	return true
+}
+
+func (f *IptablesFirewall) ruleArgs(action string, rule model.FirewallRule) []string {
+	protocol := strings.ToLower(string(rule.Protocol))
+	return []string{
+		action, "INPUT",
+		"-p", protocol,
+		"--dport", fmt.Sprint(rule.Port),
+		"-m", "comment", "--comment", rule.Name,
+		"-j", "ACCEPT",
+	}
+}
+
+func (f *IptablesFirewall) CreateRule(rule model.FirewallRule) error {
+	// -C fails when the rule does not exist yet.
+	if err := f.executor.Execute(f.ruleArgs("-C", rule)...); err == nil {
+		return nil
+	}
+	return f.executor.Execute(f.ruleArgs("-I", rule)...)
+}
+
+func (f *IptablesFirewall) DeleteRule(rule model.FirewallRule) error {
+	return f.executor.Execute(f.ruleArgs("-D", rule)...)
+}
+
+func (f *IptablesFirewall) ListRules() ([]model.FirewallRule, error) {
+	output, err := f.executor.ExecuteWithOutput("-S", "INPUT")
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]model.FirewallRule, 0)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		match := iptablesCommentRegex.FindStringSubmatch(scanner.Text())
+		if len(match) != 2 {
+			continue
+		}
+		if rule, ok := model.ParseFirewallRuleName(match[1]); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/command"
+	"acc-server-manager/local/utl/env"
+	"bufio"
+	"strings"
+)
+
+type NetshFirewall struct {
+	executor *command.CommandExecutor
+}
+
+func NewNetshFirewall() *NetshFirewall {
+	return &NetshFirewall{
+		executor: &command.CommandExecutor{
+			ExePath:   "netsh",
+			LogOutput: true,
+		},
+	}
+}
+
+func (f *NetshFirewall) Name() string {
+	return env.FirewallBackendNetsh
+}
+
+func (f *NetshFirewall) Managed() bool {
+	return true
+}
+
+func (f *NetshFirewall) CreateRule(rule model.FirewallRule) error {
+	builder := command.NewCommandBuilder().
+		Add("advfirewall").
+		Add("firewall").
+		Add("add").
+		Add("rule").
+		AddFlag("name", rule.Name).
+		AddFlag("dir", "in").
+		AddFlag("action", "allow").
+		AddFlag("protocol", rule.Protocol).
+		AddFlag("localport", rule.Port)
+
+	return f.executor.ExecuteWithBuilder(builder)
+}
+
+func (f *NetshFirewall) DeleteRule(rule model.FirewallRule) error {
+	builder := command.NewCommandBuilder().
+		Add("advfirewall").
+		Add("firewall").
+		Add("delete").
+		Add("rule").
+		AddFlag("name", rule.Name)
+
+	return f.executor.ExecuteWithBuilder(builder)
+}
+
+func (f *NetshFirewall) ListRules() ([]model.FirewallRule, error) {
+	output, err := f.executor.ExecuteWithOutput("advfirewall", "firewall", "show", "rule", "name=all", "dir=in")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNetshRules(output), nil
+}
+
+// parseNetshRules picks the "Rule Name:" lines out of `netsh advfirewall
+// firewall show rule` and keeps the ones created by the manager.
+func parseNetshRules(output string) []model.FirewallRule {
+	rules := make([]model.FirewallRule, 0)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "Rule Name:") {
+			continue
+		}
+		name := strings.TrimSpace(strings.TrimPrefix(line, "Rule Name:"))
+		if rule, ok := model.ParseFirewallRuleName(name); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/command"
+	"acc-server-manager/local/utl/env"
+	"acc-server-manager/local/utl/logging"
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var nftablesRuleRegex = regexp.MustCompile(`comment "([^"]+)".*# handle (\d+)`)
+
+// NftablesFirewall inserts its rules at the top of an input chain the host
+// already filters with, so that they come before the host's own drops. An
+// accept in a separate table would not override a drop in another table's
+// chain. The chain is not created, as it belongs to the host.
+type NftablesFirewall struct {
+	executor *command.CommandExecutor
+	chain    []string
+}
+
+func NewNftablesFirewall() *NftablesFirewall {
+	return NewNftablesFirewallWithExecutor(&command.CommandExecutor{
+		ExePath:   "nft",
+		LogOutput: true,
+	}, env.GetNftablesChain())
+}
+
+// NewNftablesFirewallWithExecutor returns an nftables firewall that runs nft
+// through executor and keeps its rules in chain, given as
+// "family table chain".
+func NewNftablesFirewallWithExecutor(executor *command.CommandExecutor, chain string) *NftablesFirewall {
+	fields := strings.Fields(chain)
+	if len(fields) != 3 {
+		logging.Warn("Invalid nftables chain %q, using %q", chain, env.DefaultNftablesChain)
+		fields = strings.Fields(env.DefaultNftablesChain)
+	}
+	return &NftablesFirewall{
+		executor: executor,
+		chain:    fields,
+	}
+}
+
+func (f *NftablesFirewall) Name() string {
+	return env.FirewallBackendNftables
+}
+
+func (f *NftablesFirewall) Managed() bool {
+	return true
+}
+
+func (f *NftablesFirewall) chainArgs(args ...string) []string {
+	return append(append([]string{}, f.chain...), args...)
+}
+
+func (f *NftablesFirewall) CreateRule(rule model.FirewallRule) error {
+	handles, err := f.listHandles()
+	if err != nil {
+		return err
+	}
+	if _, ok := handles[rule.Name]; ok {
+		return nil
+	}
+
+	args := append([]string{"insert", "rule"}, f.chainArgs(
+		strings.ToLower(string(rule.Protocol)), "dport", fmt.Sprint(rule.Port),
+		"accept", "comment", fmt.Sprintf("%q", rule.Name))...)
+	return f.executor.Execute(args...)
+}
+
+func (f *NftablesFirewall) DeleteRule(rule model.FirewallRule) error {
+	handles, err := f.listHandles()
+	if err != nil {
+		return err
+	}
+
+	handle, ok := handles[rule.Name]
+	if !ok {
+		return nil
+	}
+
+	args := append([]string{"delete", "rule"}, f.chainArgs("handle", handle)...)
+	return f.executor.Execute(args...)
+}
+
+func (f *NftablesFirewall) ListRules() ([]model.FirewallRule, error) {
+	handles, err := f.listHandles()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]model.FirewallRule, 0, len(handles))
+	for name := range handles {
+		if rule, ok := model.ParseFirewallRuleName(name); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (f *NftablesFirewall) listHandles() (map[string]string, error) {
+	args := append([]string{"-a", "list", "chain"}, f.chainArgs()...)
+	output, err := f.executor.ExecuteWithOutput(args...)
+	if err != nil {
+		if strings.Contains(output, "No such file or directory") {
+			return nil, fmt.Errorf("nftables chain %s does not exist, set NFTABLES_CHAIN to the input chain of the host", strings.Join(f.chain, " "))
+		}
+		return nil, err
+	}
+
+	return parseNftablesHandles(output), nil
+}
+
+func parseNftablesHandles(output string) map[string]string {
+	handles := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		match := nftablesRuleRegex.FindStringSubmatch(scanner.Text())
+		if len(match) == 3 {
+			handles[match[1]] = match[2]
+		}
+	}
+	return handles
+}
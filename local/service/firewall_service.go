@@ -1,95 +1,86 @@
 package service
 
 import (
-	"acc-server-manager/local/utl/command"
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/env"
 	"acc-server-manager/local/utl/logging"
 	"fmt"
 )
 
+// FirewallProvider manages inbound allow rules on one firewall backend.
+type FirewallProvider interface {
+	Name() string
+	// Managed is false when rules are handled outside the manager.
+	Managed() bool
+	CreateRule(rule model.FirewallRule) error
+	DeleteRule(rule model.FirewallRule) error
+	// ListRules returns every rule created by the manager on this backend.
+	ListRules() ([]model.FirewallRule, error)
+}
+
 type FirewallService struct {
-	executor *command.CommandExecutor
+	provider FirewallProvider
 }
 
 func NewFirewallService() *FirewallService {
-	return &FirewallService{
-		executor: &command.CommandExecutor{
-			ExePath:   "netsh",
-			LogOutput: true,
-		},
+	return NewFirewallServiceWithProvider(newFirewallProvider(env.GetFirewallBackend()))
+}
+
+func NewFirewallServiceWithProvider(provider FirewallProvider) *FirewallService {
+	logging.Info("Using %s firewall backend", provider.Name())
+	return &FirewallService{provider: provider}
+}
+
+func newFirewallProvider(backend string) FirewallProvider {
+	switch backend {
+	case env.FirewallBackendNetsh:
+		return NewNetshFirewall()
+	case env.FirewallBackendNftables:
+		return NewNftablesFirewall()
+	case env.FirewallBackendIptables:
+		return NewIptablesFirewall()
+	case env.FirewallBackendUfw:
+		return NewUfwFirewall()
+	case env.FirewallBackendNone:
+		return NewNoopFirewall()
+	default:
+		logging.Warn("Unknown firewall backend %q, firewall rules are left to the host", backend)
+		return NewNoopFirewall()
 	}
 }
 
-func (s *FirewallService) CreateServerRules(serverName string, tcpPorts, udpPorts []int) error {
+func serverRules(serverName string, tcpPorts, udpPorts []int) []model.FirewallRule {
+	rules := make([]model.FirewallRule, 0, len(tcpPorts)+len(udpPorts))
 	for _, port := range tcpPorts {
-		ruleName := fmt.Sprintf("\"%s-TCP-%d\"", serverName, port)
-		builder := command.NewCommandBuilder().
-			Add("advfirewall").
-			Add("firewall").
-			Add("add").
-			Add("rule").
-			AddFlag("name", ruleName).
-			AddFlag("dir", "in").
-			AddFlag("action", "allow").
-			AddFlag("protocol", "TCP").
-			AddFlag("localport", port)
-
-		if err := s.executor.ExecuteWithBuilder(builder); err != nil {
-			return fmt.Errorf("failed to create TCP firewall rule for port %d: %v", port, err)
-		}
-		logging.Info("Created TCP firewall rule: %s", ruleName)
+		rules = append(rules, model.NewFirewallRule(serverName, model.FirewallTCP, port))
 	}
-
 	for _, port := range udpPorts {
-		ruleName := fmt.Sprintf("%s-UDP-%d", serverName, port)
-		builder := command.NewCommandBuilder().
-			Add("advfirewall").
-			Add("firewall").
-			Add("add").
-			Add("rule").
-			AddFlag("name", ruleName).
-			AddFlag("dir", "in").
-			AddFlag("action", "allow").
-			AddFlag("protocol", "UDP").
-			AddFlag("localport", port)
-
-		if err := s.executor.ExecuteWithBuilder(builder); err != nil {
-			return fmt.Errorf("failed to create UDP firewall rule for port %d: %v", port, err)
-		}
-		logging.Info("Created UDP firewall rule: %s", ruleName)
+		rules = append(rules, model.NewFirewallRule(serverName, model.FirewallUDP, port))
 	}
+	return rules
+}
 
-	return nil
+func (s *FirewallService) Backend() string {
+	return s.provider.Name()
 }
 
-func (s *FirewallService) DeleteServerRules(serverName string, tcpPorts, udpPorts []int) error {
-	for _, port := range tcpPorts {
-		ruleName := fmt.Sprintf("\"%s-TCP-%d\"", serverName, port)
-		builder := command.NewCommandBuilder().
-			Add("advfirewall").
-			Add("firewall").
-			Add("delete").
-			Add("rule").
-			AddFlag("name", ruleName)
-
-		if err := s.executor.ExecuteWithBuilder(builder); err != nil {
-			return fmt.Errorf("failed to delete TCP firewall rule for port %d: %v", port, err)
+func (s *FirewallService) CreateServerRules(serverName string, tcpPorts, udpPorts []int) error {
+	for _, rule := range serverRules(serverName, tcpPorts, udpPorts) {
+		if err := s.provider.CreateRule(rule); err != nil {
+			return fmt.Errorf("failed to create %s firewall rule for port %d: %v", rule.Protocol, rule.Port, err)
 		}
-		logging.Info("Deleted TCP firewall rule: %s", ruleName)
+		logging.Info("Created %s firewall rule: %s", rule.Protocol, rule.Name)
 	}
 
-	for _, port := range udpPorts {
-		ruleName := fmt.Sprintf("\"%s-UDP-%d\"", serverName, port)
-		builder := command.NewCommandBuilder().
-			Add("advfirewall").
-			Add("firewall").
-			Add("delete").
-			Add("rule").
-			AddFlag("name", ruleName)
-
-		if err := s.executor.ExecuteWithBuilder(builder); err != nil {
-			return fmt.Errorf("failed to delete UDP firewall rule for port %d: %v", port, err)
+	return nil
+}
+
+func (s *FirewallService) DeleteServerRules(serverName string, tcpPorts, udpPorts []int) error {
+	for _, rule := range serverRules(serverName, tcpPorts, udpPorts) {
+		if err := s.provider.DeleteRule(rule); err != nil {
+			return fmt.Errorf("failed to delete %s firewall rule for port %d: %v", rule.Protocol, rule.Port, err)
 		}
-		logging.Info("Deleted UDP firewall rule: %s", ruleName)
+		logging.Info("Deleted %s firewall rule: %s", rule.Protocol, rule.Name)
 	}
 
 	return nil
@@ -102,3 +93,104 @@ func (s *FirewallService) UpdateServerRules(serverName string, tcpPorts, udpPort
 
 	return s.CreateServerRules(serverName, tcpPorts, udpPorts)
 }
+
+// ListServerRules returns the rules that currently exist for a server.
+func (s *FirewallService) ListServerRules(serverName string) ([]model.FirewallRule, error) {
+	rules, err := s.provider.ListRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list firewall rules: %v", err)
+	}
+
+	result := make([]model.FirewallRule, 0)
+	for _, rule := range rules {
+		if rule.BelongsTo(serverName) {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
+// ReconcileServerRules creates missing rules for the given ports and removes
+// rules left over from ports the server no longer uses.
+func (s *FirewallService) ReconcileServerRules(serverName string, tcpPorts, udpPorts []int) (*model.FirewallReconcileResult, error) {
+	expected := serverRules(serverName, tcpPorts, udpPorts)
+	result := &model.FirewallReconcileResult{
+		Backend:  s.provider.Name(),
+		Managed:  s.provider.Managed(),
+		Expected: expected,
+		Existing: []model.FirewallRule{},
+		Created:  []model.FirewallRule{},
+		Removed:  []model.FirewallRule{},
+	}
+
+	if !s.provider.Managed() {
+		return result, nil
+	}
+
+	existing, err := s.ListServerRules(serverName)
+	if err != nil {
+		return nil, err
+	}
+	result.Existing = existing
+
+	existingNames := make(map[string]bool, len(existing))
+	for _, rule := range existing {
+		existingNames[rule.Name] = true
+	}
+	expectedNames := make(map[string]bool, len(expected))
+	for _, rule := range expected {
+		expectedNames[rule.Name] = true
+	}
+
+	for _, rule := range expected {
+		if existingNames[rule.Name] {
+			continue
+		}
+		if err := s.provider.CreateRule(rule); err != nil {
+			return result, fmt.Errorf("failed to create firewall rule %s: %v", rule.Name, err)
+		}
+		result.Created = append(result.Created, rule)
+	}
+
+	for _, rule := range existing {
+		if expectedNames[rule.Name] {
+			continue
+		}
+		if err := s.provider.DeleteRule(rule); err != nil {
+			return result, fmt.Errorf("failed to delete firewall rule %s: %v", rule.Name, err)
+		}
+		result.Removed = append(result.Removed, rule)
+	}
+
+	logging.Info("Reconciled firewall rules for %s: %d created, %d removed", serverName, len(result.Created), len(result.Removed))
+	return result, nil
+}
+
+// NoopFirewall is used when the firewall is managed externally.
+type NoopFirewall struct{}
+
+func NewNoopFirewall() *NoopFirewall {
+	return &NoopFirewall{}
+}
+
+func (f *NoopFirewall) Name() string {
+	return env.FirewallBackendNone
+}
+
+func (f *NoopFirewall) Managed() bool {
+	return false
+}
+
+func (f *NoopFirewall) CreateRule(rule model.FirewallRule) error {
+	logging.Debug("Firewall managed externally, skipping creation of %s", rule.Name)
+	return nil
+}
+
+func (f *NoopFirewall) DeleteRule(rule model.FirewallRule) error {
+	logging.Debug("Firewall managed externally, skipping deletion of %s", rule.Name)
+	return nil
+}
+
+func (f *NoopFirewall) ListRules() ([]model.FirewallRule, error) {
+	return []model.FirewallRule{}, nil
+}
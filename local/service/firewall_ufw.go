@@ -0,0 +1,64 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/command"
+	"acc-server-manager/local/utl/env"
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+type UfwFirewall struct {
+	executor *command.CommandExecutor
+}
+
+func NewUfwFirewall() *UfwFirewall {
+	return &UfwFirewall{
+		executor: &command.CommandExecutor{
+			ExePath:   "ufw",
+			LogOutput: true,
+		},
+	}
+}
+
+func (f *UfwFirewall) Name() string {
+	return env.FirewallBackendUfw
+}
+
+func (f *UfwFirewall) Managed() bool {
+	return true
+}
+
+func (f *UfwFirewall) portSpec(rule model.FirewallRule) string {
+	return fmt.Sprintf("%d/%s", rule.Port, strings.ToLower(string(rule.Protocol)))
+}
+
+func (f *UfwFirewall) CreateRule(rule model.FirewallRule) error {
+	return f.executor.Execute("allow", f.portSpec(rule), "comment", rule.Name)
+}
+
+func (f *UfwFirewall) DeleteRule(rule model.FirewallRule) error {
+	return f.executor.Execute("--force", "delete", "allow", f.portSpec(rule))
+}
+
+func (f *UfwFirewall) ListRules() ([]model.FirewallRule, error) {
+	output, err := f.executor.ExecuteWithOutput("status")
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]model.FirewallRule, 0)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		idx := strings.LastIndex(line, "# ")
+		if idx < 0 {
+			continue
+		}
+		if rule, ok := model.ParseFirewallRuleName(strings.TrimSpace(line[idx+2:])); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/graceful"
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	jobWorkers         = 2
+	jobWorkerInterval  = 30 * time.Second
+	jobLogSaveInterval = time.Second
+)
+
+// JobHandler runs the jobs of one type.
+type JobHandler struct {
+	// Run does the work of a job. An error wrapping context.Canceled marks
+	// the job cancelled rather than failed.
+	Run func(ctx context.Context, run *JobRun) error
+	// Recover cleans up after a job that was running when the manager
+	// stopped. The job comes in failed; Recover sets it to rolled back when
+	// it undid what the job left half done, or to succeeded when the job
+	// turns out to have finished.
+	Recover func(ctx context.Context, job *model.Job) error
+	// Cancelled is told about a queued job that was cancelled before it ran.
+	Cancelled func(ctx context.Context, job *model.Job)
+	// Exclusive runs the jobs of the type one at a time.
+	Exclusive bool
+}
+
+// JobService runs long operations on servers as persisted jobs, on a small
+// pool of workers. Handlers that use SteamCMD hold LockSteamCMD while they
+// do, as SteamCMD cannot run twice at once.
+type JobService struct {
+	repository *repository.JobRepository
+	handlers   map[model.JobType]JobHandler
+	steamCMD   chan struct{}
+	wake       chan struct{}
+
+	mu   sync.Mutex
+	runs map[uuid.UUID]*JobRun
+}
+
+func NewJobService(repository *repository.JobRepository) *JobService {
+	logging.Debug("Initializing JobService")
+	return &JobService{
+		repository: repository,
+		handlers:   make(map[model.JobType]JobHandler),
+		steamCMD:   make(chan struct{}, 1),
+		wake:       make(chan struct{}, jobWorkers),
+		runs:       make(map[uuid.UUID]*JobRun),
+	}
+}
+
+// RegisterHandler sets the handler that runs the jobs of a type.
+func (s *JobService) RegisterHandler(jobType model.JobType, handler JobHandler) {
+	s.handlers[jobType] = handler
+}
+
+// LockSteamCMD waits until no other job uses SteamCMD and returns the
+// function that releases it. A nil JobService does not lock anything.
+func (s *JobService) LockSteamCMD(ctx context.Context) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	select {
+	case s.steamCMD <- struct{}{}:
+		return func() { <-s.steamCMD }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Start settles the jobs a previous run left running and runs queued jobs
+// until the application shuts down.
+func (s *JobService) Start() {
+	s.Reconcile(context.Background())
+
+	for i := 0; i < jobWorkers; i++ {
+		graceful.GetManager().RunGoroutine(func(ctx context.Context) {
+			ticker := time.NewTicker(jobWorkerInterval)
+			defer ticker.Stop()
+
+			for {
+				for ctx.Err() == nil && s.RunNext(ctx) {
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-s.wake:
+				case <-ticker.C:
+				}
+			}
+		})
+	}
+}
+
+// Reconcile settles the jobs that were running when the manager stopped.
+// Their handler gets to undo what they left half done; queued jobs are left
+// to run as usual.
+func (s *JobService) Reconcile(ctx context.Context) {
+	jobs, err := s.repository.GetRunning(ctx)
+	if err != nil {
+		logging.Error("Failed to get interrupted jobs: %v", err)
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		finishedAt := time.Now().UTC()
+		job.Status = model.JobFailed
+		job.Error = "manager restarted during the job"
+		job.FinishedAt = &finishedAt
+		if handler, ok := s.handlers[job.Type]; ok && handler.Recover != nil {
+			if err := handler.Recover(ctx, job); err != nil {
+				job.Status = model.JobFailed
+				job.Error = fmt.Sprintf("%s; rollback failed: %v", job.Error, err)
+			}
+		}
+		if err := s.repository.Update(ctx, job); err != nil {
+			logging.Error("Failed to save job %s: %v", job.ID, err)
+		}
+		logging.Info("Interrupted %s job %s of server %s: %s", job.Type, job.ID, job.ServerID, job.Status)
+	}
+}
+
+// RunNext runs the oldest queued job and reports whether there was one.
+// Jobs do not stop when ctx is done, so that a shutdown does not leave them
+// half done; a job cut short by the process exiting is settled by
+// Reconcile on the next start.
+func (s *JobService) RunNext(ctx context.Context) bool {
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	var exclusive []model.JobType
+	for jobType, handler := range s.handlers {
+		if handler.Exclusive {
+			exclusive = append(exclusive, jobType)
+		}
+	}
+
+	s.mu.Lock()
+	job, err := s.repository.ClaimNext(ctx, time.Now().UTC(), exclusive)
+	if err != nil || job == nil {
+		s.mu.Unlock()
+		if err != nil {
+			logging.Error("Failed to get queued job: %v", err)
+		}
+		return false
+	}
+	run := &JobRun{Job: job, service: s, cancel: cancel}
+	s.runs[job.ID] = run
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.runs, job.ID)
+		s.mu.Unlock()
+	}()
+
+	logging.Info("Running %s job %s of server %s", job.Type, job.ID, job.ServerID)
+	if handler, ok := s.handlers[job.Type]; ok {
+		err = handler.Run(jobCtx, run)
+	} else {
+		err = fmt.Errorf("no handler for %s jobs", job.Type)
+	}
+	run.finish(err)
+	return true
+}
+
+// Enqueue queues a job to be run by the next free worker.
+func (s *JobService) Enqueue(ctx context.Context, job *model.Job) error {
+	job.Status = model.JobQueued
+	if err := s.repository.Insert(ctx, job); err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// HasUnfinished reports whether a server has a job of one of the given types
+// that is queued or running.
+func (s *JobService) HasUnfinished(ctx context.Context, serverID uuid.UUID, types ...model.JobType) (bool, error) {
+	return s.repository.HasUnfinished(ctx, serverID, types...)
+}
+
+func (s *JobService) GetAll(ctx context.Context, filter *model.JobFilter) (*model.FilteredResponse, error) {
+	jobs, err := s.repository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: jobs,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+func (s *JobService) GetByID(ctx context.Context, jobID uuid.UUID) (*model.Job, error) {
+	job, err := s.repository.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Job not found")
+	}
+	return job, nil
+}
+
+// Cancel cancels a queued job, or asks a running one to stop. A running job
+// stops at the next point where it is safe to, undoing what its handler
+// needs undone, and is then marked cancelled. A job whose handler called
+// DisableCancel is refused with a conflict.
+func (s *JobService) Cancel(ctx context.Context, jobID uuid.UUID) (*model.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case model.JobQueued:
+		finishedAt := time.Now().UTC()
+		job.Status = model.JobCancelled
+		job.Error = "cancelled"
+		job.FinishedAt = &finishedAt
+		if err := s.repository.Update(ctx, job); err != nil {
+			return nil, err
+		}
+		if handler, ok := s.handlers[job.Type]; ok && handler.Cancelled != nil {
+			handler.Cancelled(ctx, job)
+		}
+		return job, nil
+	case model.JobRunning:
+		run, ok := s.runs[job.ID]
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusConflict, "job is not running in this manager")
+		}
+		if run.cancelDisabled {
+			return nil, fiber.NewError(fiber.StatusConflict, "job can no longer be cancelled")
+		}
+		run.cancel()
+		return job, nil
+	default:
+		return nil, fiber.NewError(fiber.StatusConflict, "job has already finished")
+	}
+}
+
+// JobRun is a job being run, through which its handler reports how far it
+// got. The methods of a nil JobRun do nothing.
+type JobRun struct {
+	Job *model.Job
+
+	service *JobService
+	mu      sync.Mutex
+	savedAt time.Time
+
+	// cancel and cancelDisabled are guarded by service.mu.
+	cancel         context.CancelFunc
+	cancelDisabled bool
+}
+
+// DisableCancel tells the service that the job has reached a point it must
+// run to the end from, so that cancelling it is refused rather than ignored.
+// It returns the error of ctx when the job was cancelled before.
+func (r *JobRun) DisableCancel(ctx context.Context) error {
+	if r == nil {
+		return ctx.Err()
+	}
+	r.service.mu.Lock()
+	defer r.service.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.cancelDisabled = true
+	return nil
+}
+
+// Step records the step the job is at and its progress in percent.
+func (r *JobRun) Step(step string, progress int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Job.Step = step
+	r.Job.Progress = progress
+	r.save()
+}
+
+// Log adds a line to the output of the job. The output is saved at most
+// once a second.
+func (r *JobRun) Log(line string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Job.Logs = append(r.Job.Logs, line)
+	if excess := len(r.Job.Logs) - model.MaxJobLogLines; excess > 0 {
+		r.Job.Logs = append(model.JobLogs{}, r.Job.Logs[excess:]...)
+	}
+	if time.Since(r.savedAt) >= jobLogSaveInterval {
+		r.save()
+	}
+}
+
+// SetResult records what the job produced, such as the path of a backup.
+func (r *JobRun) SetResult(result string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Job.Result = result
+	r.save()
+}
+
+// save writes the job to the database. r.mu must be held.
+func (r *JobRun) save() {
+	r.savedAt = time.Now()
+	if err := r.service.repository.Update(context.Background(), r.Job); err != nil {
+		logging.Error("Failed to save job %s: %v", r.Job.ID, err)
+	}
+}
+
+func (r *JobRun) finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := r.Job
+	finishedAt := time.Now().UTC()
+	job.FinishedAt = &finishedAt
+	switch {
+	case err == nil:
+		job.Status = model.JobSucceeded
+		job.Progress = 100
+	case errors.Is(err, context.Canceled):
+		job.Status = model.JobCancelled
+		job.Error = err.Error()
+	default:
+		job.Status = model.JobFailed
+		job.Error = err.Error()
+	}
+	r.save()
+	logging.Info("Job %s of server %s: %s", job.ID, job.ServerID, job.Status)
+}
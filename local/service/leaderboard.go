@@ -5,16 +5,24 @@ import (
 	"acc-server-manager/local/repository"
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
+// ScoreDNS is the score of a car that did not complete a lap.
+const ScoreDNS model.Score = "DNS"
+
 type LeaderboardService struct {
-	repo *repository.LeaderboardRepository
+	repo       *repository.LeaderboardRepository
+	resultRepo *repository.ResultRepository
 }
 
-func NewLeaderboardService(repo *repository.LeaderboardRepository) *LeaderboardService {
-	return &LeaderboardService{repo: repo}
+func NewLeaderboardService(repo *repository.LeaderboardRepository, resultRepo *repository.ResultRepository) *LeaderboardService {
+	return &LeaderboardService{repo: repo, resultRepo: resultRepo}
 }
 
 // Get returns the leaderboard for a server.
@@ -22,16 +30,367 @@ func (s *LeaderboardService) Get(ctx context.Context, serverID uuid.UUID) (*mode
 	return s.repo.GetOrCreateByServerID(ctx, serverID)
 }
 
-// Update replaces the leaderboard for a server.
+// Update replaces the default leaderboard for a server. Its name, season and
+// archived flag are kept.
 func (s *LeaderboardService) Update(ctx context.Context, serverID uuid.UUID, lb *model.Leaderboard) (*model.Leaderboard, error) {
 	if lb == nil {
 		return nil, fmt.Errorf("input is required")
 	}
+	existing, err := s.repo.GetOrCreateByServerID(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	lb.Name = existing.Name
+	lb.Season = existing.Season
+	lb.Archived = existing.Archived
+	setLeaderboardPositions(lb)
+	return s.repo.FullReplace(ctx, serverID, lb)
+}
+
+// List returns the championships of a server, oldest first.
+func (s *LeaderboardService) List(ctx context.Context, serverID uuid.UUID) ([]model.LeaderboardSummary, error) {
+	leaderboards, err := s.repo.ListByServerID(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]model.LeaderboardSummary, 0, len(leaderboards))
+	for _, lb := range leaderboards {
+		summaries = append(summaries, model.LeaderboardSummary{
+			ID:        lb.ID,
+			ServerID:  lb.ServerID,
+			Name:      lb.Name,
+			Season:    lb.Season,
+			Archived:  lb.Archived,
+			IsDefault: lb.IsDefault,
+			CreatedAt: lb.CreatedAt,
+		})
+	}
+	return summaries, nil
+}
+
+// GetChampionship returns one championship of a server.
+func (s *LeaderboardService) GetChampionship(ctx context.Context, serverID, championshipID uuid.UUID) (*model.Leaderboard, error) {
+	return s.resolve(ctx, serverID, championshipID)
+}
+
+// CreateChampionship adds a championship to a server. The first championship
+// of a server becomes its default.
+func (s *LeaderboardService) CreateChampionship(ctx context.Context, serverID uuid.UUID, lb *model.Leaderboard) (*model.Leaderboard, error) {
+	if err := s.validateChampionship(ctx, serverID, uuid.Nil, lb); err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.ListByServerID(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	makeDefault := lb.IsDefault || len(existing) == 0
+	lb.ID = uuid.Nil
+	lb.ServerID = serverID
+	lb.IsDefault = len(existing) == 0
+	setLeaderboardPositions(lb)
+	if err := s.repo.Create(ctx, lb); err != nil {
+		return nil, err
+	}
+	if makeDefault && !lb.IsDefault {
+		if err := s.repo.SetDefault(ctx, serverID, lb.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.resolve(ctx, serverID, lb.ID)
+}
+
+// UpdateChampionship replaces a championship of a server.
+func (s *LeaderboardService) UpdateChampionship(ctx context.Context, serverID, championshipID uuid.UUID, lb *model.Leaderboard) (*model.Leaderboard, error) {
+	existing, err := s.resolve(ctx, serverID, championshipID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateChampionship(ctx, serverID, championshipID, lb); err != nil {
+		return nil, err
+	}
+
+	setLeaderboardPositions(lb)
+	if err := s.repo.Replace(ctx, existing.ID, lb); err != nil {
+		return nil, err
+	}
+	if lb.IsDefault && !existing.IsDefault {
+		if err := s.repo.SetDefault(ctx, serverID, existing.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.resolve(ctx, serverID, existing.ID)
+}
+
+// DeleteChampionship removes a championship. When it was the default, the
+// oldest remaining championship takes its place.
+func (s *LeaderboardService) DeleteChampionship(ctx context.Context, serverID, championshipID uuid.UUID) error {
+	existing, err := s.resolve(ctx, serverID, championshipID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, existing.ID); err != nil {
+		return err
+	}
+	if !existing.IsDefault {
+		return nil
+	}
+
+	remaining, err := s.repo.ListByServerID(ctx, serverID)
+	if err != nil || len(remaining) == 0 {
+		return err
+	}
+	return s.repo.SetDefault(ctx, serverID, remaining[0].ID)
+}
+
+// SetDefaultChampionship makes a championship the one the public leaderboard
+// route serves.
+func (s *LeaderboardService) SetDefaultChampionship(ctx context.Context, serverID, championshipID uuid.UUID) (*model.Leaderboard, error) {
+	existing, err := s.resolve(ctx, serverID, championshipID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetDefault(ctx, serverID, existing.ID); err != nil {
+		return nil, err
+	}
+	return s.resolve(ctx, serverID, existing.ID)
+}
+
+// resolve returns a championship of a server, or its default championship
+// when championshipID is uuid.Nil.
+func (s *LeaderboardService) resolve(ctx context.Context, serverID, championshipID uuid.UUID) (*model.Leaderboard, error) {
+	if championshipID == uuid.Nil {
+		return s.repo.GetOrCreateByServerID(ctx, serverID)
+	}
+	lb, err := s.repo.GetByID(ctx, serverID, championshipID)
+	if err != nil {
+		return nil, err
+	}
+	if lb == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Championship not found")
+	}
+	return lb, nil
+}
+
+func (s *LeaderboardService) validateChampionship(ctx context.Context, serverID, championshipID uuid.UUID, lb *model.Leaderboard) error {
+	if lb == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "input is required")
+	}
+	lb.Name = strings.TrimSpace(lb.Name)
+	if lb.Name == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "name is required")
+	}
+
+	existing, err := s.repo.ListByServerID(ctx, serverID)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.ID != championshipID && strings.EqualFold(other.Name, lb.Name) {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("championship %q already exists", lb.Name))
+		}
+	}
+	return nil
+}
+
+func setLeaderboardPositions(lb *model.Leaderboard) {
 	for i := range lb.Drivers {
 		lb.Drivers[i].Position = i
 	}
 	for i := range lb.Races {
 		lb.Races[i].Position = i
 	}
-	return s.repo.FullReplace(ctx, serverID, lb)
+}
+
+// GetStandings computes the standings of a championship, or of the server's
+// default championship when championshipID is uuid.Nil.
+func (s *LeaderboardService) GetStandings(ctx context.Context, serverID, championshipID uuid.UUID) (*model.LeaderboardStandings, error) {
+	lb, err := s.resolve(ctx, serverID, championshipID)
+	if err != nil {
+		return nil, err
+	}
+	return computeStandings(lb), nil
+}
+
+// AddRace appends an ingested race result to a championship, or to the
+// server's default championship when championshipID is uuid.Nil.
+// Cars score by finishing position from the points table, drivers that are not
+// on the leaderboard yet are added, and the fastest lap is marked on the race.
+func (s *LeaderboardService) AddRace(ctx context.Context, serverID, championshipID uuid.UUID, input *model.LeaderboardRaceImport) (*model.Leaderboard, error) {
+	if input == nil || input.ResultID == uuid.Nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "resultId is required")
+	}
+
+	result, err := s.resultRepo.GetResult(ctx, serverID, input.ResultID)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Result not found")
+	}
+	if result.SessionType != model.SessionRace {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "only race results can be added to the leaderboard")
+	}
+
+	lb, err := s.resolve(ctx, serverID, championshipID)
+	if err != nil {
+		return nil, err
+	}
+	for _, race := range lb.Races {
+		if race.ResultSessionID != nil && *race.ResultSessionID == result.ID {
+			return nil, fiber.NewError(fiber.StatusConflict, "result is already on the leaderboard")
+		}
+	}
+
+	name := input.Name
+	if name == "" {
+		name = result.Track
+	}
+	race, drivers := buildLeaderboardRace(lb, result, name)
+	if err := s.repo.AppendRace(ctx, lb.ID, drivers, race); err != nil {
+		return nil, err
+	}
+	return s.resolve(ctx, serverID, lb.ID)
+}
+
+// buildLeaderboardRace maps the finishing order of result onto the drivers of
+// lb. It returns the race and the drivers that have to be added for it.
+func buildLeaderboardRace(lb *model.Leaderboard, result *model.ResultSession, name string) (*model.LeaderboardRace, []model.LeaderboardDriver) {
+	race := &model.LeaderboardRace{
+		Name:            name,
+		Position:        len(lb.Races),
+		ResultSessionID: &result.ID,
+	}
+
+	points := pointsByPosition(lb.PointRows)
+	known := append([]model.LeaderboardDriver(nil), lb.Drivers...)
+	var added []model.LeaderboardDriver
+	driverIDs := make(map[int][]uuid.UUID)
+
+	classified := 0
+	for _, car := range result.Cars {
+		score := ScoreDNS
+		position := 0
+		if car.LapCount > 0 {
+			classified++
+			position = classified
+			score = model.Score("0")
+			if classified <= len(points) {
+				score = model.Score(strconv.Itoa(points[classified-1]))
+			}
+		}
+
+		for _, driver := range car.Drivers {
+			leaderboardDriver := matchLeaderboardDriver(known, driver)
+			if leaderboardDriver == nil {
+				known = append(known, newLeaderboardDriver(driver, len(known)))
+				leaderboardDriver = &known[len(known)-1]
+				added = append(added, *leaderboardDriver)
+			}
+			driverIDs[car.CarID] = append(driverIDs[car.CarID], leaderboardDriver.ID)
+			race.Results = append(race.Results, model.LeaderboardResult{
+				DriverID: leaderboardDriver.ID,
+				Score:    score,
+				Position: position,
+			})
+		}
+	}
+
+	if carID, driverIndex, ok := fastestLap(result); ok && driverIndex < len(driverIDs[carID]) {
+		race.FastestLapDriverID = &driverIDs[carID][driverIndex]
+	}
+	return race, added
+}
+
+// pointsByPosition returns the points of each finishing position.
+func pointsByPosition(rows []model.LeaderboardPointRow) []int {
+	sorted := sortedPointRows(rows)
+	points := make([]int, len(sorted))
+	for i, row := range sorted {
+		points[i] = row.Points
+	}
+	return points
+}
+
+// sortedPointRows orders the points table by priority and then by points, so
+// the first row scores the winner.
+func sortedPointRows(rows []model.LeaderboardPointRow) []model.LeaderboardPointRow {
+	sorted := append([]model.LeaderboardPointRow(nil), rows...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority < sorted[j].Priority
+		}
+		return sorted[i].Points > sorted[j].Points
+	})
+	return sorted
+}
+
+// matchLeaderboardDriver finds the leaderboard driver with the Steam ID of
+// driver, or else with the same name.
+func matchLeaderboardDriver(drivers []model.LeaderboardDriver, driver model.ResultDriver) *model.LeaderboardDriver {
+	if driver.PlayerID != "" {
+		for i := range drivers {
+			if drivers[i].SteamID == driver.PlayerID {
+				return &drivers[i]
+			}
+		}
+	}
+	name := resultDriverName(driver)
+	for i := range drivers {
+		if drivers[i].SteamID != "" && driver.PlayerID != "" && drivers[i].SteamID != driver.PlayerID {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(drivers[i].Name), name) {
+			return &drivers[i]
+		}
+	}
+	return nil
+}
+
+func newLeaderboardDriver(driver model.ResultDriver, position int) model.LeaderboardDriver {
+	initials := driver.ShortName
+	if initials == "" {
+		for _, part := range strings.Fields(resultDriverName(driver)) {
+			initials += strings.ToUpper(part[:1])
+		}
+	}
+	return model.LeaderboardDriver{
+		ID:       uuid.New(),
+		Name:     resultDriverName(driver),
+		SteamID:  driver.PlayerID,
+		Initials: initials,
+		Position: position,
+	}
+}
+
+func resultDriverName(driver model.ResultDriver) string {
+	return strings.TrimSpace(driver.FirstName + " " + driver.LastName)
+}
+
+// fastestLap returns the car and driver that set the best valid lap of the
+// session.
+func fastestLap(result *model.ResultSession) (carID, driverIndex int, ok bool) {
+	best := 0
+	for _, lap := range result.Laps {
+		if !lap.IsValidForBest || lap.LapTime <= 0 {
+			continue
+		}
+		if !ok || lap.LapTime < best {
+			best, carID, driverIndex, ok = lap.LapTime, lap.CarID, lap.DriverIndex, true
+		}
+	}
+	if ok {
+		return carID, driverIndex, true
+	}
+
+	for _, car := range result.Cars {
+		if car.BestLap <= 0 || car.LapCount == 0 {
+			continue
+		}
+		if !ok || car.BestLap < best {
+			best, carID, driverIndex, ok = car.BestLap, car.CarID, 0, true
+		}
+	}
+	return carID, driverIndex, ok
 }
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Leaderboard export formats.
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+	ExportHTML = "html"
+	ExportSVG  = "svg"
+)
+
+var exportContentTypes = map[string]string{
+	ExportCSV:  "text/csv; charset=utf-8",
+	ExportJSON: fiber.MIMEApplicationJSONCharsetUTF8,
+	ExportHTML: fiber.MIMETextHTMLCharsetUTF8,
+	ExportSVG:  "image/svg+xml",
+}
+
+var (
+	exportFileNameRegex = regexp.MustCompile(`[^a-z0-9]+`)
+	exportColorRegex    = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+|rgba?\([0-9.,%\s]+\))$`)
+)
+
+// LeaderboardExportFile is a rendered export with what is needed to serve it.
+type LeaderboardExportFile struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// Export renders the standings of a championship, or of the server's default
+// championship when championshipID is uuid.Nil, in the given format.
+func (s *LeaderboardService) Export(ctx context.Context, serverID, championshipID uuid.UUID, format string) (*LeaderboardExportFile, error) {
+	format = strings.ToLower(format)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unsupported export format %q", format))
+	}
+
+	lb, err := s.resolve(ctx, serverID, championshipID)
+	if err != nil {
+		return nil, err
+	}
+	export := buildLeaderboardExport(lb, computeStandings(lb))
+
+	var data []byte
+	switch format {
+	case ExportCSV:
+		data, err = renderExportCSV(export)
+	case ExportJSON:
+		data, err = json.MarshalIndent(export, "", "  ")
+	case ExportHTML:
+		data, err = renderExportTemplate(exportHTMLTemplate, newExportView(export))
+	case ExportSVG:
+		data, err = renderExportTemplate(exportSVGTemplate, newExportView(export))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error rendering %s export: %w", format, err)
+	}
+
+	name := strings.Trim(exportFileNameRegex.ReplaceAllString(strings.ToLower(export.Championship.Name), "-"), "-")
+	if name == "" {
+		name = "leaderboard"
+	}
+	return &LeaderboardExportFile{
+		FileName:    name + "." + format,
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
+// buildLeaderboardExport resolves the styling of every cell: the fastest lap
+// colours win over the points table row of the finishing position, which wins
+// over the row awarding the same points.
+func buildLeaderboardExport(lb *model.Leaderboard, standings *model.LeaderboardStandings) *model.LeaderboardExport {
+	rows := sortedPointRows(lb.PointRows)
+	export := &model.LeaderboardExport{
+		Version:     model.LeaderboardExportVersion,
+		GeneratedAt: time.Now().UTC(),
+		Championship: model.LeaderboardExportInfo{
+			ID:            lb.ID,
+			Name:          lb.Name,
+			Season:        lb.Season,
+			CountedRounds: lb.CountedRounds,
+			FLPoints:      lb.FLPoints,
+		},
+		FastestLap: model.LeaderboardExportStyle{Color: lb.FLColor, TextColor: lb.FLTextColor},
+		Rounds:     standings.Rounds,
+		Standings:  make([]model.LeaderboardExportStanding, 0, len(standings.Standings)),
+	}
+
+	for _, standing := range standings.Standings {
+		exported := model.LeaderboardExportStanding{
+			Position: standing.Position,
+			Name:     standing.Name,
+			Initials: standing.Initials,
+			Color:    standing.Color,
+			Points:   standing.Points,
+			Wins:     standing.Wins,
+			Podiums:  standing.Podiums,
+			Rounds:   make([]model.LeaderboardExportRound, 0, len(standing.Rounds)),
+		}
+		for _, round := range standing.Rounds {
+			exportRound := model.LeaderboardExportRound{
+				Score:      round.Score,
+				Points:     round.Points,
+				Finish:     round.Finish,
+				FastestLap: round.FastestLap,
+				Dropped:    round.Dropped,
+			}
+			if round.FastestLap {
+				exportRound.Style = export.FastestLap
+			} else if round.Finish > 0 && round.Finish <= len(rows) {
+				exportRound.Style = model.LeaderboardExportStyle{Color: rows[round.Finish-1].Color, TextColor: rows[round.Finish-1].TextColor}
+			} else if points, numeric := scorePoints(round.Score); numeric {
+				for _, row := range rows {
+					if row.Points == points {
+						exportRound.Style = model.LeaderboardExportStyle{Color: row.Color, TextColor: row.TextColor}
+						break
+					}
+				}
+			}
+			exported.Rounds = append(exported.Rounds, exportRound)
+		}
+		export.Standings = append(export.Standings, exported)
+	}
+	return export
+}
+
+// exportCell is how a round is shown in the CSV, HTML and SVG exports: its
+// points, or its status, in brackets when dropped.
+func exportCell(round model.LeaderboardExportRound) string {
+	cell := string(round.Score)
+	if _, numeric := scorePoints(round.Score); numeric || round.FastestLap {
+		cell = strconv.Itoa(round.Points)
+	}
+	if round.Dropped && cell != "" {
+		cell = "(" + cell + ")"
+	}
+	return cell
+}
+
+// csvText keeps a spreadsheet from running a CSV cell as a formula, by
+// prefixing text that starts like one with a quote. Numbers, negative ones
+// included, are left as they are.
+func csvText(cell string) string {
+	if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return cell
+	}
+	if _, err := strconv.Atoi(cell); err == nil {
+		return cell
+	}
+	return "'" + cell
+}
+
+func renderExportCSV(export *model.LeaderboardExport) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"Position", "Driver", "Initials"}
+	for _, round := range export.Rounds {
+		header = append(header, csvText(round.Name))
+	}
+	header = append(header, "Points", "Wins", "Podiums")
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, standing := range export.Standings {
+		record := []string{strconv.Itoa(standing.Position), csvText(standing.Name), csvText(standing.Initials)}
+		for _, round := range standing.Rounds {
+			record = append(record, csvText(exportCell(round)))
+		}
+		record = append(record, strconv.Itoa(standing.Points), strconv.Itoa(standing.Wins), strconv.Itoa(standing.Podiums))
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+const (
+	exportRowHeight    = 32
+	exportNameWidth    = 220
+	exportRoundWidth   = 64
+	exportPointsWidth  = 72
+	exportHeaderHeight = 72
+)
+
+type exportView struct {
+	*model.LeaderboardExport
+	Width  int
+	Height int
+	Rows   []exportViewRow
+}
+
+type exportViewRow struct {
+	model.LeaderboardExportStanding
+	Y     int
+	Cells []exportViewCell
+}
+
+type exportViewCell struct {
+	Text      string
+	X         int
+	Color     string
+	TextColor string
+}
+
+func newExportView(export *model.LeaderboardExport) *exportView {
+	view := &exportView{
+		LeaderboardExport: export,
+		Width:             exportNameWidth + len(export.Rounds)*exportRoundWidth + exportPointsWidth,
+		Height:            exportHeaderHeight + len(export.Standings)*exportRowHeight,
+	}
+	for i, standing := range export.Standings {
+		row := exportViewRow{LeaderboardExportStanding: standing, Y: exportHeaderHeight + i*exportRowHeight}
+		for j, round := range standing.Rounds {
+			row.Cells = append(row.Cells, exportViewCell{
+				Text:      exportCell(round),
+				X:         exportNameWidth + j*exportRoundWidth,
+				Color:     round.Style.Color,
+				TextColor: round.Style.TextColor,
+			})
+		}
+		view.Rows = append(view.Rows, row)
+	}
+	return view
+}
+
+func renderExportTemplate(tmpl *template.Template, view *exportView) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, view); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var exportTemplateFuncs = template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+	"roundX": func(index int) int {
+		return exportNameWidth + index*exportRoundWidth
+	},
+	// css lets a styling field into a style or fill attribute, as long as it is
+	// a plain colour.
+	"css": func(color string) template.CSS {
+		if !exportColorRegex.MatchString(strings.TrimSpace(color)) {
+			return ""
+		}
+		return template.CSS(strings.TrimSpace(color))
+	},
+}
+
+var exportHTMLTemplate = template.Must(template.New("html").Funcs(exportTemplateFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Championship.Name}}{{if .Championship.Season}} {{.Championship.Season}}{{end}}</title>
+<style>
+body { font-family: sans-serif; background: #111827; color: #f9fafb; margin: 24px; }
+table { border-collapse: collapse; }
+th, td { padding: 6px 10px; text-align: center; border: 1px solid #374151; }
+td.driver { text-align: left; }
+td.driver span { display: inline-block; width: 10px; height: 10px; margin-right: 8px; }
+td.points { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Championship.Name}}{{if .Championship.Season}} <small>{{.Championship.Season}}</small>{{end}}</h1>
+<table>
+<thead>
+<tr><th>Pos</th><th>Driver</th>{{range .Rounds}}<th>{{.Name}}</th>{{end}}<th>Points</th></tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr><td>{{.Position}}</td><td class="driver"><span style="background: {{css .Color}}"></span>{{.Name}}</td>{{range .Cells}}<td{{if .Color}} style="background: {{css .Color}}; color: {{css .TextColor}}"{{end}}>{{.Text}}</td>{{end}}<td class="points">{{.Points}}</td></tr>
+{{end}}</tbody>
+</table>
+</body>
+</html>
+`))
+
+var exportSVGTemplate = template.Must(template.New("svg").Funcs(exportTemplateFuncs).Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" font-family="sans-serif" font-size="14">
+<rect width="{{.Width}}" height="{{.Height}}" fill="#111827"/>
+<text x="12" y="28" font-size="20" font-weight="bold" fill="#f9fafb">{{.Championship.Name}}{{if .Championship.Season}} {{.Championship.Season}}{{end}}</text>
+{{range $i, $round := .Rounds}}<text x="{{add (roundX $i) 32}}" y="60" text-anchor="middle" fill="#9ca3af">{{$round.Name}}</text>
+{{end}}<text x="{{add .Width -36}}" y="60" text-anchor="middle" fill="#9ca3af">Pts</text>
+{{range .Rows}}<g transform="translate(0 {{.Y}})">
+<rect x="12" y="8" width="8" height="16" fill="{{css .Color}}"/>
+<text x="28" y="21" fill="#f9fafb">{{.Position}}. {{.Name}}</text>
+{{range .Cells}}{{if .Color}}<rect x="{{add .X 2}}" y="2" width="60" height="28" fill="{{css .Color}}"/>{{end}}<text x="{{add .X 32}}" y="21" text-anchor="middle" fill="{{if .TextColor}}{{css .TextColor}}{{else}}#f9fafb{{end}}">{{.Text}}</text>
+{{end}}<text x="{{add $.Width -36}}" y="21" text-anchor="middle" font-weight="bold" fill="#f9fafb">{{.Points}}</text>
+</g>
+{{end}}</svg>
+`))
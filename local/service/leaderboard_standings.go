@@ -0,0 +1,225 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type standingsEntry struct {
+	driver       model.LeaderboardDriver
+	rounds       []model.DriverRoundStanding
+	alwaysCounts []bool
+}
+
+type standingsTotal struct {
+	entry    *standingsEntry
+	points   int
+	dropped  []bool
+	finishes map[int]int
+	wins     int
+	podiums  int
+}
+
+// computeStandings totals the scores of a leaderboard. Only the best
+// CountedRounds rounds of each driver count, ties are broken by wins, podiums
+// and then by the number of each finishing position, and every round records
+// the championship as it stood after it.
+func computeStandings(lb *model.Leaderboard) *model.LeaderboardStandings {
+	races := append([]model.LeaderboardRace(nil), lb.Races...)
+	sort.SliceStable(races, func(i, j int) bool { return races[i].Position < races[j].Position })
+
+	drivers := append([]model.LeaderboardDriver(nil), lb.Drivers...)
+	sort.SliceStable(drivers, func(i, j int) bool { return drivers[i].Position < drivers[j].Position })
+
+	statuses := make(map[string]model.LeaderboardStatus, len(lb.Statuses))
+	for _, status := range lb.Statuses {
+		statuses[strings.ToUpper(strings.TrimSpace(status.Code))] = status
+	}
+
+	standings := &model.LeaderboardStandings{
+		LeaderboardID: lb.ID,
+		CountedRounds: lb.CountedRounds,
+		Rounds:        make([]model.StandingsRound, 0, len(races)),
+		Standings:     make([]model.DriverStanding, 0, len(drivers)),
+	}
+
+	entries := make([]*standingsEntry, len(drivers))
+	for i, driver := range drivers {
+		entries[i] = &standingsEntry{driver: driver}
+	}
+
+	for _, race := range races {
+		standings.Rounds = append(standings.Rounds, model.StandingsRound{RaceID: race.ID, Name: race.Name})
+		finishes := raceFinishes(race)
+		results := make(map[string]model.LeaderboardResult, len(race.Results))
+		for _, result := range race.Results {
+			results[result.DriverID.String()] = result
+		}
+
+		for _, entry := range entries {
+			round := model.DriverRoundStanding{RaceID: race.ID}
+			alwaysCounts := false
+			if result, ok := results[entry.driver.ID.String()]; ok {
+				round.Score = result.Score
+				round.Finish = finishes[result.DriverID.String()]
+				if points, numeric := scorePoints(result.Score); numeric {
+					round.Points = points
+				} else if status, known := statuses[strings.ToUpper(strings.TrimSpace(string(result.Score)))]; known {
+					round.Points = status.Points
+					alwaysCounts = status.AlwaysCounts
+				}
+			}
+			if race.FastestLapDriverID != nil && *race.FastestLapDriverID == entry.driver.ID {
+				round.FastestLap = true
+				round.Points += lb.FLPoints
+			}
+			entry.rounds = append(entry.rounds, round)
+			entry.alwaysCounts = append(entry.alwaysCounts, alwaysCounts)
+		}
+	}
+
+	var final []*standingsTotal
+	for played := 1; played <= len(races); played++ {
+		totals := rankStandings(entries, played, lb.CountedRounds)
+		for position, total := range totals {
+			total.entry.rounds[played-1].ChampionshipPoints = total.points
+			total.entry.rounds[played-1].ChampionshipPosition = position + 1
+		}
+		final = totals
+	}
+	if len(races) == 0 {
+		final = rankStandings(entries, 0, lb.CountedRounds)
+	}
+
+	for position, total := range final {
+		standing := model.DriverStanding{
+			Position: position + 1,
+			DriverID: total.entry.driver.ID,
+			Name:     total.entry.driver.Name,
+			Initials: total.entry.driver.Initials,
+			Color:    total.entry.driver.Color,
+			Points:   total.points,
+			Wins:     total.wins,
+			Podiums:  total.podiums,
+			Rounds:   total.entry.rounds,
+		}
+		for i := range standing.Rounds {
+			round := &standing.Rounds[i]
+			round.Dropped = total.dropped[i]
+			if round.Dropped {
+				standing.DroppedPoints += round.Points
+			}
+			if round.FastestLap {
+				standing.FastestLaps++
+			}
+			if round.Finish > 0 && (standing.BestFinish == 0 || round.Finish < standing.BestFinish) {
+				standing.BestFinish = round.Finish
+			}
+		}
+		if standing.Rounds == nil {
+			standing.Rounds = []model.DriverRoundStanding{}
+		}
+		standings.Standings = append(standings.Standings, standing)
+	}
+	return standings
+}
+
+// rankStandings totals the first played rounds of every driver and orders
+// them by the championship rules.
+func rankStandings(entries []*standingsEntry, played, countedRounds int) []*standingsTotal {
+	totals := make([]*standingsTotal, len(entries))
+	for i, entry := range entries {
+		total := &standingsTotal{
+			entry:    entry,
+			dropped:  make([]bool, len(entry.rounds)),
+			finishes: make(map[int]int),
+		}
+
+		droppable := make([]int, 0, played)
+		for round := 0; round < played; round++ {
+			total.points += entry.rounds[round].Points
+			if finish := entry.rounds[round].Finish; finish > 0 {
+				total.finishes[finish]++
+				if finish == 1 {
+					total.wins++
+				}
+				if finish <= 3 {
+					total.podiums++
+				}
+			}
+			if !entry.alwaysCounts[round] {
+				droppable = append(droppable, round)
+			}
+		}
+
+		if countedRounds > 0 && played > countedRounds {
+			sort.SliceStable(droppable, func(a, b int) bool {
+				return entry.rounds[droppable[a]].Points < entry.rounds[droppable[b]].Points
+			})
+			for _, round := range droppable[:min(played-countedRounds, len(droppable))] {
+				total.dropped[round] = true
+				total.points -= entry.rounds[round].Points
+			}
+		}
+		totals[i] = total
+	}
+
+	sort.SliceStable(totals, func(i, j int) bool {
+		a, b := totals[i], totals[j]
+		if a.points != b.points {
+			return a.points > b.points
+		}
+		if a.wins != b.wins {
+			return a.wins > b.wins
+		}
+		if a.podiums != b.podiums {
+			return a.podiums > b.podiums
+		}
+		for finish := 1; finish <= max(maxFinish(a.finishes), maxFinish(b.finishes)); finish++ {
+			if a.finishes[finish] != b.finishes[finish] {
+				return a.finishes[finish] > b.finishes[finish]
+			}
+		}
+		return strings.ToLower(a.entry.driver.Name) < strings.ToLower(b.entry.driver.Name)
+	})
+	return totals
+}
+
+// raceFinishes returns the finishing position of every driver in a race. A
+// result without a stored position is placed by its numeric score.
+func raceFinishes(race model.LeaderboardRace) map[string]int {
+	finishes := make(map[string]int, len(race.Results))
+	for _, result := range race.Results {
+		if result.Position > 0 {
+			finishes[result.DriverID.String()] = result.Position
+			continue
+		}
+		points, numeric := scorePoints(result.Score)
+		if !numeric {
+			continue
+		}
+		finish := 1
+		for _, other := range race.Results {
+			if otherPoints, ok := scorePoints(other.Score); ok && otherPoints > points {
+				finish++
+			}
+		}
+		finishes[result.DriverID.String()] = finish
+	}
+	return finishes
+}
+
+func scorePoints(score model.Score) (int, bool) {
+	points, err := strconv.Atoi(strings.TrimSpace(string(score)))
+	return points, err == nil
+}
+
+func maxFinish(finishes map[int]int) int {
+	highest := 0
+	for finish := range finishes {
+		highest = max(highest, finish)
+	}
+	return highest
+}
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"acc-server-manager/local/model"
 	"acc-server-manager/local/repository"
 	"acc-server-manager/local/utl/logging"
+	"context"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -42,3 +45,32 @@ func (s *LookupService) GetSessionTypes(ctx *fiber.Ctx) (interface{}, error) {
 	logging.Debug("Getting session types")
 	return s.repository.GetSessionTypes(ctx.UserContext())
 }
+
+// carModelValues returns the known car model values, for validating config
+// files against the lookup table.
+func carModelValues(ctx context.Context, repo *repository.LookupRepository) (map[int]bool, error) {
+	cars, err := repo.GetCarModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load car models: %v", err)
+	}
+
+	values := make(map[int]bool, len(*cars))
+	for _, car := range *cars {
+		values[car.Value] = true
+	}
+	return values, nil
+}
+
+// tracksByName returns the known tracks keyed by their config name.
+func tracksByName(ctx context.Context, repo *repository.LookupRepository) (map[string]model.Track, error) {
+	tracks, err := repo.GetTracks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tracks: %v", err)
+	}
+
+	byName := make(map[string]model.Track, len(*tracks))
+	for _, track := range *tracks {
+		byName[track.Name] = track
+	}
+	return byName, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PlayerService records the drivers that connect to servers, as read from
+// their logs, and serves that history.
+type PlayerService struct {
+	repository       *repository.PlayerSessionRepository
+	serverRepository *repository.ServerRepository
+}
+
+func NewPlayerService(repository *repository.PlayerSessionRepository, serverRepository *repository.ServerRepository) *PlayerService {
+	logging.Debug("Initializing PlayerService")
+	return &PlayerService{
+		repository:       repository,
+		serverRepository: serverRepository,
+	}
+}
+
+func (s *PlayerService) GetAll(ctx context.Context, filter *model.PlayerSessionFilter) (*model.FilteredResponse, error) {
+	server, err := s.serverRepository.GetByID(ctx, filter.ServerID)
+	if err != nil || server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+
+	players, err := s.repository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: players,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+// RecordPlayer stores a change to a connected player. sessionID is only used
+// when the player is first seen.
+func (s *PlayerService) RecordPlayer(serverID, sessionID uuid.UUID, player model.PlayerState) {
+	session := model.NewPlayerSession(serverID, sessionID, &player)
+	if err := s.repository.SavePlayerSession(context.Background(), session); err != nil {
+		logging.Error("Failed to record player %s on server %s: %v", player.DriverName, serverID, err)
+	}
+}
+
+// CloseOpenSessions disconnects the players left open when the manager last
+// stopped, since their disconnects were never seen.
+func (s *PlayerService) CloseOpenSessions(serverID uuid.UUID) {
+	if err := s.repository.CloseOpenSessions(context.Background(), serverID, time.Now().UTC()); err != nil {
+		logging.Error("Failed to close player sessions of server %s: %v", serverID, err)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/logging"
+	"acc-server-manager/local/utl/tracking"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	resultsWatchInterval = 5 * time.Second
+	// resultFileTimeLayout is the timestamp ACC puts in front of results file
+	// names, e.g. 240512_193011_R.json.
+	resultFileTimeLayout = "060102_150405"
+)
+
+// ResultService ingests the results files ACC writes at the end of each
+// session and serves them.
+type ResultService struct {
+	repository       *repository.ResultRepository
+	serverRepository *repository.ServerRepository
+	watchers         sync.Map
+}
+
+func NewResultService(repository *repository.ResultRepository, serverRepository *repository.ServerRepository) *ResultService {
+	logging.Debug("Initializing ResultService")
+	return &ResultService{
+		repository:       repository,
+		serverRepository: serverRepository,
+	}
+}
+
+func (s *ResultService) GetAll(ctx context.Context, filter *model.ResultSessionFilter) (*model.FilteredResponse, error) {
+	server, err := s.serverRepository.GetByID(ctx, filter.ServerID)
+	if err != nil || server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+
+	results, err := s.repository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: results,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+func (s *ResultService) GetByID(ctx context.Context, serverID, resultID string) (*model.ResultSession, error) {
+	serverUUID, err := uuid.Parse(serverID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid server ID")
+	}
+	resultUUID, err := uuid.Parse(resultID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid result ID")
+	}
+
+	result, err := s.repository.GetResult(ctx, serverUUID, resultUUID)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Result not found")
+	}
+	return result, nil
+}
+
+// WatchResults ingests the results files a server already has and then every
+// new one written while it runs.
+func (s *ResultService) WatchResults(server *model.Server) {
+	if _, ok := s.watchers.Load(server.ID); ok {
+		return
+	}
+	resultsPath := server.GetResultsPath()
+	watcher := tracking.NewFileStatWatcher(resultsPath, resultsWatchInterval, func(fileName string, data []byte) {
+		s.ingest(server.ID, resultsPath, fileName, data)
+	})
+	if _, loaded := s.watchers.LoadOrStore(server.ID, watcher); loaded {
+		return
+	}
+	watcher.Start()
+
+	if err := s.IngestDirectory(context.Background(), server.ID, resultsPath); err != nil {
+		logging.Error("Failed to ingest results of server %s: %v", server.ID, err)
+	}
+}
+
+// StopWatchingResults stops the watcher started by WatchResults.
+func (s *ResultService) StopWatchingResults(serverID uuid.UUID) {
+	if watcher, ok := s.watchers.LoadAndDelete(serverID); ok {
+		watcher.(*tracking.FileWatcher).Stop()
+	}
+}
+
+// IngestDirectory ingests every results file in dir that is not stored yet.
+func (s *ResultService) IngestDirectory(ctx context.Context, serverID uuid.UUID, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			logging.Error("Failed to read results file %s: %v", entry.Name(), err)
+			continue
+		}
+		if _, err := s.IngestFile(ctx, serverID, dir, entry.Name(), data); err != nil {
+			logging.Error("Failed to ingest results file %s: %v", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// IngestFile stores one results file. Files that were already ingested are
+// skipped and nil is returned for them.
+func (s *ResultService) IngestFile(ctx context.Context, serverID uuid.UUID, dir, fileName string, data []byte) (*model.ResultSession, error) {
+	exists, err := s.repository.Exists(ctx, serverID, fileName)
+	if err != nil || exists {
+		return nil, err
+	}
+
+	var file model.ACCResultFile
+	if err := json.Unmarshal(decodeResultFile(data), &file); err != nil {
+		return nil, fmt.Errorf("error parsing results file %s: %w", fileName, err)
+	}
+
+	result := file.ToResultSession(serverID, fileName, resultFinishedAt(dir, fileName))
+	result.SessionID, err = s.repository.FindSessionID(ctx, serverID, result.SessionType, result.Track, result.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repository.Insert(ctx, result); err != nil {
+		return nil, fmt.Errorf("error saving results file %s: %w", fileName, err)
+	}
+
+	logging.Info("Ingested results file %s of server %s", fileName, serverID)
+	return result, nil
+}
+
+func (s *ResultService) ingest(serverID uuid.UUID, dir, fileName string, data []byte) {
+	if _, err := s.IngestFile(context.Background(), serverID, dir, fileName, data); err != nil {
+		logging.Error("Failed to ingest results file %s: %v", fileName, err)
+	}
+}
+
+// decodeResultFile returns results data as UTF-8. ACC writes the files as
+// UTF-16LE, with or without a BOM.
+func decodeResultFile(data []byte) []byte {
+	if bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}) {
+		return data[3:]
+	}
+	if bytes.HasPrefix(data, []byte{0xFF, 0xFE}) || (len(data) > 1 && data[1] == 0) {
+		if decoded, err := DecodeUTF16LEBOM(data); err == nil {
+			return decoded
+		}
+	}
+	return data
+}
+
+// resultFinishedAt reads the end of the session from the file name, falling
+// back to the file's modification time.
+func resultFinishedAt(dir, fileName string) time.Time {
+	if len(fileName) >= len(resultFileTimeLayout) {
+		if finishedAt, err := time.ParseInLocation(resultFileTimeLayout, fileName[:len(resultFileTimeLayout)], time.Local); err == nil {
+			return finishedAt.UTC()
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dir, fileName)); err == nil {
+		return info.ModTime().UTC()
+	}
+	return time.Now().UTC()
+}
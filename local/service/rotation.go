@@ -0,0 +1,332 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/graceful"
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const rotationTickInterval = 30 * time.Second
+
+// RotationService moves servers through their event rotation. Advancing
+// merges the next entry into event.json and restarts the server if it is
+// running.
+type RotationService struct {
+	repository            *repository.RotationRepository
+	serverRepository      *repository.ServerRepository
+	configService         *ConfigService
+	serviceControlService *ServiceControlService
+	serverService         *ServerService
+
+	// mu serialises advances, so a race end and a scheduled advance at the
+	// same time do not skip an entry.
+	mu sync.Mutex
+}
+
+func NewRotationService(
+	repository *repository.RotationRepository,
+	serverRepository *repository.ServerRepository,
+	configService *ConfigService,
+	serviceControlService *ServiceControlService,
+	serverService *ServerService,
+) *RotationService {
+	logging.Debug("Initializing RotationService")
+	return &RotationService{
+		repository:            repository,
+		serverRepository:      serverRepository,
+		configService:         configService,
+		serviceControlService: serviceControlService,
+		serverService:         serverService,
+	}
+}
+
+// Start plans the next scheduled advance of every enabled rotation, listens
+// for race ends and advances the due rotations until the application shuts
+// down.
+func (s *RotationService) Start() {
+	ctx := context.Background()
+	rotations, err := s.repository.GetEnabled(ctx)
+	if err != nil {
+		logging.Error("Failed to load rotations: %v", err)
+	}
+	now := time.Now().UTC()
+	for i := range rotations {
+		rotation := &rotations[i]
+		if rotation.NextRunAt == nil && rotation.CronExpression != "" {
+			rotation.NextRunAt = rotation.NextRun(now)
+			if err := s.repository.UpdateState(ctx, rotation); err != nil {
+				logging.Error("Failed to plan rotation %s: %v", rotation.ID, err)
+			}
+		}
+	}
+
+	s.serverService.AddSessionChangeListener(func(serverID uuid.UUID, from, to model.TrackSession) {
+		s.HandleSessionChange(context.Background(), serverID, from, to)
+	})
+
+	graceful.GetManager().RunGoroutine(func(ctx context.Context) {
+		ticker := time.NewTicker(rotationTickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.RunDue(ctx, time.Now().UTC())
+			}
+		}
+	})
+}
+
+// HandleSessionChange advances the rotation of a server when its race
+// session has ended.
+func (s *RotationService) HandleSessionChange(ctx context.Context, serverID uuid.UUID, from, to model.TrackSession) {
+	if from != model.SessionRace || to == model.SessionRace {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rotation, err := s.repository.GetByServerID(ctx, serverID)
+	if err != nil {
+		logging.Error("Failed to get rotation: %v", err)
+		return
+	}
+	if rotation == nil || !rotation.Enabled || !rotation.AdvanceOnRaceEnd || rotation.Pinned {
+		return
+	}
+	if err := s.advance(ctx, rotation, model.RotationTriggerRaceEnd, time.Now().UTC()); err != nil {
+		logging.Error("Failed to advance rotation of server %s: %v", serverID, err)
+	}
+}
+
+// RunDue advances every rotation whose scheduled advance is due at now. A
+// pinned rotation stays on its entry but is still planned forward.
+func (s *RotationService) RunDue(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rotations, err := s.repository.GetDue(ctx, now)
+	if err != nil {
+		logging.Error("Failed to get due rotations: %v", err)
+		return
+	}
+
+	for i := range rotations {
+		rotation := &rotations[i]
+		if rotation.Pinned {
+			rotation.NextRunAt = rotation.NextRun(now)
+			if err := s.repository.UpdateState(ctx, rotation); err != nil {
+				logging.Error("Failed to plan rotation %s: %v", rotation.ID, err)
+			}
+			continue
+		}
+		if err := s.advance(ctx, rotation, model.RotationTriggerSchedule, now); err != nil {
+			logging.Error("Failed to advance rotation of server %s: %v", rotation.ServerID, err)
+			// Try again at the next matching time rather than on every tick.
+			rotation.NextRunAt = rotation.NextRun(now)
+			if err := s.repository.UpdateState(ctx, rotation); err != nil {
+				logging.Error("Failed to plan rotation %s: %v", rotation.ID, err)
+			}
+		}
+	}
+}
+
+// advance applies the entry after the current one and stores the new
+// position. The position is kept when the entry cannot be applied.
+func (s *RotationService) advance(ctx context.Context, rotation *model.Rotation, trigger model.RotationTrigger, now time.Time) error {
+	if len(rotation.Entries) == 0 {
+		return fmt.Errorf("rotation has no entries")
+	}
+	server, err := s.serverRepository.GetByID(ctx, rotation.ServerID)
+	if err != nil || server == nil {
+		return fmt.Errorf("server not found")
+	}
+
+	next := (rotation.CurrentIndex + 1) % len(rotation.Entries)
+	entry := &rotation.Entries[next]
+	if err := s.applyEntry(ctx, server, entry); err != nil {
+		return fmt.Errorf("entry %q: %w", entry.Name, err)
+	}
+
+	rotation.CurrentIndex = next
+	rotation.LastAdvancedAt = &now
+	rotation.LastTrigger = trigger
+	rotation.NextRunAt = rotation.NextRun(now)
+	if err := s.repository.UpdateState(ctx, rotation); err != nil {
+		return err
+	}
+	logging.Info("Rotated server %s to %q (%s)", server.ServiceName, entry.Name, trigger)
+
+	s.serviceControlService.InvalidateStatus(server.ServiceName)
+	status, err := s.serviceControlService.GetCachedStatus(server.ServiceName)
+	if err != nil {
+		return fmt.Errorf("failed to get server status: %v", err)
+	}
+	if model.ParseServiceStatus(status) != model.StatusRunning {
+		return nil
+	}
+	if _, err := s.serviceControlService.RestartServer(server.ServiceName); err != nil {
+		return fmt.Errorf("failed to restart server: %v", err)
+	}
+	return nil
+}
+
+// applyEntry merges an entry's event fields into the server's event.json.
+func (s *RotationService) applyEntry(ctx context.Context, server *model.Server, entry *model.RotationEntry) error {
+	body := make(map[string]interface{}, len(entry.Event))
+	for key, value := range entry.Event {
+		body[key] = value
+	}
+
+	current, err := s.configService.readConfigJSON(server, EventJson)
+	if err != nil {
+		return err
+	}
+	matchConfigTypes(body, current)
+	if err := s.configService.ValidateConfig(ctx, server, EventJson, body); err != nil {
+		return err
+	}
+	_, err = s.configService.updateConfigInternal(ctx, server.ID.String(), EventJson, &body, false)
+	return err
+}
+
+// Get returns the rotation of a server.
+func (s *RotationService) Get(ctx context.Context, serverID string) (*model.Rotation, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	return s.getRotation(ctx, server.ID)
+}
+
+// Update replaces the settings and entries of a server's rotation, creating
+// it if needed. The current position and pin are kept while they still fit
+// the new entries.
+func (s *RotationService) Update(ctx context.Context, serverID string, input *model.Rotation) (*model.Rotation, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rotation, err := s.repository.GetByServerID(ctx, server.ID)
+	if err != nil {
+		return nil, err
+	}
+	if rotation == nil {
+		rotation = &model.Rotation{ServerID: server.ID}
+	}
+	rotation.Enabled = input.Enabled
+	rotation.AdvanceOnRaceEnd = input.AdvanceOnRaceEnd
+	rotation.CronExpression = input.CronExpression
+	rotation.Timezone = input.Timezone
+	rotation.Entries = input.Entries
+	if rotation.CurrentIndex >= len(rotation.Entries) {
+		rotation.CurrentIndex = 0
+		rotation.Pinned = false
+	}
+	rotation.NextRunAt = rotation.NextRun(time.Now().UTC())
+
+	if err := s.repository.Replace(ctx, rotation); err != nil {
+		return nil, err
+	}
+	return s.getRotation(ctx, server.ID)
+}
+
+// Delete removes the rotation of a server. Its event.json is left as it is.
+func (s *RotationService) Delete(ctx context.Context, serverID string) error {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.getRotation(ctx, server.ID); err != nil {
+		return err
+	}
+	return s.repository.DeleteByServerID(ctx, server.ID)
+}
+
+// Skip moves a server to the next entry of its rotation straight away, even
+// when the rotation is disabled or pinned. The pin is released, since it
+// belonged to the skipped entry.
+func (s *RotationService) Skip(ctx context.Context, serverID string) (*model.Rotation, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rotation, err := s.getRotation(ctx, server.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rotation.Entries) == 0 {
+		return nil, fiber.NewError(fiber.StatusConflict, "Rotation has no entries")
+	}
+	rotation.Pinned = false
+	if err := s.advance(ctx, rotation, model.RotationTriggerManual, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return rotation, nil
+}
+
+// SetPinned pins or releases the current entry of a server's rotation. A
+// pinned rotation does not advance on race ends or its schedule.
+func (s *RotationService) SetPinned(ctx context.Context, serverID string, pinned bool) (*model.Rotation, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rotation, err := s.getRotation(ctx, server.ID)
+	if err != nil {
+		return nil, err
+	}
+	rotation.Pinned = pinned
+	if err := s.repository.UpdateState(ctx, rotation); err != nil {
+		return nil, err
+	}
+	return rotation, nil
+}
+
+func (s *RotationService) getRotation(ctx context.Context, serverID uuid.UUID) (*model.Rotation, error) {
+	rotation, err := s.repository.GetByServerID(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if rotation == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Rotation not found")
+	}
+	return rotation, nil
+}
+
+func (s *RotationService) getServer(ctx context.Context, serverID string) (*model.Server, error) {
+	if _, err := uuid.Parse(serverID); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid server ID")
+	}
+	server, err := s.serverRepository.GetByID(ctx, serverID)
+	if err != nil || server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+	return server, nil
+}
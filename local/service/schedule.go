@@ -0,0 +1,298 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/graceful"
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	scheduleTickInterval = 15 * time.Second
+	// scheduleMissedAfter is how late a run may start before it is recorded
+	// as missed instead, e.g. after the manager was down.
+	scheduleMissedAfter = 5 * time.Minute
+)
+
+// ScheduleService keeps the start, stop and restart schedules of servers and
+// runs them when they are due.
+type ScheduleService struct {
+	repository            *repository.ScheduleRepository
+	executionRepository   *repository.ScheduleExecutionRepository
+	serverRepository      *repository.ServerRepository
+	serviceControlService *ServiceControlService
+	serverService         *ServerService
+	updateService         *UpdateService
+}
+
+func NewScheduleService(
+	repository *repository.ScheduleRepository,
+	executionRepository *repository.ScheduleExecutionRepository,
+	serverRepository *repository.ServerRepository,
+	serviceControlService *ServiceControlService,
+	serverService *ServerService,
+	updateService *UpdateService,
+) *ScheduleService {
+	logging.Debug("Initializing ScheduleService")
+	return &ScheduleService{
+		repository:            repository,
+		executionRepository:   executionRepository,
+		serverRepository:      serverRepository,
+		serviceControlService: serviceControlService,
+		serverService:         serverService,
+		updateService:         updateService,
+	}
+}
+
+// Start plans the enabled schedules that have no next run yet and runs due
+// schedules until the application shuts down.
+func (s *ScheduleService) Start() {
+	ctx := context.Background()
+	schedules, err := s.repository.GetEnabled(ctx)
+	if err != nil {
+		logging.Error("Failed to load schedules: %v", err)
+	}
+	now := time.Now().UTC()
+	for _, schedule := range schedules {
+		if schedule.NextRunAt == nil {
+			if err := s.repository.SetNextRun(ctx, schedule.ID, schedule.NextRun(now)); err != nil {
+				logging.Error("Failed to plan schedule %s: %v", schedule.ID, err)
+			}
+		}
+	}
+
+	graceful.GetManager().RunGoroutine(func(ctx context.Context) {
+		ticker := time.NewTicker(scheduleTickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.RunDue(ctx, time.Now().UTC())
+			}
+		}
+	})
+}
+
+// RunDue runs every schedule due at now and returns what was recorded.
+func (s *ScheduleService) RunDue(ctx context.Context, now time.Time) []model.ScheduleExecution {
+	schedules, err := s.repository.GetDue(ctx, now)
+	if err != nil {
+		logging.Error("Failed to get due schedules: %v", err)
+		return nil
+	}
+
+	executions := make([]model.ScheduleExecution, 0, len(schedules))
+	for i := range schedules {
+		schedule := &schedules[i]
+		execution := s.execute(ctx, schedule, now)
+		if err := s.executionRepository.Insert(ctx, execution); err != nil {
+			logging.Error("Failed to record run of schedule %s: %v", schedule.ID, err)
+		}
+		if err := s.repository.UpdateRunTimes(ctx, schedule.ID, now, schedule.NextRun(now)); err != nil {
+			logging.Error("Failed to plan schedule %s: %v", schedule.ID, err)
+		}
+		executions = append(executions, *execution)
+	}
+	return executions
+}
+
+func (s *ScheduleService) execute(ctx context.Context, schedule *model.Schedule, now time.Time) *model.ScheduleExecution {
+	execution := &model.ScheduleExecution{
+		ScheduleID:   schedule.ID,
+		ServerID:     schedule.ServerID,
+		Action:       schedule.Action,
+		ScheduledFor: *schedule.NextRunAt,
+		StartedAt:    time.Now().UTC(),
+	}
+	defer func() {
+		execution.FinishedAt = time.Now().UTC()
+		logging.Info("Schedule %s (%s) of server %s %s: %s", schedule.Name, schedule.Action, schedule.ServerID, execution.Status, execution.Message)
+	}()
+
+	if late := now.Sub(*schedule.NextRunAt); late > scheduleMissedAfter {
+		execution.Status = model.ScheduleExecutionSkipped
+		execution.Message = fmt.Sprintf("missed by %s", late.Round(time.Second))
+		return execution
+	}
+
+	server, err := s.serverRepository.GetByID(ctx, schedule.ServerID)
+	if err != nil || server == nil {
+		execution.Status = model.ScheduleExecutionFailed
+		execution.Message = "server not found"
+		return execution
+	}
+
+	if schedule.Action == model.ScheduleActionStop && !schedule.Force {
+		if players := s.serverService.PlayersOnline(server.ID); players > 0 {
+			execution.Status = model.ScheduleExecutionSkipped
+			execution.Message = fmt.Sprintf("%d player(s) online", players)
+			return execution
+		}
+	}
+
+	var status string
+	switch schedule.Action {
+	case model.ScheduleActionStart:
+		status, err = s.serviceControlService.StartServer(server.ServiceName)
+	case model.ScheduleActionStop:
+		status, err = s.serviceControlService.StopServer(server.ServiceName)
+	case model.ScheduleActionRestart:
+		status, err = s.serviceControlService.RestartServer(server.ServiceName)
+	case model.ScheduleActionUpdate:
+		var job *model.UpdateJob
+		if job, err = s.updateService.Enqueue(ctx, server.ID.String(), model.UpdateJobSchedule, schedule.WindowMinutes, nil); err == nil {
+			status = fmt.Sprintf("update %s queued", job.ID)
+		}
+	default:
+		err = fmt.Errorf("unknown action %q", schedule.Action)
+	}
+	if err != nil {
+		execution.Status = model.ScheduleExecutionFailed
+		execution.Message = err.Error()
+		return execution
+	}
+
+	execution.Status = model.ScheduleExecutionSucceeded
+	execution.Message = status
+	return execution
+}
+
+func (s *ScheduleService) GetAll(ctx context.Context, filter *model.ScheduleFilter) (*model.FilteredResponse, error) {
+	if err := s.ensureServer(ctx, filter.ServerID); err != nil {
+		return nil, err
+	}
+
+	schedules, err := s.repository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: schedules,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+func (s *ScheduleService) GetByID(ctx context.Context, serverID, scheduleID string) (*model.Schedule, error) {
+	schedule, err := s.repository.GetByID(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil || schedule.ServerID.String() != serverID {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Schedule not found")
+	}
+	return schedule, nil
+}
+
+func (s *ScheduleService) Create(ctx context.Context, serverID string, schedule *model.Schedule) (*model.Schedule, error) {
+	if err := s.ensureServer(ctx, serverID); err != nil {
+		return nil, err
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	schedule.ID = uuid.Nil
+	schedule.ServerID = uuid.MustParse(serverID)
+	schedule.LastRunAt = nil
+	schedule.NextRunAt = schedule.NextRun(time.Now().UTC())
+	if err := s.repository.Insert(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *ScheduleService) Update(ctx context.Context, serverID, scheduleID string, input *model.Schedule) (*model.Schedule, error) {
+	schedule, err := s.GetByID(ctx, serverID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.Name = input.Name
+	schedule.Action = input.Action
+	schedule.CronExpression = input.CronExpression
+	schedule.Timezone = input.Timezone
+	schedule.Enabled = input.Enabled
+	schedule.Force = input.Force
+	schedule.WindowMinutes = input.WindowMinutes
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	schedule.NextRunAt = schedule.NextRun(time.Now().UTC())
+	if err := s.repository.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *ScheduleService) Delete(ctx context.Context, serverID, scheduleID string) error {
+	schedule, err := s.GetByID(ctx, serverID, scheduleID)
+	if err != nil {
+		return err
+	}
+	return s.repository.Delete(ctx, schedule.ID)
+}
+
+// GetExecutions lists the run history of a server's schedules, or of one
+// schedule when the filter names it.
+func (s *ScheduleService) GetExecutions(ctx context.Context, filter *model.ScheduleExecutionFilter) (*model.FilteredResponse, error) {
+	if err := s.ensureServer(ctx, filter.ServerID); err != nil {
+		return nil, err
+	}
+	if filter.ScheduleID != "" {
+		if _, err := s.GetByID(ctx, filter.ServerID, filter.ScheduleID); err != nil {
+			return nil, err
+		}
+	}
+
+	executions, err := s.executionRepository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.executionRepository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: executions,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+func (s *ScheduleService) ensureServer(ctx context.Context, serverID string) error {
+	if _, err := uuid.Parse(serverID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid server ID")
+	}
+	server, err := s.serverRepository.GetByID(ctx, serverID)
+	if err != nil || server == nil {
+		return fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+	return nil
+}
@@ -3,10 +3,14 @@ package service
 import (
 	"acc-server-manager/local/model"
 	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/broadcasting"
 	"acc-server-manager/local/utl/env"
+	"acc-server-manager/local/utl/graceful"
 	"acc-server-manager/local/utl/logging"
 	"acc-server-manager/local/utl/tracking"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"sync"
@@ -29,14 +33,31 @@ type ServerService struct {
 	apiService       *ServiceControlService
 	configService    *ConfigService
 	steamService     *SteamService
-	windowsService   *WindowsService
+	installer        ServerInstaller
+	serviceManager   ServiceManager
 	firewallService  *FirewallService
 	webSocketService *WebSocketService
+	webhookService   *WebhookService
+	jobService       *JobService
+	playerService    *PlayerService
+	resultService    *ResultService
 	instances        sync.Map // Track instances per server
 	lastInsertTimes  sync.Map // Track last insert time per server
 	debouncers       sync.Map // Track debounce timers per server
 	logTailers       sync.Map // Track log tailers per server
 	sessionIDs       sync.Map // Track current session ID per server
+	broadcasters     sync.Map // Track broadcasting clients per server
+	creations        sync.Map // Track creation progress per server
+
+	sessionListenersMu sync.RWMutex
+	sessionListeners   []func(serverID uuid.UUID, from, to model.TrackSession)
+}
+
+// broadcaster is a running broadcasting client and the settings it was
+// started with.
+type broadcaster struct {
+	settings model.Broadcasting
+	client   *broadcasting.Client
 }
 
 type pendingState struct {
@@ -58,15 +79,48 @@ func (s *ServerService) ensureLogTailing(server *model.Server, instance *trackin
 	}()
 }
 
+// ensureBroadcasting starts a broadcasting client for the server when
+// broadcasting.json enables the API, and restarts it when the settings
+// changed.
+func (s *ServerService) ensureBroadcasting(server *model.Server, instance *tracking.AccServerInstance) {
+	settings, err := s.configService.GetBroadcasting(server)
+	if err != nil {
+		logging.Warn("Failed to read broadcasting settings of server %s: %v", server.ID, err)
+		return
+	}
+	if running, exists := s.broadcasters.Load(server.ID); exists {
+		if running.(*broadcaster).settings == *settings {
+			return
+		}
+		running.(*broadcaster).client.Stop()
+		s.broadcasters.Delete(server.ID)
+	}
+	if settings.UdpListenerPort.ToInt() == 0 {
+		return
+	}
+
+	client := broadcasting.NewClient(broadcasting.Config{
+		Address:            fmt.Sprintf("127.0.0.1:%d", settings.UdpListenerPort.ToInt()),
+		DisplayName:        "ACC Server Manager",
+		ConnectionPassword: settings.ConnectionPassword,
+		CommandPassword:    settings.CommandPassword,
+	})
+	instance.AttachBroadcasting(client)
+	s.broadcasters.Store(server.ID, &broadcaster{settings: *settings, client: client})
+	graceful.GetManager().RunGoroutine(client.Run)
+}
+
 func NewServerService(
 	repository *repository.ServerRepository,
 	stateHistoryRepo *repository.StateHistoryRepository,
 	apiService *ServiceControlService,
 	configService *ConfigService,
 	steamService *SteamService,
-	windowsService *WindowsService,
+	serviceManager ServiceManager,
 	firewallService *FirewallService,
 	webSocketService *WebSocketService,
+	playerService *PlayerService,
+	resultService *ResultService,
 ) *ServerService {
 	service := &ServerService{
 		repository:       repository,
@@ -74,9 +128,12 @@ func NewServerService(
 		apiService:       apiService,
 		configService:    configService,
 		steamService:     steamService,
-		windowsService:   windowsService,
+		installer:        steamService,
+		serviceManager:   serviceManager,
 		firewallService:  firewallService,
 		webSocketService: webSocketService,
+		playerService:    playerService,
+		resultService:    resultService,
 	}
 
 	servers, err := repository.GetAll(context.Background(), &model.ServerFilter{})
@@ -125,6 +182,39 @@ func (s *ServerService) getNextSessionID(serverID uuid.UUID) uuid.UUID {
 	return uuid.New()
 }
 
+// currentSessionID returns the ID of the server session in progress, or
+// uuid.Nil before the first session change was seen.
+func (s *ServerService) currentSessionID(serverID uuid.UUID) uuid.UUID {
+	if sessionID, ok := s.sessionIDs.Load(serverID); ok {
+		return sessionID.(uuid.UUID)
+	}
+	return uuid.Nil
+}
+
+// PlayersOnline returns how many players the server's log reports as online,
+// 0 when its runtime is not tracked.
+func (s *ServerService) PlayersOnline(serverID uuid.UUID) int {
+	instance, ok := s.instances.Load(serverID)
+	if !ok {
+		return 0
+	}
+	state := instance.(*tracking.AccServerInstance).State
+	state.RLock()
+	defer state.RUnlock()
+	return state.PlayerCount
+}
+
+// RecentLogLines returns up to n of the latest lines of the server's log and
+// when the last one was read.
+func (s *ServerService) RecentLogLines(serverID uuid.UUID, n int) ([]string, time.Time) {
+	tailer, ok := s.logTailers.Load(serverID)
+	if !ok {
+		return nil, time.Time{}
+	}
+	t := tailer.(*tracking.LogTailer)
+	return t.RecentLines(n), t.LastActivity()
+}
+
 func (s *ServerService) insertStateHistory(serverID uuid.UUID, state *model.ServerState) {
 	currentSessionInterface, exists := s.instances.Load(serverID)
 	var sessionID uuid.UUID
@@ -200,8 +290,21 @@ func (s *ServerService) GenerateServerPath(server *model.Server) {
 	server.FromSteamCMD = true
 }
 
+// SetInstaller replaces SteamCMD as the tool that installs the server files
+// of new and reinstalled servers.
+func (s *ServerService) SetInstaller(installer ServerInstaller) {
+	s.installer = installer
+}
+
+// SetWebhookService enables notifications about player counts and server
+// creation.
+func (s *ServerService) SetWebhookService(webhookService *WebhookService) {
+	s.webhookService = webhookService
+}
+
 func (s *ServerService) handleStateChange(server *model.Server, state *model.ServerState) {
 	s.updateSessionDuration(server, state.Session)
+	s.webhookService.PlayerCountChanged(server, state.PlayerCount)
 
 	s.apiService.statusCache.InvalidateStatus(server.ServiceName)
 
@@ -230,13 +333,55 @@ func (s *ServerService) handleStateChange(server *model.Server, state *model.Ser
 	}
 }
 
+// AddSessionChangeListener registers fn to be called, on its own goroutine,
+// whenever the log of a tracked server reports a session change.
+func (s *ServerService) AddSessionChangeListener(fn func(serverID uuid.UUID, from, to model.TrackSession)) {
+	s.sessionListenersMu.Lock()
+	defer s.sessionListenersMu.Unlock()
+	s.sessionListeners = append(s.sessionListeners, fn)
+}
+
+func (s *ServerService) notifySessionChange(serverID uuid.UUID, from, to model.TrackSession) {
+	s.sessionListenersMu.RLock()
+	defer s.sessionListenersMu.RUnlock()
+	for _, fn := range s.sessionListeners {
+		go fn(serverID, from, to)
+	}
+}
+
 func (s *ServerService) StartAccServerRuntime(server *model.Server) {
 	instanceInterface, exists := s.instances.Load(server.ID)
 	var instance *tracking.AccServerInstance
 	if !exists {
 		instance = tracking.NewAccServerInstance(server, func(state *model.ServerState, states ...tracking.StateChange) {
 			s.handleStateChange(server, state)
+			for _, change := range states {
+				if change == tracking.PlayerCount {
+					s.webSocketService.BroadcastLive(server.ID, model.MessageTypePlayerCount, model.PlayerCountMessage{PlayerCount: state.PlayerCount})
+				}
+			}
 		})
+		instance.OnPlayerChange = func(player model.PlayerState) {
+			s.playerService.RecordPlayer(server.ID, s.currentSessionID(server.ID), player)
+			switch {
+			case !player.IsConnected:
+				s.webSocketService.BroadcastLive(server.ID, model.MessageTypeDriverLeft, model.DriverMessage{Player: player})
+			case player.CarID == 0:
+				s.webSocketService.BroadcastLive(server.ID, model.MessageTypeDriverJoined, model.DriverMessage{Player: player})
+			}
+		}
+		instance.OnSessionChange = func(from, to model.TrackSession) {
+			s.notifySessionChange(server.ID, from, to)
+			s.webSocketService.BroadcastLive(server.ID, model.MessageTypeSessionChanged, model.SessionChangedMessage{From: from, To: to})
+		}
+		instance.OnLapCompleted = func(car model.PlayerState, sessionBest bool) {
+			lap := model.NewLapMessage(car)
+			s.webSocketService.BroadcastLive(server.ID, model.MessageTypeLapCompleted, lap)
+			if sessionBest {
+				s.webSocketService.BroadcastLive(server.ID, model.MessageTypeBestLap, lap)
+			}
+		}
+		s.playerService.CloseOpenSessions(server.ID)
 		s.instances.Store(server.ID, instance)
 	} else {
 		instance = instanceInterface.(*tracking.AccServerInstance)
@@ -248,6 +393,9 @@ func (s *ServerService) StartAccServerRuntime(server *model.Server) {
 	s.updateSessionDuration(server, instance.State.Session)
 
 	s.ensureLogTailing(server, instance)
+	s.ensureBroadcasting(server, instance)
+	s.configService.WatchConfigFiles(server)
+	s.resultService.WatchResults(server)
 }
 
 //	   		context.Context: Application context
@@ -307,6 +455,8 @@ func (as *ServerService) GetById(ctx *fiber.Ctx, serverID uuid.UUID) (*model.Ser
 	return server, nil
 }
 
+// CreateServerAsync queues a job that creates the server. Its progress can
+// be followed through the server's creation topic and the job.
 func (s *ServerService) CreateServerAsync(ctx *fiber.Ctx, server *model.Server) error {
 	logging.Info("create server start")
 	if err := server.Validate(); err != nil {
@@ -316,20 +466,107 @@ func (s *ServerService) CreateServerAsync(ctx *fiber.Ctx, server *model.Server)
 
 	s.GenerateServerPath(server)
 
-	bgCtx := context.Background()
+	payload, err := json.Marshal(createServerPayload{
+		Name:        server.Name,
+		Path:        server.Path,
+		ServiceName: server.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
 
-	go func() {
-		logging.Info("create server start background")
-		if err := s.createServerBackground(bgCtx, server); err != nil {
-			logging.Error("Async server creation failed for server %s: %v", server.ID, err)
-			s.webSocketService.BroadcastError(server.ID, "Server creation failed", err.Error())
-			s.webSocketService.BroadcastComplete(server.ID, false, fmt.Sprintf("Server creation failed: %v", err))
+	s.beginCreation(server)
+	job := &model.Job{Type: model.JobServerCreate, ServerID: server.ID, Payload: string(payload)}
+	if err := s.jobService.Enqueue(ctx.UserContext(), job); err != nil {
+		s.creations.Delete(server.ID)
+		return err
+	}
+
+	return nil
+}
+
+// createServerPayload is what a server.create job needs to create the
+// server.
+type createServerPayload struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	ServiceName string `json:"serviceName"`
+}
+
+// createJobServer returns the server a server.create job creates.
+func createJobServer(job *model.Job) (*model.Server, error) {
+	var payload createServerPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("invalid server.create job: %w", err)
+	}
+	return &model.Server{
+		ID:           job.ServerID,
+		Name:         payload.Name,
+		Path:         payload.Path,
+		ServiceName:  payload.ServiceName,
+		FromSteamCMD: true,
+	}, nil
+}
+
+func (s *ServerService) runCreateJob(ctx context.Context, run *JobRun) error {
+	server, err := createJobServer(run.Job)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.creations.Load(server.ID); !ok {
+		s.beginCreation(server)
+	}
+
+	logging.Info("create server start background")
+	if err := s.createServerBackground(ctx, server, run); err != nil {
+		logging.Error("Async server creation failed for server %s: %v", server.ID, err)
+		s.webSocketService.BroadcastError(server.ID, "Server creation failed", err.Error())
+		s.finishCreation(server.ID, false, fmt.Sprintf("Server creation failed: %v", err))
+		s.webhookService.NotifyServer(server, model.WebhookServerCreateFailed,
+			fmt.Sprintf("Server creation failed: %v", err), map[string]interface{}{"error": err.Error()})
+		return err
+	}
+	return nil
+}
+
+// recoverCreateJob undoes a creation the manager stopped in the middle of,
+// up to and including the step it was at.
+func (s *ServerService) recoverCreateJob(ctx context.Context, job *model.Job) error {
+	server, err := createJobServer(job)
+	if err != nil {
+		return err
+	}
+	if job.Step == "" {
+		return nil
+	}
+	if job.Step == string(model.StepCompleted) {
+		job.Status = model.JobSucceeded
+		job.Error = ""
+		return nil
+	}
+
+	var started []createServerStep
+	for _, stepType := range createServerStepTypes {
+		started = append(started, createServerStep{stepType: stepType})
+		if string(stepType) == job.Step {
+			break
 		}
-	}()
+	}
+	// Without a configuration there are no ports, and no firewall rules
+	// were created for them.
+	tcpPorts, udpPorts, _ := s.serverPorts(server)
+	s.rollbackSteps(ctx, server, started, tcpPorts, udpPorts)
 
+	job.Status = model.JobRolledBack
+	s.webhookService.NotifyServer(server, model.WebhookServerCreateFailed,
+		"Server creation was interrupted by a restart and rolled back", map[string]interface{}{"error": job.Error})
 	return nil
 }
 
+func (s *ServerService) createJobCancelled(ctx context.Context, job *model.Job) {
+	s.finishCreation(job.ServerID, false, "Server creation cancelled")
+}
+
 type createServerStep struct {
 	stepType    model.ServerCreationStep
 	important   bool
@@ -337,7 +574,21 @@ type createServerStep struct {
 	description string
 }
 
-func (s *ServerService) createServerBackground(ctx context.Context, server *model.Server) error {
+// createServerStepTypes are the steps of a creation, in the order
+// createServerBackground runs them.
+var createServerStepTypes = []model.ServerCreationStep{
+	model.StepValidation,
+	model.StepDirectoryCreation,
+	model.StepSteamDownload,
+	model.StepConfigGeneration,
+	model.StepServiceCreation,
+	model.StepFirewallRules,
+	model.StepDatabaseSave,
+}
+
+// createServerBackground runs the steps that create a server, reporting them
+// to run. When one fails or ctx is cancelled, the steps that ran are undone.
+func (s *ServerService) createServerBackground(ctx context.Context, server *model.Server, run *JobRun) error {
 	var serverPort int
 	var tcpPorts, udpPorts []int
 
@@ -366,8 +617,17 @@ func (s *ServerService) createServerBackground(ctx context.Context, server *mode
 			important:   true,
 			description: "Server files downloaded successfully",
 			callback: func() (string, error) {
-				if err := s.steamService.InstallServerWithWebSocket(ctx, server.Path, &server.ID, s.webSocketService); err != nil {
-					return "", fmt.Errorf("failed to install server: %v", err)
+				unlock, err := s.jobService.LockSteamCMD(ctx)
+				if err != nil {
+					return "", fmt.Errorf("failed to install server: %w", err)
+				}
+				defer unlock()
+
+				if err := s.installer.InstallServerWithCallbacks(ctx, server.Path, &server.ID, func(serverID uuid.UUID, output string, isError bool) {
+					s.webSocketService.BroadcastSteamOutput(serverID, output, isError)
+					run.Log(output)
+				}); err != nil {
+					return "", fmt.Errorf("failed to install server: %w", err)
 				}
 				return "Server files downloaded successfully", nil
 			},
@@ -398,10 +658,10 @@ func (s *ServerService) createServerBackground(ctx context.Context, server *mode
 			callback: func() (string, error) {
 				execPath := filepath.Join(server.GetServerPath(), "accServer.exe")
 				serverWorkingDir := filepath.Join(server.GetServerPath(), "server")
-				if err := s.windowsService.CreateService(ctx, server.ServiceName, execPath, serverWorkingDir, nil); err != nil {
-					return "", fmt.Errorf("failed to create Windows service: %v", err)
+				if err := s.serviceManager.CreateService(ctx, server.ServiceName, execPath, serverWorkingDir, nil); err != nil {
+					return "", fmt.Errorf("failed to create service: %v", err)
 				}
-				return fmt.Sprintf("Windows service '%s' created successfully", server.ServiceName), nil
+				return fmt.Sprintf("Service '%s' created successfully", server.ServiceName), nil
 			},
 		},
 		{
@@ -409,7 +669,6 @@ func (s *ServerService) createServerBackground(ctx context.Context, server *mode
 			important:   false,
 			description: "",
 			callback: func() (string, error) {
-				s.configureFirewall(server)
 				tcpPorts = []int{serverPort}
 				udpPorts = []int{serverPort}
 				if err := s.firewallService.CreateServerRules(server.ServiceName, tcpPorts, udpPorts); err != nil {
@@ -431,32 +690,49 @@ func (s *ServerService) createServerBackground(ctx context.Context, server *mode
 		},
 	}
 
+	// Rolling back must not stop halfway when the creation was cancelled.
+	rollbackCtx := context.WithoutCancel(ctx)
+
 	for i, step := range steps {
-		s.webSocketService.BroadcastStep(server.ID, step.stepType, model.StatusInProgress,
+		if err := ctx.Err(); err != nil {
+			s.rollbackSteps(rollbackCtx, server, steps[:i], tcpPorts, udpPorts)
+			return fmt.Errorf("server creation cancelled: %w", err)
+		}
+
+		run.Step(string(step.stepType), i*100/len(steps))
+		s.creationStep(server.ID, step.stepType, model.StatusInProgress,
 			model.GetStepDescription(step.stepType), "")
 
 		successMessage, err := step.callback()
 		if err != nil {
-			s.webSocketService.BroadcastStep(server.ID, step.stepType, model.StatusFailed,
+			s.creationStep(server.ID, step.stepType, model.StatusFailed,
 				"", err.Error())
 
-			if step.important {
-				s.rollbackSteps(ctx, server, steps[:i], tcpPorts, udpPorts)
+			// The failed step is undone too, as it may have been left
+			// half done.
+			if step.important || errors.Is(err, context.Canceled) {
+				s.rollbackSteps(rollbackCtx, server, steps[:i+1], tcpPorts, udpPorts)
 				return err
 			}
+			run.Log(err.Error())
+		} else {
+			run.Log(successMessage)
 		}
 
-		s.webSocketService.BroadcastStep(server.ID, step.stepType, model.StatusCompleted,
+		s.creationStep(server.ID, step.stepType, model.StatusCompleted,
 			successMessage, "")
 	}
 
 	s.StartAccServerRuntime(server)
 
-	s.webSocketService.BroadcastStep(server.ID, model.StepCompleted, model.StatusCompleted,
+	run.Step(string(model.StepCompleted), 100)
+	s.creationStep(server.ID, model.StepCompleted, model.StatusCompleted,
 		model.GetStepDescription(model.StepCompleted), "")
 
-	s.webSocketService.BroadcastComplete(server.ID, true,
+	s.finishCreation(server.ID, true,
 		fmt.Sprintf("Server '%s' created successfully on port %d", server.Name, serverPort))
+	s.webhookService.NotifyServer(server, model.WebhookServerCreated,
+		fmt.Sprintf("Server created on port %d", serverPort), map[string]interface{}{"port": serverPort})
 
 	return nil
 }
@@ -472,7 +748,7 @@ func (s *ServerService) rollbackSteps(ctx context.Context, server *model.Server,
 				s.firewallService.DeleteServerRules(server.ServiceName, tcpPorts, udpPorts)
 			}
 		case model.StepServiceCreation:
-			s.windowsService.DeleteService(ctx, server.ServiceName)
+			s.serviceManager.DeleteService(ctx, server.ServiceName)
 		case model.StepSteamDownload:
 			s.steamService.UninstallServer(server.Path)
 		}
@@ -485,17 +761,14 @@ func (s *ServerService) DeleteServer(ctx *fiber.Ctx, serverID uuid.UUID) error {
 		return fmt.Errorf("failed to get server details: %v", err)
 	}
 
-	if err := s.windowsService.DeleteService(ctx.UserContext(), server.ServiceName); err != nil {
-		logging.Error("Failed to delete Windows service: %v", err)
+	if err := s.serviceManager.DeleteService(ctx.UserContext(), server.ServiceName); err != nil {
+		logging.Error("Failed to delete service: %v", err)
 	}
 
-	configuration, err := s.configService.GetConfiguration(server)
+	tcpPorts, udpPorts, err := s.serverPorts(server)
 	if err != nil {
-		logging.Error("Failed to get configuration for server %d: %v", server.ID, err)
-	}
-	tcpPorts := []int{configuration.TcpPort.ToInt()}
-	udpPorts := []int{configuration.UdpPort.ToInt()}
-	if err := s.firewallService.DeleteServerRules(server.ServiceName, tcpPorts, udpPorts); err != nil {
+		logging.Error("Failed to get configuration for server %s: %v", server.ID, err)
+	} else if err := s.firewallService.DeleteServerRules(server.ServiceName, tcpPorts, udpPorts); err != nil {
 		logging.Error("Failed to delete firewall rules: %v", err)
 	}
 
@@ -511,51 +784,74 @@ func (s *ServerService) DeleteServer(ctx *fiber.Ctx, serverID uuid.UUID) error {
 		tailer.(*tracking.LogTailer).Stop()
 		s.logTailers.Delete(server.ID)
 	}
+	s.configService.StopWatchingConfigFiles(server.ID)
+	s.resultService.StopWatchingResults(server.ID)
 	s.instances.Delete(server.ID)
 	s.lastInsertTimes.Delete(server.ID)
 	s.debouncers.Delete(server.ID)
 	s.sessionIDs.Delete(server.ID)
+	if running, exists := s.broadcasters.LoadAndDelete(server.ID); exists {
+		running.(*broadcaster).client.Stop()
+	}
+	s.creations.Delete(server.ID)
+	s.webSocketService.ClearHistory(model.ServerCreationTopic(server.ID))
 
 	s.apiService.statusCache.InvalidateStatus(server.ServiceName)
 
 	return nil
 }
 
-func (s *ServerService) configureFirewall(server *model.Server) error {
-	ports, err := network.FindAvailablePortRange(DefaultStartPort, RequiredPortCount)
+func (s *ServerService) updateServerPort(server *model.Server, port int) error {
+	config, err := s.configService.GetConfiguration(server)
 	if err != nil {
-		return fmt.Errorf("failed to find available ports: %v", err)
+		return fmt.Errorf("failed to load server configuration: %v", err)
 	}
 
-	serverPort := ports[0]
-	tcpPorts := []int{serverPort}
-	udpPorts := []int{serverPort}
+	config.TcpPort = model.IntString(port)
+	config.UdpPort = model.IntString(port)
+
+	if err := s.configService.SaveConfiguration(server, config); err != nil {
+		return fmt.Errorf("failed to save server configuration: %v", err)
+	}
 
-	logging.Info("Configuring firewall for server %d with port %d", server.ID, serverPort)
+	return nil
+}
 
-	if err := s.firewallService.UpdateServerRules(server.Name, tcpPorts, udpPorts); err != nil {
-		return fmt.Errorf("failed to configure firewall: %v", err)
+func (s *ServerService) serverPorts(server *model.Server) ([]int, []int, error) {
+	configuration, err := s.configService.GetConfiguration(server)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load server configuration: %v", err)
 	}
+	return []int{configuration.TcpPort.ToInt()}, []int{configuration.UdpPort.ToInt()}, nil
+}
 
-	if err := s.updateServerPort(server, serverPort); err != nil {
-		return fmt.Errorf("failed to update server configuration: %v", err)
+// GetFirewallRules lists the firewall rules that exist for a server.
+func (s *ServerService) GetFirewallRules(ctx *fiber.Ctx, serverID uuid.UUID) ([]model.FirewallRule, error) {
+	server, err := s.repository.GetByID(ctx.UserContext(), serverID)
+	if err != nil {
+		return nil, err
+	}
+	if server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
 	}
 
-	return nil
+	return s.firewallService.ListServerRules(server.ServiceName)
 }
 
-func (s *ServerService) updateServerPort(server *model.Server, port int) error {
-	config, err := s.configService.GetConfiguration(server)
+// ReconcileFirewallRules makes the firewall match the ports in configuration.json.
+func (s *ServerService) ReconcileFirewallRules(ctx *fiber.Ctx, serverID uuid.UUID) (*model.FirewallReconcileResult, error) {
+	server, err := s.repository.GetByID(ctx.UserContext(), serverID)
 	if err != nil {
-		return fmt.Errorf("failed to load server configuration: %v", err)
+		return nil, err
+	}
+	if server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
 	}
 
-	config.TcpPort = model.IntString(port)
-	config.UdpPort = model.IntString(port)
-
-	if err := s.configService.SaveConfiguration(server, config); err != nil {
-		return fmt.Errorf("failed to save server configuration: %v", err)
+	tcpPorts, udpPorts, err := s.serverPorts(server)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return s.firewallService.ReconcileServerRules(server.ServiceName, tcpPorts, udpPorts)
 }
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// serverCreationRetention is how long the state of a finished creation can
+// still be fetched.
+const serverCreationRetention = time.Hour
+
+// serverCreation is the progress of a server being created.
+type serverCreation struct {
+	mu    sync.Mutex
+	state model.ServerCreationState
+}
+
+func (s *ServerService) beginCreation(server *model.Server) {
+	s.creations.Store(server.ID, &serverCreation{state: model.ServerCreationState{
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		Status:     model.StatusInProgress,
+		StartedAt:  time.Now().UTC(),
+	}})
+}
+
+// creationStep records a step of a creation and sends it to the server's
+// creation topic.
+func (s *ServerService) creationStep(serverID uuid.UUID, step model.ServerCreationStep, status model.StepStatus, message string, errorMsg string) {
+	if value, ok := s.creations.Load(serverID); ok {
+		creation := value.(*serverCreation)
+		creation.mu.Lock()
+		creation.state.CurrentStep = step
+		stepMessage := model.StepMessage{Step: step, Status: status, Message: message, Error: errorMsg}
+		found := false
+		for i := range creation.state.Steps {
+			if creation.state.Steps[i].Step == step {
+				creation.state.Steps[i] = stepMessage
+				found = true
+			}
+		}
+		if !found {
+			creation.state.Steps = append(creation.state.Steps, stepMessage)
+		}
+		creation.mu.Unlock()
+	}
+
+	s.webSocketService.BroadcastStep(serverID, step, status, message, errorMsg)
+}
+
+// finishCreation records the outcome of a creation and sends it to the
+// server's creation topic.
+func (s *ServerService) finishCreation(serverID uuid.UUID, success bool, message string) {
+	if value, ok := s.creations.Load(serverID); ok {
+		creation := value.(*serverCreation)
+		creation.mu.Lock()
+		creation.state.Status = model.StatusCompleted
+		if !success {
+			creation.state.Status = model.StatusFailed
+		}
+		creation.state.Message = message
+		finishedAt := time.Now().UTC()
+		creation.state.FinishedAt = &finishedAt
+		creation.mu.Unlock()
+
+		time.AfterFunc(serverCreationRetention, func() {
+			s.creations.CompareAndDelete(serverID, creation)
+		})
+	}
+
+	s.webSocketService.BroadcastComplete(serverID, success, message)
+}
+
+// GetCreationState returns the progress of a server that is being created
+// or was created within the last hour.
+func (s *ServerService) GetCreationState(serverID uuid.UUID) (*model.ServerCreationState, error) {
+	value, ok := s.creations.Load(serverID)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotFound, "No creation found for this server")
+	}
+
+	// LastSeq is read first, so a client resuming from it may see a message
+	// twice but never misses one.
+	lastSeq := s.webSocketService.LastSeq(model.ServerCreationTopic(serverID))
+	creation := value.(*serverCreation)
+	creation.mu.Lock()
+	state := creation.state
+	state.Steps = append([]model.StepMessage{}, creation.state.Steps...)
+	creation.mu.Unlock()
+	state.LastSeq = lastSeq
+	return &state, nil
+}
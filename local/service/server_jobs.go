@@ -0,0 +1,345 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/env"
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SetJobService runs the creation, reinstalls and backups of servers as
+// jobs.
+func (s *ServerService) SetJobService(jobService *JobService) {
+	s.jobService = jobService
+	jobService.RegisterHandler(model.JobServerCreate, JobHandler{
+		Run:       s.runCreateJob,
+		Recover:   s.recoverCreateJob,
+		Cancelled: s.createJobCancelled,
+	})
+	jobService.RegisterHandler(model.JobServerReinstall, JobHandler{
+		Run:     s.runReinstallJob,
+		Recover: s.recoverReinstallJob,
+	})
+	jobService.RegisterHandler(model.JobServerBackup, JobHandler{
+		Run:     s.runBackupJob,
+		Recover: s.recoverBackupJob,
+	})
+}
+
+// Reinstall queues a job that installs the ACC server files of a server
+// again through SteamCMD, keeping its configuration.
+func (s *ServerService) Reinstall(ctx context.Context, serverID uuid.UUID) (*model.Job, error) {
+	server, err := s.jobServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if !server.FromSteamCMD {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "server files are not managed by SteamCMD")
+	}
+
+	busy, err := s.jobService.HasUnfinished(ctx, server.ID, model.JobServerCreate, model.JobServerUpdate, model.JobServerReinstall)
+	if err != nil {
+		return nil, err
+	}
+	if busy {
+		return nil, fiber.NewError(fiber.StatusConflict, "an install or update is already queued or running for this server")
+	}
+
+	job := &model.Job{Type: model.JobServerReinstall, ServerID: server.ID}
+	if err := s.jobService.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Backup queues a job that archives the configuration and results of a
+// server into its backups directory.
+func (s *ServerService) Backup(ctx context.Context, serverID uuid.UUID) (*model.Job, error) {
+	server, err := s.jobServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	busy, err := s.jobService.HasUnfinished(ctx, server.ID, model.JobServerBackup)
+	if err != nil {
+		return nil, err
+	}
+	if busy {
+		return nil, fiber.NewError(fiber.StatusConflict, "a backup is already queued or running for this server")
+	}
+
+	job := &model.Job{Type: model.JobServerBackup, ServerID: server.ID}
+	if err := s.jobService.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *ServerService) jobServer(ctx context.Context, serverID uuid.UUID) (*model.Server, error) {
+	server, err := s.repository.GetByID(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+	return server, nil
+}
+
+// runReinstallJob backs up the configuration, stops the server, runs
+// SteamCMD and restores the configuration. A server that was running is
+// started again, whether the install worked or not.
+func (s *ServerService) runReinstallJob(ctx context.Context, run *JobRun) error {
+	server, err := s.jobServer(ctx, run.Job.ServerID)
+	if err != nil {
+		return err
+	}
+
+	run.Step("backup", 10)
+	backupPath, err := s.backupServer(ctx, server, false)
+	if err != nil {
+		return err
+	}
+	run.SetResult(backupPath)
+	run.Log(fmt.Sprintf("Configuration backed up to %s", backupPath))
+
+	run.Step("waiting_for_steamcmd", 20)
+	unlock, err := s.jobService.LockSteamCMD(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.apiService.InvalidateStatus(server.ServiceName)
+	status, err := s.apiService.GetCachedStatus(server.ServiceName)
+	if err != nil {
+		return fmt.Errorf("failed to get server status: %v", err)
+	}
+	wasRunning := model.ParseServiceStatus(status) == model.StatusRunning
+	if wasRunning {
+		run.Step("stopping", 30)
+		if _, err := s.apiService.StopServer(server.ServiceName); err != nil {
+			return fmt.Errorf("failed to stop server: %v", err)
+		}
+	}
+
+	run.Step("installing", 40)
+	installErr := s.installer.InstallServerWithCallbacks(ctx, server.Path, &server.ID, func(serverID uuid.UUID, output string, isError bool) {
+		s.webSocketService.BroadcastSteamOutput(serverID, output, isError)
+		run.Log(output)
+	})
+	if installErr == nil {
+		run.Step("verifying", 80)
+		var version string
+		if version, installErr = accServerVersion(server.Path); installErr == nil {
+			run.Log(fmt.Sprintf("Installed %s", version))
+		}
+	}
+
+	run.Step("restoring_config", 85)
+	if err := restoreConfig(server, backupPath); err != nil {
+		return fmt.Errorf("failed to restore configuration from %s: %v", backupPath, err)
+	}
+
+	if wasRunning {
+		run.Step("starting", 90)
+		if _, err := s.apiService.StartServer(server.ServiceName); err != nil {
+			if installErr != nil {
+				return fmt.Errorf("%w; failed to start server: %v", installErr, err)
+			}
+			return fmt.Errorf("failed to start server: %v", err)
+		}
+	}
+	return installErr
+}
+
+// recoverReinstallJob puts back the configuration an interrupted reinstall
+// backed up.
+func (s *ServerService) recoverReinstallJob(ctx context.Context, job *model.Job) error {
+	if job.Result == "" {
+		return nil
+	}
+	server, err := s.jobServer(ctx, job.ServerID)
+	if err != nil {
+		return err
+	}
+	if err := restoreConfig(server, job.Result); err != nil {
+		return err
+	}
+	job.Status = model.JobRolledBack
+	return nil
+}
+
+func (s *ServerService) runBackupJob(ctx context.Context, run *JobRun) error {
+	server, err := s.jobServer(ctx, run.Job.ServerID)
+	if err != nil {
+		return err
+	}
+
+	run.Step("archiving", 10)
+	backupPath, err := s.backupServer(ctx, server, true)
+	if err != nil {
+		return err
+	}
+	run.SetResult(backupPath)
+	run.Log(fmt.Sprintf("Backup written to %s", backupPath))
+	return nil
+}
+
+// recoverBackupJob removes the archive an interrupted backup was writing.
+func (s *ServerService) recoverBackupJob(ctx context.Context, job *model.Job) error {
+	server, err := s.jobServer(ctx, job.ServerID)
+	if err != nil {
+		return err
+	}
+	partial, _ := filepath.Glob(filepath.Join(backupDir(server), "*.zip.tmp"))
+	for _, path := range partial {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	job.Status = model.JobRolledBack
+	return nil
+}
+
+// backupDir is where the backups of a server are kept, apart from its install
+// path so that uninstalling the server does not delete them.
+func backupDir(server *model.Server) string {
+	return filepath.Join(env.GetBackupDir(), server.ID.String())
+}
+
+// backupServer archives the cfg directory of a server, and its results
+// when asked to, and returns the path of the archive. The archive is only
+// put in place once it is complete.
+func (s *ServerService) backupServer(ctx context.Context, server *model.Server, withResults bool) (path string, err error) {
+	if err := os.MkdirAll(backupDir(server), 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	path = filepath.Join(backupDir(server), fmt.Sprintf("backup-%s.zip", time.Now().UTC().Format("20060102-150405.000")))
+	tmpPath := path + ".tmp"
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	archive := zip.NewWriter(file)
+	dirs := []string{server.GetConfigPath()}
+	if withResults {
+		dirs = append(dirs, server.GetResultsPath())
+	}
+	for _, dir := range dirs {
+		if err = addToArchive(ctx, archive, server.GetServerPath(), dir); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", dir, err)
+		}
+	}
+	if err = archive.Close(); err != nil {
+		return "", fmt.Errorf("failed to write backup: %v", err)
+	}
+	if err = file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write backup: %v", err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return "", fmt.Errorf("failed to write backup: %v", err)
+	}
+	return path, nil
+}
+
+// addToArchive adds the files under dir to archive, named by their path
+// relative to root. A missing dir adds nothing.
+func addToArchive(ctx context.Context, archive *zip.Writer, root, dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		writer, err := archive.Create(filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(writer, file)
+		return err
+	})
+}
+
+// restoreConfig writes the cfg files in a backup back into the server's
+// cfg directory.
+func restoreConfig(server *model.Server, backupPath string) error {
+	archive, err := zip.OpenReader(backupPath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	configPath := server.GetConfigPath()
+	for _, entry := range archive.File {
+		name, found := strings.CutPrefix(entry.Name, "cfg/")
+		if !found || name == "" || strings.HasSuffix(name, "/") {
+			continue
+		}
+		target := filepath.Join(configPath, filepath.FromSlash(name))
+		if !strings.HasPrefix(target, filepath.Clean(configPath)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid file in backup: %s", entry.Name)
+		}
+		if err := restoreFile(entry, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreFile(entry *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	source, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, source); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
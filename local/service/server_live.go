@@ -0,0 +1,30 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/tracking"
+
+	"github.com/google/uuid"
+)
+
+// LiveSnapshot returns the current state of a tracked server for its live
+// channel, or nil when the server has no runtime.
+func (s *ServerService) LiveSnapshot(serverID uuid.UUID) *model.LiveSnapshotMessage {
+	value, ok := s.instances.Load(serverID)
+	if !ok {
+		return nil
+	}
+	instance := value.(*tracking.AccServerInstance)
+
+	snapshot := &model.LiveSnapshotMessage{Players: instance.Players()}
+	state := instance.State
+	state.RLock()
+	snapshot.Session = state.Session
+	snapshot.SessionStart = state.SessionStart
+	snapshot.PlayerCount = state.PlayerCount
+	snapshot.Track = state.Track
+	snapshot.MaxConnections = state.MaxConnections
+	snapshot.Cars = append([]model.PlayerState{}, state.Cars...)
+	state.RUnlock()
+	return snapshot
+}
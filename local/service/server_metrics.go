@@ -0,0 +1,112 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/metrics"
+	"acc-server-manager/local/utl/tracking"
+	"time"
+)
+
+var (
+	metricSessions = []model.TrackSession{model.SessionPractice, model.SessionQualify, model.SessionRace, model.SessionUnknown}
+	metricStatuses = []model.ServiceStatus{
+		model.StatusUnknown, model.StatusStopped, model.StatusStopping,
+		model.StatusRestarting, model.StatusStarting, model.StatusRunning,
+	}
+)
+
+// serverMetrics is a copy of what a tracked server reports, taken once per
+// gauge on every scrape.
+type serverMetrics struct {
+	id             string
+	name           string
+	serviceName    string
+	players        int
+	maxConnections int
+	session        model.TrackSession
+	sessionStart   time.Time
+}
+
+// RegisterMetrics adds gauges for every server with a tracked runtime to
+// registry. Sessions and service statuses are reported as one series per
+// value, set to 1 for the current one.
+func (s *ServerService) RegisterMetrics(registry *metrics.Registry) {
+	labels := []string{"server_id", "server_name"}
+
+	registry.NewGaugeFunc("acc_server_players", "Players connected to the server.", labels,
+		func(emit func(float64, ...string)) {
+			for _, server := range s.serverMetrics() {
+				emit(float64(server.players), server.id, server.name)
+			}
+		})
+	registry.NewGaugeFunc("acc_server_max_connections", "Maximum connections the server accepts.", labels,
+		func(emit func(float64, ...string)) {
+			for _, server := range s.serverMetrics() {
+				emit(float64(server.maxConnections), server.id, server.name)
+			}
+		})
+	registry.NewGaugeFunc("acc_server_session_elapsed_seconds", "Time since the current session started with players online.", labels,
+		func(emit func(float64, ...string)) {
+			now := time.Now()
+			for _, server := range s.serverMetrics() {
+				elapsed := 0.0
+				if !server.sessionStart.IsZero() {
+					elapsed = now.Sub(server.sessionStart).Seconds()
+				}
+				emit(elapsed, server.id, server.name)
+			}
+		})
+	registry.NewGaugeFunc("acc_server_session", "Session the server is in (P, Q, R or U).", append(labels, "session"),
+		func(emit func(float64, ...string)) {
+			for _, server := range s.serverMetrics() {
+				for _, session := range metricSessions {
+					emit(boolToFloat(server.session == session), server.id, server.name, string(session))
+				}
+			}
+		})
+	registry.NewGaugeFunc("acc_server_service_status", "Status of the server's service.", append(labels, "status"),
+		func(emit func(float64, ...string)) {
+			for _, server := range s.serverMetrics() {
+				status := model.StatusUnknown
+				if statusStr, err := s.apiService.GetCachedStatus(server.serviceName); err == nil {
+					status = model.ParseServiceStatus(statusStr)
+				}
+				for _, candidate := range metricStatuses {
+					emit(boolToFloat(status == candidate), server.id, server.name, candidate.String())
+				}
+			}
+		})
+}
+
+func (s *ServerService) serverMetrics() []serverMetrics {
+	var servers []serverMetrics
+	s.instances.Range(func(_, value interface{}) bool {
+		instance := value.(*tracking.AccServerInstance)
+		server := serverMetrics{
+			id:          instance.Model.ID.String(),
+			name:        instance.Model.Name,
+			serviceName: instance.Model.ServiceName,
+		}
+		if state := instance.State; state != nil {
+			state.RLock()
+			server.players = state.PlayerCount
+			server.maxConnections = state.MaxConnections
+			server.session = state.Session
+			if server.session == "" {
+				server.session = model.SessionUnknown
+			}
+			server.sessionStart = state.SessionStart
+			state.RUnlock()
+		}
+		servers = append(servers, server)
+		return true
+	})
+	return servers
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
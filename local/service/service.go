@@ -3,6 +3,7 @@ package service
 import (
 	"acc-server-manager/local/repository"
 	"acc-server-manager/local/utl/logging"
+	"acc-server-manager/local/utl/metrics"
 
 	"go.uber.org/dig"
 )
@@ -19,18 +20,46 @@ func InitializeServices(c *dig.Container) {
 	c.Provide(NewServiceControlService)
 	c.Provide(NewConfigService)
 	c.Provide(NewLookupService)
-	c.Provide(NewWindowsService)
+	c.Provide(NewServiceManager)
 	c.Provide(NewFirewallService)
 	c.Provide(NewMembershipService)
 	c.Provide(NewWebSocketService)
 	c.Provide(NewLeaderboardService)
+	c.Provide(NewEntryListService)
+	c.Provide(NewBOPService)
+	c.Provide(NewConfigTemplateService)
+	c.Provide(NewPlayerService)
+	c.Provide(NewResultService)
+	c.Provide(NewScheduleService)
+	c.Provide(NewSupervisorService)
+	c.Provide(NewUpdateService)
+	c.Provide(NewRotationService)
+	c.Provide(NewWebhookService)
+	c.Provide(NewJobService)
 
 	logging.Debug("Initializing service dependencies")
-	err := c.Invoke(func(server *ServerService, api *ServiceControlService, config *ConfigService) {
+	err := c.Invoke(func(server *ServerService, api *ServiceControlService, config *ConfigService, lookups *repository.LookupRepository, webSocket *WebSocketService, schedules *ScheduleService, supervisor *SupervisorService, updates *UpdateService, rotations *RotationService, webhooks *WebhookService, jobs *JobService) {
 		logging.Debug("Setting up service cross-references")
 		api.SetServerService(server)
 		config.SetServerService(server)
-
+		config.SetLookupRepository(lookups)
+		config.SetWebSocketService(webSocket)
+		webSocket.SetServerService(server)
+		webhooks.SetWebSocketService(webSocket)
+		api.SetWebhookService(webhooks)
+		config.SetWebhookService(webhooks)
+		server.SetWebhookService(webhooks)
+		supervisor.SetWebhookService(webhooks)
+		server.AddSessionChangeListener(webhooks.SessionChanged)
+		server.SetJobService(jobs)
+		updates.SetJobService(jobs)
+		webhooks.Start()
+		schedules.Start()
+		supervisor.Start()
+		updates.Start()
+		jobs.Start()
+		rotations.Start()
+		server.RegisterMetrics(metrics.Default)
 	})
 	if err != nil {
 		logging.Panic("unable to initialize services: " + err.Error())
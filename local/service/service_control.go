@@ -5,6 +5,7 @@ import (
 	"acc-server-manager/local/repository"
 	"context"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -14,12 +15,16 @@ type ServiceControlService struct {
 	repository       *repository.ServiceControlRepository
 	serverRepository *repository.ServerRepository
 	serverService    *ServerService
+	webhookService   *WebhookService
 	statusCache      *model.ServerStatusCache
-	windowsService   *WindowsService
+	serviceManager   ServiceManager
+	// requested holds the status each service was last asked to reach, so
+	// that a stop nobody asked for can be told apart.
+	requested sync.Map
 }
 
 func NewServiceControlService(repository *repository.ServiceControlRepository,
-	serverRepository *repository.ServerRepository) *ServiceControlService {
+	serverRepository *repository.ServerRepository, serviceManager ServiceManager) *ServiceControlService {
 	return &ServiceControlService{
 		repository:       repository,
 		serverRepository: serverRepository,
@@ -28,7 +33,7 @@ func NewServiceControlService(repository *repository.ServiceControlRepository,
 			ThrottleTime:   5 * time.Second,
 			DefaultStatus:  model.StatusRunning,
 		}),
-		windowsService: NewWindowsService(),
+		serviceManager: serviceManager,
 	}
 }
 
@@ -36,6 +41,12 @@ func (as *ServiceControlService) SetServerService(serverService *ServerService)
 	as.serverService = serverService
 }
 
+// SetWebhookService enables notifications when servers are started and
+// stopped.
+func (as *ServiceControlService) SetWebhookService(webhookService *WebhookService) {
+	as.webhookService = webhookService
+}
+
 func (as *ServiceControlService) GetStatus(ctx *fiber.Ctx) (string, error) {
 	serviceName, err := as.GetServiceName(ctx)
 	if err != nil {
@@ -123,7 +134,7 @@ func (as *ServiceControlService) ServiceControlRestartServer(ctx *fiber.Ctx) (st
 }
 
 func (as *ServiceControlService) StatusServer(serviceName string) (string, error) {
-	return as.windowsService.Status(context.Background(), serviceName)
+	return as.serviceManager.Status(context.Background(), serviceName)
 }
 
 func (as *ServiceControlService) GetCachedStatus(serviceName string) (string, error) {
@@ -142,7 +153,8 @@ func (as *ServiceControlService) GetCachedStatus(serviceName string) (string, er
 }
 
 func (as *ServiceControlService) StartServer(serviceName string) (string, error) {
-	status, err := as.windowsService.Start(context.Background(), serviceName)
+	as.requested.Store(serviceName, model.StatusRunning)
+	status, err := as.serviceManager.Start(context.Background(), serviceName)
 	if err != nil {
 		return "", err
 	}
@@ -152,11 +164,13 @@ func (as *ServiceControlService) StartServer(serviceName string) (string, error)
 		return "", err
 	}
 	as.serverService.StartAccServerRuntime(server)
+	as.webhookService.NotifyServer(server, model.WebhookServerStarted, "Server started", nil)
 	return status, err
 }
 
 func (as *ServiceControlService) StopServer(serviceName string) (string, error) {
-	status, err := as.windowsService.Stop(context.Background(), serviceName)
+	as.requested.Store(serviceName, model.StatusStopped)
+	status, err := as.serviceManager.Stop(context.Background(), serviceName)
 	if err != nil {
 		return "", err
 	}
@@ -166,12 +180,14 @@ func (as *ServiceControlService) StopServer(serviceName string) (string, error)
 		return "", err
 	}
 	as.serverService.instances.Delete(server.ID)
+	as.webhookService.NotifyServer(server, model.WebhookServerStopped, "Server stopped", nil)
 
 	return status, err
 }
 
 func (as *ServiceControlService) RestartServer(serviceName string) (string, error) {
-	status, err := as.windowsService.Restart(context.Background(), serviceName)
+	as.requested.Store(serviceName, model.StatusRunning)
+	status, err := as.serviceManager.Restart(context.Background(), serviceName)
 	if err != nil {
 		return "", err
 	}
@@ -181,9 +197,26 @@ func (as *ServiceControlService) RestartServer(serviceName string) (string, erro
 		return "", err
 	}
 	as.serverService.StartAccServerRuntime(server)
+	as.webhookService.NotifyServer(server, model.WebhookServerStarted, "Server restarted", map[string]interface{}{"restart": true})
 	return status, err
 }
 
+// RequestedStatus returns the status a service was last asked to reach, or
+// false if it has not been started or stopped since the manager started.
+func (as *ServiceControlService) RequestedStatus(serviceName string) (model.ServiceStatus, bool) {
+	status, ok := as.requested.Load(serviceName)
+	if !ok {
+		return model.StatusUnknown, false
+	}
+	return status.(model.ServiceStatus), true
+}
+
+// InvalidateStatus drops the cached status of a service so the next check
+// asks the service manager.
+func (as *ServiceControlService) InvalidateStatus(serviceName string) {
+	as.statusCache.InvalidateStatus(serviceName)
+}
+
 func (as *ServiceControlService) GetServiceName(ctx *fiber.Ctx) (string, error) {
 	var server *model.Server
 	var err error
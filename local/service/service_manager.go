@@ -1,56 +1,37 @@
 package service
 
 import (
-	"acc-server-manager/local/utl/command"
-	"strings"
+	"acc-server-manager/local/utl/env"
+	"acc-server-manager/local/utl/logging"
+	"context"
 )
 
-type ServiceManager struct {
-	executor   *command.CommandExecutor
-	psExecutor *command.CommandExecutor
+// ServiceManager runs ACC server processes as OS-level services.
+// Status returns a string understood by model.ParseServiceStatus.
+type ServiceManager interface {
+	CreateService(ctx context.Context, serviceName, execPath, workingDir string, args []string) error
+	DeleteService(ctx context.Context, serviceName string) error
+	UpdateService(ctx context.Context, serviceName, execPath, workingDir string, args []string) error
+	Status(ctx context.Context, serviceName string) (string, error)
+	Start(ctx context.Context, serviceName string) (string, error)
+	Stop(ctx context.Context, serviceName string) (string, error)
+	Restart(ctx context.Context, serviceName string) (string, error)
 }
 
-func NewServiceManager() *ServiceManager {
-	return &ServiceManager{
-		executor: &command.CommandExecutor{
-			ExePath:   "nssm",
-			LogOutput: true,
-		},
-		psExecutor: &command.CommandExecutor{
-			ExePath:   "powershell",
-			LogOutput: true,
-		},
+// NewServiceManager picks the backend configured through SERVICE_BACKEND.
+func NewServiceManager() ServiceManager {
+	backend := env.GetServiceBackend()
+	logging.Info("Using %s service backend", backend)
+
+	switch backend {
+	case env.ServiceBackendSystemd:
+		return NewSystemdService()
+	case env.ServiceBackendFake:
+		return NewFakeServiceManager()
+	case env.ServiceBackendNSSM:
+		return NewWindowsService()
+	default:
+		logging.Warn("Unknown service backend %q, falling back to %s", backend, env.ServiceBackendNSSM)
+		return NewWindowsService()
 	}
 }
-
-func (s *ServiceManager) ManageService(serviceName, action string) (string, error) {
-	output, err := s.psExecutor.ExecuteWithOutput("-nologo", "-noprofile", ".\\nssm", action, serviceName)
-	if err != nil {
-		return "", err
-	}
-
-	cleaned := strings.TrimSpace(strings.ReplaceAll(output, "\x00", ""))
-	cleaned = strings.TrimSuffix(cleaned, "\r\n")
-
-	return cleaned, nil
-}
-
-func (s *ServiceManager) Status(serviceName string) (string, error) {
-	return s.ManageService(serviceName, "status")
-}
-
-func (s *ServiceManager) Start(serviceName string) (string, error) {
-	return s.ManageService(serviceName, "start")
-}
-
-func (s *ServiceManager) Stop(serviceName string) (string, error) {
-	return s.ManageService(serviceName, "stop")
-}
-
-func (s *ServiceManager) Restart(serviceName string) (string, error) {
-	if _, err := s.Stop(serviceName); err != nil {
-		return "", err
-	}
-
-	return s.Start(serviceName)
-}
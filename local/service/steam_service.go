@@ -6,6 +6,7 @@ import (
 	"acc-server-manager/local/utl/command"
 	"acc-server-manager/local/utl/env"
 	"acc-server-manager/local/utl/logging"
+	"acc-server-manager/local/utl/metrics"
 	"acc-server-manager/local/utl/security"
 	"context"
 	"fmt"
@@ -86,131 +87,20 @@ func (s *SteamService) ensureSteamCMD(_ context.Context) error {
 	return nil
 }
 
-func (s *SteamService) InstallServerWithWebSocket(ctx context.Context, installPath string, serverID *uuid.UUID, wsService *WebSocketService) error {
-	if err := s.ensureSteamCMD(ctx); err != nil {
-		wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Error ensuring SteamCMD: %v", err), true)
-		return err
-	}
-
-	if err := s.pathValidator.ValidateInstallPath(installPath); err != nil {
-		wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Invalid installation path: %v", err), true)
-		return fmt.Errorf("invalid installation path: %v", err)
-	}
-
-	absPath, err := filepath.Abs(installPath)
-	if err != nil {
-		wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Failed to get absolute path: %v", err), true)
-		return fmt.Errorf("failed to get absolute path: %v", err)
-	}
-	absPath = filepath.Clean(absPath)
-
-	if err := os.MkdirAll(absPath, 0755); err != nil {
-		wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Failed to create install directory: %v", err), true)
-		return fmt.Errorf("failed to create install directory: %v", err)
-	}
-
-	wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Installation directory prepared: %s", absPath), false)
-
-	creds, err := s.GetCredentials(ctx)
+// InstallServerWithCallbacks installs or updates the server files in
+// installPath with SteamCMD and records how long it took.
+func (s *SteamService) InstallServerWithCallbacks(ctx context.Context, installPath string, serverID *uuid.UUID, outputCallback command.OutputCallback) error {
+	start := time.Now()
+	err := s.installServer(ctx, installPath, serverID, outputCallback)
+	result := metrics.ResultSuccess
 	if err != nil {
-		wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Failed to get Steam credentials: %v", err), true)
-		return fmt.Errorf("failed to get Steam credentials: %v", err)
-	}
-
-	steamCMDPath := env.GetSteamCMDPath()
-
-	steamCMDArgs := []string{
-		"+force_install_dir", absPath,
-		"+login",
-	}
-
-	if creds != nil && creds.Username != "" {
-		wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Using Steam credentials for user: %s", creds.Username), false)
-		steamCMDArgs = append(steamCMDArgs, creds.Username)
-		if creds.Password != "" {
-			steamCMDArgs = append(steamCMDArgs, creds.Password)
-		}
-	} else {
-		wsService.BroadcastSteamOutput(*serverID, "Using anonymous Steam login", false)
-		steamCMDArgs = append(steamCMDArgs, "anonymous")
-	}
-
-	steamCMDArgs = append(steamCMDArgs,
-		"+app_update", ACCServerAppID,
-		"validate",
-		"+quit",
-	)
-
-	args := steamCMDArgs
-
-	wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Starting SteamCMD: %s %s", steamCMDPath, strings.Join(args, " ")), false)
-
-	timeoutCtx, cancel := context.WithTimeout(ctx, 15*time.Minute)
-	defer cancel()
-
-	callbackConfig := &command.CallbackConfig{
-		OnOutput: func(serverID uuid.UUID, output string, isError bool) {
-			wsService.BroadcastSteamOutput(serverID, output, isError)
-		},
-		OnCommand: func(serverID uuid.UUID, command string, args []string, completed bool, success bool, error string) {
-			if completed {
-				if success {
-					wsService.BroadcastSteamOutput(serverID, "Command completed successfully", false)
-				} else {
-					wsService.BroadcastSteamOutput(serverID, fmt.Sprintf("Command failed: %s", error), true)
-				}
-			}
-		},
+		result = metrics.ResultFailure
 	}
-
-	callbackInteractiveExecutor := command.NewCallbackInteractiveCommandExecutor(s.executor, s.tfaManager, callbackConfig, *serverID)
-	callbackInteractiveExecutor.ExePath = steamCMDPath
-
-	if err := callbackInteractiveExecutor.ExecuteInteractive(timeoutCtx, serverID, args...); err != nil {
-		wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("SteamCMD execution failed: %v", err), true)
-		if timeoutCtx.Err() == context.DeadlineExceeded {
-			return fmt.Errorf("SteamCMD operation timed out after 15 minutes - this usually means Steam Guard confirmation is required")
-		}
-		return fmt.Errorf("failed to run SteamCMD: %v", err)
-	}
-
-	wsService.BroadcastSteamOutput(*serverID, "SteamCMD execution completed successfully, proceeding with verification...", false)
-
-	wsService.BroadcastSteamOutput(*serverID, "Waiting for Steam operations to complete...", false)
-	time.Sleep(5 * time.Second)
-
-	exePath := filepath.Join(absPath, "server", "accServer.exe")
-	wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Checking for ACC server executable at: %s", exePath), false)
-
-	if _, err := os.Stat(exePath); os.IsNotExist(err) {
-		wsService.BroadcastSteamOutput(*serverID, "accServer.exe not found, checking directory contents...", false)
-
-		if entries, dirErr := os.ReadDir(absPath); dirErr == nil {
-			wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Contents of %s:", absPath), false)
-			for _, entry := range entries {
-				wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("  - %s (dir: %v)", entry.Name(), entry.IsDir()), false)
-			}
-		}
-
-		serverDir := filepath.Join(absPath, "server")
-		if entries, dirErr := os.ReadDir(serverDir); dirErr == nil {
-			wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Contents of %s:", serverDir), false)
-			for _, entry := range entries {
-				wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("  - %s (dir: %v)", entry.Name(), entry.IsDir()), false)
-			}
-		} else {
-			wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Server directory %s does not exist or cannot be read: %v", serverDir, dirErr), true)
-		}
-
-		wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Server installation failed: accServer.exe not found in %s", exePath), true)
-		return fmt.Errorf("server installation failed: accServer.exe not found in %s", exePath)
-	}
-
-	wsService.BroadcastSteamOutput(*serverID, fmt.Sprintf("Server installation completed successfully - accServer.exe found at %s", exePath), false)
-	return nil
+	metrics.SteamCMDJobDuration.Observe(time.Since(start).Seconds(), result)
+	return err
 }
 
-func (s *SteamService) InstallServerWithCallbacks(ctx context.Context, installPath string, serverID *uuid.UUID, outputCallback command.OutputCallback) error {
+func (s *SteamService) installServer(ctx context.Context, installPath string, serverID *uuid.UUID, outputCallback command.OutputCallback) error {
 	if err := s.ensureSteamCMD(ctx); err != nil {
 		outputCallback(*serverID, fmt.Sprintf("Error ensuring SteamCMD: %v", err), true)
 		return err
@@ -274,6 +164,15 @@ func (s *SteamService) InstallServerWithCallbacks(ctx context.Context, installPa
 
 	callbacks := &command.CallbackConfig{
 		OnOutput: outputCallback,
+		OnCommand: func(serverID uuid.UUID, command string, args []string, completed bool, success bool, error string) {
+			if completed {
+				if success {
+					outputCallback(serverID, "Command completed successfully", false)
+				} else {
+					outputCallback(serverID, fmt.Sprintf("Command failed: %s", error), true)
+				}
+			}
+		},
 	}
 
 	callbackExecutor := command.NewCallbackInteractiveCommandExecutor(s.executor, s.tfaManager, callbacks, *serverID)
@@ -284,13 +183,17 @@ func (s *SteamService) InstallServerWithCallbacks(ctx context.Context, installPa
 		if timeoutCtx.Err() == context.DeadlineExceeded {
 			return fmt.Errorf("SteamCMD operation timed out after 15 minutes - this usually means Steam Guard confirmation is required")
 		}
-		return fmt.Errorf("failed to run SteamCMD: %v", err)
+		return fmt.Errorf("failed to run SteamCMD: %w", err)
 	}
 
 	outputCallback(*serverID, "SteamCMD execution completed successfully, proceeding with verification...", false)
 
 	outputCallback(*serverID, "Waiting for Steam operations to complete...", false)
-	time.Sleep(5 * time.Second)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(5 * time.Second):
+	}
 
 	exePath := filepath.Join(absPath, "server", "accServer.exe")
 	outputCallback(*serverID, fmt.Sprintf("Checking for ACC server executable at: %s", exePath), false)
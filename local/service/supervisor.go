@@ -0,0 +1,389 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/graceful"
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const supervisorTickInterval = 5 * time.Second
+
+// supervisedServer is what the supervisor remembers of a server between
+// checks.
+type supervisedServer struct {
+	seen          bool
+	lastStatus    model.ServiceStatus
+	runningSince  time.Time
+	crash         *model.ServerCrash
+	nextAttemptAt time.Time
+}
+
+// SupervisorService watches the status of every server and restarts the
+// ones that stop without anybody asking, following their restart policy.
+// A crash is a server seen stopped, either on its first check or after being
+// seen running, without a stop being requested. A server whose service keeps
+// running but whose log stays silent for longer than the stall timeout of its
+// policy is treated as hung and restarted the same way. A server restarted by
+// the service manager within a status cache lifetime is not noticed.
+type SupervisorService struct {
+	policyRepository      *repository.RestartPolicyRepository
+	crashRepository       *repository.ServerCrashRepository
+	serverRepository      *repository.ServerRepository
+	serviceControlService *ServiceControlService
+	serverService         *ServerService
+	webhookService        *WebhookService
+
+	mu      sync.Mutex
+	servers map[uuid.UUID]*supervisedServer
+}
+
+func NewSupervisorService(
+	policyRepository *repository.RestartPolicyRepository,
+	crashRepository *repository.ServerCrashRepository,
+	serverRepository *repository.ServerRepository,
+	serviceControlService *ServiceControlService,
+	serverService *ServerService,
+) *SupervisorService {
+	logging.Debug("Initializing SupervisorService")
+	return &SupervisorService{
+		policyRepository:      policyRepository,
+		crashRepository:       crashRepository,
+		serverRepository:      serverRepository,
+		serviceControlService: serviceControlService,
+		serverService:         serverService,
+		servers:               make(map[uuid.UUID]*supervisedServer),
+	}
+}
+
+// SetWebhookService enables notifications about crashes.
+func (s *SupervisorService) SetWebhookService(webhookService *WebhookService) {
+	s.webhookService = webhookService
+}
+
+// Start cancels the restarts a previous run left pending and checks the
+// servers until the application shuts down.
+func (s *SupervisorService) Start() {
+	if err := s.crashRepository.CancelPending(context.Background(), "manager restarted", time.Now().UTC()); err != nil {
+		logging.Error("Failed to cancel pending restarts: %v", err)
+	}
+
+	graceful.GetManager().RunGoroutine(func(ctx context.Context) {
+		ticker := time.NewTicker(supervisorTickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Check(ctx, time.Now().UTC())
+			}
+		}
+	})
+}
+
+// Check polls the status of every server once, recording a crash for each
+// server that stopped or hung without a stop being requested and attempting
+// the restarts that are due.
+func (s *SupervisorService) Check(ctx context.Context, now time.Time) {
+	servers, err := s.serverRepository.GetAll(ctx, &model.ServerFilter{})
+	if err != nil {
+		logging.Error("Failed to get servers to supervise: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[uuid.UUID]bool, len(*servers))
+	for i := range *servers {
+		server := &(*servers)[i]
+		seen[server.ID] = true
+		s.check(ctx, server, now)
+	}
+	for id := range s.servers {
+		if !seen[id] {
+			delete(s.servers, id)
+		}
+	}
+}
+
+func (s *SupervisorService) check(ctx context.Context, server *model.Server, now time.Time) {
+	state, ok := s.servers[server.ID]
+	if !ok {
+		state = &supervisedServer{}
+		s.servers[server.ID] = state
+	}
+
+	statusStr, err := s.serviceControlService.GetCachedStatus(server.ServiceName)
+	if err != nil {
+		logging.Debug("Failed to get status of %s: %v", server.ServiceName, err)
+		return
+	}
+	status := model.ParseServiceStatus(statusStr)
+	requested, known := s.serviceControlService.RequestedStatus(server.ServiceName)
+	stopRequested := known && requested == model.StatusStopped
+
+	switch {
+	case state.crash != nil:
+		s.retry(ctx, server, state, status, stopRequested, now)
+		return
+	case stopRequested:
+		// A stop through the manager is never a crash.
+	case status == model.StatusStopped && (!state.seen || state.lastStatus == model.StatusRunning):
+		s.recordCrash(ctx, server, state, s.policyFor(ctx, server.ID), false, now)
+	case status == model.StatusRunning && state.lastStatus == model.StatusRunning:
+		if policy := s.policyFor(ctx, server.ID); s.stalled(server, state, policy, now) {
+			s.recordCrash(ctx, server, state, policy, true, now)
+		}
+	}
+	if status == model.StatusRunning && state.lastStatus != model.StatusRunning {
+		state.runningSince = now
+	}
+	state.seen = true
+	state.lastStatus = status
+}
+
+// stalled reports whether a running server has logged nothing for longer than
+// the stall timeout of its policy, counting from its last log line or from
+// when it was last seen starting, whichever is later. A server whose log has
+// not been read yet is not judged.
+func (s *SupervisorService) stalled(server *model.Server, state *supervisedServer, policy *model.RestartPolicy, now time.Time) bool {
+	if policy.StallTimeoutMinutes == 0 {
+		return false
+	}
+	_, lastLogAt := s.serverService.RecentLogLines(server.ID, 0)
+	if lastLogAt.IsZero() {
+		return false
+	}
+	since := lastLogAt
+	if state.runningSince.After(since) {
+		since = state.runningSince
+	}
+	return now.Sub(since) > time.Duration(policy.StallTimeoutMinutes)*time.Minute
+}
+
+func (s *SupervisorService) recordCrash(ctx context.Context, server *model.Server, state *supervisedServer, policy *model.RestartPolicy, hung bool, now time.Time) {
+	lines, lastLogAt := s.serverService.RecentLogLines(server.ID, policy.LogLines)
+
+	crash := &model.ServerCrash{
+		ServerID:   server.ID,
+		DetectedAt: now,
+		Hung:       hung,
+		LogTail:    strings.Join(lines, "\n"),
+		Outcome:    model.CrashOutcomePending,
+	}
+	if !lastLogAt.IsZero() {
+		lastLogAt = lastLogAt.UTC()
+		crash.LastLogAt = &lastLogAt
+	}
+
+	switch {
+	case !policy.Enabled:
+		s.resolve(crash, model.CrashOutcomeDisabled, "", now)
+	case policy.MaxRetries == 0:
+		s.resolve(crash, model.CrashOutcomeGaveUp, "no restarts allowed", now)
+	case policy.CrashLoopCount > 0 && s.inCrashLoop(ctx, server.ID, policy, now):
+		s.resolve(crash, model.CrashOutcomeCrashLoop,
+			fmt.Sprintf("%d crashes within %d minutes", policy.CrashLoopCount, policy.CrashLoopWindowMinutes), now)
+	default:
+		state.crash = crash
+		state.nextAttemptAt = now.Add(policy.Backoff(0))
+	}
+	// A hung server left running is judged again only after another full
+	// stall timeout.
+	state.runningSince = now
+
+	if err := s.crashRepository.Insert(ctx, crash); err != nil {
+		logging.Error("Failed to record crash of %s: %v", server.ServiceName, err)
+	}
+	message := "Server stopped unexpectedly"
+	if hung {
+		message = "Server stopped logging"
+	}
+	logging.Warn("%s: %s (%s)", message, server.ServiceName, crash.Outcome)
+	s.webhookService.NotifyServer(server, model.WebhookServerCrashed, message,
+		map[string]interface{}{"crashId": crash.ID.String(), "outcome": crash.Outcome, "hung": hung})
+}
+
+func (s *SupervisorService) inCrashLoop(ctx context.Context, serverID uuid.UUID, policy *model.RestartPolicy, now time.Time) bool {
+	since := now.Add(-time.Duration(policy.CrashLoopWindowMinutes) * time.Minute)
+	count, err := s.crashRepository.CountSince(ctx, serverID, since)
+	if err != nil {
+		logging.Error("Failed to count crashes: %v", err)
+		return false
+	}
+	// The crash being recorded is not stored yet.
+	return int(count)+1 >= policy.CrashLoopCount
+}
+
+func (s *SupervisorService) retry(ctx context.Context, server *model.Server, state *supervisedServer, status model.ServiceStatus, stopRequested bool, now time.Time) {
+	crash := state.crash
+	switch {
+	case stopRequested:
+		s.resolve(crash, model.CrashOutcomeCancelled, "server was stopped", now)
+	case status == model.StatusRunning && !crash.Hung:
+		s.resolve(crash, model.CrashOutcomeCancelled, "server was started", now)
+	case now.Before(state.nextAttemptAt):
+		return
+	default:
+		policy := s.policyFor(ctx, server.ID)
+		crash.Attempts++
+		var err error
+		if crash.Hung {
+			_, err = s.serviceControlService.RestartServer(server.ServiceName)
+		} else {
+			_, err = s.serviceControlService.StartServer(server.ServiceName)
+		}
+		s.serviceControlService.InvalidateStatus(server.ServiceName)
+
+		switch {
+		case err == nil:
+			s.resolve(crash, model.CrashOutcomeRestarted, "", now)
+			logging.Info("Restarted %s after %d attempt(s)", server.ServiceName, crash.Attempts)
+		case crash.Attempts >= policy.MaxRetries:
+			s.resolve(crash, model.CrashOutcomeGaveUp, err.Error(), now)
+			logging.Error("Giving up restarting %s: %v", server.ServiceName, err)
+		default:
+			crash.Error = err.Error()
+			state.nextAttemptAt = now.Add(policy.Backoff(crash.Attempts))
+		}
+	}
+
+	if err := s.crashRepository.Update(ctx, crash); err != nil {
+		logging.Error("Failed to update crash of %s: %v", server.ServiceName, err)
+	}
+	if crash.Outcome != model.CrashOutcomePending {
+		state.crash = nil
+		state.runningSince = now
+		// A restarted server counts as running until the next fresh status,
+		// so a crash straight after the restart is still noticed.
+		if crash.Outcome == model.CrashOutcomeRestarted {
+			state.lastStatus = model.StatusRunning
+		} else {
+			state.lastStatus = status
+		}
+	}
+}
+
+func (s *SupervisorService) resolve(crash *model.ServerCrash, outcome model.CrashOutcome, reason string, now time.Time) {
+	crash.Outcome = outcome
+	crash.Error = reason
+	crash.ResolvedAt = &now
+}
+
+// policyFor returns the restart policy of a server, the default when it has
+// none or it cannot be read.
+func (s *SupervisorService) policyFor(ctx context.Context, serverID uuid.UUID) *model.RestartPolicy {
+	policy, err := s.policyRepository.GetByServerID(ctx, serverID)
+	if err != nil {
+		logging.Error("Failed to get restart policy: %v", err)
+	}
+	if policy == nil {
+		return model.DefaultRestartPolicy(serverID)
+	}
+	return policy
+}
+
+// GetPolicy returns the restart policy of a server.
+func (s *SupervisorService) GetPolicy(ctx context.Context, serverID string) (*model.RestartPolicy, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	return s.policyFor(ctx, server.ID), nil
+}
+
+// UpdatePolicy replaces the restart policy of a server.
+func (s *SupervisorService) UpdatePolicy(ctx context.Context, serverID string, input *model.RestartPolicy) (*model.RestartPolicy, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.policyRepository.GetByServerID(ctx, server.ID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &model.RestartPolicy{ServerID: server.ID}
+	}
+	policy.Enabled = input.Enabled
+	policy.MaxRetries = input.MaxRetries
+	policy.BackoffSeconds = input.BackoffSeconds
+	policy.MaxBackoffSeconds = input.MaxBackoffSeconds
+	policy.CrashLoopCount = input.CrashLoopCount
+	policy.CrashLoopWindowMinutes = input.CrashLoopWindowMinutes
+	policy.LogLines = input.LogLines
+	policy.StallTimeoutMinutes = input.StallTimeoutMinutes
+
+	if policy.ID == uuid.Nil {
+		err = s.policyRepository.Insert(ctx, policy)
+	} else {
+		err = s.policyRepository.Update(ctx, policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *SupervisorService) GetCrashes(ctx context.Context, filter *model.ServerCrashFilter) (*model.FilteredResponse, error) {
+	if _, err := s.getServer(ctx, filter.ServerID); err != nil {
+		return nil, err
+	}
+
+	crashes, err := s.crashRepository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.crashRepository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: crashes,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+func (s *SupervisorService) GetCrash(ctx context.Context, serverID, crashID string) (*model.ServerCrash, error) {
+	crash, err := s.crashRepository.GetByID(ctx, crashID)
+	if err != nil {
+		return nil, err
+	}
+	if crash == nil || crash.ServerID.String() != serverID {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Crash not found")
+	}
+	return crash, nil
+}
+
+func (s *SupervisorService) getServer(ctx context.Context, serverID string) (*model.Server, error) {
+	if _, err := uuid.Parse(serverID); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid server ID")
+	}
+	server, err := s.serverRepository.GetByID(ctx, serverID)
+	if err != nil || server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+	return server, nil
+}
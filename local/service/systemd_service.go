@@ -0,0 +1,200 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/command"
+	"acc-server-manager/local/utl/env"
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const systemdUnitTemplate = `[Unit]
+Description=ACC dedicated server (%s)
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+WorkingDirectory=%s
+ExecStart=%s
+Restart=no
+KillSignal=SIGINT
+TimeoutStopSec=30
+
+[Install]
+WantedBy=multi-user.target
+`
+
+type SystemdService struct {
+	executor *command.CommandExecutor
+	unitDir  string
+	winePath string
+}
+
+func NewSystemdService() *SystemdService {
+	return NewSystemdServiceWithExecutor(&command.CommandExecutor{
+		ExePath:   "systemctl",
+		LogOutput: true,
+	}, env.GetSystemdUnitDir(), env.GetWinePath())
+}
+
+// NewSystemdServiceWithExecutor returns a systemd backend that runs
+// systemctl through executor and writes its units into unitDir.
+func NewSystemdServiceWithExecutor(executor *command.CommandExecutor, unitDir, winePath string) *SystemdService {
+	return &SystemdService{
+		executor: executor,
+		unitDir:  unitDir,
+		winePath: winePath,
+	}
+}
+
+func (s *SystemdService) unitName(serviceName string) string {
+	return serviceName + ".service"
+}
+
+func (s *SystemdService) unitPath(serviceName string) string {
+	return filepath.Join(s.unitDir, s.unitName(serviceName))
+}
+
+func (s *SystemdService) ExecuteSystemctl(ctx context.Context, args ...string) (string, error) {
+	output, err := s.executor.ExecuteWithOutput(args...)
+	if err != nil {
+		logging.Error("systemctl command failed: systemctl %s", strings.Join(args, " "))
+		logging.Error("systemctl error output: %s", output)
+		return strings.TrimSpace(output), err
+	}
+
+	return strings.TrimSpace(output), nil
+}
+
+// quoteSystemdArg quotes an ExecStart argument. systemd expands % specifiers
+// inside quotes too, so they are escaped as well.
+func quoteSystemdArg(arg string) string {
+	escaped := strings.ReplaceAll(arg, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `"`, `\"`)
+	escaped = strings.ReplaceAll(escaped, "%", "%%")
+	return `"` + escaped + `"`
+}
+
+// buildUnit returns the unit file of a server. WorkingDirectory is written
+// as is, as systemd does not unquote it; only % is escaped there.
+func (s *SystemdService) buildUnit(serviceName, execPath, workingDir string, args []string) (string, error) {
+	for _, value := range append([]string{serviceName, s.winePath, execPath, workingDir}, args...) {
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("invalid line break in %q", value)
+		}
+	}
+
+	cmdline := []string{quoteSystemdArg(s.winePath), quoteSystemdArg(execPath)}
+	for _, arg := range args {
+		cmdline = append(cmdline, quoteSystemdArg(arg))
+	}
+
+	return fmt.Sprintf(systemdUnitTemplate, serviceName, strings.ReplaceAll(workingDir, "%", "%%"), strings.Join(cmdline, " ")), nil
+}
+
+func (s *SystemdService) CreateService(ctx context.Context, serviceName, execPath, workingDir string, args []string) error {
+	absExecPath, err := filepath.Abs(execPath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path for executable: %v", err)
+	}
+	absExecPath = filepath.Clean(absExecPath)
+
+	absWorkingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path for working directory: %v", err)
+	}
+	absWorkingDir = filepath.Clean(absWorkingDir)
+
+	logging.Info("Creating systemd unit '%s' with:", serviceName)
+	logging.Info("  Executable: %s", absExecPath)
+	logging.Info("  Working Directory: %s", absWorkingDir)
+
+	if err := os.MkdirAll(s.unitDir, 0755); err != nil {
+		return fmt.Errorf("failed to create unit directory: %v", err)
+	}
+
+	unit, err := s.buildUnit(serviceName, absExecPath, absWorkingDir, args)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.unitPath(serviceName), []byte(unit), 0644); err != nil {
+		return fmt.Errorf("failed to write unit file: %v", err)
+	}
+
+	if _, err := s.ExecuteSystemctl(ctx, "daemon-reload"); err != nil {
+		os.Remove(s.unitPath(serviceName))
+		return fmt.Errorf("failed to reload systemd: %v", err)
+	}
+
+	if _, err := s.ExecuteSystemctl(ctx, "enable", s.unitName(serviceName)); err != nil {
+		s.DeleteService(ctx, serviceName)
+		return fmt.Errorf("failed to enable service: %v", err)
+	}
+
+	logging.Info("Created systemd service: %s", serviceName)
+	return nil
+}
+
+func (s *SystemdService) DeleteService(ctx context.Context, serviceName string) error {
+	s.ExecuteSystemctl(ctx, "disable", "--now", s.unitName(serviceName))
+
+	if err := os.Remove(s.unitPath(serviceName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove unit file: %v", err)
+	}
+
+	if _, err := s.ExecuteSystemctl(ctx, "daemon-reload"); err != nil {
+		return fmt.Errorf("failed to reload systemd: %v", err)
+	}
+
+	logging.Info("Removed systemd service: %s", serviceName)
+	return nil
+}
+
+func (s *SystemdService) UpdateService(ctx context.Context, serviceName, execPath, workingDir string, args []string) error {
+	if err := s.DeleteService(ctx, serviceName); err != nil {
+		return err
+	}
+
+	return s.CreateService(ctx, serviceName, execPath, workingDir, args)
+}
+
+// Status maps `systemctl is-active` onto the NSSM-style status strings.
+// is-active exits non-zero for anything but "active", so the output is
+// trusted whenever it is present.
+func (s *SystemdService) Status(ctx context.Context, serviceName string) (string, error) {
+	output, err := s.ExecuteSystemctl(ctx, "is-active", s.unitName(serviceName))
+	if output == "" && err != nil {
+		return "", err
+	}
+
+	return model.ParseSystemdState(output).String(), nil
+}
+
+func (s *SystemdService) Start(ctx context.Context, serviceName string) (string, error) {
+	if _, err := s.ExecuteSystemctl(ctx, "start", s.unitName(serviceName)); err != nil {
+		return "", err
+	}
+
+	return s.Status(ctx, serviceName)
+}
+
+func (s *SystemdService) Stop(ctx context.Context, serviceName string) (string, error) {
+	if _, err := s.ExecuteSystemctl(ctx, "stop", s.unitName(serviceName)); err != nil {
+		return "", err
+	}
+
+	return s.Status(ctx, serviceName)
+}
+
+func (s *SystemdService) Restart(ctx context.Context, serviceName string) (string, error) {
+	if _, err := s.ExecuteSystemctl(ctx, "restart", s.unitName(serviceName)); err != nil {
+		return "", err
+	}
+
+	return s.Status(ctx, serviceName)
+}
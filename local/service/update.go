@@ -0,0 +1,497 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/command"
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const updatePlayerPollInterval = 15 * time.Second
+
+// updateProgress is how far an update got, in percent, once it reaches a
+// status.
+var updateProgress = map[model.UpdateJobStatus]int{
+	model.UpdateJobWaiting:   10,
+	model.UpdateJobStopping:  20,
+	model.UpdateJobUpdating:  30,
+	model.UpdateJobVerifying: 80,
+	model.UpdateJobStarting:  90,
+}
+
+var appManifestBuildIDRegex = regexp.MustCompile(`"buildid"\s+"(\d+)"`)
+
+// ServerInstaller runs `app_update` for the ACC server into an install path.
+// SteamService is the implementation outside of the tests.
+type ServerInstaller interface {
+	InstallServerWithCallbacks(ctx context.Context, installPath string, serverID *uuid.UUID, outputCallback command.OutputCallback) error
+}
+
+// UpdateService runs the jobs that update the ACC server files of existing
+// servers. Each update is run as a server.update job with the same ID, one
+// at a time, so that a failed update stops a rollout before the next one
+// starts.
+type UpdateService struct {
+	repository            *repository.UpdateJobRepository
+	serverRepository      *repository.ServerRepository
+	serviceControlService *ServiceControlService
+	serverService         *ServerService
+	webSocketService      *WebSocketService
+	jobService            *JobService
+	installer             ServerInstaller
+	playerPollInterval    time.Duration
+
+	mu      sync.Mutex
+	cancels map[uuid.UUID]context.CancelFunc
+}
+
+func NewUpdateService(
+	repository *repository.UpdateJobRepository,
+	serverRepository *repository.ServerRepository,
+	serviceControlService *ServiceControlService,
+	serverService *ServerService,
+	steamService *SteamService,
+	webSocketService *WebSocketService,
+) *UpdateService {
+	logging.Debug("Initializing UpdateService")
+	return &UpdateService{
+		repository:            repository,
+		serverRepository:      serverRepository,
+		serviceControlService: serviceControlService,
+		serverService:         serverService,
+		webSocketService:      webSocketService,
+		installer:             steamService,
+		playerPollInterval:    updatePlayerPollInterval,
+		cancels:               make(map[uuid.UUID]context.CancelFunc),
+	}
+}
+
+// SetInstaller replaces SteamCMD as the tool that updates the server files.
+func (s *UpdateService) SetInstaller(installer ServerInstaller) {
+	s.installer = installer
+}
+
+// SetPlayerPollInterval sets how often a waiting update checks whether the
+// server is empty.
+func (s *UpdateService) SetPlayerPollInterval(interval time.Duration) {
+	s.playerPollInterval = interval
+}
+
+// SetJobService runs updates as jobs on the job workers.
+func (s *UpdateService) SetJobService(jobService *JobService) {
+	s.jobService = jobService
+	jobService.RegisterHandler(model.JobServerUpdate, JobHandler{
+		Run:       s.runJob,
+		Cancelled: s.jobCancelled,
+		Exclusive: true,
+	})
+}
+
+// Start fails the updates a previous run left half done. Queued updates are
+// run by their jobs; those queued before updates ran as jobs get one now.
+func (s *UpdateService) Start() {
+	ctx := context.Background()
+	if err := s.repository.FailUnfinished(ctx, "manager restarted during the update", time.Now().UTC()); err != nil {
+		logging.Error("Failed to fail interrupted updates: %v", err)
+	}
+	if s.jobService == nil {
+		return
+	}
+
+	queued, err := s.repository.GetAll(ctx, &model.UpdateJobFilter{
+		BaseFilter: model.BaseFilter{PageSize: 1000, SortBy: "created_at"},
+		Status:     model.UpdateJobQueued,
+	})
+	if err != nil {
+		logging.Error("Failed to get queued updates: %v", err)
+		return
+	}
+	for _, update := range *queued {
+		if job, err := s.jobService.repository.GetByID(ctx, update.ID); err != nil || job != nil {
+			continue
+		}
+		if err := s.jobService.Enqueue(ctx, &model.Job{ID: update.ID, Type: model.JobServerUpdate, ServerID: update.ServerID}); err != nil {
+			logging.Error("Failed to queue update %s: %v", update.ID, err)
+		}
+	}
+}
+
+func (s *UpdateService) runJob(ctx context.Context, run *JobRun) error {
+	return s.run(ctx, run.Job.ID, run)
+}
+
+// jobCancelled cancels the update of a job cancelled before it ran.
+func (s *UpdateService) jobCancelled(ctx context.Context, job *model.Job) {
+	update, err := s.repository.GetByID(ctx, job.ID)
+	if err != nil || update == nil || update.Status != model.UpdateJobQueued {
+		return
+	}
+	update.Status = model.UpdateJobCancelled
+	update.Error = "cancelled"
+	update.FinishedAt = job.FinishedAt
+	if err := s.repository.Update(ctx, update); err != nil {
+		logging.Error("Failed to cancel update %s: %v", update.ID, err)
+	}
+}
+
+// run runs a queued update, reporting it to jobRun, and returns what it
+// failed with. An update that is no longer queued is not run.
+func (s *UpdateService) run(ctx context.Context, updateID uuid.UUID, jobRun *JobRun) error {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.mu.Lock()
+	job, err := s.repository.GetByID(ctx, updateID)
+	if err != nil || job == nil {
+		s.mu.Unlock()
+		return errors.New("update not found")
+	}
+	if job.Status != model.UpdateJobQueued {
+		s.mu.Unlock()
+		if job.Status == model.UpdateJobCancelled {
+			return fmt.Errorf("%s: %w", job.Error, context.Canceled)
+		}
+		return fmt.Errorf("update is %s: %s", job.Status, job.Error)
+	}
+	startedAt := time.Now().UTC()
+	job.StartedAt = &startedAt
+	s.cancels[job.ID] = cancel
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.cancels, job.ID)
+		s.mu.Unlock()
+	}()
+
+	err = s.execute(jobCtx, job, jobRun)
+
+	finishedAt := time.Now().UTC()
+	job.FinishedAt = &finishedAt
+	switch {
+	case err == nil:
+		s.setStatus(ctx, job, jobRun, model.UpdateJobSucceeded, "Update completed")
+	case errors.Is(err, context.Canceled):
+		job.Error = "cancelled"
+		s.setStatus(ctx, job, jobRun, model.UpdateJobCancelled, "Update cancelled")
+	default:
+		job.Error = err.Error()
+		s.setStatus(ctx, job, jobRun, model.UpdateJobFailed, fmt.Sprintf("Update failed: %v", err))
+		if job.BatchID != nil {
+			reason := fmt.Sprintf("update of server %s failed", job.ServerID)
+			if err := s.repository.CancelQueuedInBatch(ctx, *job.BatchID, reason, finishedAt); err != nil {
+				logging.Error("Failed to stop update rollout: %v", err)
+			}
+		}
+	}
+	return err
+}
+
+func (s *UpdateService) execute(ctx context.Context, job *model.UpdateJob, jobRun *JobRun) error {
+	server, err := s.serverRepository.GetByID(ctx, job.ServerID)
+	if err != nil || server == nil {
+		return errors.New("server not found")
+	}
+
+	s.setStatus(ctx, job, jobRun, model.UpdateJobWaiting, "Waiting for players to leave")
+	deadline := job.StartedAt.Add(time.Duration(job.WaitMinutes) * time.Minute)
+	for {
+		players := s.serverService.PlayersOnline(server.ID)
+		if players == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d player(s) still online after %d minutes", players, job.WaitMinutes)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.playerPollInterval):
+		}
+	}
+
+	// From here on the update runs to the end, so the server is not left
+	// stopped halfway through.
+	if err := s.disableCancel(ctx, job.ID, jobRun); err != nil {
+		return err
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	s.serviceControlService.InvalidateStatus(server.ServiceName)
+	status, err := s.serviceControlService.GetCachedStatus(server.ServiceName)
+	if err != nil {
+		return fmt.Errorf("failed to get server status: %v", err)
+	}
+	job.WasRunning = model.ParseServiceStatus(status) == model.StatusRunning
+	if job.WasRunning {
+		s.setStatus(ctx, job, jobRun, model.UpdateJobStopping, "Stopping server")
+		if _, err := s.serviceControlService.StopServer(server.ServiceName); err != nil {
+			return fmt.Errorf("failed to stop server: %v", err)
+		}
+	}
+
+	job.PreviousVersion, _ = accServerVersion(server.Path)
+	s.setStatus(ctx, job, jobRun, model.UpdateJobUpdating, "Updating server files")
+	unlock, err := s.jobService.LockSteamCMD(ctx)
+	if err != nil {
+		return err
+	}
+	updateErr := s.installer.InstallServerWithCallbacks(ctx, server.Path, &server.ID, func(serverID uuid.UUID, output string, isError bool) {
+		s.webSocketService.BroadcastSteamOutput(serverID, output, isError)
+		jobRun.Log(output)
+	})
+	unlock()
+	if updateErr == nil {
+		s.setStatus(ctx, job, jobRun, model.UpdateJobVerifying, "Checking accServer.exe")
+		job.NewVersion, updateErr = accServerVersion(server.Path)
+	}
+
+	// A failed update usually leaves the previous files in place, so the
+	// server is started again either way.
+	if job.WasRunning {
+		s.setStatus(ctx, job, jobRun, model.UpdateJobStarting, "Starting server")
+		if _, err := s.serviceControlService.StartServer(server.ServiceName); err != nil {
+			if updateErr != nil {
+				return fmt.Errorf("%v; failed to start server: %v", updateErr, err)
+			}
+			return fmt.Errorf("failed to start server: %v", err)
+		}
+	}
+	return updateErr
+}
+
+// disableCancel makes Cancel refuse to cancel a running update, unless it
+// was cancelled already.
+func (s *UpdateService) disableCancel(ctx context.Context, updateID uuid.UUID, jobRun *JobRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	delete(s.cancels, updateID)
+	return jobRun.DisableCancel(ctx)
+}
+
+func (s *UpdateService) setStatus(ctx context.Context, job *model.UpdateJob, jobRun *JobRun, status model.UpdateJobStatus, message string) {
+	job.Status = status
+	if err := s.repository.Update(ctx, job); err != nil {
+		logging.Error("Failed to save update %s: %v", job.ID, err)
+	}
+	if progress, ok := updateProgress[status]; ok {
+		jobRun.Step(string(status), progress)
+	}
+	jobRun.Log(message)
+	logging.Info("Update %s of server %s: %s", job.ID, job.ServerID, message)
+	s.webSocketService.BroadcastUpdateProgress(job.ServerID, model.UpdateProgressMessage{
+		UpdateID:        job.ID,
+		Status:          status,
+		Message:         message,
+		PreviousVersion: job.PreviousVersion,
+		NewVersion:      job.NewVersion,
+	})
+}
+
+// accServerVersion identifies the installed ACC server by the Steam build
+// in the app manifest, or by the hash of accServer.exe when there is none.
+func accServerVersion(installPath string) (string, error) {
+	exePath := filepath.Join(installPath, "server", "accServer.exe")
+	file, err := os.Open(exePath)
+	if err != nil {
+		return "", fmt.Errorf("accServer.exe not found in %s", filepath.Dir(exePath))
+	}
+	defer file.Close()
+
+	manifest, err := os.ReadFile(filepath.Join(installPath, "steamapps", "appmanifest_"+ACCServerAppID+".acf"))
+	if err == nil {
+		if match := appManifestBuildIDRegex.FindSubmatch(manifest); match != nil {
+			return "build " + string(match[1]), nil
+		}
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read accServer.exe: %v", err)
+	}
+	return "sha256 " + hex.EncodeToString(hash.Sum(nil))[:12], nil
+}
+
+// Enqueue queues an update of a server. waitMinutes limits how long it
+// waits for players to leave, 0 meaning DefaultUpdateWaitMinutes.
+func (s *UpdateService) Enqueue(ctx context.Context, serverID string, trigger model.UpdateJobTrigger, waitMinutes int, batchID *uuid.UUID) (*model.UpdateJob, error) {
+	server, err := s.getServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if waitMinutes < 0 {
+		verr := &model.ValidationError{}
+		verr.Add("waitMinutes", "must not be negative")
+		return nil, verr
+	}
+	if waitMinutes == 0 {
+		waitMinutes = model.DefaultUpdateWaitMinutes
+	}
+	if !server.FromSteamCMD {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "server files are not managed by SteamCMD")
+	}
+
+	busy, err := s.repository.HasUnfinished(ctx, server.ID)
+	if err != nil {
+		return nil, err
+	}
+	if busy {
+		return nil, fiber.NewError(fiber.StatusConflict, "an update is already queued or running for this server")
+	}
+	if s.jobService != nil {
+		reinstalling, err := s.jobService.HasUnfinished(ctx, server.ID, model.JobServerCreate, model.JobServerReinstall)
+		if err != nil {
+			return nil, err
+		}
+		if reinstalling {
+			return nil, fiber.NewError(fiber.StatusConflict, "an install is already queued or running for this server")
+		}
+	}
+
+	job := &model.UpdateJob{
+		ServerID:    server.ID,
+		BatchID:     batchID,
+		Trigger:     trigger,
+		Status:      model.UpdateJobQueued,
+		WaitMinutes: waitMinutes,
+	}
+	if err := s.repository.Insert(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if s.jobService != nil {
+		if err := s.jobService.Enqueue(ctx, &model.Job{ID: job.ID, Type: model.JobServerUpdate, ServerID: server.ID}); err != nil {
+			return nil, err
+		}
+	}
+	return job, nil
+}
+
+// UpdateAll queues an update of every server installed through SteamCMD
+// that has none queued yet. The updates run one after the other, and a
+// failure cancels the ones that have not started.
+func (s *UpdateService) UpdateAll(ctx context.Context, request *model.UpdateJobRequest) ([]model.UpdateJob, error) {
+	servers, err := s.serverRepository.GetAll(ctx, &model.ServerFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	batchID := uuid.New()
+	jobs := make([]model.UpdateJob, 0, len(*servers))
+	for _, server := range *servers {
+		if !server.FromSteamCMD {
+			continue
+		}
+		if busy, err := s.repository.HasUnfinished(ctx, server.ID); err != nil || busy {
+			continue
+		}
+		job, err := s.Enqueue(ctx, server.ID.String(), model.UpdateJobManual, request.WaitMinutes, &batchID)
+		if err != nil {
+			return jobs, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+func (s *UpdateService) GetAll(ctx context.Context, filter *model.UpdateJobFilter) (*model.FilteredResponse, error) {
+	if _, err := s.getServer(ctx, filter.ServerID); err != nil {
+		return nil, err
+	}
+
+	jobs, err := s.repository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: jobs,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+func (s *UpdateService) GetByID(ctx context.Context, serverID, updateID string) (*model.UpdateJob, error) {
+	job, err := s.repository.GetByID(ctx, updateID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.ServerID.String() != serverID {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Update not found")
+	}
+	return job, nil
+}
+
+// Cancel stops an update that is queued or still waiting for players.
+func (s *UpdateService) Cancel(ctx context.Context, serverID, updateID string) (*model.UpdateJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.GetByID(ctx, serverID, updateID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case model.UpdateJobQueued:
+		now := time.Now().UTC()
+		job.Status = model.UpdateJobCancelled
+		job.Error = "cancelled"
+		job.FinishedAt = &now
+		if err := s.repository.Update(ctx, job); err != nil {
+			return nil, err
+		}
+		if s.jobService != nil {
+			if _, err := s.jobService.Cancel(ctx, job.ID); err != nil {
+				logging.Warn("Failed to cancel the job of update %s: %v", job.ID, err)
+			}
+		}
+		return job, nil
+	case model.UpdateJobWaiting:
+		cancel, ok := s.cancels[job.ID]
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusConflict, "update can no longer be cancelled")
+		}
+		cancel()
+		return job, nil
+	default:
+		return nil, fiber.NewError(fiber.StatusConflict, "update can no longer be cancelled")
+	}
+}
+
+func (s *UpdateService) getServer(ctx context.Context, serverID string) (*model.Server, error) {
+	if _, err := uuid.Parse(serverID); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid server ID")
+	}
+	server, err := s.serverRepository.GetByID(ctx, serverID)
+	if err != nil || server == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Server not found")
+	}
+	return server, nil
+}
@@ -0,0 +1,606 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/graceful"
+	"acc-server-manager/local/utl/logging"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookQueueSize     = 256
+	webhookWorkers       = 2
+	webhookTimeout       = 10 * time.Second
+	webhookRetryInterval = 5 * time.Second
+	webhookRetryBatch    = 50
+	// webhookClaimTime keeps a new delivery away from the retry loop while
+	// its first attempt is running. A delivery whose first attempt was cut
+	// short by a restart is retried after it.
+	webhookClaimTime = time.Minute
+)
+
+// WebhookService sends notifications about server events to webhooks.
+// Notify only queues a notification, so a slow endpoint never holds up the
+// code reporting the event. Failed deliveries are retried from the delivery
+// log with a growing delay.
+type WebhookService struct {
+	repository         *repository.WebhookRepository
+	deliveryRepository *repository.WebhookDeliveryRepository
+	serverRepository   *repository.ServerRepository
+	client             *http.Client
+	queue              chan model.WebhookNotification
+
+	webSocketService *WebSocketService
+
+	playersMu sync.Mutex
+	players   map[uuid.UUID]int
+}
+
+func NewWebhookService(
+	repository *repository.WebhookRepository,
+	deliveryRepository *repository.WebhookDeliveryRepository,
+	serverRepository *repository.ServerRepository,
+) *WebhookService {
+	logging.Debug("Initializing WebhookService")
+	return &WebhookService{
+		repository:         repository,
+		deliveryRepository: deliveryRepository,
+		serverRepository:   serverRepository,
+		client:             &http.Client{Timeout: webhookTimeout},
+		queue:              make(chan model.WebhookNotification, webhookQueueSize),
+		players:            make(map[uuid.UUID]int),
+	}
+}
+
+// SetWebSocketService makes alert events also reach the websocket's system
+// alerts topic.
+func (s *WebhookService) SetWebSocketService(webSocketService *WebSocketService) {
+	s.webSocketService = webSocketService
+}
+
+// Start sends queued notifications and retries failed deliveries until the
+// application shuts down.
+func (s *WebhookService) Start() {
+	manager := graceful.GetManager()
+	for i := 0; i < webhookWorkers; i++ {
+		manager.RunGoroutine(func(ctx context.Context) {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case n := <-s.queue:
+					s.dispatch(ctx, n)
+				}
+			}
+		})
+	}
+
+	manager.RunGoroutine(func(ctx context.Context) {
+		ticker := time.NewTicker(webhookRetryInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.RetryDue(ctx, time.Now().UTC())
+			}
+		}
+	})
+}
+
+// Notify queues a notification without waiting. It is dropped when the
+// queue is full. A nil service ignores notifications.
+func (s *WebhookService) Notify(n model.WebhookNotification) {
+	if s == nil {
+		return
+	}
+	if n.OccurredAt.IsZero() {
+		n.OccurredAt = time.Now().UTC()
+	}
+	if s.webSocketService != nil && n.Event.IsAlert() {
+		s.webSocketService.BroadcastAlert(n)
+	}
+	select {
+	case s.queue <- n:
+	default:
+		logging.Warn("Webhook queue is full, dropping %s notification for server %s", n.Event, n.ServerID)
+	}
+}
+
+// NotifyServer queues a notification about server.
+func (s *WebhookService) NotifyServer(server *model.Server, event model.WebhookEvent, message string, data map[string]interface{}) {
+	if server == nil {
+		return
+	}
+	s.Notify(model.WebhookNotification{
+		Event:      event,
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		Message:    message,
+		Data:       data,
+	})
+}
+
+// SessionChanged is registered as a session change listener of the server
+// service.
+func (s *WebhookService) SessionChanged(serverID uuid.UUID, from, to model.TrackSession) {
+	s.Notify(model.WebhookNotification{
+		Event:    model.WebhookSessionChanged,
+		ServerID: serverID,
+		Message:  fmt.Sprintf("Session changed from %s to %s", sessionName(from), sessionName(to)),
+		Data:     map[string]interface{}{"from": from, "to": to},
+	})
+}
+
+// PlayerCountChanged queues a player count change. Which webhooks it
+// reaches depends on their thresholds.
+func (s *WebhookService) PlayerCountChanged(server *model.Server, count int) {
+	if s == nil {
+		return
+	}
+	s.playersMu.Lock()
+	previous, known := s.players[server.ID]
+	s.players[server.ID] = count
+	s.playersMu.Unlock()
+	if !known || previous == count {
+		return
+	}
+	s.NotifyServer(server, model.WebhookPlayerThreshold,
+		fmt.Sprintf("Player count changed from %d to %d", previous, count),
+		map[string]interface{}{"previous": previous, "current": count})
+}
+
+// ProcessQueued sends every queued notification on the calling goroutine.
+func (s *WebhookService) ProcessQueued(ctx context.Context) {
+	for {
+		select {
+		case n := <-s.queue:
+			s.dispatch(ctx, n)
+		default:
+			return
+		}
+	}
+}
+
+func (s *WebhookService) dispatch(ctx context.Context, n model.WebhookNotification) {
+	if n.ServerName == "" && n.ServerID != uuid.Nil {
+		if server, err := s.serverRepository.GetByID(ctx, n.ServerID); err == nil && server != nil {
+			n.ServerName = server.Name
+		}
+	}
+
+	webhooks, err := s.repository.GetEnabledFor(ctx, n.ServerID)
+	if err != nil {
+		logging.Error("Failed to get webhooks: %v", err)
+		return
+	}
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		if !webhook.Matches(&n) {
+			continue
+		}
+		if _, err := s.deliver(ctx, webhook, forWebhook(webhook, n)); err != nil {
+			logging.Error("Failed to deliver %s to webhook %s: %v", n.Event, webhook.ID, err)
+		}
+	}
+}
+
+// forWebhook tailors a player count change to the threshold the webhook
+// watches.
+func forWebhook(webhook *model.Webhook, n model.WebhookNotification) model.WebhookNotification {
+	if n.Event != model.WebhookPlayerThreshold {
+		return n
+	}
+	current, _ := n.Data["current"].(int)
+	data := make(map[string]interface{}, len(n.Data)+1)
+	for key, value := range n.Data {
+		data[key] = value
+	}
+	data["threshold"] = webhook.PlayerThreshold
+	n.Data = data
+	if current >= webhook.PlayerThreshold {
+		n.Message = fmt.Sprintf("Player count reached %d (threshold %d)", current, webhook.PlayerThreshold)
+	} else {
+		n.Message = fmt.Sprintf("Player count dropped to %d (below %d)", current, webhook.PlayerThreshold)
+	}
+	return n
+}
+
+// deliver records a delivery of n to webhook and makes the first attempt.
+func (s *WebhookService) deliver(ctx context.Context, webhook *model.Webhook, n model.WebhookNotification) (*model.WebhookDelivery, error) {
+	payload, err := webhookPayload(webhook.Kind, n)
+	if err != nil {
+		return nil, err
+	}
+	claimedUntil := time.Now().UTC().Add(webhookClaimTime)
+	delivery := &model.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		ServerID:      n.ServerID,
+		Event:         n.Event,
+		Payload:       string(payload),
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: &claimedUntil,
+	}
+	if err := s.deliveryRepository.Insert(ctx, delivery); err != nil {
+		return nil, err
+	}
+	s.attempt(ctx, webhook, delivery, time.Now().UTC())
+	return delivery, nil
+}
+
+// RetryDue retries the failed deliveries whose next attempt is due at now.
+func (s *WebhookService) RetryDue(ctx context.Context, now time.Time) {
+	deliveries, err := s.deliveryRepository.GetDueRetries(ctx, now, webhookRetryBatch)
+	if err != nil {
+		logging.Error("Failed to get webhook retries: %v", err)
+		return
+	}
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		webhook, err := s.repository.GetByID(ctx, delivery.WebhookID)
+		if err != nil || webhook == nil || !webhook.Enabled {
+			delivery.Status = model.WebhookDeliveryFailed
+			delivery.Error = "webhook was deleted or disabled"
+			delivery.NextAttemptAt = nil
+			if err := s.deliveryRepository.Update(ctx, delivery); err != nil {
+				logging.Error("Failed to update webhook delivery %s: %v", delivery.ID, err)
+			}
+			continue
+		}
+		s.attempt(ctx, webhook, delivery, now)
+	}
+}
+
+// attempt sends a delivery once and records the outcome, planning the next
+// attempt after a failure.
+func (s *WebhookService) attempt(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery, now time.Time) {
+	delivery.Attempts++
+	statusCode, err := s.send(ctx, webhook, delivery)
+	delivery.StatusCode = statusCode
+
+	switch {
+	case err == nil:
+		delivered := time.Now().UTC()
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.Error = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &delivered
+	case delivery.Attempts >= model.WebhookMaxAttempts:
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+		logging.Warn("Giving up delivering %s to webhook %s: %v", delivery.Event, webhook.ID, err)
+	default:
+		next := now.Add(delivery.RetryDelay())
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := s.deliveryRepository.Update(context.WithoutCancel(ctx), delivery); err != nil {
+		logging.Error("Failed to update webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+func (s *WebhookService) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "acc-server-manager-webhook")
+	if webhook.Kind == model.WebhookGeneric {
+		req.Header.Set("X-Webhook-Event", string(delivery.Event))
+		req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+		if webhook.Secret != "" {
+			req.Header.Set("X-Webhook-Signature", SignWebhookPayload(webhook.Secret, body))
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature of a generic webhook
+// body: "sha256=" and the hex HMAC-SHA256 of the body keyed with the secret.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookPayload builds the body sent to a webhook of the given kind.
+func webhookPayload(kind model.WebhookKind, n model.WebhookNotification) ([]byte, error) {
+	title := webhookTitle(n)
+	switch kind {
+	case model.WebhookDiscord:
+		fields := []map[string]interface{}{{"name": "Server", "value": orDash(n.ServerName), "inline": true}}
+		for _, key := range sortedDataKeys(n.Data) {
+			fields = append(fields, map[string]interface{}{"name": key, "value": fmt.Sprint(n.Data[key]), "inline": true})
+		}
+		return json.Marshal(map[string]interface{}{
+			"username": "ACC Server Manager",
+			"embeds": []map[string]interface{}{{
+				"title":       title,
+				"description": n.Message,
+				"color":       webhookColor(n.Event),
+				"timestamp":   n.OccurredAt.Format(time.RFC3339),
+				"fields":      fields,
+			}},
+		})
+	case model.WebhookSlack:
+		fields := []map[string]interface{}{{"title": "Server", "value": orDash(n.ServerName), "short": true}}
+		for _, key := range sortedDataKeys(n.Data) {
+			fields = append(fields, map[string]interface{}{"title": key, "value": fmt.Sprint(n.Data[key]), "short": true})
+		}
+		return json.Marshal(map[string]interface{}{
+			"text": fmt.Sprintf("*%s*: %s", title, n.Message),
+			"attachments": []map[string]interface{}{{
+				"color":  fmt.Sprintf("#%06x", webhookColor(n.Event)),
+				"fields": fields,
+				"ts":     n.OccurredAt.Unix(),
+			}},
+		})
+	default:
+		return json.Marshal(n)
+	}
+}
+
+func webhookTitle(n model.WebhookNotification) string {
+	name := n.ServerName
+	if name == "" {
+		name = "Server"
+	}
+	switch n.Event {
+	case model.WebhookServerStarted:
+		return name + " started"
+	case model.WebhookServerStopped:
+		return name + " stopped"
+	case model.WebhookServerCrashed:
+		return name + " crashed"
+	case model.WebhookSessionChanged:
+		return name + " session changed"
+	case model.WebhookPlayerThreshold:
+		return name + " player count"
+	case model.WebhookServerCreated:
+		return name + " created"
+	case model.WebhookServerCreateFailed:
+		return name + " could not be created"
+	case model.WebhookConfigChanged:
+		return name + " config changed"
+	default:
+		return name + " test notification"
+	}
+}
+
+// webhookColor is the embed and attachment colour of an event.
+func webhookColor(event model.WebhookEvent) int {
+	switch event {
+	case model.WebhookServerStarted, model.WebhookServerCreated:
+		return 0x22c55e
+	case model.WebhookServerCrashed, model.WebhookServerCreateFailed:
+		return 0xef4444
+	case model.WebhookServerStopped:
+		return 0xf59e0b
+	default:
+		return 0x3b82f6
+	}
+}
+
+func sessionName(session model.TrackSession) string {
+	switch session {
+	case model.SessionPractice:
+		return "practice"
+	case model.SessionQualify:
+		return "qualifying"
+	case model.SessionRace:
+		return "race"
+	default:
+		return "unknown"
+	}
+}
+
+func sortedDataKeys(data map[string]interface{}) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func orDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
+
+func (s *WebhookService) GetAll(ctx context.Context, filter *model.WebhookFilter) (*model.FilteredResponse, error) {
+	webhooks, err := s.repository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range *webhooks {
+		hideSecret(&(*webhooks)[i])
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: webhooks,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+func (s *WebhookService) GetByID(ctx context.Context, id string) (*model.Webhook, error) {
+	webhook, err := s.getWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	hideSecret(webhook)
+	return webhook, nil
+}
+
+func (s *WebhookService) Create(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error) {
+	if err := s.validate(ctx, webhook); err != nil {
+		return nil, err
+	}
+	webhook.ID = uuid.Nil
+	if err := s.repository.Insert(ctx, webhook); err != nil {
+		return nil, err
+	}
+	hideSecret(webhook)
+	return webhook, nil
+}
+
+// Update replaces a webhook's settings. The secret is kept when none is
+// given.
+func (s *WebhookService) Update(ctx context.Context, id string, input *model.Webhook) (*model.Webhook, error) {
+	webhook, err := s.getWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(ctx, input); err != nil {
+		return nil, err
+	}
+
+	webhook.ServerID = input.ServerID
+	webhook.Name = input.Name
+	webhook.Kind = input.Kind
+	webhook.URL = input.URL
+	if input.Secret != "" {
+		webhook.Secret = input.Secret
+	}
+	webhook.Events = input.Events
+	webhook.PlayerThreshold = input.PlayerThreshold
+	webhook.Enabled = input.Enabled
+	if err := s.repository.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+	hideSecret(webhook)
+	return webhook, nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id string) error {
+	webhook, err := s.getWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repository.Delete(ctx, webhook.ID)
+}
+
+// Test sends a test notification to a webhook straight away, even when it
+// is disabled, and returns the delivery.
+func (s *WebhookService) Test(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	webhook, err := s.getWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	n := model.WebhookNotification{
+		Event:      model.WebhookTest,
+		Message:    fmt.Sprintf("Test notification for webhook %q", webhook.Name),
+		OccurredAt: time.Now().UTC(),
+	}
+	if webhook.ServerID != nil {
+		n.ServerID = *webhook.ServerID
+		if server, err := s.serverRepository.GetByID(ctx, n.ServerID); err == nil && server != nil {
+			n.ServerName = server.Name
+		}
+	}
+	return s.deliver(ctx, webhook, n)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, filter *model.WebhookDeliveryFilter) (*model.FilteredResponse, error) {
+	if _, err := s.getWebhook(ctx, filter.WebhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.deliveryRepository.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.deliveryRepository.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBy, _ := filter.GetSorting()
+	return &model.FilteredResponse{
+		Items: deliveries,
+		Params: model.Params{
+			SortBy:       sortBy,
+			Page:         filter.Page,
+			Rpp:          filter.PageSize,
+			TotalRecords: int(total),
+		},
+	}, nil
+}
+
+func (s *WebhookService) validate(ctx context.Context, webhook *model.Webhook) error {
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+	if webhook.ServerID != nil {
+		server, err := s.serverRepository.GetByID(ctx, *webhook.ServerID)
+		if err != nil || server == nil {
+			return fiber.NewError(fiber.StatusNotFound, "Server not found")
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) getWebhook(ctx context.Context, id string) (*model.Webhook, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+	webhook, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Webhook not found")
+	}
+	return webhook, nil
+}
+
+// hideSecret keeps the secret out of responses.
+func hideSecret(webhook *model.Webhook) {
+	webhook.HasSecret = webhook.Secret != ""
+	webhook.Secret = ""
+}
@@ -4,6 +4,7 @@ import (
 	"acc-server-manager/local/model"
 	"acc-server-manager/local/utl/logging"
 	"encoding/json"
+	"errors"
 	"sync"
 	"time"
 
@@ -11,45 +12,380 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	// WebSocketPingInterval is how often connections are pinged. A client
+	// that stays silent for twice as long is disconnected.
+	WebSocketPingInterval = 30 * time.Second
+	// webSocketQueueSize is how many messages may wait for a connection.
+	// A client that falls further behind is disconnected so it cannot stall
+	// everyone else.
+	webSocketQueueSize = 64
+	// webSocketHistorySize is how many messages of a replayable topic are
+	// kept for clients that reconnect.
+	webSocketHistorySize = 1000
+)
+
+var (
+	ErrUnknownTopic     = errors.New("unknown topic")
+	ErrServerNotRunning = errors.New("server is not running")
+)
+
+// WebSocketConn is the part of a websocket connection the service writes
+// to. *websocket.Conn implements it.
+type WebSocketConn interface {
+	WriteMessage(messageType int, data []byte) error
+	Close() error
+}
+
+// WebSocketConnection is a connected client. Everything sent to it goes
+// through its queue, which a single goroutine writes out.
 type WebSocketConnection struct {
-	conn     *websocket.Conn
-	serverID *uuid.UUID
-	userID   *uuid.UUID
+	conn   WebSocketConn
+	userID *uuid.UUID
+	send   chan [][]byte
+	done   chan struct{}
+	once   sync.Once
+
+	topics map[model.WebSocketTopic]bool // Guarded by WebSocketService.mu
+}
+
+// enqueue queues messages without waiting and reports whether they fit.
+// Messages queued together take up a single place in the queue.
+func (c *WebSocketConnection) enqueue(frames ...[]byte) bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+	}
+	select {
+	case c.send <- frames:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *WebSocketConnection) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// messageHistory keeps the latest messages of a topic in a ring buffer.
+// Message seq is stored at frames[(seq-1)%len(frames)].
+type messageHistory struct {
+	lastSeq uint64
+	frames  [][]byte
 }
 
+func (h *messageHistory) add(frame []byte) {
+	h.lastSeq++
+	h.frames[(h.lastSeq-1)%uint64(len(h.frames))] = frame
+}
+
+// firstSeq returns the oldest message still kept.
+func (h *messageHistory) firstSeq() uint64 {
+	if h.lastSeq <= uint64(len(h.frames)) {
+		return 1
+	}
+	return h.lastSeq - uint64(len(h.frames)) + 1
+}
+
+// since returns the kept messages that came after lastSeq. A lastSeq ahead
+// of the history, e.g. from before the manager restarted, replays all of it.
+func (h *messageHistory) since(lastSeq uint64) [][]byte {
+	if lastSeq > h.lastSeq {
+		lastSeq = 0
+	}
+	first := h.firstSeq()
+	if lastSeq+1 > first {
+		first = lastSeq + 1
+	}
+	var frames [][]byte
+	for seq := first; seq <= h.lastSeq; seq++ {
+		frames = append(frames, h.frames[(seq-1)%uint64(len(h.frames))])
+	}
+	return frames
+}
+
+// WebSocketService delivers messages to the connections subscribed to a
+// topic, and keeps the recent messages of replayable topics.
 type WebSocketService struct {
-	connections sync.Map
-	mu          sync.RWMutex
+	connections   sync.Map
+	mu            sync.RWMutex
+	subscribers   map[model.WebSocketTopic]map[string]*WebSocketConnection
+	history       map[model.WebSocketTopic]*messageHistory
+	pingInterval  time.Duration
+	serverService *ServerService
 }
 
 func NewWebSocketService() *WebSocketService {
-	return &WebSocketService{}
+	return &WebSocketService{
+		subscribers:  make(map[model.WebSocketTopic]map[string]*WebSocketConnection),
+		history:      make(map[model.WebSocketTopic]*messageHistory),
+		pingInterval: WebSocketPingInterval,
+	}
 }
 
-func (ws *WebSocketService) AddConnection(connID string, conn *websocket.Conn, userID *uuid.UUID) {
+// SetServerService enables the snapshot sent when a live topic is
+// subscribed.
+func (ws *WebSocketService) SetServerService(serverService *ServerService) {
+	ws.serverService = serverService
+}
+
+func (ws *WebSocketService) AddConnection(connID string, conn WebSocketConn, userID *uuid.UUID) {
 	wsConn := &WebSocketConnection{
 		conn:   conn,
 		userID: userID,
+		send:   make(chan [][]byte, webSocketQueueSize),
+		done:   make(chan struct{}),
+		topics: make(map[model.WebSocketTopic]bool),
 	}
 	ws.connections.Store(connID, wsConn)
+	go ws.writeLoop(connID, wsConn)
 	logging.Info("WebSocket connection added: %s for user: %v", connID, userID)
 }
 
 func (ws *WebSocketService) RemoveConnection(connID string) {
 	if conn, exists := ws.connections.LoadAndDelete(connID); exists {
 		if wsConn, ok := conn.(*WebSocketConnection); ok {
-			wsConn.conn.Close()
+			ws.mu.Lock()
+			for topic := range wsConn.topics {
+				ws.removeSubscriber(topic, connID)
+			}
+			ws.mu.Unlock()
+			wsConn.close()
 		}
 	}
 	logging.Info("WebSocket connection removed: %s", connID)
 }
 
-func (ws *WebSocketService) SetServerID(connID string, serverID uuid.UUID) {
+// writeLoop writes the queued messages of a connection and pings it until
+// the connection is removed.
+func (ws *WebSocketService) writeLoop(connID string, wsConn *WebSocketConnection) {
+	ticker := time.NewTicker(ws.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case <-wsConn.done:
+			return
+		case frames := <-wsConn.send:
+			for _, data := range frames {
+				if err = wsConn.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+					break
+				}
+			}
+		case <-ticker.C:
+			err = wsConn.conn.WriteMessage(websocket.PingMessage, nil)
+		}
+		if err != nil {
+			logging.Error("Failed to send WebSocket message to connection %s: %v", connID, err)
+			ws.RemoveConnection(connID)
+			return
+		}
+	}
+}
+
+// Subscribe adds a topic to a connection. Subscribing to a replayable topic
+// replays the kept messages that came after lastSeq. Subscribing to the live
+// topic of a server sends a snapshot of the server's current state instead,
+// and fails with ErrServerNotRunning when the server is not tracked.
+func (ws *WebSocketService) Subscribe(connID string, topic model.WebSocketTopic, lastSeq uint64) error {
+	if _, ok := topic.Permission(); !ok {
+		return ErrUnknownTopic
+	}
+	conn, exists := ws.connections.Load(connID)
+	if !exists {
+		return nil
+	}
+	wsConn := conn.(*WebSocketConnection)
+
+	var snapshot *model.LiveSnapshotMessage
+	serverID, _ := topic.ServerID()
+	if topic.IsLive() {
+		if ws.serverService != nil {
+			snapshot = ws.serverService.LiveSnapshot(serverID)
+		}
+		if snapshot == nil {
+			return ErrServerNotRunning
+		}
+	}
+
+	// The confirmation and replay are queued before mu is released, so no
+	// message published meanwhile can overtake them.
+	ws.mu.Lock()
+	wsConn.topics[topic] = true
+	subscribers, ok := ws.subscribers[topic]
+	if !ok {
+		subscribers = make(map[string]*WebSocketConnection)
+		ws.subscribers[topic] = subscribers
+	}
+	subscribers[connID] = wsConn
+
+	confirmation := model.SubscriptionMessage{Topic: topic}
+	var replay [][]byte
+	if history, ok := ws.history[topic]; ok {
+		confirmation.FirstSeq = history.firstSeq()
+		confirmation.LastSeq = history.lastSeq
+		replay = history.since(lastSeq)
+	}
+	messages := []model.WebSocketMessage{newWebSocketMessage(model.MessageTypeSubscribed, topic, confirmation)}
+	if snapshot != nil {
+		messages = append(messages, newWebSocketMessage(model.MessageTypeLiveSnapshot, topic, snapshot))
+	}
+	frames, err := marshalFrames(messages...)
+	if err != nil {
+		ws.mu.Unlock()
+		logging.Error("Failed to marshal WebSocket message: %v", err)
+		return nil
+	}
+	fits := wsConn.enqueue(append(frames, replay...)...)
+	ws.mu.Unlock()
+
+	if !fits {
+		ws.dropSlow([]string{connID})
+	}
+	return nil
+}
+
+// LastSeq returns the number of the latest message of a replayable topic.
+func (ws *WebSocketService) LastSeq(topic model.WebSocketTopic) uint64 {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	if history, ok := ws.history[topic]; ok {
+		return history.lastSeq
+	}
+	return 0
+}
+
+// ClearHistory forgets the kept messages of a topic.
+func (ws *WebSocketService) ClearHistory(topic model.WebSocketTopic) {
+	ws.mu.Lock()
+	delete(ws.history, topic)
+	ws.mu.Unlock()
+}
+
+// Unsubscribe removes a topic from a connection.
+func (ws *WebSocketService) Unsubscribe(connID string, topic model.WebSocketTopic) {
+	conn, exists := ws.connections.Load(connID)
+	if !exists {
+		return
+	}
+	wsConn := conn.(*WebSocketConnection)
+
+	ws.mu.Lock()
+	delete(wsConn.topics, topic)
+	ws.removeSubscriber(topic, connID)
+	ws.mu.Unlock()
+
+	ws.sendTo(connID, wsConn, newWebSocketMessage(model.MessageTypeUnsubscribed, topic, model.SubscriptionMessage{Topic: topic}))
+}
+
+// removeSubscriber must be called with mu held.
+func (ws *WebSocketService) removeSubscriber(topic model.WebSocketTopic, connID string) {
+	if subscribers, ok := ws.subscribers[topic]; ok {
+		delete(subscribers, connID)
+		if len(subscribers) == 0 {
+			delete(ws.subscribers, topic)
+		}
+	}
+}
+
+// SendError tells a connection that a command failed.
+func (ws *WebSocketService) SendError(connID string, topic model.WebSocketTopic, message string) {
 	if conn, exists := ws.connections.Load(connID); exists {
-		if wsConn, ok := conn.(*WebSocketConnection); ok {
-			wsConn.serverID = &serverID
+		ws.sendTo(connID, conn.(*WebSocketConnection), newWebSocketMessage(model.MessageTypeError, topic,
+			model.ErrorMessage{Error: message, Details: string(topic)}))
+	}
+}
+
+// Pong answers a ping command of a connection.
+func (ws *WebSocketService) Pong(connID string) {
+	if conn, exists := ws.connections.Load(connID); exists {
+		ws.sendTo(connID, conn.(*WebSocketConnection), model.WebSocketMessage{
+			Type:      model.MessageTypePong,
+			Timestamp: time.Now().Unix(),
+		})
+	}
+}
+
+// Publish queues a message for every connection subscribed to topic. The
+// messages of a replayable topic are numbered and kept.
+func (ws *WebSocketService) Publish(topic model.WebSocketTopic, message model.WebSocketMessage) {
+	var slow []string
+	ws.mu.Lock()
+	history, replayable := ws.history[topic]
+	if !replayable && topic.Replayable() {
+		history = &messageHistory{frames: make([][]byte, webSocketHistorySize)}
+		ws.history[topic] = history
+		replayable = true
+	}
+	if replayable {
+		message.Seq = history.lastSeq + 1
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		ws.mu.Unlock()
+		logging.Error("Failed to marshal WebSocket message: %v", err)
+		return
+	}
+	if replayable {
+		history.add(data)
+	}
+	for connID, wsConn := range ws.subscribers[topic] {
+		if !wsConn.enqueue(data) {
+			slow = append(slow, connID)
 		}
 	}
+	ws.mu.Unlock()
+
+	ws.dropSlow(slow)
+}
+
+func marshalFrames(messages ...model.WebSocketMessage) ([][]byte, error) {
+	frames := make([][]byte, 0, len(messages))
+	for _, message := range messages {
+		data, err := json.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, data)
+	}
+	return frames, nil
+}
+
+func (ws *WebSocketService) sendTo(connID string, wsConn *WebSocketConnection, message model.WebSocketMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		logging.Error("Failed to marshal WebSocket message: %v", err)
+		return
+	}
+	if !wsConn.enqueue(data) {
+		ws.dropSlow([]string{connID})
+	}
+}
+
+func (ws *WebSocketService) dropSlow(connIDs []string) {
+	for _, connID := range connIDs {
+		logging.Warn("WebSocket connection %s is not keeping up, disconnecting it", connID)
+		ws.RemoveConnection(connID)
+	}
+}
+
+func newWebSocketMessage(messageType model.WebSocketMessageType, topic model.WebSocketTopic, data interface{}) model.WebSocketMessage {
+	message := model.WebSocketMessage{
+		Type:      messageType,
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	}
+	if serverID, ok := topic.ServerID(); ok {
+		message.ServerID = &serverID
+	}
+	return message
 }
 
 func (ws *WebSocketService) BroadcastStep(serverID uuid.UUID, step model.ServerCreationStep, status model.StepStatus, message string, errorMsg string) {
@@ -60,14 +396,8 @@ func (ws *WebSocketService) BroadcastStep(serverID uuid.UUID, step model.ServerC
 		Error:   errorMsg,
 	}
 
-	wsMsg := model.WebSocketMessage{
-		Type:      model.MessageTypeStep,
-		ServerID:  &serverID,
-		Timestamp: time.Now().Unix(),
-		Data:      stepMsg,
-	}
-
-	ws.broadcastToServer(serverID, wsMsg)
+	topic := model.ServerCreationTopic(serverID)
+	ws.Publish(topic, newWebSocketMessage(model.MessageTypeStep, topic, stepMsg))
 }
 
 func (ws *WebSocketService) BroadcastSteamOutput(serverID uuid.UUID, output string, isError bool) {
@@ -76,14 +406,8 @@ func (ws *WebSocketService) BroadcastSteamOutput(serverID uuid.UUID, output stri
 		IsError: isError,
 	}
 
-	wsMsg := model.WebSocketMessage{
-		Type:      model.MessageTypeSteamOutput,
-		ServerID:  &serverID,
-		Timestamp: time.Now().Unix(),
-		Data:      steamMsg,
-	}
-
-	ws.broadcastToServer(serverID, wsMsg)
+	topic := model.ServerCreationTopic(serverID)
+	ws.Publish(topic, newWebSocketMessage(model.MessageTypeSteamOutput, topic, steamMsg))
 }
 
 func (ws *WebSocketService) BroadcastError(serverID uuid.UUID, error string, details string) {
@@ -92,14 +416,8 @@ func (ws *WebSocketService) BroadcastError(serverID uuid.UUID, error string, det
 		Details: details,
 	}
 
-	wsMsg := model.WebSocketMessage{
-		Type:      model.MessageTypeError,
-		ServerID:  &serverID,
-		Timestamp: time.Now().Unix(),
-		Data:      errorMsg,
-	}
-
-	ws.broadcastToServer(serverID, wsMsg)
+	topic := model.ServerCreationTopic(serverID)
+	ws.Publish(topic, newWebSocketMessage(model.MessageTypeError, topic, errorMsg))
 }
 
 func (ws *WebSocketService) BroadcastComplete(serverID uuid.UUID, success bool, message string) {
@@ -109,50 +427,41 @@ func (ws *WebSocketService) BroadcastComplete(serverID uuid.UUID, success bool,
 		Message:  message,
 	}
 
-	wsMsg := model.WebSocketMessage{
-		Type:      model.MessageTypeComplete,
-		ServerID:  &serverID,
-		Timestamp: time.Now().Unix(),
-		Data:      completeMsg,
-	}
-
-	ws.broadcastToServer(serverID, wsMsg)
+	topic := model.ServerCreationTopic(serverID)
+	ws.Publish(topic, newWebSocketMessage(model.MessageTypeComplete, topic, completeMsg))
 }
 
-func (ws *WebSocketService) broadcastToServer(serverID uuid.UUID, message model.WebSocketMessage) {
-	data, err := json.Marshal(message)
-	if err != nil {
-		logging.Error("Failed to marshal WebSocket message: %v", err)
-		return
+func (ws *WebSocketService) BroadcastConfigChanged(serverID uuid.UUID, revision *model.Config) {
+	changedMsg := model.ConfigChangedMessage{
+		ConfigFile: revision.ConfigFile,
+		RevisionID: revision.ID,
+		ChangedBy:  revision.ChangedBy,
 	}
 
-	sentToAssociatedConnections := false
+	topic := model.ServerLiveTopic(serverID)
+	ws.Publish(topic, newWebSocketMessage(model.MessageTypeConfigChanged, topic, changedMsg))
+}
 
-	ws.connections.Range(func(key, value interface{}) bool {
-		if wsConn, ok := value.(*WebSocketConnection); ok {
-			if wsConn.serverID != nil && *wsConn.serverID == serverID {
-				if err := wsConn.conn.WriteMessage(websocket.TextMessage, data); err != nil {
-					logging.Error("Failed to send WebSocket message to connection %s: %v", key, err)
-					ws.RemoveConnection(key.(string))
-				} else {
-					sentToAssociatedConnections = true
-				}
-			}
-		}
-		return true
-	})
+func (ws *WebSocketService) BroadcastUpdateProgress(serverID uuid.UUID, progress model.UpdateProgressMessage) {
+	topic := model.ServerCreationTopic(serverID)
+	ws.Publish(topic, newWebSocketMessage(model.MessageTypeUpdate, topic, progress))
+}
 
-	if !sentToAssociatedConnections && (message.Type == model.MessageTypeStep || message.Type == model.MessageTypeError || message.Type == model.MessageTypeComplete) {
-		ws.connections.Range(func(key, value interface{}) bool {
-			if wsConn, ok := value.(*WebSocketConnection); ok {
-				if err := wsConn.conn.WriteMessage(websocket.TextMessage, data); err != nil {
-					logging.Error("Failed to send WebSocket message to connection %s: %v", key, err)
-					ws.RemoveConnection(key.(string))
-				}
-			}
-			return true
-		})
+// BroadcastLive sends a message to the subscribers of a server's live
+// topic.
+func (ws *WebSocketService) BroadcastLive(serverID uuid.UUID, messageType model.WebSocketMessageType, data interface{}) {
+	topic := model.ServerLiveTopic(serverID)
+	ws.Publish(topic, newWebSocketMessage(messageType, topic, data))
+}
+
+// BroadcastAlert sends a server event to the subscribers of the system
+// alerts topic.
+func (ws *WebSocketService) BroadcastAlert(n model.WebhookNotification) {
+	message := newWebSocketMessage(model.MessageTypeAlert, model.TopicSystemAlerts, n)
+	if n.ServerID != uuid.Nil {
+		message.ServerID = &n.ServerID
 	}
+	ws.Publish(model.TopicSystemAlerts, message)
 }
 
 func (ws *WebSocketService) BroadcastToUser(userID uuid.UUID, message model.WebSocketMessage) {
@@ -162,17 +471,16 @@ func (ws *WebSocketService) BroadcastToUser(userID uuid.UUID, message model.WebS
 		return
 	}
 
+	var slow []string
 	ws.connections.Range(func(key, value interface{}) bool {
 		if wsConn, ok := value.(*WebSocketConnection); ok {
-			if wsConn.userID != nil && *wsConn.userID == userID {
-				if err := wsConn.conn.WriteMessage(websocket.TextMessage, data); err != nil {
-					logging.Error("Failed to send WebSocket message to connection %s: %v", key, err)
-					ws.RemoveConnection(key.(string))
-				}
+			if wsConn.userID != nil && *wsConn.userID == userID && !wsConn.enqueue(data) {
+				slow = append(slow, key.(string))
 			}
 		}
 		return true
 	})
+	ws.dropSlow(slow)
 }
 
 func (ws *WebSocketService) GetActiveConnections() int {
@@ -0,0 +1,279 @@
+package broadcasting
+
+import (
+	"acc-server-manager/local/utl/logging"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultUpdateInterval = 250 * time.Millisecond
+	// readTimeout is how often a blocked read wakes up to check whether the
+	// client was stopped.
+	readTimeout = time.Second
+	// silenceTimeout is how long the server may stay quiet before the client
+	// registers again, e.g. after the server was restarted.
+	silenceTimeout = 10 * time.Second
+	retryInterval  = 5 * time.Second
+	// entryListThrottle keeps an unknown car from requesting the entry list
+	// on every update.
+	entryListThrottle = time.Second
+	maxDatagramSize   = 64 * 1024
+)
+
+var errSilent = errors.New("no message received from the broadcasting server")
+
+// Conn is the datagram connection to the broadcasting server. *net.UDPConn
+// implements it, and ReplayConn stands in for it in tests.
+type Conn interface {
+	Read(b []byte) (int, error)
+	Write(b []byte) (int, error)
+	SetReadDeadline(t time.Time) error
+	Close() error
+}
+
+type Config struct {
+	// Address is the host and port the server's broadcasting API listens on.
+	Address            string
+	DisplayName        string
+	ConnectionPassword string
+	CommandPassword    string
+	// UpdateInterval is how often the server sends realtime updates. It
+	// defaults to 250ms.
+	UpdateInterval time.Duration
+}
+
+// Client registers with the broadcasting API of an ACC server and decodes
+// what it sends. Every message is handed to the matching callback, which is
+// called on the goroutine running Run.
+type Client struct {
+	config Config
+	dial   func() (Conn, error)
+
+	OnRegistration   func(RegistrationResult)
+	OnRealtimeUpdate func(RealtimeUpdate)
+	OnCarUpdate      func(RealtimeCarUpdate)
+	OnEntryList      func(EntryList)
+	OnEntryListCar   func(EntryListCar)
+	OnTrackData      func(TrackData)
+	OnEvent          func(BroadcastingEvent)
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+
+	mu                 sync.Mutex
+	conn               Conn
+	connectionID       int32
+	registered         bool
+	knownCars          map[int]bool
+	entryListRequested time.Time
+}
+
+// NewClient creates a client that talks to config.Address over UDP.
+func NewClient(config Config) *Client {
+	return NewClientWithDialer(config, func() (Conn, error) {
+		addr, err := net.ResolveUDPAddr("udp", config.Address)
+		if err != nil {
+			return nil, err
+		}
+		return net.DialUDP("udp", nil, addr)
+	})
+}
+
+// NewClientWithDialer creates a client that gets its connection from dial,
+// which is called again whenever the client reconnects.
+func NewClientWithDialer(config Config, dial func() (Conn, error)) *Client {
+	if config.UpdateInterval <= 0 {
+		config.UpdateInterval = defaultUpdateInterval
+	}
+	return &Client{
+		config:    config,
+		dial:      dial,
+		stopChan:  make(chan struct{}),
+		knownCars: make(map[int]bool),
+	}
+}
+
+// Run keeps the client registered until ctx is done or Stop is called. A
+// lost connection is retried every few seconds.
+func (c *Client) Run(ctx context.Context) {
+	for {
+		conn, err := c.dial()
+		if err == nil {
+			err = c.session(ctx, conn)
+		}
+		if c.stopped(ctx) {
+			return
+		}
+		if err != nil {
+			logging.Debug("Broadcasting connection to %s lost: %v", c.config.Address, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.stopChan:
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// Stop makes Run unregister and return within a second.
+func (c *Client) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopChan)
+	})
+}
+
+func (c *Client) stopped(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	case <-c.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// session registers on conn and handles what the server sends until it
+// goes quiet, the connection fails or the client is stopped.
+func (c *Client) session(ctx context.Context, conn Conn) error {
+	c.mu.Lock()
+	c.conn = conn
+	c.registered = false
+	c.knownCars = make(map[int]bool)
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		if c.registered {
+			conn.Write(connectionCommand(unregisterCommandApplication, c.connectionID))
+		}
+		c.conn = nil
+		c.registered = false
+		c.mu.Unlock()
+		conn.Close()
+	}()
+
+	register := registerCommand(c.config.DisplayName, c.config.ConnectionPassword,
+		c.config.CommandPassword, int32(c.config.UpdateInterval/time.Millisecond))
+	if _, err := conn.Write(register); err != nil {
+		return err
+	}
+
+	buf := make([]byte, maxDatagramSize)
+	lastMessage := time.Now()
+	for !c.stopped(ctx) {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		n, err := conn.Read(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if time.Since(lastMessage) > silenceTimeout {
+					return errSilent
+				}
+				continue
+			}
+			return err
+		}
+		lastMessage = time.Now()
+		if err := c.Process(buf[:n]); err != nil {
+			logging.Debug("Ignoring broadcasting message from %s: %v", c.config.Address, err)
+		}
+	}
+	return nil
+}
+
+// Process decodes one datagram and calls the matching callback. Requests
+// that follow from it, like fetching the entry list when an unknown car
+// shows up, are only sent while Run is connected.
+func (c *Client) Process(data []byte) error {
+	msg, err := decodeMessage(data)
+	if err != nil {
+		return err
+	}
+
+	switch msg := msg.(type) {
+	case RegistrationResult:
+		c.mu.Lock()
+		c.connectionID = msg.ConnectionID
+		c.registered = msg.Success
+		c.mu.Unlock()
+		if msg.Success {
+			c.send(connectionCommand(requestEntryList, msg.ConnectionID))
+			c.send(connectionCommand(requestTrackData, msg.ConnectionID))
+		} else {
+			logging.Warn("Broadcasting server %s refused registration: %s", c.config.Address, msg.Error)
+		}
+		if c.OnRegistration != nil {
+			c.OnRegistration(msg)
+		}
+	case RealtimeUpdate:
+		if c.OnRealtimeUpdate != nil {
+			c.OnRealtimeUpdate(msg)
+		}
+	case RealtimeCarUpdate:
+		c.requestEntryListFor(msg.CarIndex)
+		if c.OnCarUpdate != nil {
+			c.OnCarUpdate(msg)
+		}
+	case EntryList:
+		c.mu.Lock()
+		c.knownCars = make(map[int]bool)
+		c.mu.Unlock()
+		if c.OnEntryList != nil {
+			c.OnEntryList(msg)
+		}
+	case EntryListCar:
+		c.mu.Lock()
+		c.knownCars[msg.CarIndex] = true
+		c.mu.Unlock()
+		if c.OnEntryListCar != nil {
+			c.OnEntryListCar(msg)
+		}
+	case TrackData:
+		if c.OnTrackData != nil {
+			c.OnTrackData(msg)
+		}
+	case BroadcastingEvent:
+		if c.OnEvent != nil {
+			c.OnEvent(msg)
+		}
+	default:
+		return fmt.Errorf("%w: %T", errUnknownMessage, msg)
+	}
+	return nil
+}
+
+// requestEntryListFor asks for the entry list again when a car the client
+// has not been told about yet sends an update.
+func (c *Client) requestEntryListFor(carIndex int) {
+	c.mu.Lock()
+	if c.knownCars[carIndex] || !c.registered || time.Since(c.entryListRequested) < entryListThrottle {
+		c.mu.Unlock()
+		return
+	}
+	c.entryListRequested = time.Now()
+	connectionID := c.connectionID
+	c.mu.Unlock()
+
+	c.send(connectionCommand(requestEntryList, connectionID))
+}
+
+func (c *Client) send(data []byte) {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return
+	}
+	if _, err := conn.Write(data); err != nil {
+		logging.Debug("Failed to send broadcasting request to %s: %v", c.config.Address, err)
+	}
+}
@@ -0,0 +1,505 @@
+package broadcasting
+
+import (
+	"acc-server-manager/local/model"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ProtocolVersion is the version of the ACC broadcasting protocol spoken by
+// the client.
+const ProtocolVersion = 4
+
+// Messages sent to the server.
+const (
+	registerCommandApplication   byte = 1
+	unregisterCommandApplication byte = 9
+	requestEntryList             byte = 10
+	requestTrackData             byte = 11
+)
+
+// Messages received from the server.
+const (
+	registrationResult byte = 1
+	realtimeUpdate     byte = 2
+	realtimeCarUpdate  byte = 3
+	entryList          byte = 4
+	trackData          byte = 5
+	entryListCar       byte = 6
+	broadcastingEvent  byte = 7
+)
+
+// SessionType is the kind of session reported in a RealtimeUpdate.
+type SessionType byte
+
+const (
+	SessionPractice        SessionType = 0
+	SessionQualifying      SessionType = 4
+	SessionSuperpole       SessionType = 9
+	SessionRace            SessionType = 10
+	SessionHotlap          SessionType = 11
+	SessionHotstint        SessionType = 12
+	SessionHotlapSuperpole SessionType = 13
+	SessionReplay          SessionType = 14
+)
+
+// TrackSession maps the session type to the sessions the manager knows.
+func (t SessionType) TrackSession() model.TrackSession {
+	switch t {
+	case SessionPractice:
+		return model.SessionPractice
+	case SessionQualifying, SessionSuperpole:
+		return model.SessionQualify
+	case SessionRace:
+		return model.SessionRace
+	default:
+		return model.SessionUnknown
+	}
+}
+
+// SessionPhase is the stage of the session reported in a RealtimeUpdate.
+type SessionPhase byte
+
+const (
+	PhaseNone         SessionPhase = 0
+	PhaseStarting     SessionPhase = 1
+	PhasePreFormation SessionPhase = 2
+	PhaseFormationLap SessionPhase = 3
+	PhasePreSession   SessionPhase = 4
+	PhaseSession      SessionPhase = 5
+	PhaseSessionOver  SessionPhase = 6
+	PhasePostSession  SessionPhase = 7
+	PhaseResultUI     SessionPhase = 8
+)
+
+// CarLocation is where on the track a car is.
+type CarLocation byte
+
+const (
+	LocationNone     CarLocation = 0
+	LocationTrack    CarLocation = 1
+	LocationPitlane  CarLocation = 2
+	LocationPitEntry CarLocation = 3
+	LocationPitExit  CarLocation = 4
+)
+
+func (l CarLocation) String() string {
+	switch l {
+	case LocationTrack:
+		return "track"
+	case LocationPitlane:
+		return "pitlane"
+	case LocationPitEntry:
+		return "pit_entry"
+	case LocationPitExit:
+		return "pit_exit"
+	default:
+		return "none"
+	}
+}
+
+// EventType is the kind of a BroadcastingEvent.
+type EventType byte
+
+const (
+	EventNone            EventType = 0
+	EventGreenFlag       EventType = 1
+	EventSessionOver     EventType = 2
+	EventPenaltyCommMsg  EventType = 3
+	EventAccident        EventType = 4
+	EventLapCompleted    EventType = 5
+	EventBestSessionLap  EventType = 6
+	EventBestPersonalLap EventType = 7
+)
+
+type RegistrationResult struct {
+	ConnectionID int32
+	Success      bool
+	ReadOnly     bool
+	Error        string
+}
+
+// LapInfo is a lap of a car. Times are in milliseconds, 0 when unknown, and
+// Splits always holds the three sectors.
+type LapInfo struct {
+	LapTimeMs      int
+	CarIndex       int
+	DriverIndex    int
+	Splits         []int
+	IsInvalid      bool
+	IsValidForBest bool
+	IsOutLap       bool
+	IsInLap        bool
+}
+
+// RealtimeUpdate is the state of the session, sent once per update interval
+// before the car updates.
+type RealtimeUpdate struct {
+	EventIndex          int
+	SessionIndex        int
+	SessionType         SessionType
+	Phase               SessionPhase
+	SessionTimeMs       float32
+	SessionEndTimeMs    float32
+	FocusedCarIndex     int
+	ActiveCameraSet     string
+	ActiveCamera        string
+	CurrentHudPage      string
+	IsReplayPlaying     bool
+	ReplaySessionTime   float32
+	ReplayRemainingTime float32
+	TimeOfDayMs         float32
+	AmbientTemp         int
+	TrackTemp           int
+	Clouds              float32
+	RainLevel           float32
+	Wetness             float32
+	BestSessionLap      LapInfo
+}
+
+// RealtimeCarUpdate is the state of one car, sent once per update interval.
+// Laps counts the completed laps.
+type RealtimeCarUpdate struct {
+	CarIndex       int
+	DriverIndex    int
+	DriverCount    int
+	Gear           int
+	WorldPosX      float32
+	WorldPosY      float32
+	Yaw            float32
+	Location       CarLocation
+	Kmh            int
+	Position       int
+	CupPosition    int
+	TrackPosition  int
+	SplinePosition float32
+	Laps           int
+	DeltaMs        int
+	BestSessionLap LapInfo
+	LastLap        LapInfo
+	CurrentLap     LapInfo
+}
+
+// EntryList lists the cars of the session. Each of them is described by an
+// EntryListCar that follows.
+type EntryList struct {
+	ConnectionID int32
+	CarIndexes   []int
+}
+
+type DriverInfo struct {
+	FirstName   string
+	LastName    string
+	ShortName   string
+	Category    int
+	Nationality int
+}
+
+// Name is the full name of the driver.
+func (d DriverInfo) Name() string {
+	if d.LastName == "" {
+		return d.FirstName
+	}
+	if d.FirstName == "" {
+		return d.LastName
+	}
+	return d.FirstName + " " + d.LastName
+}
+
+type EntryListCar struct {
+	CarIndex           int
+	CarModel           int
+	TeamName           string
+	RaceNumber         int
+	CupCategory        int
+	CurrentDriverIndex int
+	Nationality        int
+	Drivers            []DriverInfo
+}
+
+// Driver returns the driver with the given index, or false when the car has
+// no such driver.
+func (c EntryListCar) Driver(index int) (DriverInfo, bool) {
+	if index < 0 || index >= len(c.Drivers) {
+		return DriverInfo{}, false
+	}
+	return c.Drivers[index], true
+}
+
+type TrackData struct {
+	ConnectionID int32
+	TrackName    string
+	TrackID      int
+	TrackMeters  int
+	CameraSets   map[string][]string
+	HudPages     []string
+}
+
+type BroadcastingEvent struct {
+	Type    EventType
+	Message string
+	TimeMs  int
+	CarID   int
+}
+
+var errUnknownMessage = errors.New("unknown broadcasting message")
+
+// reader decodes the little-endian fields of a message. The first read past
+// the end of the message sets err and every later read returns zero values.
+type reader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.off+n > len(r.data) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *reader) u8() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) u16() int {
+	if b := r.next(2); b != nil {
+		return int(binary.LittleEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *reader) i32() int32 {
+	if b := r.next(4); b != nil {
+		return int32(binary.LittleEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (r *reader) f32() float32 {
+	if b := r.next(4); b != nil {
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (r *reader) bool() bool {
+	return r.u8() > 0
+}
+
+func (r *reader) string() string {
+	length := r.u16()
+	return string(r.next(length))
+}
+
+// lapTime reads a time in milliseconds, which ACC sends as int32 max when it
+// is not known.
+func (r *reader) lapTime() int {
+	ms := r.i32()
+	if ms == math.MaxInt32 {
+		return 0
+	}
+	return int(ms)
+}
+
+func (r *reader) lap() LapInfo {
+	lap := LapInfo{
+		LapTimeMs:   r.lapTime(),
+		CarIndex:    r.u16(),
+		DriverIndex: r.u16(),
+	}
+	splitCount := int(r.u8())
+	lap.Splits = make([]int, 0, max(splitCount, 3))
+	for i := 0; i < splitCount; i++ {
+		lap.Splits = append(lap.Splits, r.lapTime())
+	}
+	for len(lap.Splits) < 3 {
+		lap.Splits = append(lap.Splits, 0)
+	}
+	lap.IsInvalid = r.bool()
+	lap.IsValidForBest = r.bool()
+	lap.IsOutLap = r.bool()
+	lap.IsInLap = r.bool()
+	return lap
+}
+
+// decodeMessage decodes a datagram received from the server into one of the
+// message types above.
+func decodeMessage(data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	r := &reader{data: data, off: 1}
+
+	var msg interface{}
+	switch data[0] {
+	case registrationResult:
+		msg = RegistrationResult{
+			ConnectionID: r.i32(),
+			Success:      r.bool(),
+			ReadOnly:     r.u8() == 0,
+			Error:        r.string(),
+		}
+	case realtimeUpdate:
+		update := RealtimeUpdate{
+			EventIndex:       r.u16(),
+			SessionIndex:     r.u16(),
+			SessionType:      SessionType(r.u8()),
+			Phase:            SessionPhase(r.u8()),
+			SessionTimeMs:    r.f32(),
+			SessionEndTimeMs: r.f32(),
+			FocusedCarIndex:  int(r.i32()),
+			ActiveCameraSet:  r.string(),
+			ActiveCamera:     r.string(),
+			CurrentHudPage:   r.string(),
+			IsReplayPlaying:  r.bool(),
+		}
+		if update.IsReplayPlaying {
+			update.ReplaySessionTime = r.f32()
+			update.ReplayRemainingTime = r.f32()
+		}
+		update.TimeOfDayMs = r.f32()
+		update.AmbientTemp = int(r.u8())
+		update.TrackTemp = int(r.u8())
+		update.Clouds = float32(r.u8()) / 10
+		update.RainLevel = float32(r.u8()) / 10
+		update.Wetness = float32(r.u8()) / 10
+		update.BestSessionLap = r.lap()
+		msg = update
+	case realtimeCarUpdate:
+		msg = RealtimeCarUpdate{
+			CarIndex:       r.u16(),
+			DriverIndex:    r.u16(),
+			DriverCount:    int(r.u8()),
+			Gear:           int(r.u8()) - 2,
+			WorldPosX:      r.f32(),
+			WorldPosY:      r.f32(),
+			Yaw:            r.f32(),
+			Location:       CarLocation(r.u8()),
+			Kmh:            r.u16(),
+			Position:       r.u16(),
+			CupPosition:    r.u16(),
+			TrackPosition:  r.u16(),
+			SplinePosition: r.f32(),
+			Laps:           r.u16(),
+			DeltaMs:        int(r.i32()),
+			BestSessionLap: r.lap(),
+			LastLap:        r.lap(),
+			CurrentLap:     r.lap(),
+		}
+	case entryList:
+		list := EntryList{ConnectionID: r.i32()}
+		count := r.u16()
+		for i := 0; i < count && r.err == nil; i++ {
+			list.CarIndexes = append(list.CarIndexes, r.u16())
+		}
+		msg = list
+	case entryListCar:
+		car := EntryListCar{
+			CarIndex:           r.u16(),
+			CarModel:           int(r.u8()),
+			TeamName:           r.string(),
+			RaceNumber:         int(r.i32()),
+			CupCategory:        int(r.u8()),
+			CurrentDriverIndex: int(r.u8()),
+			Nationality:        r.u16(),
+		}
+		count := int(r.u8())
+		for i := 0; i < count && r.err == nil; i++ {
+			car.Drivers = append(car.Drivers, DriverInfo{
+				FirstName:   r.string(),
+				LastName:    r.string(),
+				ShortName:   r.string(),
+				Category:    int(r.u8()),
+				Nationality: r.u16(),
+			})
+		}
+		msg = car
+	case trackData:
+		track := TrackData{
+			ConnectionID: r.i32(),
+			TrackName:    r.string(),
+			TrackID:      int(r.i32()),
+			TrackMeters:  int(r.i32()),
+			CameraSets:   make(map[string][]string),
+		}
+		sets := int(r.u8())
+		for i := 0; i < sets && r.err == nil; i++ {
+			name := r.string()
+			cameras := int(r.u8())
+			for j := 0; j < cameras && r.err == nil; j++ {
+				track.CameraSets[name] = append(track.CameraSets[name], r.string())
+			}
+		}
+		pages := int(r.u8())
+		for i := 0; i < pages && r.err == nil; i++ {
+			track.HudPages = append(track.HudPages, r.string())
+		}
+		msg = track
+	case broadcastingEvent:
+		msg = BroadcastingEvent{
+			Type:    EventType(r.u8()),
+			Message: r.string(),
+			TimeMs:  int(r.i32()),
+			CarID:   int(r.i32()),
+		}
+	default:
+		return nil, fmt.Errorf("%w: type %d", errUnknownMessage, data[0])
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("error decoding broadcasting message type %d: %w", data[0], r.err)
+	}
+	return msg, nil
+}
+
+// writer encodes the little-endian fields of a message.
+type writer struct {
+	bytes.Buffer
+}
+
+func (w *writer) u8(v byte) {
+	w.WriteByte(v)
+}
+
+func (w *writer) i32(v int32) {
+	binary.Write(w, binary.LittleEndian, v)
+}
+
+func (w *writer) string(s string) {
+	binary.Write(w, binary.LittleEndian, uint16(len(s)))
+	w.WriteString(s)
+}
+
+func registerCommand(displayName, connectionPassword, commandPassword string, updateIntervalMs int32) []byte {
+	var w writer
+	w.u8(registerCommandApplication)
+	w.u8(ProtocolVersion)
+	w.string(displayName)
+	w.string(connectionPassword)
+	w.i32(updateIntervalMs)
+	w.string(commandPassword)
+	return w.Bytes()
+}
+
+// connectionCommand encodes the messages that only carry the connection ID.
+func connectionCommand(msgType byte, connectionID int32) []byte {
+	var w writer
+	w.u8(msgType)
+	w.i32(connectionID)
+	return w.Bytes()
+}
@@ -0,0 +1,141 @@
+package broadcasting
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayConn is a Conn that hands out captured datagrams in order instead of
+// talking to a server, and records what the client writes. Once every
+// datagram was read, reads time out like a server that went quiet.
+type ReplayConn struct {
+	mu       sync.Mutex
+	packets  [][]byte
+	next     int
+	written  [][]byte
+	deadline time.Time
+	closed   bool
+	closing  chan struct{}
+	drained  chan struct{}
+}
+
+func NewReplayConn(packets [][]byte) *ReplayConn {
+	conn := &ReplayConn{
+		packets: packets,
+		closing: make(chan struct{}),
+		drained: make(chan struct{}),
+	}
+	if len(packets) == 0 {
+		close(conn.drained)
+	}
+	return conn
+}
+
+// LoadCapture reads a capture with one hex encoded datagram per line. Blank
+// lines and lines starting with # are skipped.
+func LoadCapture(r io.Reader) ([][]byte, error) {
+	var packets [][]byte
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 2*maxDatagramSize+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		packet, err := hex.DecodeString(strings.ReplaceAll(text, " ", ""))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		packets = append(packets, packet)
+	}
+	return packets, scanner.Err()
+}
+
+// LoadCaptureFile reads a capture written in the format of LoadCapture.
+func LoadCaptureFile(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadCapture(file)
+}
+
+// Drained is closed once every captured datagram was read.
+func (c *ReplayConn) Drained() <-chan struct{} {
+	return c.drained
+}
+
+// Written returns the datagrams the client sent, in order.
+func (c *ReplayConn) Written() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]byte(nil), c.written...)
+}
+
+func (c *ReplayConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return 0, net.ErrClosed
+	}
+	if c.next < len(c.packets) {
+		n := copy(b, c.packets[c.next])
+		c.next++
+		if c.next == len(c.packets) {
+			close(c.drained)
+		}
+		c.mu.Unlock()
+		return n, nil
+	}
+	deadline := c.deadline
+	c.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-c.closing:
+		return 0, net.ErrClosed
+	case <-timeout:
+		return 0, os.ErrDeadlineExceeded
+	}
+}
+
+func (c *ReplayConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	c.written = append(c.written, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func (c *ReplayConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	return nil
+}
+
+// Close ends the replay. A client that reconnects needs a new ReplayConn.
+func (c *ReplayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.closing)
+	}
+	return nil
+}
@@ -81,11 +81,13 @@ func (e *CallbackInteractiveCommandExecutor) ExecuteInteractive(ctx context.Cont
 
 	var cmdErr, outputErr error
 	completedCount := 0
+	exited := false
 
 	for completedCount < 2 {
 		select {
 		case cmdErr = <-cmdDone:
 			completedCount++
+			exited = true
 			logging.Info("Command execution completed")
 			e.callbacks.OnOutput(e.serverID, "Command execution completed", false)
 		case outputErr = <-outputDone:
@@ -93,6 +95,11 @@ func (e *CallbackInteractiveCommandExecutor) ExecuteInteractive(ctx context.Cont
 			logging.Info("Output monitoring completed")
 		case <-ctx.Done():
 			e.callbacks.OnOutput(e.serverID, "Command execution cancelled", true)
+			// The process is killed along with ctx; wait for it to exit so
+			// that whoever cancelled can clean up its files.
+			if !exited {
+				<-cmdDone
+			}
 			return ctx.Err()
 		}
 	}
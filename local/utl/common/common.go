@@ -17,16 +17,31 @@ import (
 )
 
 type RouteGroups struct {
-	Api          fiber.Router
-	Auth         fiber.Router
-	Server       fiber.Router
-	Config       fiber.Router
-	Lookup       fiber.Router
-	StateHistory fiber.Router
-	Membership   fiber.Router
-	System       fiber.Router
-	WebSocket    fiber.Router
-	Leaderboard  fiber.Router
+	Api            fiber.Router
+	Auth           fiber.Router
+	Server         fiber.Router
+	Config         fiber.Router
+	Lookup         fiber.Router
+	StateHistory   fiber.Router
+	Membership     fiber.Router
+	System         fiber.Router
+	WebSocket      fiber.Router
+	Leaderboard    fiber.Router
+	Championships  fiber.Router
+	EntryList      fiber.Router
+	BOP            fiber.Router
+	ConfigTemplate fiber.Router
+	Players        fiber.Router
+	Results        fiber.Router
+	Schedules      fiber.Router
+	RestartPolicy  fiber.Router
+	Crashes        fiber.Router
+	Updates        fiber.Router
+	ServerUpdates  fiber.Router
+	Rotation       fiber.Router
+	Metrics        fiber.Router
+	Webhooks       fiber.Router
+	Jobs           fiber.Router
 }
 
 func CheckError(err error) {
@@ -209,6 +224,13 @@ func parseValue(field reflect.Value, value string, tag reflect.StructTag) error
 			return fmt.Errorf("unsupported struct type: %v", field.Type())
 		}
 
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := parseValue(elem.Elem(), value, tag); err != nil {
+			return err
+		}
+		field.Set(elem)
+
 	default:
 		return fmt.Errorf("unsupported field type: %v", field.Kind())
 	}
@@ -14,6 +14,9 @@ var (
 	SecretCode    string
 	EncryptionKey string
 	AccessKey     string
+	// MetricsToken lets scrapers read /metrics as a bearer token without
+	// the access key. Scraping needs the access key when it is empty.
+	MetricsToken string
 )
 
 func Init() {
@@ -22,6 +25,7 @@ func Init() {
 	SecretCode = getEnvRequired("APP_SECRET_CODE")
 	EncryptionKey = getEnvRequired("ENCRYPTION_KEY")
 	AccessKey = getEnvRequired("ACCESS_KEY")
+	MetricsToken = getEnv("METRICS_TOKEN", "")
 
 	if len(EncryptionKey) != 32 {
 		log.Fatal("ENCRYPTION_KEY must be exactly 32 bytes long for AES-256")
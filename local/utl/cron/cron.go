@@ -0,0 +1,203 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed five field cron expression:
+// minute, hour, day of month, month and day of week.
+type Schedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	// A day matches when both day fields match if either of them is "*",
+	// and when either matches otherwise, as in Vixie cron.
+	dayOfMonthAny bool
+	dayOfWeekAny  bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField     = field{name: "minute", min: 0, max: 59}
+	hourField       = field{name: "hour", min: 0, max: 23}
+	dayOfMonthField = field{name: "day of month", min: 1, max: 31}
+	monthField      = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dayOfWeekField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a five field cron expression such as "30 19 * * fri" or one of
+// the macros @yearly, @monthly, @weekly, @daily and @hourly. Fields accept
+// "*", values, names of months and weekdays, ranges, lists and "/" steps.
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	schedule := &Schedule{
+		dayOfMonthAny: strings.HasPrefix(fields[2], "*"),
+		dayOfWeekAny:  strings.HasPrefix(fields[4], "*"),
+	}
+	var err error
+	if schedule.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if schedule.dayOfMonth, err = dayOfMonthField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if schedule.dayOfWeek, err = dayOfWeekField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	// Sunday can be written as 0 or 7.
+	if schedule.dayOfWeek&(1<<7) != 0 {
+		schedule.dayOfWeek |= 1
+	}
+	return schedule, nil
+}
+
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangeExpr = part[:i]
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", part[i+1:], f.name)
+			}
+		}
+
+		start, end := f.min, f.max
+		switch {
+		case rangeExpr == "*":
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err error
+			if start, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeExpr, f.name)
+			}
+		default:
+			value, err := f.value(rangeExpr)
+			if err != nil {
+				return 0, err
+			}
+			start = value
+			if !strings.Contains(part, "/") {
+				end = value
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(expr string) (int, error) {
+	if value, ok := f.names[strings.ToLower(expr)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", expr, f.name)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %d", f.name, f.min, f.max, value)
+	}
+	return value, nil
+}
+
+// Next returns the first time after t that matches the schedule, in t's
+// location, or the zero time if there is none within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.dayOfMonthAny || s.dayOfWeekAny {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
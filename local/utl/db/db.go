@@ -9,6 +9,7 @@ import (
 	"go.uber.org/dig"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func Start(di *dig.Container) {
@@ -21,6 +22,9 @@ func Start(di *dig.Container) {
 	if err != nil {
 		logging.Panic("failed to connect database")
 	}
+	if err := RegisterMetrics(db); err != nil {
+		logging.Error("Failed to register database metrics: %v", err)
+	}
 	err = di.Provide(func() *gorm.DB {
 		return db
 	})
@@ -54,6 +58,25 @@ func Migrate(db *gorm.DB) {
 		&model.LeaderboardRace{},
 		&model.LeaderboardResult{},
 		&model.LeaderboardPointRow{},
+		&model.LeaderboardStatus{},
+		&model.ConfigTemplate{},
+		&model.ConfigTemplateAssignment{},
+		&model.PlayerSession{},
+		&model.ResultSession{},
+		&model.ResultCar{},
+		&model.ResultDriver{},
+		&model.ResultLap{},
+		&model.ResultPenalty{},
+		&model.Schedule{},
+		&model.ScheduleExecution{},
+		&model.RestartPolicy{},
+		&model.ServerCrash{},
+		&model.UpdateJob{},
+		&model.Rotation{},
+		&model.RotationEntry{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
+		&model.Job{},
 	)
 
 	if err != nil {
@@ -76,6 +99,10 @@ func runMigrations(db *gorm.DB) {
 		logging.Error("Failed to run password security migration: %v", err)
 	}
 
+	if err := migrations.RunMultipleLeaderboardsMigration(db); err != nil {
+		logging.Error("Failed to run multiple leaderboards migration: %v", err)
+	}
+
 	logging.Info("Custom database migrations completed")
 }
 
@@ -139,14 +166,63 @@ func seedCarModels(db *gorm.DB) error {
 	carModels := []model.CarModel{
 		{Value: 0, CarModel: "Porsche 991 GT3 R"},
 		{Value: 1, CarModel: "Mercedes-AMG GT3"},
+		{Value: 2, CarModel: "Ferrari 488 GT3"},
+		{Value: 3, CarModel: "Audi R8 LMS"},
+		{Value: 4, CarModel: "Lamborghini Huracan GT3"},
+		{Value: 5, CarModel: "McLaren 650S GT3"},
+		{Value: 6, CarModel: "Nissan GT-R Nismo GT3 2018"},
+		{Value: 7, CarModel: "BMW M6 GT3"},
+		{Value: 8, CarModel: "Bentley Continental GT3 2018"},
+		{Value: 9, CarModel: "Porsche 991II GT3 Cup"},
+		{Value: 10, CarModel: "Nissan GT-R Nismo GT3 2017"},
+		{Value: 11, CarModel: "Bentley Continental GT3 2016"},
+		{Value: 12, CarModel: "Aston Martin V12 Vantage GT3"},
+		{Value: 13, CarModel: "Reiter Engineering R-EX GT3"},
+		{Value: 14, CarModel: "Emil Frey Jaguar G3"},
+		{Value: 15, CarModel: "Lexus RC F GT3"},
+		{Value: 16, CarModel: "Lamborghini Huracan GT3 Evo"},
+		{Value: 17, CarModel: "Honda NSX GT3"},
+		{Value: 18, CarModel: "Lamborghini Huracan SuperTrofeo"},
+		{Value: 19, CarModel: "Audi R8 LMS Evo"},
+		{Value: 20, CarModel: "Aston Martin V8 Vantage GT3"},
+		{Value: 21, CarModel: "Honda NSX GT3 Evo"},
+		{Value: 22, CarModel: "McLaren 720S GT3"},
+		{Value: 23, CarModel: "Porsche 911II GT3 R"},
+		{Value: 24, CarModel: "Ferrari 488 GT3 Evo"},
+		{Value: 25, CarModel: "Mercedes-AMG GT3 2020"},
+		{Value: 26, CarModel: "Ferrari 488 Challenge Evo"},
+		{Value: 27, CarModel: "BMW M2 CS Racing"},
+		{Value: 28, CarModel: "Porsche 992 GT3 Cup"},
+		{Value: 29, CarModel: "Lamborghini Huracan SuperTrofeo EVO2"},
+		{Value: 30, CarModel: "BMW M4 GT3"},
+		{Value: 31, CarModel: "Audi R8 LMS GT3 evo II"},
+		{Value: 32, CarModel: "Ferrari 296 GT3"},
+		{Value: 33, CarModel: "Lamborghini Huracan GT3 EVO2"},
+		{Value: 34, CarModel: "Porsche 992 GT3 R"},
+		{Value: 35, CarModel: "McLaren 720S GT3 Evo"},
+		{Value: 36, CarModel: "Ford Mustang GT3"},
+		{Value: 50, CarModel: "Alpine A110 GT4"},
+		{Value: 51, CarModel: "Aston Martin V8 Vantage GT4"},
+		{Value: 52, CarModel: "Audi R8 LMS GT4"},
+		{Value: 53, CarModel: "BMW M4 GT4"},
+		{Value: 55, CarModel: "Chevrolet Camaro GT4"},
+		{Value: 56, CarModel: "Ginetta G55 GT4"},
+		{Value: 57, CarModel: "KTM X-Bow GT4"},
+		{Value: 58, CarModel: "Maserati MC GT4"},
+		{Value: 59, CarModel: "McLaren 570S GT4"},
+		{Value: 60, CarModel: "Mercedes-AMG GT4"},
+		{Value: 61, CarModel: "Porsche 718 Cayman GT4"},
+		{Value: 80, CarModel: "Audi R8 LMS GT2"},
+		{Value: 82, CarModel: "KTM XBOW GT2"},
+		{Value: 83, CarModel: "Maserati MC20 GT2"},
+		{Value: 84, CarModel: "Mercedes-AMG GT2"},
+		{Value: 85, CarModel: "Porsche 911 GT2 RS CS Evo"},
+		{Value: 86, CarModel: "Porsche 935"},
 	}
 
-	for _, cm := range carModels {
-		if err := db.FirstOrCreate(&cm).Error; err != nil {
-			return err
-		}
-	}
-	return nil
+	// Upsert by value so databases seeded before the lookup values were
+	// stored verbatim get their rows corrected.
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&carModels).Error
 }
 
 func seedDriverCategories(db *gorm.DB) error {
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"acc-server-manager/local/utl/metrics"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const queryStartKey = "metrics:query_start"
+
+// RegisterMetrics times every query run through db and records it in
+// metrics.DBQueryDuration.
+func RegisterMetrics(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("metrics:before_create", startQueryTimer),
+		callbacks.Create().After("gorm:create").Register("metrics:after_create", observeQuery("create")),
+		callbacks.Query().Before("gorm:query").Register("metrics:before_query", startQueryTimer),
+		callbacks.Query().After("gorm:query").Register("metrics:after_query", observeQuery("query")),
+		callbacks.Update().Before("gorm:update").Register("metrics:before_update", startQueryTimer),
+		callbacks.Update().After("gorm:update").Register("metrics:after_update", observeQuery("update")),
+		callbacks.Delete().Before("gorm:delete").Register("metrics:before_delete", startQueryTimer),
+		callbacks.Delete().After("gorm:delete").Register("metrics:after_delete", observeQuery("delete")),
+		callbacks.Row().Before("gorm:row").Register("metrics:before_row", startQueryTimer),
+		callbacks.Row().After("gorm:row").Register("metrics:after_row", observeQuery("row")),
+		callbacks.Raw().Before("gorm:raw").Register("metrics:before_raw", startQueryTimer),
+		callbacks.Raw().After("gorm:raw").Register("metrics:after_raw", observeQuery("raw")),
+	)
+}
+
+func startQueryTimer(tx *gorm.DB) {
+	tx.InstanceSet(queryStartKey, time.Now())
+}
+
+func observeQuery(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		value, ok := tx.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		table := tx.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		metrics.DBQueryDuration.Observe(time.Since(start).Seconds(), operation, table)
+	}
+}
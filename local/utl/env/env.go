@@ -3,11 +3,31 @@ package env
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 )
 
 const (
-	DefaultSteamCMDPath = "c:\\steamcmd\\steamcmd.exe"
-	DefaultNSSMPath     = ".\\nssm.exe"
+	DefaultSteamCMDPath   = "c:\\steamcmd\\steamcmd.exe"
+	DefaultNSSMPath       = ".\\nssm.exe"
+	DefaultSystemdUnitDir = "/etc/systemd/system"
+	DefaultWinePath       = "wine"
+	DefaultNftablesChain  = "inet filter input"
+	DefaultBackupDir      = "backups"
+)
+
+const (
+	ServiceBackendNSSM    = "nssm"
+	ServiceBackendSystemd = "systemd"
+	ServiceBackendFake    = "fake"
+)
+
+const (
+	FirewallBackendNetsh    = "netsh"
+	FirewallBackendNftables = "nftables"
+	FirewallBackendIptables = "iptables"
+	FirewallBackendUfw      = "ufw"
+	FirewallBackendNone     = "none"
 )
 
 func GetSteamCMDPath() string {
@@ -29,6 +49,63 @@ func GetNSSMPath() string {
 	return DefaultNSSMPath
 }
 
+// GetServiceBackend returns the backend used to run ACC server processes.
+// Falls back to NSSM on Windows and systemd everywhere else.
+func GetServiceBackend() string {
+	if backend := os.Getenv("SERVICE_BACKEND"); backend != "" {
+		return strings.ToLower(backend)
+	}
+	if runtime.GOOS == "windows" {
+		return ServiceBackendNSSM
+	}
+	return ServiceBackendSystemd
+}
+
+func GetSystemdUnitDir() string {
+	if path := os.Getenv("SYSTEMD_UNIT_DIR"); path != "" {
+		return path
+	}
+	return DefaultSystemdUnitDir
+}
+
+// GetBackupDir returns the directory server backups are kept in, one
+// directory per server. It lies outside the servers' install paths so that
+// deleting or reinstalling a server leaves its backups alone.
+func GetBackupDir() string {
+	if path := os.Getenv("BACKUP_DIR"); path != "" {
+		return path
+	}
+	return DefaultBackupDir
+}
+
+func GetWinePath() string {
+	if path := os.Getenv("WINE_PATH"); path != "" {
+		return path
+	}
+	return DefaultWinePath
+}
+
+// GetFirewallBackend returns the firewall that receives server port rules.
+// Falls back to netsh on Windows; elsewhere the firewall is left to the host.
+func GetFirewallBackend() string {
+	if backend := os.Getenv("FIREWALL_BACKEND"); backend != "" {
+		return strings.ToLower(backend)
+	}
+	if runtime.GOOS == "windows" {
+		return FirewallBackendNetsh
+	}
+	return FirewallBackendNone
+}
+
+// GetNftablesChain returns the nftables chain, as "family table chain", that
+// the nftables firewall adds server port rules to.
+func GetNftablesChain() string {
+	if chain := os.Getenv("NFTABLES_CHAIN"); chain != "" {
+		return chain
+	}
+	return DefaultNftablesChain
+}
+
 func ValidatePaths() map[string]error {
 	errors := make(map[string]error)
 
@@ -37,9 +114,17 @@ func ValidatePaths() map[string]error {
 		errors["STEAMCMD_PATH"] = err
 	}
 
-	nssmPath := GetNSSMPath()
-	if _, err := os.Stat(nssmPath); os.IsNotExist(err) {
-		errors["NSSM_PATH"] = err
+	switch GetServiceBackend() {
+	case ServiceBackendNSSM:
+		nssmPath := GetNSSMPath()
+		if _, err := os.Stat(nssmPath); os.IsNotExist(err) {
+			errors["NSSM_PATH"] = err
+		}
+	case ServiceBackendSystemd:
+		unitDir := GetSystemdUnitDir()
+		if _, err := os.Stat(unitDir); os.IsNotExist(err) {
+			errors["SYSTEMD_UNIT_DIR"] = err
+		}
 	}
 
 	return errors
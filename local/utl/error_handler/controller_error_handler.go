@@ -26,11 +26,15 @@ type ErrorResponse struct {
 }
 
 func (ceh *ControllerErrorHandler) HandleError(c *fiber.Ctx, err error, statusCode int, context ...string) error {
+	return ceh.handleError(c, err, statusCode, nil, context...)
+}
+
+func (ceh *ControllerErrorHandler) handleError(c *fiber.Ctx, err error, statusCode int, details map[string]string, context ...string) error {
 	if err == nil {
 		return nil
 	}
 
-	_, file, line, _ := runtime.Caller(1)
+	_, file, line, _ := runtime.Caller(2)
 	file = strings.TrimPrefix(file, "acc-server-manager/")
 
 	contextStr := ""
@@ -48,8 +52,9 @@ func (ceh *ControllerErrorHandler) HandleError(c *fiber.Ctx, err error, statusCo
 	)
 
 	errorResponse := ErrorResponse{
-		Error: cleanErrorMsg,
-		Code:  statusCode,
+		Error:   cleanErrorMsg,
+		Code:    statusCode,
+		Details: details,
 	}
 
 	if c != nil {
@@ -93,6 +98,11 @@ func (ceh *ControllerErrorHandler) HandleValidationError(c *fiber.Ctx, err error
 	return ceh.HandleError(c, err, fiber.StatusBadRequest, "VALIDATION", field)
 }
 
+// HandleFieldErrors responds with 400 and one Details entry per invalid field.
+func (ceh *ControllerErrorHandler) HandleFieldErrors(c *fiber.Ctx, err error, fields map[string]string) error {
+	return ceh.handleError(c, err, fiber.StatusBadRequest, fields, "VALIDATION")
+}
+
 func (ceh *ControllerErrorHandler) HandleDatabaseError(c *fiber.Ctx, err error) error {
 	return ceh.HandleError(c, err, fiber.StatusInternalServerError, "DATABASE")
 }
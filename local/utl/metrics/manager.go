@@ -0,0 +1,49 @@
+package metrics
+
+// Metrics of the manager itself. The per-server gauges are registered by
+// the server service, which owns the tracked instances.
+var (
+	HTTPRequests = Default.NewCounterVec(
+		"acc_manager_http_requests_total",
+		"HTTP requests handled, by route and status code.",
+		"method", "route", "status",
+	)
+	HTTPRequestDuration = Default.NewHistogramVec(
+		"acc_manager_http_request_duration_seconds",
+		"Time taken to handle HTTP requests, by route.",
+		DefaultBuckets,
+		"method", "route",
+	)
+	SteamCMDJobDuration = Default.NewHistogramVec(
+		"acc_manager_steamcmd_job_duration_seconds",
+		"Time taken by SteamCMD installs and updates.",
+		[]float64{10, 30, 60, 120, 300, 600, 900},
+		"result",
+	)
+	CacheRequests = Default.NewCounterVec(
+		"acc_manager_cache_requests_total",
+		"Cache lookups, by cache and whether they hit.",
+		"cache", "result",
+	)
+	DBQueryDuration = Default.NewHistogramVec(
+		"acc_manager_db_query_duration_seconds",
+		"Time taken by database queries, by operation and table.",
+		[]float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		"operation", "table",
+	)
+)
+
+// Cache names and results for CacheRequests.
+const (
+	CacheStatus = "server_status"
+	CacheConfig = "server_config"
+
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// Result labels for SteamCMDJobDuration.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
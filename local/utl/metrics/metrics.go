@@ -0,0 +1,276 @@
+// Package metrics keeps counters, histograms and gauges and writes them in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds the metrics written by a scrape, in name order.
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// Default is the registry served on /metrics.
+var Default = NewRegistry()
+
+// register adds m under name, replacing a metric registered earlier under
+// the same name.
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = m
+}
+
+// Write writes every metric in the text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	metrics := make([]metric, len(names))
+	for i, name := range names {
+		metrics[i] = r.metrics[name]
+	}
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// desc is the name, help and label names shared by every kind of metric.
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d *desc) writeHeader(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, typ)
+}
+
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// formatLabels renders the label pairs of a series with extra pairs, such as
+// a histogram's "le", appended.
+func (d *desc) formatLabels(labelValues []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(d.labels)+len(extra)/2)
+	for i, label := range d.labels {
+		pairs = append(pairs, label+`="`+escapeLabel(labelValues[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec is a counter per combination of label values.
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec registers a counter on r.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name: name, help: help, labels: labels}, values: make(map[string]*counterSeries)}
+	r.register(name, c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter by v, which must not be negative.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	series, ok := c.values[key]
+	if !ok {
+		series = &counterSeries{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = series
+	}
+	series.value += v
+}
+
+// Value returns the current count for the label values.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if series, ok := c.values[key]; ok {
+		return series.value
+	}
+	return 0
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w, "counter")
+	for _, key := range sortedKeys(c.values) {
+		series := c.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.formatLabels(series.labelValues), formatFloat(series.value))
+	}
+}
+
+// HistogramVec counts observations into cumulative buckets per combination
+// of label values.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// DefaultBuckets suit request latencies in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// NewHistogramVec registers a histogram with the given upper bucket bounds
+// on r.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{desc: desc{name: name, help: help, labels: labels}, buckets: sorted, values: make(map[string]*histogramSeries)}
+	r.register(name, h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	series, ok := h.values[key]
+	if !ok {
+		series = &histogramSeries{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = series
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			series.counts[i]++
+		}
+	}
+	series.count++
+	series.sum += v
+}
+
+// Count returns how many values were observed for the label values.
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if series, ok := h.values[key]; ok {
+		return series.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		series := h.values[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.formatLabels(series.labelValues, "le", formatFloat(bound)), series.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.formatLabels(series.labelValues, "le", "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.formatLabels(series.labelValues), formatFloat(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.formatLabels(series.labelValues), series.count)
+	}
+}
+
+// GaugeFunc is a gauge whose samples are read from collect on every scrape.
+// collect calls emit once per series.
+type GaugeFunc struct {
+	desc
+	collect func(emit func(value float64, labelValues ...string))
+}
+
+// NewGaugeFunc registers a gauge read by collect on r.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func(emit func(value float64, labelValues ...string))) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{name: name, help: help, labels: labels}, collect: collect}
+	r.register(name, g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w, "gauge")
+	g.collect(func(value float64, labelValues ...string) {
+		g.key(labelValues)
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.formatLabels(labelValues), formatFloat(value))
+	})
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
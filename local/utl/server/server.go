@@ -2,6 +2,7 @@ package server
 
 import (
 	"acc-server-manager/local/api"
+	"acc-server-manager/local/middleware/metrics"
 	"acc-server-manager/local/middleware/security"
 	"acc-server-manager/local/utl/logging"
 	"os"
@@ -25,6 +26,7 @@ func Start(di *dig.Container) *fiber.App {
 
 	securityMW := security.NewSecurityMiddleware()
 
+	app.Use(metrics.NewRequestMetricsMiddleware().Handler())
 	app.Use(securityMW.SecurityHeaders())
 	app.Use(securityMW.LogSecurityEvents())
 	app.Use(securityMW.TimeoutMiddleware(20 * time.Minute))
@@ -0,0 +1,159 @@
+package tracking
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/broadcasting"
+	"sort"
+)
+
+// AttachBroadcasting makes the entry list and car updates of client feed
+// the live timing in the instance's State.
+func (instance *AccServerInstance) AttachBroadcasting(client *broadcasting.Client) {
+	client.OnEntryList = instance.HandleEntryList
+	client.OnEntryListCar = instance.HandleEntryListCar
+	client.OnCarUpdate = instance.HandleCarUpdate
+	client.OnRealtimeUpdate = instance.HandleRealtimeUpdate
+}
+
+// HandleRealtimeUpdate starts over the lap counting and session best when a
+// new session begins.
+func (instance *AccServerInstance) HandleRealtimeUpdate(update broadcasting.RealtimeUpdate) {
+	instance.carsMu.Lock()
+	defer instance.carsMu.Unlock()
+
+	if update.SessionIndex == instance.sessionIndex {
+		return
+	}
+	instance.sessionIndex = update.SessionIndex
+	instance.bestLapTime = 0
+	for _, car := range instance.cars {
+		car.CurrentLap = 0
+	}
+}
+
+// HandleEntryList forgets the cars that are no longer in the session.
+func (instance *AccServerInstance) HandleEntryList(list broadcasting.EntryList) {
+	instance.carsMu.Lock()
+	defer instance.carsMu.Unlock()
+
+	listed := make(map[int]bool, len(list.CarIndexes))
+	for _, carIndex := range list.CarIndexes {
+		listed[carIndex] = true
+	}
+	for carIndex := range instance.cars {
+		if !listed[carIndex] {
+			delete(instance.cars, carIndex)
+			delete(instance.entries, carIndex)
+		}
+	}
+	instance.publishCars()
+}
+
+// HandleEntryListCar records the team, car and drivers of a car.
+func (instance *AccServerInstance) HandleEntryListCar(entry broadcasting.EntryListCar) {
+	instance.carsMu.Lock()
+	defer instance.carsMu.Unlock()
+
+	instance.entries[entry.CarIndex] = entry
+	car := instance.car(entry.CarIndex)
+	car.RaceNumber = entry.RaceNumber
+	car.CarModel = entry.CarModel
+	car.TeamName = entry.TeamName
+	if driver, ok := entry.Driver(entry.CurrentDriverIndex); ok {
+		car.DriverName = driver.Name()
+	}
+	instance.publishCars()
+}
+
+// HandleCarUpdate records the position and lap times of a car, and reports
+// a lap once the car's lap count goes up.
+func (instance *AccServerInstance) HandleCarUpdate(update broadcasting.RealtimeCarUpdate) {
+	instance.carsMu.Lock()
+	car := instance.car(update.CarIndex)
+	if entry, ok := instance.entries[update.CarIndex]; ok {
+		if driver, ok := entry.Driver(update.DriverIndex); ok {
+			car.DriverName = driver.Name()
+		}
+	}
+	completedLap := car.CurrentLap > 0 && update.Laps+1 > car.CurrentLap && update.LastLap.LapTimeMs > 0
+	car.Position = update.Position
+	car.CurrentLap = update.Laps + 1
+	car.CurrentLapTime = update.CurrentLap.LapTimeMs
+	car.CurrentLapSectors = update.CurrentLap.Splits
+	car.LastLapTime = update.LastLap.LapTimeMs
+	car.LastLapSectors = update.LastLap.Splits
+	car.LastLapInvalid = update.LastLap.IsInvalid
+	car.BestLapTime = update.BestSessionLap.LapTimeMs
+	car.SplinePosition = update.SplinePosition
+	car.Location = update.Location.String()
+
+	sessionBest := false
+	if completedLap && !car.LastLapInvalid && (instance.bestLapTime == 0 || car.LastLapTime < instance.bestLapTime) {
+		instance.bestLapTime = car.LastLapTime
+		sessionBest = true
+	}
+	cars := instance.publishCars()
+	instance.carsMu.Unlock()
+
+	if completedLap && instance.OnLapCompleted != nil {
+		for _, published := range cars {
+			if published.CarID == update.CarIndex {
+				instance.OnLapCompleted(published, sessionBest)
+				break
+			}
+		}
+	}
+}
+
+// car returns the timing of a car, creating it when the car is new.
+// carsMu must be held.
+func (instance *AccServerInstance) car(carIndex int) *model.PlayerState {
+	car, ok := instance.cars[carIndex]
+	if !ok {
+		car = &model.PlayerState{CarID: carIndex}
+		instance.cars[carIndex] = car
+	}
+	return car
+}
+
+// publishCars copies the timing of every car into State.Cars, joined with
+// the connection the server log reported for the car, and returns the copy.
+// carsMu must be held.
+func (instance *AccServerInstance) publishCars() []model.PlayerState {
+	connections := make(map[int]model.PlayerState)
+	for _, player := range instance.Players() {
+		if player.CarID != 0 {
+			connections[player.CarID] = player
+		}
+	}
+
+	cars := make([]model.PlayerState, 0, len(instance.cars))
+	for _, car := range instance.cars {
+		snapshot := *car
+		if player, ok := connections[car.CarID]; ok {
+			snapshot.ID = player.ID
+			snapshot.ConnectionID = player.ConnectionID
+			snapshot.SteamID = player.SteamID
+			snapshot.ConnectedAt = player.ConnectedAt
+			snapshot.IsConnected = player.IsConnected
+			if snapshot.DriverName == "" {
+				snapshot.DriverName = player.DriverName
+			}
+		}
+		cars = append(cars, snapshot)
+	}
+	sort.Slice(cars, func(i, j int) bool {
+		if (cars[i].Position == 0) != (cars[j].Position == 0) {
+			return cars[j].Position == 0
+		}
+		if cars[i].Position != cars[j].Position {
+			return cars[i].Position < cars[j].Position
+		}
+		return cars[i].CarID < cars[j].CarID
+	})
+
+	instance.State.Lock()
+	instance.State.Cars = cars
+	instance.State.Unlock()
+	return cars
+}
@@ -0,0 +1,176 @@
+package tracking
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileSnapshot is what the watcher remembers of a file. hash is unset for
+// files that have not been read yet, and data is only kept when the watcher
+// reports old contents.
+type fileSnapshot struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+	hashed  bool
+	data    []byte
+}
+
+// FileWatcher polls a directory and reports JSON files that were created or
+// modified by something other than WriteFile. Deleted files are forgotten
+// without being reported.
+type FileWatcher struct {
+	dir       string
+	interval  time.Duration
+	onChange  func(fileName string, oldData, newData []byte)
+	keepData  bool
+	mu        sync.Mutex
+	files     map[string]fileSnapshot
+	stopChan  chan struct{}
+	isRunning bool
+}
+
+// NewFileWatcher returns a watcher that keeps the contents of every file, so
+// that onChange gets both the old and the new contents of a changed file.
+func NewFileWatcher(dir string, interval time.Duration, onChange func(fileName string, oldData, newData []byte)) *FileWatcher {
+	return &FileWatcher{
+		dir:      dir,
+		interval: interval,
+		onChange: onChange,
+		keepData: true,
+		files:    make(map[string]fileSnapshot),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// NewFileStatWatcher returns a watcher that only remembers the size,
+// modification time and hash of each file, for directories that grow too
+// large to keep in memory. onChange gets the new contents of a changed file.
+func NewFileStatWatcher(dir string, interval time.Duration, onChange func(fileName string, newData []byte)) *FileWatcher {
+	return &FileWatcher{
+		dir:      dir,
+		interval: interval,
+		onChange: func(fileName string, _, newData []byte) { onChange(fileName, newData) },
+		files:    make(map[string]fileSnapshot),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start takes a snapshot of the directory, so files that already exist are
+// not reported, and then polls it until Stop is called.
+func (w *FileWatcher) Start() {
+	w.mu.Lock()
+	if w.isRunning {
+		w.mu.Unlock()
+		return
+	}
+	w.isRunning = true
+	w.scan(false)
+	w.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stopChan:
+				return
+			case <-ticker.C:
+				w.Poll()
+			}
+		}
+	}()
+}
+
+func (w *FileWatcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.isRunning {
+		return
+	}
+	w.isRunning = false
+	close(w.stopChan)
+}
+
+// Poll checks the directory once and reports every changed file.
+func (w *FileWatcher) Poll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.scan(true)
+}
+
+// WriteFile writes a file in the watched directory without it being reported
+// as a change.
+func (w *FileWatcher) WriteFile(fileName string, data []byte, perm os.FileMode) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	path := filepath.Join(w.dir, fileName)
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		w.files[fileName] = w.snapshot(info, data)
+	}
+	return nil
+}
+
+func (w *FileWatcher) snapshot(info os.FileInfo, data []byte) fileSnapshot {
+	snapshot := fileSnapshot{modTime: info.ModTime(), size: info.Size(), hash: sha256.Sum256(data), hashed: true}
+	if w.keepData {
+		snapshot.data = data
+	}
+	return snapshot
+}
+
+func (w *FileWatcher) scan(report bool) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return
+	}
+
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(name), ".json") {
+			continue
+		}
+		seen[name] = true
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		previous, known := w.files[name]
+		if known && previous.modTime.Equal(info.ModTime()) && previous.size == info.Size() {
+			continue
+		}
+		if !report && !w.keepData {
+			// Nothing is reported yet, so there is no need to read the file.
+			w.files[name] = fileSnapshot{modTime: info.ModTime(), size: info.Size()}
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(w.dir, name))
+		if err != nil {
+			continue
+		}
+		snapshot := w.snapshot(info, data)
+		w.files[name] = snapshot
+
+		if report && (!previous.hashed || previous.hash != snapshot.hash) && w.onChange != nil {
+			w.onChange(name, previous.data, data)
+		}
+	}
+
+	for name := range w.files {
+		if !seen[name] {
+			delete(w.files, name)
+		}
+	}
+}
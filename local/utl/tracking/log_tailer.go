@@ -3,15 +3,23 @@ package tracking
 import (
 	"bufio"
 	"os"
+	"sync"
 	"time"
 )
 
+// logTailerHistory is how many of the latest lines a tailer keeps.
+const logTailerHistory = 200
+
 type LogTailer struct {
 	filePath   string
 	handleLine func(string)
 	stopChan   chan struct{}
 	isRunning  bool
 	tracker    *PositionTracker
+
+	mu         sync.Mutex
+	recent     []string
+	lastLineAt time.Time
 }
 
 func NewLogTailer(filePath string, handleLine func(string)) *LogTailer {
@@ -61,6 +69,7 @@ func (t *LogTailer) Start() {
 					scanner := bufio.NewScanner(file)
 					for scanner.Scan() {
 						line := scanner.Text()
+						t.remember(line)
 						t.handleLine(line)
 						lastSize, _ = file.Seek(0, 1)
 
@@ -85,3 +94,34 @@ func (t *LogTailer) Stop() {
 	}
 	close(t.stopChan)
 }
+
+func (t *LogTailer) remember(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.recent) == logTailerHistory {
+		t.recent = append(t.recent[:0], t.recent[1:]...)
+	}
+	t.recent = append(t.recent, line)
+	t.lastLineAt = time.Now()
+}
+
+// RecentLines returns up to n of the latest lines read, oldest first.
+func (t *LogTailer) RecentLines(n int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if n > len(t.recent) {
+		n = len(t.recent)
+	}
+	lines := make([]string, n)
+	copy(lines, t.recent[len(t.recent)-n:])
+	return lines
+}
+
+// LastActivity returns when the last line was read, zero if none was.
+func (t *LogTailer) LastActivity() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastLineAt
+}
@@ -0,0 +1,142 @@
+package tracking
+
+import (
+	"acc-server-manager/local/model"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Players returns a copy of the players currently connected.
+func (instance *AccServerInstance) Players() []model.PlayerState {
+	instance.playersMu.Lock()
+	defer instance.playersMu.Unlock()
+
+	players := make([]model.PlayerState, 0, len(instance.players))
+	for _, player := range instance.players {
+		players = append(players, *player)
+	}
+	return players
+}
+
+// PlayerConnected registers a driver from a "New connection request" line.
+func (instance *AccServerInstance) PlayerConnected(connectionID int, driverName, steamID string, carModel int) {
+	instance.playersMu.Lock()
+	previous, reconnected := instance.players[connectionID]
+	if reconnected {
+		instance.disconnect(previous)
+	}
+
+	player := &model.PlayerState{
+		ID:           uuid.New(),
+		ConnectionID: connectionID,
+		DriverName:   driverName,
+		SteamID:      steamID,
+		CarModel:     carModel,
+		ConnectedAt:  time.Now().UTC(),
+		IsConnected:  true,
+	}
+	instance.players[connectionID] = player
+	snapshot := *player
+	instance.playersMu.Unlock()
+
+	if reconnected {
+		instance.notifyPlayerChange(*previous)
+	}
+	instance.notifyPlayerChange(snapshot)
+}
+
+// PlayerCarAssigned attaches the car created for the latest connection that
+// does not have one yet, since ACC logs the car without its connection ID.
+func (instance *AccServerInstance) PlayerCarAssigned(carID, carModel, raceNumber int) {
+	instance.playersMu.Lock()
+	var player *model.PlayerState
+	for _, candidate := range instance.players {
+		if candidate.CarID != 0 || candidate.CarModel != carModel {
+			continue
+		}
+		if player == nil || candidate.ConnectedAt.After(player.ConnectedAt) {
+			player = candidate
+		}
+	}
+	if player == nil {
+		instance.playersMu.Unlock()
+		return
+	}
+	player.CarID = carID
+	player.RaceNumber = raceNumber
+	snapshot := *player
+	instance.playersMu.Unlock()
+
+	instance.notifyPlayerChange(snapshot)
+}
+
+// PlayerDisconnected closes the connection with the given ID.
+func (instance *AccServerInstance) PlayerDisconnected(connectionID int) {
+	instance.playersMu.Lock()
+	player, ok := instance.players[connectionID]
+	if !ok {
+		instance.playersMu.Unlock()
+		return
+	}
+	instance.disconnect(player)
+	instance.playersMu.Unlock()
+
+	instance.notifyPlayerChange(*player)
+}
+
+// CarDisconnected closes the connection driving the given car.
+func (instance *AccServerInstance) CarDisconnected(carID int) {
+	instance.playersMu.Lock()
+	var player *model.PlayerState
+	for _, candidate := range instance.players {
+		if candidate.CarID == carID {
+			player = candidate
+			break
+		}
+	}
+	if player == nil {
+		instance.playersMu.Unlock()
+		return
+	}
+	instance.disconnect(player)
+	instance.playersMu.Unlock()
+
+	instance.notifyPlayerChange(*player)
+}
+
+// DisconnectAllPlayers closes every open connection, e.g. once the server
+// reports that no clients are online.
+func (instance *AccServerInstance) DisconnectAllPlayers() {
+	instance.playersMu.Lock()
+	disconnected := make([]*model.PlayerState, 0, len(instance.players))
+	for _, player := range instance.players {
+		instance.disconnect(player)
+		disconnected = append(disconnected, player)
+	}
+	instance.playersMu.Unlock()
+
+	for _, player := range disconnected {
+		instance.notifyPlayerChange(*player)
+	}
+}
+
+// disconnect marks player as gone and forgets it. playersMu must be held.
+func (instance *AccServerInstance) disconnect(player *model.PlayerState) {
+	now := time.Now().UTC()
+	player.DisconnectedAt = &now
+	player.IsConnected = false
+	delete(instance.players, player.ConnectionID)
+}
+
+func (instance *AccServerInstance) notifyPlayerChange(player model.PlayerState) {
+	if instance.OnPlayerChange != nil {
+		instance.OnPlayerChange(player)
+	}
+}
+
+func atoi(value string) int {
+	number, _ := strconv.Atoi(value)
+	return number
+}
@@ -2,11 +2,13 @@ package tracking
 
 import (
 	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/broadcasting"
 	"acc-server-manager/local/utl/regex_handler"
 	"bufio"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -26,6 +28,24 @@ type AccServerInstance struct {
 	Model         *model.Server
 	State         *model.ServerState
 	OnStateChange func(*model.ServerState, ...StateChange)
+	// OnPlayerChange, when set, receives a copy of a player whenever one
+	// connects, is given a car or disconnects.
+	OnPlayerChange func(model.PlayerState)
+	// OnSessionChange, when set, receives both sides of every "Session
+	// changed" line in the server log.
+	OnSessionChange func(from, to model.TrackSession)
+	// OnLapCompleted, when set, receives the timing of a car that completed
+	// a lap, and whether the lap is the best of the session so far.
+	OnLapCompleted func(car model.PlayerState, sessionBest bool)
+
+	playersMu sync.Mutex
+	players   map[int]*model.PlayerState // Connected players by connection ID
+
+	carsMu       sync.Mutex
+	cars         map[int]*model.PlayerState        // Live timing by car index
+	entries      map[int]broadcasting.EntryListCar // Broadcast entries by car index
+	sessionIndex int
+	bestLapTime  int // Best valid lap of the session
 }
 
 func NewAccServerInstance(server *model.Server, onStateChange func(*model.ServerState, ...StateChange)) *AccServerInstance {
@@ -33,6 +53,9 @@ func NewAccServerInstance(server *model.Server, onStateChange func(*model.Server
 		Model:         server,
 		State:         &model.ServerState{PlayerCount: 0},
 		OnStateChange: onStateChange,
+		players:       make(map[int]*model.PlayerState),
+		cars:          make(map[int]*model.PlayerState),
+		entries:       make(map[int]broadcasting.EntryListCar),
 	}
 }
 
@@ -64,6 +87,15 @@ func (rh *StateRegexHandler) Count(line string) int {
 	return count
 }
 
+// Match returns the submatches of line, or nil when it does not match.
+func (rh *StateRegexHandler) Match(line string) []string {
+	var match []string
+	rh.Contains(line, func(strs ...string) {
+		match = strs
+	})
+	return match
+}
+
 func (rh *StateRegexHandler) Change(line string) (string, string) {
 	var old string = ""
 	var new string = ""
@@ -101,6 +133,9 @@ const (
 	UDPCount
 	ClientsOnline
 	RemovingDeadConnection
+	NewConnectionRequest
+	CarConnection
+	CarDisconnected
 )
 
 var logStateContain = map[LogStateType]string{
@@ -109,13 +144,19 @@ var logStateContain = map[LogStateType]string{
 	UDPCount:               "Udp message count",
 	ClientsOnline:          "client(s) online",
 	RemovingDeadConnection: "Removing dead connection",
+	NewConnectionRequest:   "New connection request",
+	CarConnection:          "Creating new car connection",
+	CarDisconnected:        "has no driving connection anymore",
 }
 
 var sessionChangeRegex = NewRegexHandler(`Session changed: (\w+) -> (\w+)`, logStateContain[SessionChange])
 var leaderboardUpdateRegex = NewRegexHandler(`Updated leaderboard for (\d+) clients`, logStateContain[LeaderboardUpdate])
 var udpCountRegex = NewRegexHandler(`Udp message count (\d+) client`, logStateContain[UDPCount])
 var clientsOnlineRegex = NewRegexHandler(`(\d+) client\(s\) online`, logStateContain[ClientsOnline])
-var removingDeadConnectionsRegex = NewRegexHandler(`Removing dead connection`, logStateContain[RemovingDeadConnection])
+var removingDeadConnectionsRegex = NewRegexHandler(`Removing dead connection (\d+)`, logStateContain[RemovingDeadConnection])
+var newConnectionRequestRegex = NewRegexHandler(`New connection request: id (\d+) (.+) (S\d+) on car model (\d+)`, logStateContain[NewConnectionRequest])
+var carConnectionRegex = NewRegexHandler(`Creating new car connection: carId (\d+), carModel (\d+), raceNumber #(\d+)`, logStateContain[CarConnection])
+var carDisconnectedRegex = NewRegexHandler(`[Cc]ar (\d+) has no driving connection anymore`, logStateContain[CarDisconnected])
 
 var logStateRegex = map[LogStateType]*StateRegexHandler{
 	SessionChange:          sessionChangeRegex,
@@ -123,6 +164,9 @@ var logStateRegex = map[LogStateType]*StateRegexHandler{
 	UDPCount:               udpCountRegex,
 	ClientsOnline:          clientsOnlineRegex,
 	RemovingDeadConnection: removingDeadConnectionsRegex,
+	NewConnectionRequest:   newConnectionRequestRegex,
+	CarConnection:          carConnectionRegex,
+	CarDisconnected:        carDisconnectedRegex,
 }
 
 func (instance *AccServerInstance) HandleLogLine(line string) {
@@ -134,13 +178,34 @@ func (instance *AccServerInstance) HandleLogLine(line string) {
 			case ClientsOnline:
 				count := regexHandler.Count(line)
 				instance.UpdatePlayerCount(count)
+				if count == 0 {
+					instance.DisconnectAllPlayers()
+				}
 			case SessionChange:
-				_, new := regexHandler.Change(line)
+				old, new := regexHandler.Change(line)
 
 				trackSession := model.ToTrackSession(new)
 				instance.UpdateSessionChange(trackSession)
+				if instance.OnSessionChange != nil {
+					instance.OnSessionChange(model.ToTrackSession(old), trackSession)
+				}
 			case RemovingDeadConnection:
 				instance.UpdatePlayerCount(instance.State.PlayerCount - 1)
+				if match := regexHandler.Match(line); match != nil {
+					instance.PlayerDisconnected(atoi(match[1]))
+				}
+			case NewConnectionRequest:
+				if match := regexHandler.Match(line); match != nil {
+					instance.PlayerConnected(atoi(match[1]), strings.TrimSpace(match[2]), match[3], atoi(match[4]))
+				}
+			case CarConnection:
+				if match := regexHandler.Match(line); match != nil {
+					instance.PlayerCarAssigned(atoi(match[1]), atoi(match[2]), atoi(match[3]))
+				}
+			case CarDisconnected:
+				if match := regexHandler.Match(line); match != nil {
+					instance.CarDisconnected(atoi(match[1]))
+				}
 			}
 		}
 	}
@@ -184,7 +184,7 @@ func createTestData(t *testing.T, tempDir string) *TestData {
 		"eventRules.json": `{
 			"qualifyStandingType": "1",
 			"pitWindowLengthSec": "600",
-			"driverStIntStringTimeSec": "300",
+			"driverStintTimeSec": "300",
 			"mandatoryPitstopCount": "0",
 			"maxTotalDrivingTime": "0",
 			"isRefuellingAllowedInRace": 0,
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/cache"
+	"acc-server-manager/tests"
+	"errors"
+	"testing"
+)
+
+func newBOPService(t *testing.T, helper *tests.TestHelper) *service.BOPService {
+	tests.AssertNoError(t, helper.DB.AutoMigrate(&model.Track{}, &model.CarModel{}))
+	tests.AssertNoError(t, helper.DB.Create(&[]model.Track{
+		{Name: "monza", UniquePitBoxes: 29, PrivateServerSlots: 60},
+		{Name: "spa", UniquePitBoxes: 82, PrivateServerSlots: 82},
+	}).Error)
+	tests.AssertNoError(t, helper.DB.Create(&[]model.CarModel{
+		{Value: 0, CarModel: "Porsche 991 GT3 R"},
+		{Value: 32, CarModel: "Ferrari 296 GT3"},
+	}).Error)
+	tests.AssertNoError(t, helper.InsertTestServer())
+
+	configRepo := repository.NewConfigRepository(helper.DB)
+	serverRepo := repository.NewServerRepository(helper.DB)
+	lookupRepo := repository.NewLookupRepository(helper.DB, cache.NewInMemoryCache())
+	configService := service.NewConfigService(configRepo, serverRepo)
+
+	return service.NewBOPService(configService, serverRepo, lookupRepo)
+}
+
+func TestBOPService_SetAndRemoveEntry(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	bopService := newBOPService(t, helper)
+	ctx := helper.CreateContext()
+	serverID := helper.TestData.ServerID
+
+	_, err := bopService.SetEntry(ctx, serverID, &model.BOPEntry{Track: "monza", CarModel: 32, BallastKg: 10})
+	tests.AssertNoError(t, err)
+	_, err = bopService.SetEntry(ctx, serverID, &model.BOPEntry{Track: "spa", CarModel: 0, Restrictor: 2})
+	tests.AssertNoError(t, err)
+
+	bop, err := bopService.SetEntry(ctx, serverID, &model.BOPEntry{Track: "monza", CarModel: 32, BallastKg: -5})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 2, len(bop.Entries))
+	tests.AssertEqual(t, model.IntString(-5), bop.Entries[0].BallastKg)
+
+	monza, err := bopService.GetBOP(ctx, serverID, "monza")
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(monza.Entries))
+
+	bop, err = bopService.RemoveEntry(ctx, serverID, "monza", 32)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(bop.Entries))
+	tests.AssertEqual(t, "spa", bop.Entries[0].Track)
+
+	_, err = bopService.RemoveEntry(ctx, serverID, "monza", 32)
+	tests.AssertEqual(t, true, errors.Is(err, service.ErrBOPEntryNotFound))
+
+	var history []model.Config
+	tests.AssertNoError(t, helper.DB.Where("server_id = ? AND config_file = ?", serverID, service.BOPJson).Find(&history).Error)
+	tests.AssertEqual(t, 4, len(history))
+}
+
+func TestBOPService_RejectsUnknownTrackAndCar(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	bopService := newBOPService(t, helper)
+	ctx := helper.CreateContext()
+
+	_, err := bopService.SetEntry(ctx, helper.TestData.ServerID, &model.BOPEntry{Track: "daytona", CarModel: 99, BallastKg: 50})
+
+	var validationErr *model.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	fields := validationErr.Fields()
+	tests.AssertEqual(t, `unknown track "daytona"`, fields["entries[0].track"])
+	tests.AssertEqual(t, "unknown car model 99", fields["entries[0].carModel"])
+	tests.AssertEqual(t, "must be between -40 and 40", fields["entries[0].ballastKg"])
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/utl/broadcasting"
+	"acc-server-manager/local/utl/tracking"
+	"acc-server-manager/tests"
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBroadcastingClient_ReplayFeedsServerState(t *testing.T) {
+	packets, err := broadcasting.LoadCaptureFile("../../testdata/broadcasting_session.capture")
+	tests.AssertNoError(t, err)
+
+	instance := tracking.NewAccServerInstance(&model.Server{Name: "Replay"}, func(*model.ServerState, ...tracking.StateChange) {})
+	instance.PlayerConnected(3, "Alan Turing", "S76561198000000001", 32)
+	instance.PlayerCarAssigned(1002, 32, 88)
+
+	conn := broadcasting.NewReplayConn(packets)
+	client := broadcasting.NewClientWithDialer(broadcasting.Config{
+		Address:            "replay",
+		DisplayName:        "ACC Server Manager",
+		ConnectionPassword: "secret",
+	}, func() (broadcasting.Conn, error) {
+		return conn, nil
+	})
+	instance.AttachBroadcasting(client)
+	var events []broadcasting.BroadcastingEvent
+	client.OnEvent = func(event broadcasting.BroadcastingEvent) {
+		events = append(events, event)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		client.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-conn.Drained():
+	case <-time.After(5 * time.Second):
+		t.Fatal("replay was not read")
+	}
+	client.Stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not stop")
+	}
+
+	written := conn.Written()
+	tests.AssertEqual(t, 4, len(written))
+	tests.AssertEqual(t, "[1 4]", fmt.Sprint(written[0][:2]))
+	tests.AssertEqual(t, true, bytes.Contains(written[0], []byte("secret")))
+	tests.AssertEqual(t, "[10 7 0 0 0]", fmt.Sprint(written[1]))
+	tests.AssertEqual(t, "[11 7 0 0 0]", fmt.Sprint(written[2]))
+	tests.AssertEqual(t, "[9 7 0 0 0]", fmt.Sprint(written[3]))
+
+	tests.AssertEqual(t, 1, len(events))
+	tests.AssertEqual(t, broadcasting.EventLapCompleted, events[0].Type)
+	tests.AssertEqual(t, 1002, events[0].CarID)
+
+	instance.State.RLock()
+	cars := instance.State.Cars
+	instance.State.RUnlock()
+	tests.AssertEqual(t, 2, len(cars))
+
+	leader := cars[0]
+	tests.AssertEqual(t, 1002, leader.CarID)
+	tests.AssertEqual(t, 1, leader.Position)
+	tests.AssertEqual(t, 88, leader.RaceNumber)
+	tests.AssertEqual(t, "Apex Motorsport", leader.TeamName)
+	tests.AssertEqual(t, "Alan Turing", leader.DriverName)
+	tests.AssertEqual(t, "S76561198000000001", leader.SteamID)
+	tests.AssertEqual(t, 3, leader.ConnectionID)
+	tests.AssertEqual(t, 6, leader.CurrentLap)
+	tests.AssertEqual(t, 79100, leader.CurrentLapTime)
+	tests.AssertEqual(t, "[35200 38000 0]", fmt.Sprint(leader.CurrentLapSectors))
+	tests.AssertEqual(t, 108200, leader.LastLapTime)
+	tests.AssertEqual(t, "[35400 38200 34600]", fmt.Sprint(leader.LastLapSectors))
+	tests.AssertEqual(t, 107900, leader.BestLapTime)
+	tests.AssertEqual(t, "track", leader.Location)
+
+	second := cars[1]
+	tests.AssertEqual(t, 1001, second.CarID)
+	tests.AssertEqual(t, 2, second.Position)
+	tests.AssertEqual(t, "Grace Hopper", second.DriverName)
+	tests.AssertEqual(t, "", second.SteamID)
+	tests.AssertEqual(t, 0, second.CurrentLapTime)
+	tests.AssertEqual(t, "[0 0 0]", fmt.Sprint(second.CurrentLapSectors))
+	tests.AssertEqual(t, 109400, second.LastLapTime)
+	tests.AssertEqual(t, "pitlane", second.Location)
+}
+
+func TestBroadcasting_Validate(t *testing.T) {
+	configuration := &model.Configuration{UdpPort: 9231, TcpPort: 9232}
+
+	tests.AssertNoError(t, (&model.Broadcasting{}).Validate(configuration))
+	tests.AssertNoError(t, (&model.Broadcasting{UdpListenerPort: 9000}).Validate(configuration))
+	tests.AssertError(t, (&model.Broadcasting{UdpListenerPort: 9231}).Validate(configuration),
+		"validation failed: updListenerPort: must differ from the server's udpPort and tcpPort")
+	tests.AssertError(t, (&model.Broadcasting{UdpListenerPort: 70000}).Validate(nil),
+		"validation failed: updListenerPort: must be between 0 and 65535")
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/tests"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	oldConfig := `{"track": "spa", "ambientTemp": 22, "sessions": [{"sessionType": "P"}, {"sessionType": "R"}]}`
+	newConfig := `{"track": "monza", "cloudLevel": 0.2, "sessions": [{"sessionType": "P"}]}`
+
+	changes, err := model.DiffConfigs(oldConfig, newConfig)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 4, len(changes))
+
+	tests.AssertEqual(t, model.ConfigChange{Path: "ambientTemp", Type: model.ConfigChangeRemoved, OldValue: float64(22)}, changes[0])
+	tests.AssertEqual(t, model.ConfigChange{Path: "cloudLevel", Type: model.ConfigChangeAdded, NewValue: 0.2}, changes[1])
+	tests.AssertEqual(t, "sessions[1]", changes[2].Path)
+	tests.AssertEqual(t, model.ConfigChangeRemoved, changes[2].Type)
+	tests.AssertEqual(t, model.ConfigChange{Path: "track", Type: model.ConfigChangeChanged, OldValue: "spa", NewValue: "monza"}, changes[3])
+}
+
+func TestConfigService_HistoryDiffAndRollback(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	configRepo := repository.NewConfigRepository(helper.DB)
+	serverRepo := repository.NewServerRepository(helper.DB)
+	configService := service.NewConfigService(configRepo, serverRepo)
+
+	userID := uuid.New()
+	ctx := model.ContextWithActor(helper.CreateContext(), model.Actor{UserID: &userID, Username: "admin"})
+	server := helper.TestData.Server
+
+	first, err := configService.SaveBOP(ctx, server, &model.BOP{Entries: []model.BOPEntry{{Track: "spa", CarModel: 32, BallastKg: 10}}})
+	tests.AssertNoError(t, err)
+	second, err := configService.SaveBOP(ctx, server, &model.BOP{Entries: []model.BOPEntry{{Track: "spa", CarModel: 32, BallastKg: 25}}})
+	tests.AssertNoError(t, err)
+
+	history, err := configService.GetHistory(ctx, &model.ConfigFilter{ServerBasedFilter: model.ServerBasedFilter{ServerID: server.ID.String()}})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 2, history.TotalRecords)
+	revisions := *history.Items.(*[]model.Config)
+	tests.AssertEqual(t, "admin", revisions[0].ChangedBy)
+	tests.AssertEqual(t, userID, *revisions[0].ChangedByID)
+
+	diff, err := configService.DiffRevisions(ctx, server.ID, &first.ID, second.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(diff.Changes))
+	tests.AssertEqual(t, "entries[0].ballastKg", diff.Changes[0].Path)
+	tests.AssertEqual(t, float64(25), diff.Changes[0].NewValue)
+
+	rollback, err := configService.RollbackConfig(ctx, server.ID, first.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, service.BOPJson, rollback.ConfigFile)
+
+	bop, err := configService.GetBOP(server)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.IntString(10), bop.Entries[0].BallastKg)
+
+	_, err = configService.RollbackConfig(ctx, server.ID, uuid.New())
+	tests.AssertError(t, err, "Config revision not found")
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/tests"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestConfigTemplateService_ApplyAndDrift(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	tests.AssertNoError(t, helper.DB.AutoMigrate(&model.ConfigTemplate{}, &model.ConfigTemplateAssignment{}))
+
+	configPath := helper.TestData.Server.GetConfigPath()
+	tests.AssertNoError(t, os.MkdirAll(configPath, 0755))
+	assistRules, err := tests.EncodeUTF16LEBOM([]byte(helper.TestData.ConfigFiles[service.AssistRulesJson]))
+	tests.AssertNoError(t, err)
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(configPath, service.AssistRulesJson), assistRules, 0644))
+
+	configRepo := repository.NewConfigRepository(helper.DB)
+	serverRepo := repository.NewServerRepository(helper.DB)
+	configService := service.NewConfigService(configRepo, serverRepo)
+	templateService := service.NewConfigTemplateService(repository.NewConfigTemplateRepository(helper.DB), serverRepo, configService)
+	ctx := helper.CreateContext()
+	serverID := helper.TestData.ServerID
+
+	template, err := templateService.Create(ctx, &model.ConfigTemplate{
+		Name: "League assists",
+		Content: model.ConfigTemplateContent{
+			"assistRules": {"stabilityControlLevelMax": 0, "disableIdealLine": 1},
+		},
+	})
+	tests.AssertNoError(t, err)
+
+	results, err := templateService.Apply(ctx, template.ID, []uuid.UUID{serverID, uuid.New()}, false)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, true, results[0].Success)
+	tests.AssertEqual(t, false, results[1].Success)
+
+	assists, err := configService.LoadConfigs(helper.TestData.Server)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.IntString(1), assists.AssistRules.DisableIdealLine)
+	tests.AssertEqual(t, model.IntString(1), assists.AssistRules.DisableAutosteer)
+
+	drift, err := templateService.Drift(ctx, template.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(drift))
+	tests.AssertEqual(t, false, drift[0].Drifted)
+
+	edited, err := tests.EncodeUTF16LEBOM([]byte(`{"stabilityControlLevelMax": "0", "disableIdealLine": "0"}`))
+	tests.AssertNoError(t, err)
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(configPath, service.AssistRulesJson), edited, 0644))
+
+	drift, err = templateService.Drift(ctx, template.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, true, drift[0].Drifted)
+	changes := drift[0].Changes[service.AssistRulesJson]
+	tests.AssertEqual(t, 1, len(changes))
+	tests.AssertEqual(t, "disableIdealLine", changes[0].Path)
+
+	other, err := templateService.Create(ctx, &model.ConfigTemplate{
+		Name:    "Assists on",
+		Content: model.ConfigTemplateContent{"assistRules": {"disableIdealLine": 0}},
+	})
+	tests.AssertNoError(t, err)
+	_, err = templateService.Apply(ctx, other.ID, []uuid.UUID{serverID}, false)
+	tests.AssertNoError(t, err)
+
+	drift, err = templateService.Drift(ctx, template.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 0, len(drift))
+}
+
+func TestConfigTemplateService_RejectsUnknownFields(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(&model.ConfigTemplate{}, &model.ConfigTemplateAssignment{}))
+	serverRepo := repository.NewServerRepository(helper.DB)
+	configService := service.NewConfigService(repository.NewConfigRepository(helper.DB), serverRepo)
+	templateService := service.NewConfigTemplateService(repository.NewConfigTemplateRepository(helper.DB), serverRepo, configService)
+
+	_, err := templateService.Create(helper.CreateContext(), &model.ConfigTemplate{
+		Name:    "Broken",
+		Content: model.ConfigTemplateContent{"assistRules": {"notAField": 1}},
+	})
+
+	var validationErr *model.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+}
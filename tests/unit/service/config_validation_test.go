@@ -0,0 +1,221 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/cache"
+	"acc-server-manager/tests"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newValidatingConfigService(t *testing.T, helper *tests.TestHelper) *service.ConfigService {
+	tests.AssertNoError(t, helper.DB.AutoMigrate(&model.Track{}))
+	tests.AssertNoError(t, helper.DB.Create(&[]model.Track{
+		{Name: "monza", UniquePitBoxes: 29, PrivateServerSlots: 60},
+		{Name: "zandvoort", UniquePitBoxes: 25, PrivateServerSlots: 50},
+		{Name: "suzuka", UniquePitBoxes: 51, PrivateServerSlots: 105},
+	}).Error)
+
+	configPath := helper.TestData.Server.GetConfigPath()
+	tests.AssertNoError(t, os.MkdirAll(configPath, 0755))
+	for _, file := range []string{service.EventJson, service.SettingsJson} {
+		data, err := tests.EncodeUTF16LEBOM([]byte(helper.TestData.ConfigFiles[file]))
+		tests.AssertNoError(t, err)
+		tests.AssertNoError(t, os.WriteFile(filepath.Join(configPath, file), data, 0644))
+	}
+
+	configService := service.NewConfigService(repository.NewConfigRepository(helper.DB), repository.NewServerRepository(helper.DB))
+	configService.SetLookupRepository(repository.NewLookupRepository(helper.DB, cache.NewInMemoryCache()))
+	return configService
+}
+
+func TestConfigService_ValidateConfigReportsEveryField(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	configService := newValidatingConfigService(t, helper)
+	ctx := helper.CreateContext()
+	server := helper.TestData.Server
+
+	err := configService.ValidateConfig(ctx, server, service.EventJson, map[string]interface{}{
+		"track":      "monzza",
+		"cloudLevel": 1.5,
+		"ambientTmp": 22,
+		"sessions": []interface{}{
+			map[string]interface{}{"sessionType": "R", "dayOfWeekend": 3, "hourOfDay": 14, "sessionDurationMinutes": 20},
+			map[string]interface{}{"sessionType": "Q", "dayOfWeekend": 2, "hourOfDay": 25, "sessionDurationMinutes": 10},
+		},
+	})
+
+	var verr *model.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	fields := verr.Fields()
+	tests.AssertEqual(t, "unknown field", fields["ambientTmp"])
+	tests.AssertEqual(t, "must be between 0 and 1", fields["cloudLevel"])
+	tests.AssertEqual(t, "unknown track \"monzza\"", fields["track"])
+	tests.AssertEqual(t, "must be between 0 and 23", fields["sessions[1].hourOfDay"])
+	tests.AssertEqual(t, 4, len(fields))
+
+	err = configService.ValidateConfig(ctx, server, service.EventJson, map[string]interface{}{
+		"sessions": []interface{}{
+			map[string]interface{}{"sessionType": "R", "dayOfWeekend": 3},
+			map[string]interface{}{"sessionType": "Q", "dayOfWeekend": 2},
+		},
+	})
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	fields = verr.Fields()
+	tests.AssertEqual(t, "must not be before the previous session", fields["sessions[1].dayOfWeekend"])
+	tests.AssertEqual(t, "Qualifying cannot follow Race", fields["sessions[1].sessionType"])
+
+	tests.AssertNoError(t, configService.ValidateConfig(ctx, server, service.EventJson, map[string]interface{}{
+		"track":      "zandvoort",
+		"cloudLevel": 0.3,
+		"rain":       0,
+	}))
+}
+
+func TestConfigService_ValidateConfigChecksCarSlotsAgainstTrack(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	configService := newValidatingConfigService(t, helper)
+	ctx := helper.CreateContext()
+	server := helper.TestData.Server
+
+	event, err := tests.EncodeUTF16LEBOM([]byte(`{"track": "zandvoort"}`))
+	tests.AssertNoError(t, err)
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(server.GetConfigPath(), service.EventJson), event, 0644))
+
+	err = configService.ValidateConfig(ctx, server, service.SettingsJson, map[string]interface{}{"maxCarSlots": "70"})
+	var verr *model.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	tests.AssertEqual(t, "must be at most 50 on zandvoort", verr.Fields()["maxCarSlots"])
+
+	tests.AssertNoError(t, configService.ValidateConfig(ctx, server, service.SettingsJson, map[string]interface{}{"maxCarSlots": 30}))
+
+	err = configService.ValidateConfig(ctx, server, "notes.json", map[string]interface{}{})
+	tests.AssertError(t, err, "unknown config file notes.json")
+}
+
+// stockEventRules is eventRules.json as the ACC dedicated server ships it.
+const stockEventRules = `{
+	"qualifyStandingType": 1,
+	"pitWindowLengthSec": -1,
+	"driverStintTimeSec": -1,
+	"mandatoryPitstopCount": 0,
+	"maxTotalDrivingTime": -1,
+	"maxDriversCount": 1,
+	"isRefuellingAllowedInRace": true,
+	"isRefuellingTimeFixed": false,
+	"isMandatoryPitstopRefuellingRequired": false,
+	"isMandatoryPitstopTyreChangeRequired": false,
+	"isMandatoryPitstopSwapDriverRequired": false,
+	"tyreSetCount": 50
+}`
+
+// updateConfigFile saves body into a config file of server the way the
+// config endpoint does.
+func updateConfigFile(t *testing.T, configService *service.ConfigService, server *model.Server, file string, body map[string]interface{}) error {
+	var updateErr error
+	app := fiber.New()
+	app.Post("/:file", func(c *fiber.Ctx) error {
+		c.Locals("serverId", server.ID.String())
+		_, updateErr = configService.UpdateConfig(c, &body)
+		return nil
+	})
+	_, err := app.Test(httptest.NewRequest("POST", "/"+file, nil))
+	tests.AssertNoError(t, err)
+	return updateErr
+}
+
+func TestConfigService_SavesStockEventRules(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	configService := newValidatingConfigService(t, helper)
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server, err := repository.NewServerRepository(helper.DB).GetByID(helper.CreateContext(), helper.TestData.Server.ID)
+	tests.AssertNoError(t, err)
+
+	var body map[string]interface{}
+	tests.AssertNoError(t, json.Unmarshal([]byte(stockEventRules), &body))
+	tests.AssertNoError(t, updateConfigFile(t, configService, server, service.EventRulesJson+"?override=true", body))
+
+	data, err := os.ReadFile(filepath.Join(server.GetConfigPath(), service.EventRulesJson))
+	tests.AssertNoError(t, err)
+	data, err = service.DecodeUTF16LEBOM(data)
+	tests.AssertNoError(t, err)
+	var eventRules model.EventRules
+	tests.AssertNoError(t, json.Unmarshal(data, &eventRules))
+	tests.AssertEqual(t, model.IntString(-1), eventRules.DriverStintTimeSec)
+	tests.AssertEqual(t, model.IntString(1), eventRules.MaxDriversCount)
+	tests.AssertEqual(t, model.IntString(50), eventRules.TyreSetCount)
+}
+
+func TestConfigService_ValidateConfigAcceptsStockKeys(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	configService := newValidatingConfigService(t, helper)
+	ctx := helper.CreateContext()
+	server := helper.TestData.Server
+
+	tests.AssertNoError(t, configService.ValidateConfig(ctx, server, service.ConfigurationJson, map[string]interface{}{
+		"udpPort": 9231, "tcpPort": 9232, "maxConnections": 85, "lanDiscovery": 1,
+		"registerToLobby": 1, "configVersion": 1, "publicIP": "203.0.113.7",
+	}))
+	tests.AssertNoError(t, configService.ValidateConfig(ctx, server, service.SettingsJson, map[string]interface{}{
+		"serverName": "ACC", "dumpLeaderboards": 0, "dumpEntryList": 1, "configVersion": 1,
+	}))
+	tests.AssertNoError(t, configService.ValidateConfig(ctx, server, service.EventJson, map[string]interface{}{
+		"track": "monza", "metaData": "", "configVersion": 1,
+	}))
+	tests.AssertNoError(t, configService.ValidateConfig(ctx, server, service.BroadcastingJson, map[string]interface{}{
+		"updListenerPort": 9600, "connectionPassword": "", "commandPassword": "", "configVersion": 1,
+	}))
+
+	err := configService.ValidateConfig(ctx, server, service.EventRulesJson, map[string]interface{}{"driverStIntStringTimeSec": 300})
+	var verr *model.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	tests.AssertEqual(t, "unknown field", verr.Fields()["driverStIntStringTimeSec"])
+}
+
+func TestConfigService_SavesTrackSlotsAbove82(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	configService := newValidatingConfigService(t, helper)
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server, err := repository.NewServerRepository(helper.DB).GetByID(helper.CreateContext(), helper.TestData.Server.ID)
+	tests.AssertNoError(t, err)
+
+	tests.AssertNoError(t, updateConfigFile(t, configService, server, service.EventJson, map[string]interface{}{"track": "suzuka"}))
+	tests.AssertNoError(t, updateConfigFile(t, configService, server, service.SettingsJson, map[string]interface{}{"maxCarSlots": 105}))
+
+	settings, err := configService.LoadConfigs(server)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.IntString(105), settings.Settings.MaxCarSlots)
+
+	err = updateConfigFile(t, configService, server, service.SettingsJson, map[string]interface{}{"maxCarSlots": 106})
+	var verr *model.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	tests.AssertEqual(t, "must be at most 105 on suzuka", verr.Fields()["maxCarSlots"])
+}
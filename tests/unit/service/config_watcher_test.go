@@ -0,0 +1,65 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/tests"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func configRevisions(t *testing.T, helper *tests.TestHelper) []model.Config {
+	var revisions []model.Config
+	tests.AssertNoError(t, helper.DB.Where("server_id = ?", helper.TestData.ServerID).Order("changed_at").Find(&revisions).Error)
+	return revisions
+}
+
+func TestConfigService_WatchConfigFilesRecordsExternalEdits(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	configPath := server.GetConfigPath()
+	tests.AssertNoError(t, os.MkdirAll(configPath, 0755))
+	configuration, err := tests.EncodeUTF16LEBOM([]byte(helper.TestData.ConfigFiles[service.ConfigurationJson]))
+	tests.AssertNoError(t, err)
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(configPath, service.ConfigurationJson), configuration, 0644))
+
+	configService := service.NewConfigService(repository.NewConfigRepository(helper.DB), repository.NewServerRepository(helper.DB))
+	configService.WatchConfigFiles(server)
+	defer configService.StopWatchingConfigFiles(server.ID)
+
+	cached, err := configService.GetConfiguration(server)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.IntString(9231), cached.UdpPort)
+
+	edited, err := tests.EncodeUTF16LEBOM([]byte(`{"udpPort": "9300", "tcpPort": "9232", "maxConnections": "30"}`))
+	tests.AssertNoError(t, err)
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(configPath, service.ConfigurationJson), edited, 0644))
+
+	deadline := time.Now().Add(10 * time.Second)
+	for len(configRevisions(t, helper)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	revisions := configRevisions(t, helper)
+	tests.AssertEqual(t, 1, len(revisions))
+	tests.AssertEqual(t, model.ActorExternal, revisions[0].ChangedBy)
+	tests.AssertEqual(t, service.ConfigurationJson, revisions[0].ConfigFile)
+
+	current, err := configService.GetConfiguration(server)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.IntString(9300), current.UdpPort)
+
+	_, err = configService.SaveEntryList(helper.CreateContext(), server, &model.EntryList{})
+	tests.AssertNoError(t, err)
+	time.Sleep(3 * time.Second)
+
+	revisions = configRevisions(t, helper)
+	tests.AssertEqual(t, 2, len(revisions))
+	tests.AssertEqual(t, model.ActorSystem, revisions[1].ChangedBy)
+}
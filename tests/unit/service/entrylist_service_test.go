@@ -0,0 +1,125 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/cache"
+	"acc-server-manager/tests"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newEntryListService(t *testing.T, helper *tests.TestHelper) *service.EntryListService {
+	tests.AssertNoError(t, helper.DB.AutoMigrate(&model.CarModel{}))
+	tests.AssertNoError(t, helper.DB.Create(&[]model.CarModel{
+		{Value: 0, CarModel: "Porsche 991 GT3 R"},
+		{Value: 32, CarModel: "Ferrari 296 GT3"},
+	}).Error)
+	tests.AssertNoError(t, helper.InsertTestServer())
+
+	configRepo := repository.NewConfigRepository(helper.DB)
+	serverRepo := repository.NewServerRepository(helper.DB)
+	lookupRepo := repository.NewLookupRepository(helper.DB, cache.NewInMemoryCache())
+	configService := service.NewConfigService(configRepo, serverRepo)
+
+	return service.NewEntryListService(configService, serverRepo, lookupRepo)
+}
+
+func testEntry(playerID string, raceNumber, carModel int) model.Entry {
+	entry := model.NewEntry()
+	entry.RaceNumber = model.IntString(raceNumber)
+	entry.ForcedCarModel = model.IntString(carModel)
+	entry.Drivers = []model.EntryDriver{{FirstName: "Test", LastName: "Driver", ShortName: "TDR", PlayerID: playerID}}
+	return entry
+}
+
+func TestEntryListService_AddEntry_WritesUTF16File(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	entryListService := newEntryListService(t, helper)
+	ctx := helper.CreateContext()
+	serverID := helper.TestData.ServerID
+
+	entry := testEntry("S76561198000000001", 7, 32)
+	entryList, err := entryListService.AddEntry(ctx, serverID, &entry)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(entryList.Entries))
+
+	data, err := os.ReadFile(filepath.Join(helper.TestData.Server.GetConfigPath(), service.EntryListJson))
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, true, bytes.HasPrefix(data, []byte{0xFF, 0xFE}))
+
+	stored, err := entryListService.GetEntryList(ctx, serverID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(stored.Entries))
+	tests.AssertEqual(t, model.IntString(32), stored.Entries[0].ForcedCarModel)
+	tests.AssertEqual(t, "S76561198000000001", stored.Entries[0].Drivers[0].PlayerID)
+}
+
+func TestEntryListService_RejectsInvalidEntries(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	entryListService := newEntryListService(t, helper)
+	ctx := helper.CreateContext()
+	serverID := helper.TestData.ServerID
+
+	entryList := &model.EntryList{Entries: []model.Entry{
+		testEntry("S76561198000000001", 7, 99),
+		testEntry("76561198000000002", 7, 0),
+	}}
+	_, err := entryListService.ReplaceEntryList(ctx, serverID, entryList)
+
+	var validationErr *model.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	fields := validationErr.Fields()
+	tests.AssertEqual(t, "unknown car model 99", fields["entries[0].forcedCarModel"])
+	tests.AssertNotNil(t, fields["entries[1].drivers[0].playerID"])
+	tests.AssertEqual(t, "race number 7 is already used by entries[0]", fields["entries[1].raceNumber"])
+
+	_, err = os.Stat(filepath.Join(helper.TestData.Server.GetConfigPath(), service.EntryListJson))
+	tests.AssertEqual(t, true, os.IsNotExist(err))
+}
+
+func TestEntryListService_UpdateAndDeleteEntry(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	entryListService := newEntryListService(t, helper)
+	ctx := helper.CreateContext()
+	serverID := helper.TestData.ServerID
+
+	first := testEntry("S76561198000000001", 1, 0)
+	second := testEntry("S76561198000000002", 2, model.NoForcedCarModel)
+	_, err := entryListService.ReplaceEntryList(ctx, serverID, &model.EntryList{Entries: []model.Entry{first, second}})
+	tests.AssertNoError(t, err)
+
+	second.BallastKg = 15
+	entryList, err := entryListService.UpdateEntry(ctx, serverID, 1, &second)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.IntString(15), entryList.Entries[1].BallastKg)
+
+	entryList, err = entryListService.DeleteEntry(ctx, serverID, 0)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(entryList.Entries))
+	tests.AssertEqual(t, "S76561198000000002", entryList.Entries[0].Drivers[0].PlayerID)
+
+	_, err = entryListService.DeleteEntry(ctx, serverID, 5)
+	tests.AssertEqual(t, true, errors.Is(err, service.ErrEntryNotFound))
+}
+
+func TestEntry_UnmarshalDefaults(t *testing.T) {
+	var entry model.Entry
+	err := json.Unmarshal([]byte(`{"drivers": [{"playerID": "S76561198000000001"}]}`), &entry)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.IntString(model.NoForcedCarModel), entry.ForcedCarModel)
+	tests.AssertEqual(t, model.IntString(-1), entry.RaceNumber)
+}
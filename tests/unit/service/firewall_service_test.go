@@ -0,0 +1,190 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/command"
+	"acc-server-manager/tests"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type memoryFirewall struct {
+	rules map[string]model.FirewallRule
+}
+
+func newMemoryFirewall(rules ...model.FirewallRule) *memoryFirewall {
+	f := &memoryFirewall{rules: make(map[string]model.FirewallRule)}
+	for _, rule := range rules {
+		f.rules[rule.Name] = rule
+	}
+	return f
+}
+
+func (f *memoryFirewall) Name() string  { return "memory" }
+func (f *memoryFirewall) Managed() bool { return true }
+
+func (f *memoryFirewall) CreateRule(rule model.FirewallRule) error {
+	f.rules[rule.Name] = rule
+	return nil
+}
+
+func (f *memoryFirewall) DeleteRule(rule model.FirewallRule) error {
+	delete(f.rules, rule.Name)
+	return nil
+}
+
+func (f *memoryFirewall) ListRules() ([]model.FirewallRule, error) {
+	rules := make([]model.FirewallRule, 0, len(f.rules))
+	for _, rule := range f.rules {
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func TestParseFirewallRuleName(t *testing.T) {
+	rule, ok := model.ParseFirewallRuleName("ACC-Server-1a2b3c4d-UDP-9600")
+	tests.AssertEqual(t, true, ok)
+	tests.AssertEqual(t, model.FirewallUDP, rule.Protocol)
+	tests.AssertEqual(t, 9600, rule.Port)
+	tests.AssertEqual(t, true, rule.BelongsTo("ACC-Server-1a2b3c4d"))
+	tests.AssertEqual(t, false, rule.BelongsTo("ACC-Server-ffffffff"))
+
+	_, ok = model.ParseFirewallRuleName("Remote Desktop - User Mode (TCP-In)")
+	tests.AssertEqual(t, false, ok)
+}
+
+func TestFirewallService_CreateAndListServerRules(t *testing.T) {
+	provider := newMemoryFirewall(model.NewFirewallRule("ACC-Server-other", model.FirewallTCP, 9700))
+	firewall := service.NewFirewallServiceWithProvider(provider)
+
+	err := firewall.CreateServerRules("ACC-Server-test", []int{9600}, []int{9600})
+	tests.AssertNoError(t, err)
+
+	rules, err := firewall.ListServerRules("ACC-Server-test")
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 2, len(rules))
+
+	err = firewall.DeleteServerRules("ACC-Server-test", []int{9600}, []int{9600})
+	tests.AssertNoError(t, err)
+
+	rules, err = firewall.ListServerRules("ACC-Server-test")
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 0, len(rules))
+	tests.AssertEqual(t, 1, len(provider.rules))
+}
+
+func TestFirewallService_ReconcileServerRules(t *testing.T) {
+	provider := newMemoryFirewall(
+		model.NewFirewallRule("ACC-Server-test", model.FirewallTCP, 9600),
+		model.NewFirewallRule("ACC-Server-test", model.FirewallUDP, 9500),
+	)
+	firewall := service.NewFirewallServiceWithProvider(provider)
+
+	result, err := firewall.ReconcileServerRules("ACC-Server-test", []int{9600}, []int{9600})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(result.Created))
+	tests.AssertEqual(t, "ACC-Server-test-UDP-9600", result.Created[0].Name)
+	tests.AssertEqual(t, 1, len(result.Removed))
+	tests.AssertEqual(t, "ACC-Server-test-UDP-9500", result.Removed[0].Name)
+	tests.AssertEqual(t, 2, len(provider.rules))
+}
+
+func TestFirewallService_ReconcileSkipsUnmanagedBackend(t *testing.T) {
+	firewall := service.NewFirewallServiceWithProvider(service.NewNoopFirewall())
+
+	result, err := firewall.ReconcileServerRules("ACC-Server-test", []int{9600}, []int{9600})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, false, result.Managed)
+	tests.AssertEqual(t, 0, len(result.Created))
+}
+
+// fakeNft writes a script standing in for nft that records its arguments and
+// lists chain as the content of the chain.
+func fakeNft(t *testing.T, dir, chain string) (exePath, logPath string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake nft is a shell script")
+	}
+	logPath = filepath.Join(dir, "nft.log")
+	chainPath := filepath.Join(dir, "chain.txt")
+	tests.AssertNoError(t, os.WriteFile(chainPath, []byte(chain), 0644))
+	exePath = filepath.Join(dir, "nft")
+	script := "#!/bin/sh\necho \"$*\" >> " + logPath + "\n" +
+		"if [ \"$1\" = \"-a\" ]; then cat " + chainPath + "; fi\n"
+	tests.AssertNoError(t, os.WriteFile(exePath, []byte(script), 0755))
+	return exePath, logPath
+}
+
+func TestNftablesFirewall_InsertsIntoHostChain(t *testing.T) {
+	dir := t.TempDir()
+	exePath, logPath := fakeNft(t, dir, `table inet filter {
+	chain input { # handle 1
+		type filter hook input priority filter; policy drop;
+		tcp dport 9600 accept comment "ACC-Server-test-TCP-9600" # handle 7
+		ct state established,related accept # handle 2
+	}
+}
+`)
+	firewall := service.NewNftablesFirewallWithExecutor(&command.CommandExecutor{ExePath: exePath}, "inet filter input")
+
+	tests.AssertNoError(t, firewall.CreateRule(model.NewFirewallRule("ACC-Server-test", model.FirewallUDP, 9600)))
+	tests.AssertNoError(t, firewall.CreateRule(model.NewFirewallRule("ACC-Server-test", model.FirewallTCP, 9600)))
+	tests.AssertNoError(t, firewall.DeleteRule(model.NewFirewallRule("ACC-Server-test", model.FirewallTCP, 9600)))
+
+	rules, err := firewall.ListRules()
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(rules))
+	tests.AssertEqual(t, model.FirewallTCP, rules[0].Protocol)
+
+	logged, err := os.ReadFile(logPath)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, strings.Join([]string{
+		"-a list chain inet filter input",
+		`insert rule inet filter input udp dport 9600 accept comment "ACC-Server-test-UDP-9600"`,
+		"-a list chain inet filter input",
+		"-a list chain inet filter input",
+		"delete rule inet filter input handle 7",
+		"-a list chain inet filter input",
+	}, "\n")+"\n", string(logged))
+}
+
+func TestNftablesFirewall_MissingChain(t *testing.T) {
+	dir := t.TempDir()
+	exePath, _ := fakeNft(t, dir, "")
+	script := "#!/bin/sh\necho 'Error: No such file or directory' >&2\nexit 1\n"
+	tests.AssertNoError(t, os.WriteFile(exePath, []byte(script), 0755))
+	firewall := service.NewNftablesFirewallWithExecutor(&command.CommandExecutor{ExePath: exePath}, "inet firewalld filter_INPUT")
+
+	err := firewall.CreateRule(model.NewFirewallRule("ACC-Server-test", model.FirewallUDP, 9600))
+	tests.AssertError(t, err, "nftables chain inet firewalld filter_INPUT does not exist, set NFTABLES_CHAIN to the input chain of the host")
+}
+
+func TestIptablesFirewall_InsertsAtTopOfInput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake iptables is a shell script")
+	}
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "iptables.log")
+	exePath := filepath.Join(dir, "iptables")
+	// -C only finds the TCP rule.
+	script := "#!/bin/sh\necho \"$*\" >> " + logPath + "\n" +
+		"if [ \"$1\" = \"-C\" ] && [ \"$6\" != \"9600\" -o \"$4\" != \"tcp\" ]; then exit 1; fi\n"
+	tests.AssertNoError(t, os.WriteFile(exePath, []byte(script), 0755))
+	firewall := service.NewIptablesFirewallWithExecutor(&command.CommandExecutor{ExePath: exePath})
+
+	tests.AssertNoError(t, firewall.CreateRule(model.NewFirewallRule("ACC-Server-test", model.FirewallUDP, 9600)))
+	tests.AssertNoError(t, firewall.CreateRule(model.NewFirewallRule("ACC-Server-test", model.FirewallTCP, 9600)))
+	tests.AssertNoError(t, firewall.DeleteRule(model.NewFirewallRule("ACC-Server-test", model.FirewallTCP, 9600)))
+
+	logged, err := os.ReadFile(logPath)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, strings.Join([]string{
+		"-C INPUT -p udp --dport 9600 -m comment --comment ACC-Server-test-UDP-9600 -j ACCEPT",
+		"-I INPUT -p udp --dport 9600 -m comment --comment ACC-Server-test-UDP-9600 -j ACCEPT",
+		"-C INPUT -p tcp --dport 9600 -m comment --comment ACC-Server-test-TCP-9600 -j ACCEPT",
+		"-D INPUT -p tcp --dport 9600 -m comment --comment ACC-Server-test-TCP-9600 -j ACCEPT",
+	}, "\n")+"\n", string(logged))
+}
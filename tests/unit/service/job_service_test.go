@@ -0,0 +1,347 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/tests"
+	"archive/zip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func newTestJobService(t *testing.T, helper *tests.TestHelper) (*service.JobService, *repository.JobRepository) {
+	tests.AssertNoError(t, helper.DB.AutoMigrate(&model.Job{}))
+	jobRepo := repository.NewJobRepository(helper.DB)
+	return service.NewJobService(jobRepo), jobRepo
+}
+
+func TestJobService_RunsAndCancelsQueuedJobs(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	jobService, _ := newTestJobService(t, helper)
+	var cancelled []string
+	jobService.RegisterHandler(model.JobServerBackup, service.JobHandler{
+		Run: func(ctx context.Context, run *service.JobRun) error {
+			run.Step("archiving", 50)
+			run.Log("archived cfg")
+			run.SetResult("backup.zip")
+			return nil
+		},
+		Cancelled: func(ctx context.Context, job *model.Job) {
+			cancelled = append(cancelled, job.ID.String())
+		},
+	})
+
+	ctx := helper.CreateContext()
+	serverID := helper.TestData.Server.ID
+	first := &model.Job{Type: model.JobServerBackup, ServerID: serverID}
+	tests.AssertNoError(t, jobService.Enqueue(ctx, first))
+	second := &model.Job{Type: model.JobServerBackup, ServerID: serverID}
+	tests.AssertNoError(t, jobService.Enqueue(ctx, second))
+
+	busy, err := jobService.HasUnfinished(ctx, serverID, model.JobServerBackup)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, true, busy)
+
+	job, err := jobService.Cancel(ctx, second.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobCancelled, job.Status)
+	tests.AssertEqual(t, second.ID.String(), strings.Join(cancelled, ","))
+
+	tests.AssertEqual(t, true, jobService.RunNext(ctx))
+	tests.AssertEqual(t, false, jobService.RunNext(ctx))
+
+	job, err = jobService.GetByID(ctx, first.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobSucceeded, job.Status)
+	tests.AssertEqual(t, "archiving", job.Step)
+	tests.AssertEqual(t, 100, job.Progress)
+	tests.AssertEqual(t, "[archived cfg]", fmt.Sprint(job.Logs))
+	tests.AssertEqual(t, "backup.zip", job.Result)
+
+	_, err = jobService.Cancel(ctx, first.ID)
+	tests.AssertError(t, err, "job has already finished")
+
+	busy, err = jobService.HasUnfinished(ctx, serverID, model.JobServerBackup)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, false, busy)
+}
+
+func TestJobService_CancelRunningJob(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	jobService, _ := newTestJobService(t, helper)
+	started := make(chan struct{})
+	jobService.RegisterHandler(model.JobServerReinstall, service.JobHandler{
+		Run: func(ctx context.Context, run *service.JobRun) error {
+			close(started)
+			<-ctx.Done()
+			return fmt.Errorf("failed to run SteamCMD: %w", ctx.Err())
+		},
+	})
+
+	ctx := helper.CreateContext()
+	job := &model.Job{Type: model.JobServerReinstall, ServerID: helper.TestData.Server.ID}
+	tests.AssertNoError(t, jobService.Enqueue(ctx, job))
+
+	done := make(chan bool)
+	go func() {
+		done <- jobService.RunNext(ctx)
+	}()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not start")
+	}
+
+	running, err := jobService.Cancel(ctx, job.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobRunning, running.Status)
+	select {
+	case ran := <-done:
+		tests.AssertEqual(t, true, ran)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not stop")
+	}
+
+	stored, err := jobService.GetByID(ctx, job.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobCancelled, stored.Status)
+	tests.AssertEqual(t, "failed to run SteamCMD: context canceled", stored.Error)
+}
+
+func TestJobService_ReconcileAndExclusiveJobs(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	jobService, jobRepo := newTestJobService(t, helper)
+	jobService.RegisterHandler(model.JobServerUpdate, service.JobHandler{
+		Run: func(ctx context.Context, run *service.JobRun) error {
+			return nil
+		},
+		Exclusive: true,
+	})
+	jobService.RegisterHandler(model.JobServerBackup, service.JobHandler{
+		Recover: func(ctx context.Context, job *model.Job) error {
+			job.Status = model.JobRolledBack
+			return nil
+		},
+	})
+
+	ctx := helper.CreateContext()
+	serverID := helper.TestData.Server.ID
+	update := &model.Job{Type: model.JobServerUpdate, ServerID: serverID, Status: model.JobRunning}
+	tests.AssertNoError(t, jobRepo.Insert(ctx, update))
+	backup := &model.Job{Type: model.JobServerBackup, ServerID: serverID, Status: model.JobRunning}
+	tests.AssertNoError(t, jobRepo.Insert(ctx, backup))
+	queued := &model.Job{Type: model.JobServerUpdate, ServerID: serverID}
+	tests.AssertNoError(t, jobService.Enqueue(ctx, queued))
+
+	// Another update is still running.
+	tests.AssertEqual(t, false, jobService.RunNext(ctx))
+
+	jobService.Reconcile(ctx)
+
+	stored, err := jobService.GetByID(ctx, update.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobFailed, stored.Status)
+	tests.AssertEqual(t, "manager restarted during the job", stored.Error)
+	stored, err = jobService.GetByID(ctx, backup.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobRolledBack, stored.Status)
+
+	tests.AssertEqual(t, true, jobService.RunNext(ctx))
+	stored, err = jobService.GetByID(ctx, queued.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobSucceeded, stored.Status)
+}
+
+func TestServerService_RecoverInterruptedCreation(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	jobService, jobRepo := newTestJobService(t, helper)
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+	_, _, serverService := newTestServerRuntime(helper, repository.NewServerRepository(helper.DB))
+	serverService.SetJobService(jobService)
+
+	ctx := helper.CreateContext()
+	installPath := filepath.Join(helper.TempDir, "servers", "ACC-Server-New")
+	tests.AssertNoError(t, writeTestInstall(installPath, "1"))
+	job := &model.Job{
+		Type:     model.JobServerCreate,
+		ServerID: helper.TestData.Server.ID,
+		Status:   model.JobRunning,
+		Step:     string(model.StepSteamDownload),
+		Payload:  fmt.Sprintf(`{"name":"New","path":%q,"serviceName":"ACC-Server-New"}`, installPath),
+	}
+	tests.AssertNoError(t, jobRepo.Insert(ctx, job))
+
+	jobService.Reconcile(ctx)
+
+	stored, err := jobService.GetByID(ctx, job.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobRolledBack, stored.Status)
+	_, err = os.Stat(installPath)
+	tests.AssertEqual(t, true, os.IsNotExist(err))
+}
+
+func TestServerService_BackupJob(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	jobService, _ := newTestJobService(t, helper)
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+	backupDir := filepath.Join(helper.TempDir, "backups")
+	t.Setenv("BACKUP_DIR", backupDir)
+	serverRepo := repository.NewServerRepository(helper.DB)
+	_, _, serverService := newTestServerRuntime(helper, serverRepo)
+	serverService.SetJobService(jobService)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	ctx := helper.CreateContext()
+	server, err := serverRepo.GetByID(ctx, helper.TestData.Server.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertNoError(t, os.MkdirAll(server.GetConfigPath(), 0755))
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(server.GetConfigPath(), "configuration.json"), []byte(`{"tcpPort": 9232}`), 0644))
+
+	job, err := serverService.Backup(ctx, server.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobQueued, job.Status)
+	_, err = serverService.Backup(ctx, server.ID)
+	tests.AssertError(t, err, "a backup is already queued or running for this server")
+
+	tests.AssertEqual(t, true, jobService.RunNext(ctx))
+	job, err = jobService.GetByID(ctx, job.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobSucceeded, job.Status)
+	tests.AssertEqual(t, filepath.Join(backupDir, server.ID.String()), filepath.Dir(job.Result))
+
+	// Backups outlive the install they were taken of.
+	tests.AssertNoError(t, service.NewSteamService(nil, nil).UninstallServer(server.Path))
+	_, err = os.Stat(server.Path)
+	tests.AssertEqual(t, true, os.IsNotExist(err))
+
+	archive, err := zip.OpenReader(job.Result)
+	tests.AssertNoError(t, err)
+	defer archive.Close()
+	found := false
+	for _, file := range archive.File {
+		if file.Name == "cfg/configuration.json" {
+			found = true
+		}
+	}
+	tests.AssertEqual(t, true, found)
+}
+
+func TestServerService_CancelCreationRollsBack(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	jobService, _ := newTestJobService(t, helper)
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+	serverRepo := repository.NewServerRepository(helper.DB)
+	manager, _, serverService := newTestServerRuntime(helper, serverRepo)
+	serverService.SetJobService(jobService)
+	installer := &fakeInstaller{build: "1", started: make(chan struct{}), release: make(chan struct{})}
+	serverService.SetInstaller(installer)
+	t.Setenv("STEAMCMD_PATH", filepath.Join(helper.TempDir, "steamcmd", "steamcmd.exe"))
+
+	app := fiber.New()
+	fiberCtx := helper.CreateFiberCtx()
+	defer helper.ReleaseFiberCtx(app, fiberCtx)
+	server := &model.Server{ID: uuid.New(), Name: "New"}
+	tests.AssertNoError(t, serverService.CreateServerAsync(fiberCtx, server))
+
+	ctx := helper.CreateContext()
+	done := make(chan bool)
+	go func() {
+		done <- jobService.RunNext(ctx)
+	}()
+	select {
+	case <-installer.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("creation did not start installing")
+	}
+	_, err := os.Stat(filepath.Join(server.Path, "server", "accServer.exe"))
+	tests.AssertNoError(t, err)
+
+	var job model.Job
+	tests.AssertNoError(t, helper.DB.Where("server_id = ?", server.ID).First(&job).Error)
+	_, err = jobService.Cancel(ctx, job.ID)
+	tests.AssertNoError(t, err)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("creation did not stop")
+	}
+
+	stored, err := jobService.GetByID(ctx, job.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobCancelled, stored.Status)
+	tests.AssertEqual(t, string(model.StepSteamDownload), stored.Step)
+	_, err = os.Stat(server.Path)
+	tests.AssertEqual(t, true, os.IsNotExist(err))
+	_, err = manager.Status(ctx, server.ServiceName)
+	tests.AssertEqual(t, true, err != nil)
+	created, err := serverRepo.GetByID(ctx, server.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, true, created == nil)
+}
+
+func TestServerService_RecoverCreationAtServiceStep(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	jobService, jobRepo := newTestJobService(t, helper)
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+	manager, _, serverService := newTestServerRuntime(helper, repository.NewServerRepository(helper.DB))
+	serverService.SetJobService(jobService)
+
+	ctx := helper.CreateContext()
+	installPath := filepath.Join(helper.TempDir, "servers", "ACC-Server-New")
+	tests.AssertNoError(t, writeTestInstall(installPath, "1"))
+	tests.AssertNoError(t, manager.CreateService(ctx, "ACC-Server-New", "accServer.exe", filepath.Join(installPath, "server"), nil))
+	job := &model.Job{
+		Type:     model.JobServerCreate,
+		ServerID: uuid.New(),
+		Status:   model.JobRunning,
+		Step:     string(model.StepServiceCreation),
+		Payload:  fmt.Sprintf(`{"name":"New","path":%q,"serviceName":"ACC-Server-New"}`, installPath),
+	}
+	tests.AssertNoError(t, jobRepo.Insert(ctx, job))
+
+	jobService.Reconcile(ctx)
+
+	stored, err := jobService.GetByID(ctx, job.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobRolledBack, stored.Status)
+	_, err = manager.Status(ctx, "ACC-Server-New")
+	tests.AssertError(t, err, "service ACC-Server-New not found")
+	tests.AssertEqual(t, true, strings.Contains(strings.Join(manager.Calls(), ","), "delete:ACC-Server-New"))
+	_, err = os.Stat(installPath)
+	tests.AssertEqual(t, true, os.IsNotExist(err))
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/tests"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestLeaderboardService_AddRaceFromResult(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+		&model.Leaderboard{}, &model.LeaderboardDriver{}, &model.LeaderboardRace{}, &model.LeaderboardResult{}, &model.LeaderboardPointRow{},
+		&model.LeaderboardStatus{},
+	))
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+
+	resultsPath := server.GetResultsPath()
+	tests.AssertNoError(t, os.MkdirAll(resultsPath, 0755))
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(resultsPath, "240512_193011_R.json"), []byte(testRaceResult), 0644))
+
+	resultRepo := repository.NewResultRepository(helper.DB)
+	resultService := service.NewResultService(resultRepo, repository.NewServerRepository(helper.DB))
+	tests.AssertNoError(t, resultService.IngestDirectory(helper.CreateContext(), server.ID, resultsPath))
+	var result model.ResultSession
+	tests.AssertNoError(t, helper.DB.First(&result).Error)
+
+	leaderboardService := service.NewLeaderboardService(repository.NewLeaderboardRepository(helper.DB), resultRepo)
+	_, err := leaderboardService.Update(helper.CreateContext(), server.ID, &model.Leaderboard{
+		FLPoints: 1,
+		Drivers:  []model.LeaderboardDriver{{Name: "lando norris", Initials: "LN"}},
+		PointRows: []model.LeaderboardPointRow{
+			{Label: "P2", Points: 18},
+			{Label: "P1", Points: 25},
+		},
+	})
+	tests.AssertNoError(t, err)
+
+	lb, err := leaderboardService.AddRace(helper.CreateContext(), server.ID, uuid.Nil, &model.LeaderboardRaceImport{ResultID: result.ID})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 2, len(lb.Drivers))
+	tests.AssertEqual(t, 1, len(lb.Races))
+	race := lb.Races[0]
+	tests.AssertEqual(t, "spa", race.Name)
+
+	drivers := make(map[string]model.LeaderboardDriver)
+	for _, driver := range lb.Drivers {
+		drivers[driver.Name] = driver
+	}
+	verstappen, ok := drivers["Max Verstappen"]
+	tests.AssertEqual(t, true, ok)
+	tests.AssertEqual(t, "S76561198000000001", verstappen.SteamID)
+	tests.AssertEqual(t, "VER", verstappen.Initials)
+
+	scores := make(map[string]model.Score)
+	for _, r := range race.Results {
+		scores[r.DriverID.String()] = r.Score
+	}
+	tests.AssertEqual(t, model.Score("25"), scores[verstappen.ID.String()])
+	tests.AssertEqual(t, model.Score("18"), scores[drivers["lando norris"].ID.String()])
+	tests.AssertNotNil(t, race.FastestLapDriverID)
+	tests.AssertEqual(t, verstappen.ID, *race.FastestLapDriverID)
+
+	_, err = leaderboardService.AddRace(helper.CreateContext(), server.ID, uuid.Nil, &model.LeaderboardRaceImport{ResultID: result.ID})
+	tests.AssertError(t, err, "result is already on the leaderboard")
+}
+
+func TestLeaderboardService_GetStandings(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.Leaderboard{}, &model.LeaderboardDriver{}, &model.LeaderboardRace{}, &model.LeaderboardResult{},
+		&model.LeaderboardPointRow{}, &model.LeaderboardStatus{},
+	))
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	results := func(scores map[uuid.UUID]model.Score) []model.LeaderboardResult {
+		var list []model.LeaderboardResult
+		for driverID, score := range scores {
+			list = append(list, model.LeaderboardResult{DriverID: driverID, Score: score})
+		}
+		return list
+	}
+
+	leaderboardService := service.NewLeaderboardService(repository.NewLeaderboardRepository(helper.DB), repository.NewResultRepository(helper.DB))
+	_, err := leaderboardService.Update(helper.CreateContext(), server.ID, &model.Leaderboard{
+		FLPoints:      1,
+		CountedRounds: 2,
+		Drivers: []model.LeaderboardDriver{
+			{ID: a, Name: "A"}, {ID: b, Name: "B"}, {ID: c, Name: "C"}, {ID: d, Name: "D"},
+		},
+		Statuses: []model.LeaderboardStatus{{Code: "DSQ", Points: 0, AlwaysCounts: true}},
+		Races: []model.LeaderboardRace{
+			{Name: "R1", Results: results(map[uuid.UUID]model.Score{a: "25", b: "18", c: "15"})},
+			{Name: "R2", FastestLapDriverID: &c, Results: results(map[uuid.UUID]model.Score{a: "DNF", b: "25", c: "18"})},
+			{Name: "R3", Results: results(map[uuid.UUID]model.Score{a: "25", b: "dsq", c: "18", d: "25"})},
+		},
+	})
+	tests.AssertNoError(t, err)
+
+	standings, err := leaderboardService.GetStandings(helper.CreateContext(), server.ID, uuid.Nil)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 3, len(standings.Rounds))
+	tests.AssertEqual(t, 4, len(standings.Standings))
+
+	order := make([]string, 0, 4)
+	points := make([]int, 0, 4)
+	for _, standing := range standings.Standings {
+		order = append(order, standing.Name)
+		points = append(points, standing.Points)
+	}
+	tests.AssertEqual(t, "A,C,B,D", strings.Join(order, ","))
+	tests.AssertEqual(t, "[50 37 25 25]", fmt.Sprint(points))
+
+	driverA := standings.Standings[0]
+	tests.AssertEqual(t, 2, driverA.Wins)
+	tests.AssertEqual(t, true, driverA.Rounds[1].Dropped)
+	tests.AssertEqual(t, 3, driverA.Rounds[1].ChampionshipPosition)
+	tests.AssertEqual(t, 1, driverA.Rounds[2].ChampionshipPosition)
+
+	driverC := standings.Standings[1]
+	tests.AssertEqual(t, 1, driverC.FastestLaps)
+	tests.AssertEqual(t, 19, driverC.Rounds[1].Points)
+	tests.AssertEqual(t, 15, driverC.DroppedPoints)
+	tests.AssertEqual(t, 3, driverC.Rounds[2].Finish)
+
+	driverB := standings.Standings[2]
+	tests.AssertEqual(t, false, driverB.Rounds[2].Dropped)
+	tests.AssertEqual(t, true, driverB.Rounds[0].Dropped)
+	tests.AssertEqual(t, 1, driverB.Rounds[1].ChampionshipPosition)
+}
+
+func TestLeaderboardService_Championships(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.Leaderboard{}, &model.LeaderboardDriver{}, &model.LeaderboardRace{}, &model.LeaderboardResult{},
+		&model.LeaderboardPointRow{}, &model.LeaderboardStatus{},
+	))
+	tests.AssertNoError(t, helper.InsertTestServer())
+	serverID := helper.TestData.Server.ID
+
+	leaderboardService := service.NewLeaderboardService(repository.NewLeaderboardRepository(helper.DB), repository.NewResultRepository(helper.DB))
+	ctx := helper.CreateContext()
+
+	sprint, err := leaderboardService.CreateChampionship(ctx, serverID, &model.Leaderboard{
+		Name:      "Sprint Cup",
+		Season:    "2024",
+		PointRows: []model.LeaderboardPointRow{{Label: "P1", Points: 10}},
+	})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, true, sprint.IsDefault)
+	tests.AssertEqual(t, 1, len(sprint.PointRows))
+
+	endurance, err := leaderboardService.CreateChampionship(ctx, serverID, &model.Leaderboard{
+		Name:      "Endurance Cup",
+		PointRows: []model.LeaderboardPointRow{{Label: "P1", Points: 50}, {Label: "P2", Points: 40}},
+	})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, false, endurance.IsDefault)
+
+	_, err = leaderboardService.CreateChampionship(ctx, serverID, &model.Leaderboard{Name: "sprint cup"})
+	tests.AssertError(t, err, `championship "sprint cup" already exists`)
+
+	current, err := leaderboardService.Get(ctx, serverID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, sprint.ID, current.ID)
+
+	_, err = leaderboardService.SetDefaultChampionship(ctx, serverID, endurance.ID)
+	tests.AssertNoError(t, err)
+	current, err = leaderboardService.Get(ctx, serverID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, endurance.ID, current.ID)
+	tests.AssertEqual(t, 2, len(current.PointRows))
+
+	updated, err := leaderboardService.Update(ctx, serverID, &model.Leaderboard{FLPoints: 2})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, "Endurance Cup", updated.Name)
+	tests.AssertEqual(t, 0, len(updated.PointRows))
+
+	archived, err := leaderboardService.UpdateChampionship(ctx, serverID, sprint.ID, &model.Leaderboard{Name: "Sprint Cup", Season: "2024", Archived: true, FLPoints: 1})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, true, archived.Archived)
+
+	championships, err := leaderboardService.List(ctx, serverID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 2, len(championships))
+
+	tests.AssertNoError(t, leaderboardService.DeleteChampionship(ctx, serverID, endurance.ID))
+	current, err = leaderboardService.Get(ctx, serverID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, sprint.ID, current.ID)
+	tests.AssertEqual(t, true, current.IsDefault)
+
+	_, err = leaderboardService.GetChampionship(ctx, serverID, endurance.ID)
+	tests.AssertError(t, err, "Championship not found")
+}
+
+func TestLeaderboardService_Export(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.Leaderboard{}, &model.LeaderboardDriver{}, &model.LeaderboardRace{}, &model.LeaderboardResult{},
+		&model.LeaderboardPointRow{}, &model.LeaderboardStatus{},
+	))
+	tests.AssertNoError(t, helper.InsertTestServer())
+	serverID := helper.TestData.Server.ID
+
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	leaderboardService := service.NewLeaderboardService(repository.NewLeaderboardRepository(helper.DB), repository.NewResultRepository(helper.DB))
+	_, err := leaderboardService.CreateChampionship(helper.CreateContext(), serverID, &model.Leaderboard{
+		Name:        "Sprint Cup",
+		Season:      "2024",
+		FLPoints:    1,
+		FLColor:     "#8b5cf6",
+		FLTextColor: "#000000",
+		Drivers: []model.LeaderboardDriver{
+			{ID: a, Name: "Max Verstappen", Color: "#1e3a8a"},
+			{ID: b, Name: "Lando, Norris", Color: `red" onload="alert(1)`},
+			{ID: c, Name: `=HYPERLINK("http://evil.example","Click")`, Initials: "@LH"},
+		},
+		PointRows: []model.LeaderboardPointRow{
+			{Label: "P1", Points: 25, Color: "#facc15", TextColor: "#000000"},
+			{Label: "P2", Points: 18, Color: "#d1d5db", TextColor: "#000000"},
+		},
+		Races: []model.LeaderboardRace{
+			{Name: "Spa", FastestLapDriverID: &b, Results: []model.LeaderboardResult{
+				{DriverID: a, Score: "25", Position: 1}, {DriverID: b, Score: "18", Position: 2},
+			}},
+			{Name: "Monza", Results: []model.LeaderboardResult{
+				{DriverID: a, Score: "DNF"}, {DriverID: b, Score: "25", Position: 1},
+			}},
+		},
+	})
+	tests.AssertNoError(t, err)
+
+	file, err := leaderboardService.Export(helper.CreateContext(), serverID, uuid.Nil, "csv")
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, "sprint-cup.csv", file.FileName)
+	tests.AssertEqual(t, "Position,Driver,Initials,Spa,Monza,Points,Wins,Podiums\n"+
+		"1,\"Lando, Norris\",,19,25,44,1,2\n"+
+		"2,Max Verstappen,,25,DNF,25,1,1\n"+
+		"3,\"'=HYPERLINK(\"\"http://evil.example\"\",\"\"Click\"\")\",'@LH,,,0,0,0\n", string(file.Data))
+
+	file, err = leaderboardService.Export(helper.CreateContext(), serverID, uuid.Nil, "JSON")
+	tests.AssertNoError(t, err)
+	var export model.LeaderboardExport
+	tests.AssertNoError(t, json.Unmarshal(file.Data, &export))
+	tests.AssertEqual(t, model.LeaderboardExportVersion, export.Version)
+	tests.AssertEqual(t, "Sprint Cup", export.Championship.Name)
+	tests.AssertEqual(t, "#8b5cf6", export.Standings[0].Rounds[0].Style.Color)
+	tests.AssertEqual(t, "#facc15", export.Standings[0].Rounds[1].Style.Color)
+	tests.AssertEqual(t, "", export.Standings[1].Rounds[1].Style.Color)
+
+	for _, format := range []string{"html", "svg"} {
+		file, err = leaderboardService.Export(helper.CreateContext(), serverID, uuid.Nil, format)
+		tests.AssertNoError(t, err)
+		rendered := string(file.Data)
+		tests.AssertEqual(t, true, strings.Contains(rendered, "#facc15"))
+		tests.AssertEqual(t, true, strings.Contains(rendered, "#1e3a8a"))
+		tests.AssertEqual(t, false, strings.Contains(rendered, "onload"))
+	}
+
+	_, err = leaderboardService.Export(helper.CreateContext(), serverID, uuid.Nil, "pdf")
+	tests.AssertError(t, err, `unsupported export format "pdf"`)
+}
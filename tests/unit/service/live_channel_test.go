@@ -0,0 +1,372 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/broadcasting"
+	"acc-server-manager/local/utl/tracking"
+	"acc-server-manager/tests"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/websocket/v2"
+	"github.com/google/uuid"
+)
+
+// recordingConn is a websocket connection that keeps the messages written
+// to it.
+type recordingConn struct {
+	mu       sync.Mutex
+	messages []model.WebSocketMessage
+}
+
+func (c *recordingConn) WriteMessage(messageType int, data []byte) error {
+	if messageType != websocket.TextMessage {
+		return nil
+	}
+	var message model.WebSocketMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, message)
+	return nil
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) types() []model.WebSocketMessageType {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	types := make([]model.WebSocketMessageType, 0, len(c.messages))
+	for _, message := range c.messages {
+		types = append(types, message.Type)
+	}
+	return types
+}
+
+// waitForTypes waits until the messages written to conn have the expected
+// types, since the service writes them from another goroutine.
+func (c *recordingConn) waitForTypes(t *testing.T, expected string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for fmt.Sprint(c.types()) != expected && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	tests.AssertEqual(t, expected, fmt.Sprint(c.types()))
+}
+
+func TestWebSocketService_LiveChannel(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+
+	serverRepo := repository.NewServerRepository(helper.DB)
+	manager := service.NewFakeServiceManager()
+	webSocket := service.NewWebSocketService()
+	serviceControl := service.NewServiceControlService(repository.NewServiceControlRepository(helper.DB), serverRepo, manager)
+	serverService := service.NewServerService(
+		serverRepo,
+		repository.NewStateHistoryRepository(helper.DB),
+		serviceControl,
+		service.NewConfigService(repository.NewConfigRepository(helper.DB), serverRepo),
+		nil,
+		manager,
+		nil,
+		webSocket,
+		service.NewPlayerService(repository.NewPlayerSessionRepository(helper.DB), serverRepo),
+		service.NewResultService(repository.NewResultRepository(helper.DB), serverRepo),
+	)
+	serviceControl.SetServerService(serverService)
+	webSocket.SetServerService(serverService)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	tests.AssertNoError(t, manager.CreateService(helper.CreateContext(), server.ServiceName, "accServer.exe", server.Path, nil))
+
+	logDir := server.GetLogPath()
+	tests.AssertNoError(t, os.MkdirAll(logDir, 0755))
+	logFile := filepath.Join(logDir, "server.log")
+	tests.AssertNoError(t, os.WriteFile(logFile, nil, 0644))
+
+	conn := &recordingConn{}
+	webSocket.AddConnection("conn-1", conn, nil)
+
+	topic := model.ServerLiveTopic(server.ID)
+
+	// Not running yet, so there is nothing to subscribe to.
+	tests.AssertEqual(t, service.ErrServerNotRunning, webSocket.Subscribe("conn-1", topic, 0))
+
+	_, err := serviceControl.StartServer(server.ServiceName)
+	tests.AssertNoError(t, err)
+
+	tests.AssertNoError(t, webSocket.Subscribe("conn-1", topic, 0))
+	conn.waitForTypes(t, "[subscribed live_snapshot]")
+
+	log, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+	tests.AssertNoError(t, err)
+	_, err = log.WriteString("New connection request: id 3 Alan Turing S76561198000000001 on car model 32\n" +
+		"1 client(s) online\n" +
+		"Session changed: PRACTICE -> RACE\n")
+	tests.AssertNoError(t, err)
+	tests.AssertNoError(t, log.Close())
+
+	conn.waitForTypes(t, "[subscribed live_snapshot driver_joined player_count session_changed]")
+
+	conn.mu.Lock()
+	joined := conn.messages[2].Data.(map[string]interface{})["player"].(map[string]interface{})
+	changed := conn.messages[4].Data.(map[string]interface{})
+	conn.mu.Unlock()
+	tests.AssertEqual(t, "Alan Turing", joined["driverName"])
+	tests.AssertEqual(t, "R", changed["to"])
+
+	// Nothing is sent once the channel is unsubscribed.
+	webSocket.Unsubscribe("conn-1", topic)
+	webSocket.BroadcastLive(server.ID, model.MessageTypePlayerCount, model.PlayerCountMessage{PlayerCount: 2})
+	conn.waitForTypes(t, "[subscribed live_snapshot driver_joined player_count session_changed unsubscribed]")
+}
+
+func TestWebSocketService_Topics(t *testing.T) {
+	webSocket := service.NewWebSocketService()
+	serverID := uuid.New()
+	otherServerID := uuid.New()
+
+	creation := &recordingConn{}
+	alerts := &recordingConn{}
+	idle := &recordingConn{}
+	webSocket.AddConnection("creation", creation, nil)
+	webSocket.AddConnection("alerts", alerts, nil)
+	webSocket.AddConnection("idle", idle, nil)
+	defer webSocket.RemoveConnection("creation")
+	defer webSocket.RemoveConnection("alerts")
+	defer webSocket.RemoveConnection("idle")
+
+	tests.AssertNoError(t, webSocket.Subscribe("creation", model.ServerCreationTopic(serverID), 0))
+	tests.AssertNoError(t, webSocket.Subscribe("alerts", model.TopicSystemAlerts, 0))
+	tests.AssertEqual(t, service.ErrUnknownTopic, webSocket.Subscribe("idle", "server:"+model.WebSocketTopic(serverID.String())+":other", 0))
+
+	webSocket.BroadcastStep(otherServerID, model.StepValidation, model.StatusInProgress, "", "")
+	webSocket.BroadcastStep(serverID, model.StepValidation, model.StatusInProgress, "", "")
+	webSocket.BroadcastComplete(serverID, true, "done")
+	webSocket.BroadcastAlert(model.WebhookNotification{Event: model.WebhookServerCrashed, ServerID: serverID})
+
+	creation.waitForTypes(t, "[subscribed step complete]")
+	alerts.waitForTypes(t, "[subscribed alert]")
+	idle.waitForTypes(t, "[]")
+
+	creation.mu.Lock()
+	tests.AssertEqual(t, serverID, *creation.messages[1].ServerID)
+	creation.mu.Unlock()
+}
+
+func (c *recordingConn) seqs() []uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seqs := make([]uint64, 0, len(c.messages))
+	for _, message := range c.messages {
+		seqs = append(seqs, message.Seq)
+	}
+	return seqs
+}
+
+func TestWebSocketService_ReplayAfterReconnect(t *testing.T) {
+	webSocket := service.NewWebSocketService()
+	serverID := uuid.New()
+	topic := model.ServerCreationTopic(serverID)
+
+	webSocket.BroadcastStep(serverID, model.StepValidation, model.StatusInProgress, "", "")
+	webSocket.BroadcastStep(serverID, model.StepValidation, model.StatusCompleted, "", "")
+	webSocket.BroadcastSteamOutput(serverID, "Update state (0x61) downloading", false)
+
+	first := &recordingConn{}
+	webSocket.AddConnection("first", first, nil)
+	tests.AssertNoError(t, webSocket.Subscribe("first", topic, 0))
+	first.waitForTypes(t, "[subscribed step step steam_output]")
+	tests.AssertEqual(t, "[0 1 2 3]", fmt.Sprint(first.seqs()))
+	webSocket.RemoveConnection("first")
+
+	webSocket.BroadcastSteamOutput(serverID, "Success! App '1430110' fully installed.", false)
+	webSocket.BroadcastComplete(serverID, true, "done")
+	tests.AssertEqual(t, uint64(5), webSocket.LastSeq(topic))
+
+	// The reconnecting client only gets what it missed.
+	second := &recordingConn{}
+	webSocket.AddConnection("second", second, nil)
+	defer webSocket.RemoveConnection("second")
+	tests.AssertNoError(t, webSocket.Subscribe("second", topic, 3))
+	second.waitForTypes(t, "[subscribed steam_output complete]")
+	tests.AssertEqual(t, "[0 4 5]", fmt.Sprint(second.seqs()))
+	second.mu.Lock()
+	confirmation := second.messages[0].Data.(map[string]interface{})
+	second.mu.Unlock()
+	tests.AssertEqual(t, float64(1), confirmation["first_seq"])
+	tests.AssertEqual(t, float64(5), confirmation["last_seq"])
+
+	// A last_seq from before a restart replays everything.
+	third := &recordingConn{}
+	webSocket.AddConnection("third", third, nil)
+	defer webSocket.RemoveConnection("third")
+	tests.AssertNoError(t, webSocket.Subscribe("third", topic, 99))
+	third.waitForTypes(t, "[subscribed step step steam_output steam_output complete]")
+
+	webSocket.ClearHistory(topic)
+	tests.AssertEqual(t, uint64(0), webSocket.LastSeq(topic))
+}
+
+func TestWebSocketService_ReplayKeepsLatestMessages(t *testing.T) {
+	webSocket := service.NewWebSocketService()
+	serverID := uuid.New()
+
+	for i := 1; i <= 1100; i++ {
+		webSocket.BroadcastSteamOutput(serverID, fmt.Sprintf("line %d", i), false)
+	}
+
+	conn := &recordingConn{}
+	webSocket.AddConnection("conn", conn, nil)
+	defer webSocket.RemoveConnection("conn")
+	tests.AssertNoError(t, webSocket.Subscribe("conn", model.ServerCreationTopic(serverID), 0))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(conn.seqs()) < 1001 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	seqs := conn.seqs()
+	tests.AssertEqual(t, 1001, len(seqs))
+	tests.AssertEqual(t, uint64(101), seqs[1])
+	tests.AssertEqual(t, uint64(1100), seqs[1000])
+}
+
+func TestServerService_GetCreationStateUnknownServer(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	_, _, serverService := newTestServerRuntime(helper, repository.NewServerRepository(helper.DB))
+	_, err := serverService.GetCreationState(uuid.New())
+	tests.AssertError(t, err, "No creation found for this server")
+}
+
+func TestWebSocketTopic_Permission(t *testing.T) {
+	serverID := uuid.New()
+
+	for topic, expected := range map[model.WebSocketTopic]bool{
+		model.ServerCreationTopic(serverID):     true,
+		model.ServerLiveTopic(serverID):         true,
+		model.TopicSystemAlerts:                 true,
+		"server:not-a-uuid:live":                false,
+		"system:other":                          false,
+		model.WebSocketTopic(serverID.String()): false,
+	} {
+		permission, ok := topic.Permission()
+		tests.AssertEqual(t, expected, ok)
+		if ok {
+			tests.AssertEqual(t, model.ServerView, permission)
+		}
+	}
+
+	command := model.WebSocketCommand{Type: model.CommandSubscribe, ServerID: serverID}
+	tests.AssertEqual(t, model.ServerLiveTopic(serverID), command.CommandTopic())
+}
+
+// blockingConn is a client that never reads, so every write blocks until
+// the connection is closed.
+type blockingConn struct {
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *blockingConn) WriteMessage(messageType int, data []byte) error {
+	<-c.closed
+	return net.ErrClosed
+}
+
+func (c *blockingConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func TestWebSocketService_SlowClientIsDisconnected(t *testing.T) {
+	webSocket := service.NewWebSocketService()
+	serverID := uuid.New()
+
+	slow := &blockingConn{closed: make(chan struct{})}
+	webSocket.AddConnection("slow", slow, nil)
+	tests.AssertNoError(t, webSocket.Subscribe("slow", model.ServerCreationTopic(serverID), 0))
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 200; i++ {
+			webSocket.BroadcastSteamOutput(serverID, fmt.Sprintf("line %d", i), false)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcasting was stalled by a slow client")
+	}
+
+	select {
+	case <-slow.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow client was not disconnected")
+	}
+	tests.AssertEqual(t, 0, webSocket.GetActiveConnections())
+}
+
+func TestAccServerInstance_LapCompleted(t *testing.T) {
+	instance := tracking.NewAccServerInstance(&model.Server{Name: "Laps"}, func(*model.ServerState, ...tracking.StateChange) {})
+
+	var laps []model.LapMessage
+	var best []bool
+	instance.OnLapCompleted = func(car model.PlayerState, sessionBest bool) {
+		laps = append(laps, model.NewLapMessage(car))
+		best = append(best, sessionBest)
+	}
+
+	update := func(carIndex, laps, lastLap int, invalid bool) {
+		instance.HandleCarUpdate(broadcasting.RealtimeCarUpdate{
+			CarIndex: carIndex,
+			Position: carIndex,
+			Laps:     laps,
+			LastLap:  broadcasting.LapInfo{LapTimeMs: lastLap, Splits: []int{0, 0, 0}, IsInvalid: invalid},
+		})
+	}
+
+	instance.HandleRealtimeUpdate(broadcasting.RealtimeUpdate{SessionIndex: 1})
+	update(1, 0, 0, false)
+	update(2, 0, 0, false)
+	update(1, 0, 0, false)
+	update(1, 1, 110000, false)
+	update(2, 1, 109000, true)
+	update(2, 2, 111000, false)
+	update(1, 2, 108000, false)
+	update(1, 2, 108000, false)
+
+	tests.AssertEqual(t, 4, len(laps))
+	tests.AssertEqual(t, "[true false false true]", fmt.Sprint(best))
+	tests.AssertEqual(t, 1, laps[0].Lap)
+	tests.AssertEqual(t, 110000, laps[0].LapTime)
+	tests.AssertEqual(t, true, laps[1].Invalid)
+	tests.AssertEqual(t, 2, laps[3].Lap)
+
+	// A new session starts the session best over.
+	instance.HandleRealtimeUpdate(broadcasting.RealtimeUpdate{SessionIndex: 2})
+	update(2, 0, 0, false)
+	update(2, 1, 115000, false)
+	tests.AssertEqual(t, 5, len(laps))
+	tests.AssertEqual(t, true, best[4])
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/utl/metrics"
+	"acc-server-manager/tests"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsRegistry_Write(t *testing.T) {
+	registry := metrics.NewRegistry()
+	requests := registry.NewCounterVec("http_requests_total", "Requests handled.", "route", "status")
+	duration := registry.NewHistogramVec("http_request_duration_seconds", "Request time.", []float64{0.1, 1}, "route")
+
+	requests.Inc("/server/:id", "200")
+	requests.Add(2, "/server/:id", "200")
+	requests.Inc(`/a"b`, "500")
+	duration.Observe(0.05, "/server/:id")
+	duration.Observe(0.5, "/server/:id")
+
+	var out bytes.Buffer
+	tests.AssertNoError(t, registry.Write(&out))
+	expected := `# HELP http_request_duration_seconds Request time.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{route="/server/:id",le="0.1"} 1
+http_request_duration_seconds_bucket{route="/server/:id",le="1"} 2
+http_request_duration_seconds_bucket{route="/server/:id",le="+Inf"} 2
+http_request_duration_seconds_sum{route="/server/:id"} 0.55
+http_request_duration_seconds_count{route="/server/:id"} 2
+# HELP http_requests_total Requests handled.
+# TYPE http_requests_total counter
+http_requests_total{route="/a\"b",status="500"} 1
+http_requests_total{route="/server/:id",status="200"} 3
+`
+	tests.AssertEqual(t, expected, out.String())
+}
+
+func TestServerService_RegisterMetrics(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+
+	serverRepo := repository.NewServerRepository(helper.DB)
+	manager, serviceControl, serverService := newTestServerRuntime(helper, serverRepo)
+	registry := metrics.NewRegistry()
+	serverService.RegisterMetrics(registry)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	ctx := helper.CreateContext()
+	tests.AssertNoError(t, manager.CreateService(ctx, server.ServiceName, "accServer.exe", server.Path, nil))
+
+	logDir := server.GetLogPath()
+	tests.AssertNoError(t, os.MkdirAll(logDir, 0755))
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(logDir, "server.log"), []byte("Session changed: PRACTICE -> RACE\n"), 0644))
+
+	_, err := serviceControl.StartServer(server.ServiceName)
+	tests.AssertNoError(t, err)
+
+	labels := `server_id="` + server.ID.String() + `",server_name="` + server.Name + `"`
+	raceLine := `acc_server_session{` + labels + `,session="R"} 1`
+
+	var out bytes.Buffer
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		out.Reset()
+		tests.AssertNoError(t, registry.Write(&out))
+		if strings.Contains(out.String(), raceLine) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	for _, line := range []string{
+		raceLine,
+		`acc_server_session{` + labels + `,session="P"} 0`,
+		`acc_server_players{` + labels + `} 0`,
+		`acc_server_service_status{` + labels + `,status="SERVICE_RUNNING"} 1`,
+		`acc_server_service_status{` + labels + `,status="SERVICE_STOPPED"} 0`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("metrics do not contain %q:\n%s", line, out.String())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/tracking"
+	"acc-server-manager/tests"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPlayerService_RecordsSessionsFromLog(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(&model.PlayerSession{}))
+	tests.AssertNoError(t, helper.InsertTestServer())
+
+	playerService := service.NewPlayerService(repository.NewPlayerSessionRepository(helper.DB), repository.NewServerRepository(helper.DB))
+	server := helper.TestData.Server
+	sessionID := uuid.New()
+
+	instance := tracking.NewAccServerInstance(server, func(*model.ServerState, ...tracking.StateChange) {})
+	instance.OnPlayerChange = func(player model.PlayerState) {
+		playerService.RecordPlayer(server.ID, sessionID, player)
+	}
+
+	for _, line := range []string{
+		"New connection request: id 0 Max Verstappen S76561198000000001 on car model 32",
+		"Creating new car connection: carId 1001, carModel 32, raceNumber #1",
+		"New connection request: id 1 Lando Norris S76561198000000002 on car model 20",
+		"Creating new car connection: carId 1002, carModel 20, raceNumber #4",
+		"2 client(s) online",
+		"Removing dead connection 1 (timeout)",
+	} {
+		instance.HandleLogLine(line)
+	}
+
+	tests.AssertEqual(t, 1, len(instance.Players()))
+
+	online := true
+	result, err := playerService.GetAll(helper.CreateContext(), &model.PlayerSessionFilter{
+		ServerBasedFilter: model.ServerBasedFilter{ServerID: server.ID.String()},
+		Online:            &online,
+	})
+	tests.AssertNoError(t, err)
+	players := *result.Items.(*[]model.PlayerSession)
+	tests.AssertEqual(t, 1, len(players))
+	tests.AssertEqual(t, "Max Verstappen", players[0].DriverName)
+	tests.AssertEqual(t, "S76561198000000001", players[0].SteamID)
+	tests.AssertEqual(t, 1001, players[0].CarID)
+	tests.AssertEqual(t, 1, players[0].RaceNumber)
+	tests.AssertEqual(t, sessionID, players[0].SessionID)
+
+	result, err = playerService.GetAll(helper.CreateContext(), &model.PlayerSessionFilter{
+		ServerBasedFilter: model.ServerBasedFilter{ServerID: server.ID.String()},
+		SteamID:           "S76561198000000002",
+	})
+	tests.AssertNoError(t, err)
+	players = *result.Items.(*[]model.PlayerSession)
+	tests.AssertEqual(t, 1, len(players))
+	tests.AssertEqual(t, 1002, players[0].CarID)
+	tests.AssertNotNil(t, players[0].DisconnectedAt)
+
+	instance.HandleLogLine("0 client(s) online")
+
+	result, err = playerService.GetAll(helper.CreateContext(), &model.PlayerSessionFilter{
+		ServerBasedFilter: model.ServerBasedFilter{ServerID: server.ID.String()},
+		Online:            &online,
+	})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 0, len(*result.Items.(*[]model.PlayerSession)))
+	tests.AssertEqual(t, 0, len(instance.Players()))
+}
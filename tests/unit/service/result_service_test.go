@@ -0,0 +1,141 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/tracking"
+	"acc-server-manager/tests"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testRaceResult = `{
+	"sessionType": "R",
+	"trackName": "spa",
+	"sessionIndex": 2,
+	"raceWeekendIndex": 0,
+	"serverName": "Test Server",
+	"sessionResult": {
+		"bestlap": 138512,
+		"isWetSession": 0,
+		"leaderBoardLines": [
+			{
+				"car": {"carId": 1001, "raceNumber": 1, "carModel": 32, "cupCategory": 0, "carGroup": "GT3", "teamName": "",
+					"drivers": [{"firstName": "Max", "lastName": "Verstappen", "shortName": "VER", "playerId": "S76561198000000001"}]},
+				"timing": {"bestLap": 138512, "totalTime": 280100, "lapCount": 2},
+				"missingMandatoryPitstop": 0,
+				"driverTotalTimes": [280100.5]
+			},
+			{
+				"car": {"carId": 1002, "raceNumber": 4, "carModel": 20, "cupCategory": 0, "carGroup": "GT3", "teamName": "",
+					"drivers": [{"firstName": "Lando", "lastName": "Norris", "shortName": "NOR", "playerId": "S76561198000000002"}]},
+				"timing": {"bestLap": 139004, "totalTime": 281500, "lapCount": 2},
+				"missingMandatoryPitstop": 1,
+				"driverTotalTimes": [281500]
+			}
+		]
+	},
+	"laps": [
+		{"carId": 1001, "driverIndex": 0, "laptime": 141588, "isValidForBest": true, "splits": [45000, 50000, 46588]},
+		{"carId": 1002, "driverIndex": 0, "laptime": 142496, "isValidForBest": true, "splits": [45500, 50500, 46496]},
+		{"carId": 1001, "driverIndex": 0, "laptime": 138512, "isValidForBest": true, "splits": [44000, 49000, 45512]},
+		{"carId": 1002, "driverIndex": 0, "laptime": 139004, "isValidForBest": false, "splits": [44200, 49300, 45504]}
+	],
+	"penalties": [
+		{"carId": 1002, "driverIndex": 0, "reason": "Cutting", "penalty": "DriveThrough", "penaltyValue": 3, "violationInLap": 1, "clearedInLap": 2}
+	],
+	"post_race_penalties": []
+}`
+
+func TestResultService_IngestsResultsFolder(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+
+	history := &model.StateHistory{
+		ServerID:    server.ID,
+		Session:     model.SessionRace,
+		Track:       "spa",
+		DateCreated: time.Date(2024, 5, 12, 18, 0, 0, 0, time.Local).UTC(),
+	}
+	tests.AssertNoError(t, helper.DB.Create(history).Error)
+
+	resultsPath := server.GetResultsPath()
+	tests.AssertNoError(t, os.MkdirAll(resultsPath, 0755))
+	data, err := tests.EncodeUTF16LEBOM([]byte(testRaceResult))
+	tests.AssertNoError(t, err)
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(resultsPath, "240512_193011_R.json"), data, 0644))
+
+	resultService := service.NewResultService(repository.NewResultRepository(helper.DB), repository.NewServerRepository(helper.DB))
+	tests.AssertNoError(t, resultService.IngestDirectory(helper.CreateContext(), server.ID, resultsPath))
+	tests.AssertNoError(t, resultService.IngestDirectory(helper.CreateContext(), server.ID, resultsPath))
+
+	response, err := resultService.GetAll(helper.CreateContext(), &model.ResultSessionFilter{
+		ServerBasedFilter: model.ServerBasedFilter{ServerID: server.ID.String()},
+	})
+	tests.AssertNoError(t, err)
+	sessions := *response.Items.(*[]model.ResultSession)
+	tests.AssertEqual(t, 1, len(sessions))
+	tests.AssertEqual(t, model.SessionRace, sessions[0].SessionType)
+	tests.AssertEqual(t, 138512, sessions[0].BestLap)
+	tests.AssertNotNil(t, sessions[0].SessionID)
+	tests.AssertEqual(t, history.SessionID, *sessions[0].SessionID)
+	tests.AssertEqual(t, time.Date(2024, 5, 12, 19, 30, 11, 0, time.Local).UTC(), sessions[0].FinishedAt.UTC())
+
+	result, err := resultService.GetByID(helper.CreateContext(), server.ID.String(), sessions[0].ID.String())
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 2, len(result.Cars))
+	tests.AssertEqual(t, 1001, result.Cars[0].CarID)
+	tests.AssertEqual(t, "S76561198000000001", result.Cars[0].Drivers[0].PlayerID)
+	tests.AssertEqual(t, 280100, result.Cars[0].Drivers[0].DrivingTime)
+	tests.AssertEqual(t, true, result.Cars[1].MissingMandatoryPitstop)
+	tests.AssertEqual(t, 4, len(result.Laps))
+	tests.AssertEqual(t, 2, result.Laps[1].LapNumber)
+	tests.AssertEqual(t, 49000, result.Laps[1].Sector2)
+	tests.AssertEqual(t, 1, len(result.Penalties))
+	tests.AssertEqual(t, "DriveThrough", result.Penalties[0].Penalty)
+
+	_, err = resultService.GetByID(helper.CreateContext(), server.ID.String(), history.ID.String())
+	tests.AssertError(t, err, "Result not found")
+}
+
+func TestFileStatWatcher_ReportsNewAndChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "251012_180000_R.json")
+	tests.AssertNoError(t, os.WriteFile(existing, []byte(`{"a": 1}`), 0644))
+
+	var reported []string
+	watcher := tracking.NewFileStatWatcher(dir, time.Hour, func(fileName string, data []byte) {
+		reported = append(reported, fileName+" "+string(data))
+	})
+	watcher.Start()
+	defer watcher.Stop()
+
+	watcher.Poll()
+	tests.AssertEqual(t, 0, len(reported))
+
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(dir, "251012_190000_R.json"), []byte(`{"b": 2}`), 0644))
+	watcher.Poll()
+	tests.AssertEqual(t, "[251012_190000_R.json {\"b\": 2}]", fmt.Sprint(reported))
+
+	// Rewriting a file with the same contents is not a change once it has
+	// been read.
+	later := time.Now().Add(time.Minute)
+	tests.AssertNoError(t, os.Chtimes(filepath.Join(dir, "251012_190000_R.json"), later, later))
+	watcher.Poll()
+	tests.AssertEqual(t, 1, len(reported))
+
+	tests.AssertNoError(t, os.WriteFile(existing, []byte(`{"a": 10}`), 0644))
+	watcher.Poll()
+	tests.AssertEqual(t, 2, len(reported))
+	tests.AssertEqual(t, "251012_180000_R.json {\"a\": 10}", reported[1])
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/tests"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRotationService_Advance(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.Rotation{}, &model.RotationEntry{}, &model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+
+	serverRepo := repository.NewServerRepository(helper.DB)
+	manager, serviceControl, serverService := newTestServerRuntime(helper, serverRepo)
+	configService := service.NewConfigService(repository.NewConfigRepository(helper.DB), serverRepo)
+	rotations := service.NewRotationService(repository.NewRotationRepository(helper.DB), serverRepo, configService, serviceControl, serverService)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	serverID := server.ID.String()
+	ctx := helper.CreateContext()
+	tests.AssertNoError(t, manager.CreateService(ctx, server.ServiceName, "accServer.exe", server.Path, nil))
+
+	configPath := server.GetConfigPath()
+	tests.AssertNoError(t, os.MkdirAll(configPath, 0755))
+	event, err := tests.EncodeUTF16LEBOM([]byte(helper.TestData.ConfigFiles[service.EventJson]))
+	tests.AssertNoError(t, err)
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(configPath, service.EventJson), event, 0644))
+
+	_, err = rotations.Update(ctx, serverID, &model.Rotation{
+		Enabled: true,
+		Entries: []model.RotationEntry{{Event: model.RotationEvent{"trak": "monza"}}},
+	})
+	tests.AssertError(t, err, "validation failed: entries[0].name: is required; entries[0].event.trak: unknown field")
+
+	rotation, err := rotations.Update(ctx, serverID, &model.Rotation{
+		Enabled:          true,
+		AdvanceOnRaceEnd: true,
+		Entries: []model.RotationEntry{
+			{Name: "Spa", Event: model.RotationEvent{"track": "spa"}},
+			{Name: "Monza wet", Event: model.RotationEvent{"track": "monza", "rain": 0.4, "ambientTemp": 18}},
+			{Name: "Zandvoort", Event: model.RotationEvent{"track": "zandvoort"}},
+		},
+	})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, "Spa", rotation.Current().Name)
+
+	// The end of the race is picked up from the server log.
+	sessionChanges := make(chan struct{}, 2)
+	serverService.AddSessionChangeListener(func(id uuid.UUID, from, to model.TrackSession) {
+		rotations.HandleSessionChange(ctx, id, from, to)
+		sessionChanges <- struct{}{}
+	})
+	logDir := server.GetLogPath()
+	tests.AssertNoError(t, os.MkdirAll(logDir, 0755))
+	log := "Session changed: PRACTICE -> RACE\nSession changed: RACE -> PRACTICE\n"
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(logDir, "server.log"), []byte(log), 0644))
+
+	_, err = serviceControl.StartServer(server.ServiceName)
+	tests.AssertNoError(t, err)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-sessionChanges:
+		case <-time.After(5 * time.Second):
+			t.Fatal("session changes were not reported")
+		}
+	}
+
+	rotation, err = rotations.Get(ctx, serverID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, "Monza wet", rotation.Current().Name)
+	tests.AssertEqual(t, model.RotationTriggerRaceEnd, rotation.LastTrigger)
+
+	eventConfig, err := configService.GetEventConfig(server)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, "monza", eventConfig.Track)
+	tests.AssertEqual(t, 0.4, eventConfig.Rain)
+	tests.AssertEqual(t, model.IntString(18), eventConfig.AmbientTemp)
+	tests.AssertEqual(t, model.IntString(80), eventConfig.PreRaceWaitingTimeSeconds)
+
+	calls := manager.Calls()
+	tests.AssertEqual(t, "restart:"+server.ServiceName, calls[len(calls)-1])
+
+	// A pinned rotation stays put until it is skipped by hand.
+	_, err = rotations.SetPinned(ctx, serverID, true)
+	tests.AssertNoError(t, err)
+	rotations.HandleSessionChange(ctx, server.ID, model.SessionRace, model.SessionPractice)
+	rotation, err = rotations.Get(ctx, serverID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, rotation.CurrentIndex)
+
+	rotation, err = rotations.Skip(ctx, serverID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, "Zandvoort", rotation.Current().Name)
+	tests.AssertEqual(t, false, rotation.Pinned)
+
+	// The schedule wraps around to the first entry.
+	_, err = rotations.Update(ctx, serverID, &model.Rotation{
+		Enabled:        true,
+		CronExpression: "0 * * * *",
+		Entries:        rotation.Entries,
+	})
+	tests.AssertNoError(t, err)
+	rotation, err = rotations.Get(ctx, serverID)
+	tests.AssertNoError(t, err)
+	tests.AssertNotNil(t, rotation.NextRunAt)
+
+	rotations.RunDue(ctx, *rotation.NextRunAt)
+	rotation, err = rotations.Get(ctx, serverID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, "Spa", rotation.Current().Name)
+	tests.AssertEqual(t, model.RotationTriggerSchedule, rotation.LastTrigger)
+
+	eventConfig, err = configService.GetEventConfig(server)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, "spa", eventConfig.Track)
+}
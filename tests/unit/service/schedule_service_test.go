@@ -0,0 +1,160 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/cron"
+	"acc-server-manager/tests"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCron_Next(t *testing.T) {
+	cases := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"0 4 * * *", "2024-03-01T10:00:00Z", "2024-03-02T04:00:00Z"},
+		{"*/15 * * * *", "2024-03-01T10:07:30Z", "2024-03-01T10:15:00Z"},
+		{"30 20 * * FRI", "2024-03-01T21:00:00Z", "2024-03-08T20:30:00Z"},
+		{"0 0 1 JAN *", "2024-03-01T00:00:00Z", "2025-01-01T00:00:00Z"},
+		{"@hourly", "2024-03-01T10:00:00Z", "2024-03-01T11:00:00Z"},
+		{"0 12 13 * 5", "2024-03-01T00:00:00Z", "2024-03-01T12:00:00Z"},
+	}
+
+	for _, c := range cases {
+		schedule, err := cron.Parse(c.expr)
+		tests.AssertNoError(t, err)
+		from, _ := time.Parse(time.RFC3339, c.from)
+		tests.AssertEqual(t, c.want, schedule.Next(from).Format(time.RFC3339))
+	}
+
+	_, err := cron.Parse("61 * * * *")
+	tests.AssertError(t, err, "minute must be between 0 and 59, got 61")
+}
+
+func TestSchedule_NextRunInTimezone(t *testing.T) {
+	schedule := &model.Schedule{CronExpression: "0 4 * * *", Timezone: "Europe/Zagreb", Enabled: true}
+	from, _ := time.Parse(time.RFC3339, "2024-07-01T12:00:00Z")
+
+	next := schedule.NextRun(from)
+	tests.AssertNotNil(t, next)
+	tests.AssertEqual(t, "2024-07-02T02:00:00Z", next.Format(time.RFC3339))
+
+	schedule.Enabled = false
+	tests.AssertEqual(t, true, schedule.NextRun(from) == nil)
+}
+
+// newTestServerRuntime wires a server service to a fake service manager. It
+// must be called before servers are inserted, as the server service starts
+// the runtime of every existing server.
+func newTestServerRuntime(helper *tests.TestHelper, serverRepo *repository.ServerRepository) (*service.FakeServiceManager, *service.ServiceControlService, *service.ServerService) {
+	manager := service.NewFakeServiceManager()
+	serviceControl := service.NewServiceControlService(repository.NewServiceControlRepository(helper.DB), serverRepo, manager)
+	serverService := service.NewServerService(
+		serverRepo,
+		repository.NewStateHistoryRepository(helper.DB),
+		serviceControl,
+		service.NewConfigService(repository.NewConfigRepository(helper.DB), serverRepo),
+		nil,
+		manager,
+		nil,
+		service.NewWebSocketService(),
+		service.NewPlayerService(repository.NewPlayerSessionRepository(helper.DB), serverRepo),
+		service.NewResultService(repository.NewResultRepository(helper.DB), serverRepo),
+	)
+	serviceControl.SetServerService(serverService)
+	return manager, serviceControl, serverService
+}
+
+func TestScheduleService_RunDue(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.Schedule{}, &model.ScheduleExecution{}, &model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+
+	serverRepo := repository.NewServerRepository(helper.DB)
+	manager, serviceControl, serverService := newTestServerRuntime(helper, serverRepo)
+	scheduleService := service.NewScheduleService(
+		repository.NewScheduleRepository(helper.DB),
+		repository.NewScheduleExecutionRepository(helper.DB),
+		serverRepo,
+		serviceControl,
+		serverService,
+		nil,
+	)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	serverID := server.ID.String()
+	ctx := helper.CreateContext()
+	tests.AssertNoError(t, manager.CreateService(ctx, server.ServiceName, "accServer.exe", server.Path, nil))
+
+	logDir := server.GetLogPath()
+	tests.AssertNoError(t, os.MkdirAll(logDir, 0755))
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(logDir, "server.log"), []byte("2 client(s) online\n"), 0644))
+
+	_, err := scheduleService.Create(ctx, serverID, &model.Schedule{Name: "Nightly", Action: "reboot", CronExpression: "0 25 * * *"})
+	tests.AssertError(t, err, `validation failed: action: must be "start", "stop", "restart" or "update"; cronExpression: hour must be between 0 and 23, got 25`)
+
+	start, err := scheduleService.Create(ctx, serverID, &model.Schedule{Name: "Morning start", Action: model.ScheduleActionStart, CronExpression: "0 8 * * *", Enabled: true})
+	tests.AssertNoError(t, err)
+	tests.AssertNotNil(t, start.NextRunAt)
+
+	executions := scheduleService.RunDue(ctx, *start.NextRunAt)
+	tests.AssertEqual(t, 1, len(executions))
+	tests.AssertEqual(t, model.ScheduleExecutionSucceeded, executions[0].Status)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for serverService.PlayersOnline(server.ID) == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	tests.AssertEqual(t, 2, serverService.PlayersOnline(server.ID))
+
+	// Drop the start schedule so it cannot fall due alongside the stop
+	// schedule below, whatever the time of day.
+	tests.AssertNoError(t, scheduleService.Delete(ctx, serverID, start.ID.String()))
+
+	stop, err := scheduleService.Create(ctx, serverID, &model.Schedule{Name: "Night stop", Action: model.ScheduleActionStop, CronExpression: "0 2 * * *", Enabled: true})
+	tests.AssertNoError(t, err)
+
+	executions = scheduleService.RunDue(ctx, *stop.NextRunAt)
+	tests.AssertEqual(t, 1, len(executions))
+	tests.AssertEqual(t, model.ScheduleExecutionSkipped, executions[0].Status)
+	tests.AssertEqual(t, "2 player(s) online", executions[0].Message)
+
+	stored, err := scheduleService.GetByID(ctx, serverID, stop.ID.String())
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, true, stored.NextRunAt.After(*stop.NextRunAt))
+
+	stored.Force = true
+	stored, err = scheduleService.Update(ctx, serverID, stop.ID.String(), stored)
+	tests.AssertNoError(t, err)
+
+	missed := stored.NextRunAt.Add(time.Hour)
+	executions = scheduleService.RunDue(ctx, missed)
+	tests.AssertEqual(t, 1, len(executions))
+	tests.AssertEqual(t, model.ScheduleExecutionSkipped, executions[0].Status)
+	tests.AssertEqual(t, "missed by 1h0m0s", executions[0].Message)
+
+	stored, err = scheduleService.GetByID(ctx, serverID, stop.ID.String())
+	tests.AssertNoError(t, err)
+	executions = scheduleService.RunDue(ctx, *stored.NextRunAt)
+	tests.AssertEqual(t, 1, len(executions))
+	tests.AssertEqual(t, model.ScheduleExecutionSucceeded, executions[0].Status)
+	tests.AssertEqual(t, 0, serverService.PlayersOnline(server.ID))
+
+	history, err := scheduleService.GetExecutions(ctx, &model.ScheduleExecutionFilter{
+		ServerBasedFilter: model.ServerBasedFilter{ServerID: serverID},
+		ScheduleID:        stop.ID.String(),
+	})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 3, history.Params.TotalRecords)
+}
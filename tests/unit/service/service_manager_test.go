@@ -0,0 +1,115 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/command"
+	"acc-server-manager/tests"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFakeServiceManager_Lifecycle(t *testing.T) {
+	manager := service.NewFakeServiceManager()
+	ctx := context.Background()
+
+	err := manager.CreateService(ctx, "ACC-Server-Test", "accServer.exe", "server", nil)
+	tests.AssertNoError(t, err)
+
+	status, err := manager.Status(ctx, "ACC-Server-Test")
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.StatusStopped, model.ParseServiceStatus(status))
+
+	status, err = manager.Start(ctx, "ACC-Server-Test")
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.StatusRunning, model.ParseServiceStatus(status))
+
+	status, err = manager.Stop(ctx, "ACC-Server-Test")
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.StatusStopped, model.ParseServiceStatus(status))
+
+	err = manager.DeleteService(ctx, "ACC-Server-Test")
+	tests.AssertNoError(t, err)
+
+	_, err = manager.Status(ctx, "ACC-Server-Test")
+	tests.AssertError(t, err, "service ACC-Server-Test not found")
+
+	calls := manager.Calls()
+	tests.AssertEqual(t, 6, len(calls))
+	tests.AssertEqual(t, "create:ACC-Server-Test", calls[0])
+	tests.AssertEqual(t, "start:ACC-Server-Test", calls[2])
+}
+
+func TestFakeServiceManager_FailOn(t *testing.T) {
+	manager := service.NewFakeServiceManager()
+	ctx := context.Background()
+
+	manager.FailOn("create", tests.ErrorForTesting("install failed"))
+
+	err := manager.CreateService(ctx, "ACC-Server-Test", "accServer.exe", "server", nil)
+	tests.AssertError(t, err, "install failed")
+
+	_, err = manager.Start(ctx, "ACC-Server-Test")
+	tests.AssertError(t, err, "service ACC-Server-Test not found")
+}
+
+func TestParseSystemdState(t *testing.T) {
+	cases := map[string]model.ServiceStatus{
+		"active":       model.StatusRunning,
+		"inactive":     model.StatusStopped,
+		"failed":       model.StatusStopped,
+		"activating":   model.StatusStarting,
+		"deactivating": model.StatusStopping,
+		"reloading":    model.StatusRestarting,
+		"unknown":      model.StatusUnknown,
+		"":             model.StatusUnknown,
+	}
+
+	for state, expected := range cases {
+		tests.AssertEqual(t, expected, model.ParseSystemdState(state))
+	}
+}
+
+// fakeSystemctl writes a script standing in for systemctl that records its
+// arguments.
+func fakeSystemctl(t *testing.T, dir string) (exePath, logPath string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake systemctl is a shell script")
+	}
+	logPath = filepath.Join(dir, "systemctl.log")
+	exePath = filepath.Join(dir, "systemctl")
+	script := "#!/bin/sh\necho \"$*\" >> " + logPath + "\n"
+	tests.AssertNoError(t, os.WriteFile(exePath, []byte(script), 0755))
+	return exePath, logPath
+}
+
+func TestSystemdService_CreateServiceWritesUnit(t *testing.T) {
+	dir := t.TempDir()
+	exePath, logPath := fakeSystemctl(t, dir)
+	unitDir := filepath.Join(dir, "units")
+	manager := service.NewSystemdServiceWithExecutor(&command.CommandExecutor{ExePath: exePath}, unitDir, "/usr/bin/wine")
+
+	serverDir := filepath.Join(dir, "servers", "ACC 100%", "server")
+	err := manager.CreateService(context.Background(), "ACC-Server-Test", filepath.Join(serverDir, "accServer.exe"), serverDir, []string{`say "hi"`})
+	tests.AssertNoError(t, err)
+
+	unit, err := os.ReadFile(filepath.Join(unitDir, "ACC-Server-Test.service"))
+	tests.AssertNoError(t, err)
+	escapedDir := strings.ReplaceAll(serverDir, "%", "%%")
+	tests.AssertEqual(t, true, strings.Contains(string(unit), "\nWorkingDirectory="+escapedDir+"\n"))
+	tests.AssertEqual(t, true, strings.Contains(string(unit),
+		"\nExecStart=\"/usr/bin/wine\" \""+escapedDir+`/accServer.exe" "say \"hi\""`+"\n"))
+
+	logged, err := os.ReadFile(logPath)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, "daemon-reload\nenable ACC-Server-Test.service\n", string(logged))
+
+	err = manager.CreateService(context.Background(), "ACC-Server-Bad", "accServer.exe", filepath.Join(dir, "bad\ndir"), nil)
+	tests.AssertError(t, err, "invalid line break in \""+filepath.Join(dir, "bad\\ndir")+"\"")
+	_, err = os.Stat(filepath.Join(unitDir, "ACC-Server-Bad.service"))
+	tests.AssertEqual(t, true, os.IsNotExist(err))
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/tests"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRestartPolicy_Backoff(t *testing.T) {
+	policy := &model.RestartPolicy{BackoffSeconds: 10, MaxBackoffSeconds: 60}
+
+	tests.AssertEqual(t, 10*time.Second, policy.Backoff(0))
+	tests.AssertEqual(t, 20*time.Second, policy.Backoff(1))
+	tests.AssertEqual(t, 40*time.Second, policy.Backoff(2))
+	tests.AssertEqual(t, 60*time.Second, policy.Backoff(3))
+	tests.AssertEqual(t, 60*time.Second, policy.Backoff(30))
+}
+
+func TestSupervisorService_RestartsCrashedServer(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.RestartPolicy{}, &model.ServerCrash{}, &model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+
+	serverRepo := repository.NewServerRepository(helper.DB)
+	manager, serviceControl, serverService := newTestServerRuntime(helper, serverRepo)
+	supervisor := service.NewSupervisorService(
+		repository.NewRestartPolicyRepository(helper.DB),
+		repository.NewServerCrashRepository(helper.DB),
+		serverRepo,
+		serviceControl,
+		serverService,
+	)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	serverID := server.ID.String()
+	ctx := helper.CreateContext()
+	tests.AssertNoError(t, manager.CreateService(ctx, server.ServiceName, "accServer.exe", server.Path, nil))
+
+	_, err := supervisor.UpdatePolicy(ctx, serverID, &model.RestartPolicy{Enabled: true, MaxRetries: 2, BackoffSeconds: 10, MaxBackoffSeconds: 5})
+	tests.AssertError(t, err, "validation failed: maxBackoffSeconds: must be at least backoffSeconds (10)")
+	_, err = supervisor.UpdatePolicy(ctx, serverID, &model.RestartPolicy{
+		Enabled: true, MaxRetries: 2, BackoffSeconds: 10, MaxBackoffSeconds: 60,
+		CrashLoopCount: 2, CrashLoopWindowMinutes: 30, LogLines: 2,
+	})
+	tests.AssertNoError(t, err)
+
+	logDir := server.GetLogPath()
+	tests.AssertNoError(t, os.MkdirAll(logDir, 0755))
+	log := "Server starting\nSession changed: PRACTICE -> RACE\nUnhandled exception\n"
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(logDir, "server.log"), []byte(log), 0644))
+
+	_, err = serviceControl.StartServer(server.ServiceName)
+	tests.AssertNoError(t, err)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if lines, _ := serverService.RecentLogLines(server.ID, 3); len(lines) == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	now := time.Now().UTC()
+	supervisor.Check(ctx, now)
+
+	// A stop through the manager is not a crash.
+	_, err = serviceControl.StopServer(server.ServiceName)
+	tests.AssertNoError(t, err)
+	serviceControl.InvalidateStatus(server.ServiceName)
+	supervisor.Check(ctx, now.Add(time.Second))
+
+	_, err = serviceControl.StartServer(server.ServiceName)
+	tests.AssertNoError(t, err)
+	serviceControl.InvalidateStatus(server.ServiceName)
+	supervisor.Check(ctx, now.Add(2*time.Second))
+
+	manager.SetStatus(server.ServiceName, model.StatusStopped)
+	serviceControl.InvalidateStatus(server.ServiceName)
+	supervisor.Check(ctx, now.Add(3*time.Second))
+
+	crashes, err := supervisor.GetCrashes(ctx, &model.ServerCrashFilter{ServerBasedFilter: model.ServerBasedFilter{ServerID: serverID}})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, crashes.Params.TotalRecords)
+	crash := (*crashes.Items.(*[]model.ServerCrash))[0]
+	tests.AssertEqual(t, model.CrashOutcomePending, crash.Outcome)
+	tests.AssertEqual(t, "Session changed: PRACTICE -> RACE\nUnhandled exception", crash.LogTail)
+
+	// The first attempt fails and the second waits twice the backoff.
+	manager.FailOn("start", tests.ErrorForTesting("access denied"))
+	supervisor.Check(ctx, now.Add(8*time.Second))
+	supervisor.Check(ctx, now.Add(14*time.Second))
+	crash1, err := supervisor.GetCrash(ctx, serverID, crash.ID.String())
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, crash1.Attempts)
+	tests.AssertEqual(t, "access denied", crash1.Error)
+
+	manager.FailOn("start", nil)
+	supervisor.Check(ctx, now.Add(30*time.Second))
+	supervisor.Check(ctx, now.Add(35*time.Second))
+	crash1, err = supervisor.GetCrash(ctx, serverID, crash.ID.String())
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.CrashOutcomeRestarted, crash1.Outcome)
+	tests.AssertEqual(t, 2, crash1.Attempts)
+
+	// A second crash within the window is a crash loop.
+	manager.SetStatus(server.ServiceName, model.StatusStopped)
+	serviceControl.InvalidateStatus(server.ServiceName)
+	supervisor.Check(ctx, now.Add(40*time.Second))
+
+	crashes, err = supervisor.GetCrashes(ctx, &model.ServerCrashFilter{
+		ServerBasedFilter: model.ServerBasedFilter{ServerID: serverID},
+		Outcome:           model.CrashOutcomeCrashLoop,
+	})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, crashes.Params.TotalRecords)
+}
+
+func TestSupervisorService_RestartsStoppedAndHungServer(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.RestartPolicy{}, &model.ServerCrash{}, &model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+
+	serverRepo := repository.NewServerRepository(helper.DB)
+	manager, serviceControl, serverService := newTestServerRuntime(helper, serverRepo)
+	supervisor := service.NewSupervisorService(
+		repository.NewRestartPolicyRepository(helper.DB),
+		repository.NewServerCrashRepository(helper.DB),
+		serverRepo,
+		serviceControl,
+		serverService,
+	)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	serverID := server.ID.String()
+	ctx := helper.CreateContext()
+	tests.AssertNoError(t, manager.CreateService(ctx, server.ServiceName, "accServer.exe", server.Path, nil))
+
+	_, err := supervisor.UpdatePolicy(ctx, serverID, &model.RestartPolicy{
+		Enabled: true, MaxRetries: 2, BackoffSeconds: 10, MaxBackoffSeconds: 60, StallTimeoutMinutes: -1,
+	})
+	tests.AssertError(t, err, "validation failed: stallTimeoutMinutes: must not be negative")
+	_, err = supervisor.UpdatePolicy(ctx, serverID, &model.RestartPolicy{
+		Enabled: true, MaxRetries: 2, BackoffSeconds: 10, MaxBackoffSeconds: 60, StallTimeoutMinutes: 10, LogLines: 1,
+	})
+	tests.AssertNoError(t, err)
+
+	logDir := server.GetLogPath()
+	tests.AssertNoError(t, os.MkdirAll(logDir, 0755))
+	tests.AssertNoError(t, os.WriteFile(filepath.Join(logDir, "server.log"), []byte("Server starting\nWaiting for connections\n"), 0644))
+
+	// A server stopped when first seen, without a stop being requested, is
+	// a crash.
+	now := time.Now().UTC()
+	supervisor.Check(ctx, now)
+	crashes, err := supervisor.GetCrashes(ctx, &model.ServerCrashFilter{ServerBasedFilter: model.ServerBasedFilter{ServerID: serverID}})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, crashes.Params.TotalRecords)
+	stopped := (*crashes.Items.(*[]model.ServerCrash))[0]
+	tests.AssertEqual(t, false, stopped.Hung)
+
+	supervisor.Check(ctx, now.Add(10*time.Second))
+	crash, err := supervisor.GetCrash(ctx, serverID, stopped.ID.String())
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.CrashOutcomeRestarted, crash.Outcome)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, lastLogAt := serverService.RecentLogLines(server.ID, 0); !lastLogAt.IsZero() || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// A running server that stops logging for longer than the stall timeout
+	// is restarted.
+	supervisor.Check(ctx, now.Add(5*time.Minute))
+	supervisor.Check(ctx, now.Add(20*time.Minute))
+	crashes, err = supervisor.GetCrashes(ctx, &model.ServerCrashFilter{
+		ServerBasedFilter: model.ServerBasedFilter{ServerID: serverID},
+		Outcome:           model.CrashOutcomePending,
+	})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, crashes.Params.TotalRecords)
+	hung := (*crashes.Items.(*[]model.ServerCrash))[0]
+	tests.AssertEqual(t, true, hung.Hung)
+	tests.AssertEqual(t, "Waiting for connections", hung.LogTail)
+
+	supervisor.Check(ctx, now.Add(20*time.Minute+10*time.Second))
+	crash, err = supervisor.GetCrash(ctx, serverID, hung.ID.String())
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.CrashOutcomeRestarted, crash.Outcome)
+	calls := manager.Calls()
+	tests.AssertEqual(t, "restart:"+server.ServiceName, calls[len(calls)-1])
+
+	// The stall timeout counts again from the restart.
+	supervisor.Check(ctx, now.Add(25*time.Minute))
+	crashes, err = supervisor.GetCrashes(ctx, &model.ServerCrashFilter{ServerBasedFilter: model.ServerBasedFilter{ServerID: serverID}})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 2, crashes.Params.TotalRecords)
+}
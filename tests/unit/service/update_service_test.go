@@ -0,0 +1,247 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/local/utl/command"
+	"acc-server-manager/tests"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeInstaller stands in for SteamCMD by writing the given build into the
+// install path. With started set, it closes started once the files are
+// written and waits for release or ctx before reporting success.
+type fakeInstaller struct {
+	build   string
+	err     error
+	paths   []string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *fakeInstaller) InstallServerWithCallbacks(ctx context.Context, installPath string, serverID *uuid.UUID, outputCallback command.OutputCallback) error {
+	f.paths = append(f.paths, installPath)
+	if f.err != nil {
+		return f.err
+	}
+	if err := writeTestInstall(installPath, f.build); err != nil {
+		return err
+	}
+	if f.started != nil {
+		close(f.started)
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return fmt.Errorf("failed to run SteamCMD: %w", ctx.Err())
+		}
+	}
+	outputCallback(*serverID, "Success! App '1430110' fully installed.", false)
+	return nil
+}
+
+func writeTestInstall(installPath, build string) error {
+	if err := os.MkdirAll(filepath.Join(installPath, "server"), 0755); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(installPath, "steamapps"), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(installPath, "server", "accServer.exe"), []byte("MZ"+build), 0644); err != nil {
+		return err
+	}
+	manifest := "\"AppState\"\n{\n\t\"appid\"\t\t\"1430110\"\n\t\"buildid\"\t\t\"" + build + "\"\n}\n"
+	return os.WriteFile(filepath.Join(installPath, "steamapps", "appmanifest_1430110.acf"), []byte(manifest), 0644)
+}
+
+func newTestUpdateService(t *testing.T, helper *tests.TestHelper) (*service.UpdateService, *service.FakeServiceManager, *service.ServiceControlService, *fakeInstaller) {
+	tests.AssertNoError(t, helper.DB.AutoMigrate(
+		&model.UpdateJob{}, &model.PlayerSession{},
+		&model.ResultSession{}, &model.ResultCar{}, &model.ResultDriver{}, &model.ResultLap{}, &model.ResultPenalty{},
+	))
+
+	serverRepo := repository.NewServerRepository(helper.DB)
+	manager, serviceControl, serverService := newTestServerRuntime(helper, serverRepo)
+	updateService := service.NewUpdateService(
+		repository.NewUpdateJobRepository(helper.DB),
+		serverRepo,
+		serviceControl,
+		serverService,
+		nil,
+		service.NewWebSocketService(),
+	)
+	installer := &fakeInstaller{build: "2"}
+	updateService.SetInstaller(installer)
+	return updateService, manager, serviceControl, installer
+}
+
+func TestUpdateService_UpdatesRunningServer(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	updateService, manager, serviceControl, installer := newTestUpdateService(t, helper)
+	jobService, _ := newTestJobService(t, helper)
+	updateService.SetJobService(jobService)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	tests.AssertNoError(t, writeTestInstall(server.Path, "1"))
+	ctx := helper.CreateContext()
+	tests.AssertNoError(t, manager.CreateService(ctx, server.ServiceName, "accServer.exe", server.GetServerPath(), nil))
+	_, err := serviceControl.StartServer(server.ServiceName)
+	tests.AssertNoError(t, err)
+
+	job, err := updateService.Enqueue(ctx, server.ID.String(), model.UpdateJobManual, 0, nil)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.UpdateJobQueued, job.Status)
+	tests.AssertEqual(t, model.DefaultUpdateWaitMinutes, job.WaitMinutes)
+
+	_, err = updateService.Enqueue(ctx, server.ID.String(), model.UpdateJobManual, 0, nil)
+	tests.AssertError(t, err, "an update is already queued or running for this server")
+
+	tests.AssertEqual(t, true, jobService.RunNext(ctx))
+	tests.AssertEqual(t, false, jobService.RunNext(ctx))
+
+	job, err = updateService.GetByID(ctx, server.ID.String(), job.ID.String())
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.UpdateJobSucceeded, job.Status)
+	tests.AssertEqual(t, true, job.WasRunning)
+	tests.AssertEqual(t, "build 1", job.PreviousVersion)
+	tests.AssertEqual(t, "build 2", job.NewVersion)
+	tests.AssertEqual(t, server.Path, strings.Join(installer.paths, ","))
+
+	calls := manager.Calls()
+	tests.AssertEqual(t, "start:ACC-Server-Test,status:ACC-Server-Test,stop:ACC-Server-Test,start:ACC-Server-Test", strings.Join(calls[1:], ","))
+}
+
+func TestUpdateService_FailureStopsRollout(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	updateService, manager, _, installer := newTestUpdateService(t, helper)
+	jobService, _ := newTestJobService(t, helper)
+	updateService.SetJobService(jobService)
+	installer.err = tests.ErrorForTesting("No subscription")
+
+	ctx := helper.CreateContext()
+	for _, name := range []string{"ACC-Server-A", "ACC-Server-B"} {
+		server := &model.Server{
+			Name:         name,
+			Path:         filepath.Join(helper.TempDir, name),
+			ServiceName:  name,
+			FromSteamCMD: true,
+		}
+		tests.AssertNoError(t, helper.DB.Create(server).Error)
+		tests.AssertNoError(t, writeTestInstall(server.Path, "1"))
+		tests.AssertNoError(t, manager.CreateService(ctx, name, "accServer.exe", server.GetServerPath(), nil))
+	}
+	jobs, err := updateService.UpdateAll(ctx, &model.UpdateJobRequest{WaitMinutes: 5})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 2, len(jobs))
+
+	// The job of the update cancelled by the failure still runs, and ends
+	// without updating.
+	tests.AssertEqual(t, true, jobService.RunNext(ctx))
+	tests.AssertEqual(t, true, jobService.RunNext(ctx))
+	tests.AssertEqual(t, false, jobService.RunNext(ctx))
+	tests.AssertEqual(t, 1, len(installer.paths))
+
+	var statuses, jobStatuses []string
+	for _, job := range jobs {
+		stored, err := updateService.GetByID(ctx, job.ServerID.String(), job.ID.String())
+		tests.AssertNoError(t, err)
+		tests.AssertEqual(t, *jobs[0].BatchID, *stored.BatchID)
+		statuses = append(statuses, string(stored.Status)+": "+stored.Error)
+
+		storedJob, err := jobService.GetByID(ctx, job.ID)
+		tests.AssertNoError(t, err)
+		jobStatuses = append(jobStatuses, string(storedJob.Status))
+	}
+	sort.Strings(statuses)
+	tests.AssertEqual(t, "cancelled: update of server "+jobs[0].ServerID.String()+" failed|failed: No subscription", strings.Join(statuses, "|"))
+	sort.Strings(jobStatuses)
+	tests.AssertEqual(t, "cancelled|failed", strings.Join(jobStatuses, "|"))
+}
+
+func TestUpdateService_RunsAsJob(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	updateService, manager, _, _ := newTestUpdateService(t, helper)
+	jobService, _ := newTestJobService(t, helper)
+	updateService.SetJobService(jobService)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	tests.AssertNoError(t, writeTestInstall(server.Path, "1"))
+	ctx := helper.CreateContext()
+	tests.AssertNoError(t, manager.CreateService(ctx, server.ServiceName, "accServer.exe", server.GetServerPath(), nil))
+
+	update, err := updateService.Enqueue(ctx, server.ID.String(), model.UpdateJobManual, 0, nil)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, true, jobService.RunNext(ctx))
+
+	update, err = updateService.GetByID(ctx, server.ID.String(), update.ID.String())
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.UpdateJobSucceeded, update.Status)
+
+	job, err := jobService.GetByID(ctx, update.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobServerUpdate, job.Type)
+	tests.AssertEqual(t, model.JobSucceeded, job.Status)
+	tests.AssertEqual(t, string(model.UpdateJobVerifying), job.Step)
+	tests.AssertEqual(t, true, strings.Contains(strings.Join(job.Logs, "\n"), "Success! App '1430110' fully installed."))
+}
+
+func TestUpdateService_RefusesCancelOnceUpdating(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	updateService, manager, _, installer := newTestUpdateService(t, helper)
+	jobService, _ := newTestJobService(t, helper)
+	updateService.SetJobService(jobService)
+	installer.started = make(chan struct{})
+	installer.release = make(chan struct{})
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	tests.AssertNoError(t, writeTestInstall(server.Path, "1"))
+	ctx := helper.CreateContext()
+	tests.AssertNoError(t, manager.CreateService(ctx, server.ServiceName, "accServer.exe", server.GetServerPath(), nil))
+
+	update, err := updateService.Enqueue(ctx, server.ID.String(), model.UpdateJobManual, 0, nil)
+	tests.AssertNoError(t, err)
+	done := make(chan bool)
+	go func() {
+		done <- jobService.RunNext(ctx)
+	}()
+	select {
+	case <-installer.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("update did not start installing")
+	}
+
+	_, err = jobService.Cancel(ctx, update.ID)
+	tests.AssertError(t, err, "job can no longer be cancelled")
+	_, err = updateService.Cancel(ctx, server.ID.String(), update.ID.String())
+	tests.AssertError(t, err, "update can no longer be cancelled")
+
+	close(installer.release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("update did not finish")
+	}
+	job, err := jobService.GetByID(ctx, update.ID)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, model.JobSucceeded, job.Status)
+}
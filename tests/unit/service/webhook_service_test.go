@@ -0,0 +1,165 @@
+package service
+
+import (
+	"acc-server-manager/local/model"
+	"acc-server-manager/local/repository"
+	"acc-server-manager/local/service"
+	"acc-server-manager/tests"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newWebhookEndpoint records the requests it receives and answers with
+// status.
+func newWebhookEndpoint(t *testing.T, status int) (*httptest.Server, func() []webhookRequest) {
+	var mu sync.Mutex
+	var requests []webhookRequest
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, webhookRequest{header: r.Header.Clone(), body: body})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(endpoint.Close)
+	return endpoint, func() []webhookRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]webhookRequest(nil), requests...)
+	}
+}
+
+func newTestWebhookService(t *testing.T, helper *tests.TestHelper) (*service.WebhookService, *repository.WebhookDeliveryRepository) {
+	tests.AssertNoError(t, helper.DB.AutoMigrate(&model.Webhook{}, &model.WebhookDelivery{}))
+	deliveryRepo := repository.NewWebhookDeliveryRepository(helper.DB)
+	webhooks := service.NewWebhookService(
+		repository.NewWebhookRepository(helper.DB),
+		deliveryRepo,
+		repository.NewServerRepository(helper.DB),
+	)
+	return webhooks, deliveryRepo
+}
+
+func TestWebhookService_SignedDelivery(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	webhooks, deliveryRepo := newTestWebhookService(t, helper)
+	endpoint, requests := newWebhookEndpoint(t, http.StatusOK)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	ctx := helper.CreateContext()
+
+	created, err := webhooks.Create(ctx, &model.Webhook{
+		ServerID: &server.ID,
+		Name:     "Ops",
+		Kind:     model.WebhookGeneric,
+		URL:      endpoint.URL,
+		Secret:   "s3cret",
+		Events:   model.WebhookEvents{model.WebhookServerStarted},
+		Enabled:  true,
+	})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, "", created.Secret)
+	tests.AssertEqual(t, true, created.HasSecret)
+
+	webhooks.NotifyServer(server, model.WebhookServerStopped, "Server stopped", nil)
+	webhooks.NotifyServer(server, model.WebhookServerStarted, "Server started", nil)
+	webhooks.ProcessQueued(ctx)
+
+	received := requests()
+	tests.AssertEqual(t, 1, len(received))
+	request := received[0]
+	tests.AssertEqual(t, "server.started", request.header.Get("X-Webhook-Event"))
+	tests.AssertEqual(t, service.SignWebhookPayload("s3cret", request.body), request.header.Get("X-Webhook-Signature"))
+
+	var notification model.WebhookNotification
+	tests.AssertNoError(t, json.Unmarshal(request.body, &notification))
+	tests.AssertEqual(t, server.ID, notification.ServerID)
+	tests.AssertEqual(t, server.Name, notification.ServerName)
+	tests.AssertEqual(t, "Server started", notification.Message)
+
+	deliveries, err := deliveryRepo.GetAll(ctx, &model.WebhookDeliveryFilter{WebhookID: created.ID.String()})
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(*deliveries))
+	delivery := (*deliveries)[0]
+	tests.AssertEqual(t, model.WebhookDeliverySucceeded, delivery.Status)
+	tests.AssertEqual(t, request.header.Get("X-Webhook-Delivery"), delivery.ID.String())
+}
+
+func TestWebhookService_RetriesUntilFailed(t *testing.T) {
+	helper := tests.NewTestHelper(t)
+	defer helper.Cleanup()
+
+	webhooks, deliveryRepo := newTestWebhookService(t, helper)
+	endpoint, requests := newWebhookEndpoint(t, http.StatusInternalServerError)
+
+	tests.AssertNoError(t, helper.InsertTestServer())
+	server := helper.TestData.Server
+	ctx := helper.CreateContext()
+
+	created, err := webhooks.Create(ctx, &model.Webhook{
+		Name:            "Discord",
+		Kind:            model.WebhookDiscord,
+		URL:             endpoint.URL,
+		Events:          model.WebhookEvents{model.WebhookPlayerThreshold},
+		PlayerThreshold: 5,
+		Enabled:         true,
+	})
+	tests.AssertNoError(t, err)
+
+	// The first count is only remembered, and 3 to 4 stays below the
+	// threshold.
+	webhooks.PlayerCountChanged(server, 3)
+	webhooks.PlayerCountChanged(server, 4)
+	webhooks.PlayerCountChanged(server, 6)
+	webhooks.ProcessQueued(ctx)
+
+	received := requests()
+	tests.AssertEqual(t, 1, len(received))
+	if !strings.Contains(string(received[0].body), `"embeds"`) ||
+		!strings.Contains(string(received[0].body), "Player count reached 6 (threshold 5)") {
+		t.Errorf("unexpected Discord payload: %s", received[0].body)
+	}
+	tests.AssertEqual(t, "", received[0].header.Get("X-Webhook-Signature"))
+
+	filter := &model.WebhookDeliveryFilter{WebhookID: created.ID.String()}
+	deliveries, err := deliveryRepo.GetAll(ctx, filter)
+	tests.AssertNoError(t, err)
+	tests.AssertEqual(t, 1, len(*deliveries))
+	tests.AssertEqual(t, model.WebhookDeliveryPending, (*deliveries)[0].Status)
+	tests.AssertEqual(t, 1, (*deliveries)[0].Attempts)
+
+	// Nothing is due before the retry delay has passed.
+	webhooks.RetryDue(ctx, time.Now().UTC())
+	tests.AssertEqual(t, 1, len(requests()))
+
+	now := time.Now().UTC()
+	for i := 0; i < model.WebhookMaxAttempts; i++ {
+		now = now.Add(24 * time.Hour)
+		webhooks.RetryDue(ctx, now)
+	}
+
+	tests.AssertEqual(t, model.WebhookMaxAttempts, len(requests()))
+	deliveries, err = deliveryRepo.GetAll(ctx, filter)
+	tests.AssertNoError(t, err)
+	delivery := (*deliveries)[0]
+	tests.AssertEqual(t, model.WebhookDeliveryFailed, delivery.Status)
+	tests.AssertEqual(t, model.WebhookMaxAttempts, delivery.Attempts)
+	tests.AssertEqual(t, http.StatusInternalServerError, delivery.StatusCode)
+	if delivery.NextAttemptAt != nil {
+		t.Errorf("failed delivery still has a next attempt at %v", delivery.NextAttemptAt)
+	}
+}